@@ -0,0 +1,153 @@
+package main
+
+import (
+	"avg_calculator_ex4/avgcalculator"
+	"data_processor/processor"
+	"dim_reducer/reducer"
+	"dispatcher_ex4/ex4"
+	completerConfig "distance_completer/config"
+	"errors"
+	"ex4_journey_saver/journeysaver"
+	"ex4_sink/sink"
+	"filters_config"
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"os"
+	"saver_ex_3/ex3"
+	"server/server"
+	"simple_saver/saver"
+	"strings"
+)
+
+// The stages do not connect to RabbitMQ nor send heartbeats, but their configs require these values
+const inMemoryRabbitAddress = "in-memory"
+const noHealthCheckers = "none"
+
+// retentionKeys Keys of the retention of the artifacts, the stages take the general ones unless they have their own
+var retentionKeys = []string{"retention.ttl", "retention.fetched", "retention.interval"}
+
+// AllInOneConfig Configuration of every stage of the pipeline. Each stage is configured
+// with the same keys that it uses when it runs in its own container
+type AllInOneConfig struct {
+	WorkDir           string
+	Server            *server.ServerConfig
+	DataProcessor     *processor.Config
+	ReducerEx1        *reducer.Config
+	ReducerEx2        *reducer.Config
+	FilterStopovers   *filters_config.FilterConfig
+	FilterDistances   *filters_config.FilterConfig
+	DistanceCompleter *completerConfig.CompleterConfig
+	SaverEx1          *saver.Config
+	SaverEx2          *saver.Config
+	SaverEx3          *ex3.SaverConfig
+	SaverEx4          *saver.Config
+	DispatcherEx4     *ex4.DispatcherEx4Config
+	JourneySaver      *journeysaver.Ex4JourneySaverConfig
+	AvgCalculator     *avgcalculator.AvgCalculatorConfig
+	Sink              *sink.SinkConfig
+}
+
+func InitEnv() (*viper.Viper, error) {
+	v := viper.New()
+
+	v.AutomaticEnv()
+	v.SetEnvPrefix("cli")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+
+	_ = v.BindEnv("log", "level")
+	_ = v.BindEnv("workdir")
+	_ = v.BindEnv("config", "file")
+
+	v.SetDefault("config.file", "./config.yaml")
+	v.SetDefault("workdir", "./data")
+	v.SetDefault("log.level", "info")
+
+	v.SetConfigFile(v.GetString("config.file"))
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("configuration could not be read from %v: %v", v.GetString("config.file"), err)
+	}
+
+	return v, nil
+}
+
+// getStageEnv Returns the section of the configuration of a stage. The values that are not needed when running
+// in a single process get a default. If goroutinesKey is not empty, the total nodes for the EOF
+// default to the goroutines of the stage, as there is only one replica of it, which also runs the coordinator of the EOF
+func getStageEnv(env *viper.Viper, section string, goroutinesKey string) (*viper.Viper, error) {
+	stageEnv := env.Sub(section)
+	if stageEnv == nil {
+		return nil, fmt.Errorf("missing configuration of stage %v", section)
+	}
+	stageEnv.SetDefault("log.level", env.GetString("log.level"))
+	stageEnv.SetDefault("name", section)
+	stageEnv.SetDefault("rabbitmq.address", inMemoryRabbitAddress)
+	stageEnv.SetDefault("healthchecker.addresses", noHealthCheckers)
+	for _, key := range retentionKeys {
+		stageEnv.SetDefault(key, env.GetString(key))
+	}
+	if goroutinesKey != "" {
+		stageEnv.SetDefault("total.nodes.for.eof", stageEnv.GetUint(goroutinesKey))
+		stageEnv.SetDefault("eof.coordinator", true)
+	}
+	return stageEnv, nil
+}
+
+func GetConfig(env *viper.Viper) (*AllInOneConfig, error) {
+	if err := config.InitLogger(env.GetString("log.level")); err != nil {
+		return nil, err
+	}
+
+	workDir := env.GetString("workdir")
+	if workDir == "" {
+		return nil, errors.New("missing workdir")
+	}
+
+	c := &AllInOneConfig{WorkDir: workDir}
+	stages := []struct {
+		section       string
+		goroutinesKey string
+		parse         func(stageEnv *viper.Viper) error
+	}{
+		{"server", "", func(e *viper.Viper) (err error) { c.Server, err = server.GetConfig(e); return }},
+		{"data_processor", "processor.goroutines", func(e *viper.Viper) (err error) { c.DataProcessor, err = processor.GetConfig(e); return }},
+		{"reducer_ex1", "reducer.goroutines", func(e *viper.Viper) (err error) { c.ReducerEx1, err = reducer.GetConfig(e); return }},
+		{"reducer_ex2", "reducer.goroutines", func(e *viper.Viper) (err error) { c.ReducerEx2, err = reducer.GetConfig(e); return }},
+		{"filter_stopovers", "filter.goroutines", func(e *viper.Viper) (err error) { c.FilterStopovers, err = filters_config.GetConfigFilters(e); return }},
+		{"filter_distances", "filter.goroutines", func(e *viper.Viper) (err error) { c.FilterDistances, err = filters_config.GetConfigFilters(e); return }},
+		{"distance_completer", "completer.goroutines", func(e *viper.Viper) (err error) { c.DistanceCompleter, err = completerConfig.GetConfig(e); return }},
+		{"saver_ex1", "", func(e *viper.Viper) (err error) { c.SaverEx1, err = saver.GetConfig(e); return }},
+		{"saver_ex2", "", func(e *viper.Viper) (err error) { c.SaverEx2, err = saver.GetConfig(e); return }},
+		{"saver_ex3", "dispatchers.count", func(e *viper.Viper) (err error) { c.SaverEx3, err = ex3.GetConfig(e); return }},
+		{"saver_ex4", "", func(e *viper.Viper) (err error) { c.SaverEx4, err = saver.GetConfig(e); return }},
+		{"dispatcher_ex4", "internal.dispatcher.count", func(e *viper.Viper) (err error) { c.DispatcherEx4, err = ex4.GetConfig(e); return }},
+		{"ex4_journey_saver", "", func(e *viper.Viper) (err error) { c.JourneySaver, err = journeysaver.GetConfig(e); return }},
+		{"avg_calculator_ex4", "", func(e *viper.Viper) (err error) { c.AvgCalculator, err = avgcalculator.GetConfig(e); return }},
+		{"ex4_sink", "", func(e *viper.Viper) (err error) { c.Sink, err = sink.GetConfig(e); return }},
+	}
+	for _, stage := range stages {
+		stageEnv, err := getStageEnv(env, stage.section, stage.goroutinesKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := stage.parse(stageEnv); err != nil {
+			return nil, fmt.Errorf("error in configuration of stage %v: %v", stage.section, err)
+		}
+	}
+
+	// The stages set the log level of their own section, so we restore the general one
+	if err := config.InitLogger(env.GetString("log.level")); err != nil {
+		return nil, err
+	}
+	log.Infof("AllInOneConfig | action: config | result: success | log_level: %s | workdir: %v", env.GetString("log.level"), workDir)
+	return c, nil
+}
+
+// changeToWorkDir Moves the process to the folder where the stages will keep their files
+func changeToWorkDir(workDir string) error {
+	if err := os.MkdirAll(workDir, os.ModePerm); err != nil {
+		return err
+	}
+	return os.Chdir(workDir)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestShouldParseTheConfigOfEveryStage(t *testing.T) {
+	env, err := InitEnv()
+	assert.Nil(t, err, "Should have read the config file")
+
+	c, err := GetConfig(env)
+	assert.Nil(t, err, "Should have parsed the config")
+	assert.Equal(t, "./data", c.WorkDir)
+	assert.Equal(t, "flight_row_processor", c.DataProcessor.InputQueueName)
+	assert.Equal(t, []string{"legId", "route", "totalFare"}, c.ReducerEx1.ColumnsToKeep)
+	assert.Equal(t, "localhost:8083", c.SaverEx3.GetterAddress)
+	assert.Equal(t, []string{"localhost:8084"}, c.Server.GetterAddresses[4])
+}
+
+func TestTheTotalNodesForEOFShouldDefaultToTheGoroutinesOfTheStage(t *testing.T) {
+	env, err := InitEnv()
+	assert.Nil(t, err, "Should have read the config file")
+
+	c, err := GetConfig(env)
+	assert.Nil(t, err, "Should have parsed the config")
+	assert.Equal(t, uint(c.DataProcessor.GoroutinesCount), c.DataProcessor.TotalEofNodes)
+	assert.Equal(t, uint(c.FilterStopovers.GoroutinesCount), c.FilterStopovers.TotalEofNodes)
+	assert.Equal(t, c.DispatcherEx4.DispatchersCount, c.DispatcherEx4.TotalEofNodes)
+	assert.Equal(t, c.SaverEx3.DispatchersCount, c.SaverEx3.TotalEofNodes)
+	assert.True(t, c.DataProcessor.EOFCoordinator, "The only replica of the stage should run the coordinator of the EOF")
+	assert.True(t, c.ReducerEx1.EOFCoordinator)
+}
+
+func TestShouldFailIfAStageIsMissing(t *testing.T) {
+	env := viper.New()
+	env.Set("log.level", "info")
+	env.Set("workdir", "./data")
+
+	_, err := GetConfig(env)
+	assert.NotNil(t, err, "Should have failed because the stages are missing")
+}
@@ -0,0 +1,3 @@
+module all_in_one
+
+go 1.21
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	log "github.com/sirupsen/logrus"
+	"os"
+)
+
+func main() {
+	sigs := utils.CreateSignalListener()
+	env, err := InitEnv()
+	if err != nil {
+		log.Fatalf("Main - All In One | Error initializing env | %s", err)
+	}
+	config, err := GetConfig(env)
+	if err != nil {
+		log.Fatalf("Main - All In One | Error initializing config | %s", err)
+	}
+	err = changeToWorkDir(config.WorkDir)
+	if err != nil {
+		log.Fatalf("Main - All In One | Error moving to workdir %v | %s", config.WorkDir, err)
+	}
+
+	pipeline := NewPipeline(config)
+	if pipeline.HandleCommand(os.Args[1:]) {
+		return
+	}
+	pipeline.Start()
+	log.Infof("Main - All In One | Server listening at %v", config.Server.ServerAddress)
+	<-sigs
+	log.Infof("Main - All In One | Ending pipeline...")
+	pipeline.Close()
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"avg_calculator_ex4/avgcalculator"
+	"data_processor/processor"
+	"dim_reducer/reducer"
+	"dispatcher_ex4/ex4"
+	"distance_completer/controllers"
+	"ex4_journey_saver/journeysaver"
+	"ex4_sink/sink"
+	"filter_distancias/distances"
+	"filter_escalas/stopovers"
+	"filters_config"
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
+	"github.com/brunograssano/Distribuidos-TP1/common/getters"
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
+	"github.com/brunograssano/Distribuidos-TP1/common/retention"
+	log "github.com/sirupsen/logrus"
+	"saver_ex_3/ex3"
+	"server/server"
+	"simple_saver/saver"
+	"strconv"
+)
+
+// Pipeline Every stage of the four queries running as goroutines of the same process. The stages
+// communicate through an in memory broker, each one with its own middleware as if it were a different service
+type Pipeline struct {
+	c           *AllInOneConfig
+	broker      *middleware.InMemoryBroker
+	middlewares []middleware.QueueMiddlewareI
+	getters     []*getters.Getter
+	server      *server.Server
+	saverEx3    *ex3.Ex3Handler
+	dispatcher4 *ex4.DispatcherEx4
+	collectors  *stageCollectors
+}
+
+// stageCollectors Collectors of the artifacts of the clients of each stage that keeps files
+type stageCollectors struct {
+	distanceCompleter *retention.Collector
+	saverEx1          *retention.Collector
+	saverEx2          *retention.Collector
+	saverEx3          *retention.Collector
+	saverEx4          *retention.Collector
+	journeySaver      *retention.Collector
+}
+
+func (sc *stageCollectors) all() []*retention.Collector {
+	return append(sc.ownedByPipeline(), sc.saverEx3)
+}
+
+// ownedByPipeline Collectors started and closed by the pipeline. The one of the saver of the exercise 3 is handled by the saver
+func (sc *stageCollectors) ownedByPipeline() []*retention.Collector {
+	return []*retention.Collector{sc.distanceCompleter, sc.saverEx1, sc.saverEx2, sc.saverEx4, sc.journeySaver}
+}
+
+func NewPipeline(c *AllInOneConfig) *Pipeline {
+	return &Pipeline{
+		c:      c,
+		broker: middleware.NewInMemoryBroker(),
+		collectors: &stageCollectors{
+			distanceCompleter: controllers.NewCollector(c.DistanceCompleter),
+			saverEx1:          saver.NewCollector(c.SaverEx1),
+			saverEx2:          saver.NewCollector(c.SaverEx2),
+			saverEx3:          ex3.NewCollector(c.SaverEx3),
+			saverEx4:          saver.NewCollector(c.SaverEx4),
+			journeySaver:      journeysaver.NewCollector(c.JourneySaver),
+		},
+	}
+}
+
+// HandleCommand Runs the purge command in every stage that keeps files of the clients.
+// Returns true if it was the command, so the pipeline does not start
+func (p *Pipeline) HandleCommand(args []string) bool {
+	handled := false
+	for _, collector := range p.collectors.all() {
+		handled = retention.HandleCommand(args, collector)
+	}
+	return handled
+}
+
+func (p *Pipeline) newMiddleware() middleware.QueueMiddlewareI {
+	qMiddleware := middleware.NewInMemoryQueueMiddleware(p.broker)
+	p.middlewares = append(p.middlewares, qMiddleware)
+	return qMiddleware
+}
+
+// Start Creates every stage and the server, and starts collecting the artifacts of the clients
+func (p *Pipeline) Start() {
+	p.startStages()
+	p.startServer()
+	for _, collector := range p.collectors.ownedByPipeline() {
+		go collector.CollectLoop()
+	}
+	log.Infof("Pipeline | All the stages are running")
+}
+
+// startStages Creates every stage and spawns its goroutines. The stages are created from the end of the pipeline
+// to the beginning, so the queues and bindings exist before the first message is sent
+func (p *Pipeline) startStages() {
+	p.startSimpleSaver(p.c.SaverEx1, p.collectors.saverEx1)
+	p.startSimpleSaver(p.c.SaverEx2, p.collectors.saverEx2)
+	p.startSimpleSaver(p.c.SaverEx4, p.collectors.saverEx4)
+	p.startSaverEx3()
+	p.startSink()
+	p.startAvgCalculator()
+	p.startJourneySaver()
+	p.startDispatcherEx4()
+	p.startReducer(p.c.ReducerEx1)
+	p.startReducer(p.c.ReducerEx2)
+	p.startFilterStopovers()
+	p.startFilterDistances()
+	p.startDistanceCompleter()
+	p.startDataProcessor()
+}
+
+// Close Closes the server, the getters, the collectors and the middlewares of every stage
+func (p *Pipeline) Close() {
+	log.Infof("Pipeline | Closing stages...")
+	if p.server != nil {
+		_ = p.server.End()
+	}
+	for _, getter := range p.getters {
+		getter.Close()
+	}
+	if p.saverEx3 != nil {
+		p.saverEx3.Close()
+	}
+	if p.dispatcher4 != nil {
+		p.dispatcher4.Close()
+	}
+	for _, qMiddleware := range p.middlewares {
+		qMiddleware.Close()
+	}
+	for _, collector := range p.collectors.ownedByPipeline() {
+		collector.Close()
+	}
+}
+
+func (p *Pipeline) startServer() {
+	p.server = server.NewServer(p.c.Server, p.newMiddleware())
+	go p.server.StartServerLoop()
+}
+
+func (p *Pipeline) startDataProcessor() {
+	qFactory := queuefactory.NewSimpleQueueFactory(p.newMiddleware(), p.c.DataProcessor.Compressions)
+	for i := 0; i < p.c.DataProcessor.GoroutinesCount; i++ {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		dataProcessor := processor.NewDataProcessor(i, qFactory, p.c.DataProcessor, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		go dataProcessor.ProcessData()
+	}
+	if p.c.DataProcessor.EOFCoordinator {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		coordinator := processor.NewEOFCoordinator(qFactory, p.c.DataProcessor, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		go coordinator.CoordinateLoop()
+	}
+}
+
+func (p *Pipeline) startReducer(c *reducer.Config) {
+	qMiddleware := p.newMiddleware()
+	simpleFactory := queuefactory.NewSimpleQueueFactory(qMiddleware, c.Compressions)
+	fanoutFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, c.OutputQueueName, "", c.Compressions)
+	for i := 0; i < c.GoroutinesCount; i++ {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		consumer := simpleFactory.CreateConsumer(c.InputQueueName)
+		producer := fanoutFactory.CreateProducer(c.OutputQueueName)
+		prodToCons := simpleFactory.CreateProducer(c.InputQueueName)
+		toCoordinator := simpleFactory.CreateProducer(queueProtocol.CoordinatorQueueName(c.InputQueueName))
+		r := reducer.NewReducer(i, consumer, producer, prodToCons, toCoordinator, c, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		go r.ReduceDims()
+	}
+	if c.EOFCoordinator {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		coordinator := reducer.NewEOFCoordinator(simpleFactory, fanoutFactory, c, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		go coordinator.CoordinateLoop()
+	}
+}
+
+func (p *Pipeline) startFilterStopovers() {
+	c := p.c.FilterStopovers
+	qMiddleware := p.newMiddleware()
+	qFactory := queuefactory.NewSimpleQueueFactory(qMiddleware, c.Compressions)
+	for i := 0; i < c.GoroutinesCount; i++ {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		inputQueue := qFactory.CreateConsumer(c.InputQueueName)
+		prodToCons := qFactory.CreateProducer(c.InputQueueName)
+		outputQueues := filters_config.NewOutputProducers(qMiddleware, c)
+		toCoordinator := qFactory.CreateProducer(queueProtocol.CoordinatorQueueName(c.InputQueueName))
+		filter := stopovers.NewFilterStopovers(i, inputQueue, outputQueues, prodToCons, toCoordinator, c, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		go filter.FilterStopovers()
+	}
+	p.startFilterCoordinator(qMiddleware, c)
+}
+
+func (p *Pipeline) startFilterDistances() {
+	qMiddleware := p.newMiddleware()
+	qFactory := queuefactory.NewSimpleQueueFactory(qMiddleware, p.c.FilterDistances.Compressions)
+	for i := 0; i < p.c.FilterDistances.GoroutinesCount; i++ {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		filter := distances.NewFilterDistances(i, qFactory, p.c.FilterDistances, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		go filter.FilterDistances()
+	}
+	p.startFilterCoordinator(qMiddleware, p.c.FilterDistances)
+}
+
+func (p *Pipeline) startFilterCoordinator(qMiddleware middleware.QueueMiddlewareI, c *filters_config.FilterConfig) {
+	if !c.EOFCoordinator {
+		return
+	}
+	checkpointerHandler := checkpointer.NewCheckpointerHandler()
+	coordinator := filters_config.NewEOFCoordinator(qMiddleware, c, checkpointerHandler)
+	checkpointerHandler.RestoreCheckpoint()
+	go coordinator.CoordinateLoop()
+}
+
+func (p *Pipeline) startDistanceCompleter() {
+	c := p.c.DistanceCompleter
+	qMiddleware := p.newMiddleware()
+	simpleFactory := queuefactory.NewSimpleQueueFactory(qMiddleware, c.Compressions)
+	exchangeFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, c.ExchangeNameAirports, c.RoutingKeyExchangeAirports, c.Compressions)
+	for i := 0; i < c.GoroutinesCount; i++ {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		distCompleter := controllers.NewDistanceCompleter(i, simpleFactory, c, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		go distCompleter.CompleteDistances()
+	}
+	if c.EOFCoordinator {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		coordinator := controllers.NewEOFCoordinator(simpleFactory, c, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		go coordinator.CoordinateLoop()
+	}
+	checkpointerHandler := checkpointer.NewCheckpointerHandler()
+	airportsSaver := controllers.NewAirportSaver(c, exchangeFactory, checkpointerHandler, p.collectors.distanceCompleter)
+	checkpointerHandler.RestoreCheckpoint()
+	go airportsSaver.SaveAirports()
+}
+
+func (p *Pipeline) startSimpleSaver(c *saver.Config, collector *retention.Collector) {
+	qFactory := queuefactory.NewFanoutExchangeQueueFactory(p.newMiddleware(), c.InputQueueName, "", c.Compressions)
+	checkpointerHandler := checkpointer.NewCheckpointerHandler()
+	simpleSaver := saver.NewSimpleSaver(qFactory, c, checkpointerHandler, collector)
+	checkpointerHandler.RestoreCheckpoint()
+	go simpleSaver.SaveData()
+
+	getterConf := getters.NewGetterConfig(c.ID, []string{c.OutputFileName}, c.GetterAddress, c.GetterBatchLines, c.GetterStreaming, collector)
+	getter, err := getters.NewGetter(getterConf)
+	if err != nil {
+		log.Fatalf("Pipeline | Error initializing Getter of %v | %s", c.ServiceName, err)
+	}
+	p.getters = append(p.getters, getter)
+	go getter.ReturnResults()
+}
+
+func (p *Pipeline) startSaverEx3() {
+	c := p.c.SaverEx3
+	qMiddleware := p.newMiddleware()
+	qFactory := queuefactory.NewTopicFactory(qMiddleware, []string{"", c.ID}, c.InputQueueName, c.Compressions)
+	p.saverEx3 = ex3.NewEx3Handler(c, qFactory, queuefactory.NewSimpleQueueFactory(qMiddleware, c.Compressions), p.collectors.saverEx3)
+	go p.saverEx3.StartHandler()
+}
+
+func (p *Pipeline) startDispatcherEx4() {
+	p.dispatcher4 = ex4.NewDispatcherEx4(p.c.DispatcherEx4, p.newMiddleware())
+	go p.dispatcher4.StartDispatch()
+}
+
+func (p *Pipeline) startJourneySaver() {
+	c := p.c.JourneySaver
+	qMiddleware := p.newMiddleware()
+	qFanoutFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, c.OutputQueueNameAccum, "", c.Compressions)
+	qFanoutFactorySink := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, c.OutputQueueNameSaver, "", c.Compressions)
+	for i := uint(0); i < c.InternalSaversCount; i++ {
+		qFactory := queuefactory.NewTopicFactory(qMiddleware, []string{"", strconv.Itoa(int(i + c.RoutingKeyInput))}, c.InputQueueName, c.Compressions)
+		inputQ := qFactory.CreateConsumer(fmt.Sprintf("%v-%v-%v", c.InputQueueName, c.ID, i+c.RoutingKeyInput))
+		chkHandler := checkpointer.NewCheckpointerHandler()
+		prodToAccum := qFanoutFactory.CreateProducer(c.OutputQueueNameAccum)
+		prodToSink := qFanoutFactorySink.CreateProducer(c.OutputQueueNameSaver)
+		js := journeysaver.NewJourneySaver(inputQ, prodToAccum, prodToSink, c.TotalSaversCount, chkHandler, p.collectors.journeySaver, i, c.InputQueueName, i+c.RoutingKeyInput)
+		chkHandler.RestoreCheckpoint()
+		go js.SavePricesForJourneys()
+	}
+}
+
+func (p *Pipeline) startAvgCalculator() {
+	c := p.c.AvgCalculator
+	qMiddleware := p.newMiddleware()
+	qTopicFactory := queuefactory.NewTopicFactory(qMiddleware, []string{""}, c.OutputQueueName, c.Compressions)
+	qFanoutFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, c.InputQueueName, "", c.Compressions)
+	inputQueue := qFanoutFactory.CreateConsumer(fmt.Sprintf("%v-%v", c.InputQueueName, c.ID))
+	toJourneySavers := queueProtocol.NewKeyedOutputs(c.OutputQueueName, c.SaversCount, func(idx int) queueProtocol.ProducerProtocolInterface {
+		return qTopicFactory.CreateProducer(strconv.Itoa(idx))
+	})
+	chkHandler := checkpointer.NewCheckpointerHandler()
+	avgCalculator := avgcalculator.NewAvgCalculator(toJourneySavers, inputQueue, c, chkHandler)
+	chkHandler.RestoreCheckpoint()
+	go avgCalculator.CalculateAvgLoop()
+}
+
+func (p *Pipeline) startSink() {
+	c := p.c.Sink
+	qMiddleware := p.newMiddleware()
+	qFanoutInputFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, c.InputQueueName, "", c.Compressions)
+	qFanoutOutputFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, c.OutputQueueName, "", c.Compressions)
+	inputQueue := qFanoutInputFactory.CreateConsumer(fmt.Sprintf("%v-%v", c.InputQueueName, c.ID))
+	toSaver4 := qFanoutOutputFactory.CreateProducer(c.OutputQueueName)
+	chkHandler := checkpointer.NewCheckpointerHandler()
+	journeySink := sink.NewJourneySink(inputQueue, toSaver4, c.SaversCount, chkHandler)
+	chkHandler.RestoreCheckpoint()
+	go journeySink.HandleJourneys()
+}
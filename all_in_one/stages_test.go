@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const e2eClient = "e2e"
+
+func airportRow(code string, lat float32, long float32) *dataStructures.DynamicMap {
+	return dataStructures.NewDynamicMap(map[string]dataStructures.Column{
+		utils.AirportCode: dataStructures.NewStringColumn(code),
+		utils.Latitude:    dataStructures.NewFloat32Column(lat),
+		utils.Longitude:   dataStructures.NewFloat32Column(long),
+	})
+}
+
+func flightRow(legId string, segments string, distance float32) *dataStructures.DynamicMap {
+	return dataStructures.NewDynamicMap(map[string]dataStructures.Column{
+		utils.LegId:                      dataStructures.NewStringColumn(legId),
+		utils.StartingAirport:            dataStructures.NewStringColumn("EZE"),
+		utils.DestinationAirport:         dataStructures.NewStringColumn("JFK"),
+		utils.TravelDuration:             dataStructures.NewStringColumn("PT20H"),
+		utils.TotalFare:                  dataStructures.NewFloat32Column(500),
+		utils.TotalTravelDistance:        dataStructures.NewFloat32Column(distance),
+		utils.SegmentsAirlineName:        dataStructures.NewStringColumn("Aerolineas"),
+		utils.SegmentsArrivalAirportCode: dataStructures.NewStringColumn(segments),
+	})
+}
+
+// startE2EPipeline Starts the stages in a new temporary work dir, with the getters listening on any free port
+func startE2EPipeline(t *testing.T) *Pipeline {
+	env, err := InitEnv()
+	assert.Nil(t, err)
+	c, err := GetConfig(env)
+	assert.Nil(t, err)
+	for _, address := range []*string{&c.SaverEx1.GetterAddress, &c.SaverEx2.GetterAddress, &c.SaverEx3.GetterAddress, &c.SaverEx4.GetterAddress} {
+		*address = "localhost:0"
+	}
+	// The stages keep writing their checkpoints until the test process ends, even after they are closed,
+	// so the test does not return to the folder of the package and the work dir is not removed
+	workDir, err := os.MkdirTemp("", "all_in_one_e2e")
+	assert.Nil(t, err)
+	assert.Nil(t, changeToWorkDir(workDir))
+
+	p := NewPipeline(c)
+	p.startStages()
+	t.Cleanup(p.Close)
+	return p
+}
+
+func resultLines(t *testing.T, file string) []string {
+	content, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	return strings.Split(strings.TrimSpace(string(content)), "\n")
+}
+
+func TestTheFlightsGoThroughTheProcessorTheFiltersAndTheSavers(t *testing.T) {
+	p := startE2EPipeline(t)
+	params := queryparams.Default()
+	params.Queries = []int{1, 2}
+	qMiddleware := p.newMiddleware()
+	server := p.c.Server
+	toAirports := queues.NewProducerQueueProtocolHandler(qMiddleware.CreateExchangeProducer(server.ExchangeNameAirports, server.ExchangeRKAirports, server.ExchangeTypeAirports, true), serializer.NoCompression)
+	toFlights := queues.NewProducerQueueProtocolHandler(qMiddleware.CreateProducer(server.QueueNameFlightRows, true), serializer.NoCompression)
+
+	airports := []*dataStructures.DynamicMap{airportRow("EZE", -34.82, -58.53), airportRow("JFK", 40.64, -73.78)}
+	messages := []*dataStructures.Message{
+		dataStructures.NewCompleteMessage(dataStructures.Airports, airports, e2eClient, 1),
+		dataStructures.NewCompleteMessage(dataStructures.EOFAirports, []*dataStructures.DynamicMap{}, e2eClient, 2),
+	}
+	for _, msg := range messages {
+		msg.Params = params.ToDynMap()
+		assert.Nil(t, toAirports.Send(msg))
+	}
+
+	// The first flight has three stopovers and goes around the world, the second one is direct
+	flights := []*dataStructures.DynamicMap{flightRow("long", "GRU||MIA||ATL||JFK", 100000), flightRow("direct", "JFK", 5300)}
+	eof := dataStructures.NewDynamicMap(map[string]dataStructures.Column{utils.ExpectedRows: dataStructures.NewInt64Column(int64(len(flights)))})
+	messages = []*dataStructures.Message{
+		dataStructures.NewCompleteMessage(dataStructures.FlightRows, flights, e2eClient, 3),
+		dataStructures.NewCompleteMessage(dataStructures.EOFFlightRows, []*dataStructures.DynamicMap{eof}, e2eClient, 4),
+	}
+	for _, msg := range messages {
+		msg.Params = params.ToDynMap()
+		assert.Nil(t, toFlights.Send(msg))
+	}
+
+	for _, file := range []string{p.c.SaverEx1.OutputFileName, p.c.SaverEx2.OutputFileName} {
+		results := fmt.Sprintf("%v/%v_%v.csv", e2eClient, file, e2eClient)
+		assert.Eventuallyf(t, func() bool { return filemanager.DirectoryExists(results) }, 10*time.Second, 20*time.Millisecond, "The results %v should be ready", results)
+		lines := resultLines(t, results)
+		assert.Len(t, lines, 1, "Only the long flight passes the filters")
+		assert.Contains(t, lines[0], "long")
+	}
+}
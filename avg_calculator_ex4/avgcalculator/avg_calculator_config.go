@@ -1,4 +1,4 @@
-package main
+package avgcalculator
 
 import (
 	"errors"
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -19,6 +20,8 @@ type AvgCalculatorConfig struct {
 	SaversCount             uint
 	ServiceName             string
 	AddressesHealthCheckers []string
+	// Compressions Compression of the messages sent to each queue or exchange
+	Compressions queues.Compressions
 }
 
 // InitEnv Initializes the configuration properties from a config file and environment
@@ -32,6 +35,7 @@ func InitEnv() (*viper.Viper, error) {
 
 	_ = v.BindEnv("id")
 	_ = v.BindEnv("log", "level")
+	_ = v.BindEnv("compression")
 	_ = v.BindEnv("rabbitmq", "address")
 	_ = v.BindEnv("rabbitmq", "queue", "input")
 	_ = v.BindEnv("rabbitmq", "queue", "output")
@@ -53,6 +57,11 @@ func GetConfig(env *viper.Viper) (*AvgCalculatorConfig, error) {
 		return nil, err
 	}
 
+	compressions, err := config.GetCompressions(env.GetStringMapString("compression"))
+	if err != nil {
+		return nil, err
+	}
+
 	id := env.GetString("id")
 	if id == "" {
 		return nil, errors.New("missing id")
@@ -109,5 +118,6 @@ func GetConfig(env *viper.Viper) (*AvgCalculatorConfig, error) {
 		SaversCount:             saversCount,
 		AddressesHealthCheckers: healthCheckerAddresses,
 		ServiceName:             serviceName,
+		Compressions:            compressions,
 	}, nil
 }
@@ -1,10 +1,11 @@
-package main
+package avgcalculator
 
 import (
+	"errors"
+	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructure "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
-	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 )
@@ -12,7 +13,7 @@ import (
 const accumCheckpointId = 0
 
 type AvgCalculator struct {
-	toJourneySavers        []queueProtocol.ProducerProtocolInterface
+	toJourneySavers        *queueProtocol.KeyedOutputs
 	pricesConsumer         queueProtocol.ConsumerProtocolInterface
 	c                      *AvgCalculatorConfig
 	valuesReceivedByClient map[string]PartialSum
@@ -20,7 +21,7 @@ type AvgCalculator struct {
 }
 
 func NewAvgCalculator(
-	toJourneySavers []queueProtocol.ProducerProtocolInterface,
+	toJourneySavers *queueProtocol.KeyedOutputs,
 	pricesConsumer queueProtocol.ConsumerProtocolInterface,
 	c *AvgCalculatorConfig,
 	chkHandler *checkpointer.CheckpointerHandler) *AvgCalculator {
@@ -39,21 +40,28 @@ func NewAvgCalculator(
 // performs the calculation, and sends the results back
 func (a *AvgCalculator) CalculateAvgLoop() {
 	log.Infof("AvgCalculator | Started Avg Calculator loop")
-	log.Infof("AvgCalculator | Starting await of internalSavers | Now waiting for %v savers...", len(a.toJourneySavers))
 	for {
-		msg, ok := a.pricesConsumer.Pop()
-		if !ok {
+		msg, err := a.pricesConsumer.Pop()
+		if errors.Is(err, queueProtocol.ErrConsumerClosed) {
 			log.Errorf("AvgCalculator | Consumer closed when not expected, exiting average calculator")
 			return
 		}
+		if err != nil {
+			log.Errorf("AvgCalculator | Skipping message | %v", err)
+			continue
+		}
 		log.Debugf("AvgCalculator | Received message from saver")
 
-		if msg.TypeMessage != dataStructure.EOFFlightRows {
+		if msg.TypeMessage == dataStructure.Abort {
+			a.handleAbortMsg(msg)
+		} else if msg.TypeMessage == dataStructure.EOFFlightRows {
+			a.handleEofMsg(msg)
+		} else {
 			log.Warnf("AvgCalculator | Warning Message | Received a message that was not expected | Skipping...")
+			a.pricesConsumer.DeadLetter(msg.DynMaps, fmt.Errorf("unknown type of message %v", msg.TypeMessage))
 			continue
 		}
-		a.handleEofMsg(msg)
-		err := a.checkpointer.DoCheckpoint(accumCheckpointId)
+		err = a.checkpointer.DoCheckpoint(accumCheckpointId)
 		if err != nil {
 			log.Errorf("AvgCalculator | Error on checkpointing | %v", err)
 		}
@@ -80,11 +88,18 @@ func (a *AvgCalculator) handleEofMsg(msg *dataStructure.Message) {
 	a.valuesReceivedByClient[msg.ClientId] = currPartialSum
 
 	log.Debugf("AvgCalculator | New Accum Price: %v | New Accum Count: %v", a.valuesReceivedByClient[msg.ClientId].sumOfPrices, a.valuesReceivedByClient[msg.ClientId].sumOfRows)
-	if a.valuesReceivedByClient[msg.ClientId].numOfSavers == len(a.toJourneySavers) {
+	if a.valuesReceivedByClient[msg.ClientId].numOfSavers == int(a.saversOf(msg)) {
 		a.onFinishedClientId(msg)
 	}
 }
 
+// handleAbortMsg Forgets the values received from the savers for the client, as it cancelled its session
+func (a *AvgCalculator) handleAbortMsg(msg *dataStructure.Message) {
+	log.Infof("AvgCalculator | Received Abort of client %v. Clearing its data...", msg.ClientId)
+	a.pricesConsumer.ClearData(msg.ClientId)
+	delete(a.valuesReceivedByClient, msg.ClientId)
+}
+
 func (a *AvgCalculator) getPartialSumOfClient(msg *dataStructure.Message) PartialSum {
 	_, exists := a.valuesReceivedByClient[msg.ClientId]
 	if !exists {
@@ -93,14 +108,18 @@ func (a *AvgCalculator) getPartialSumOfClient(msg *dataStructure.Message) Partia
 	return a.valuesReceivedByClient[msg.ClientId]
 }
 
+// saversOf Returns the journey savers of the view of the client of the message
+func (a *AvgCalculator) saversOf(msg *dataStructure.Message) uint {
+	return uint(len(a.toJourneySavers.Of(msg)))
+}
+
 // sendToJourneySavers Sends the average to the journey savers
 func (a *AvgCalculator) sendToJourneySavers(avg float32, msg *dataStructure.Message) {
-	avgBytes := serializer.SerializeFloat(avg)
-	dynMap := make(map[string][]byte)
-	dynMap[utils.FinalAvg] = avgBytes
+	dynMap := make(map[string]dataStructure.Column)
+	dynMap[utils.FinalAvg] = dataStructure.NewFloat32Column(avg)
 	data := []*dataStructure.DynamicMap{dataStructure.NewDynamicMap(dynMap)}
 
-	for i, channel := range a.toJourneySavers {
+	for i, channel := range a.toJourneySavers.Of(msg) {
 		log.Infof("AvgCalculator | Sending average for client %v to saver %v", msg.ClientId, i)
 		msgToSend := dataStructure.NewTypeMessageWithDataAndMsgId(dataStructure.FinalAvgMsg, msg, data, msg.MessageId+uint(i)+1)
 		err := channel.Send(msgToSend)
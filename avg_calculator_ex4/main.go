@@ -1,6 +1,7 @@
 package main
 
 import (
+	"avg_calculator_ex4/avgcalculator"
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	"github.com/brunograssano/Distribuidos-TP1/common/heartbeat"
@@ -14,26 +15,24 @@ import (
 
 func main() {
 	sigs := utils.CreateSignalListener()
-	env, err := InitEnv()
+	env, err := avgcalculator.InitEnv()
 	if err != nil {
 		log.Fatalf("Main - Ex4 Avg Calculator | Error initializing env | %s", err)
 	}
-	config, err := GetConfig(env)
+	config, err := avgcalculator.GetConfig(env)
 	if err != nil {
 		log.Fatalf("Main - Ex4 Avg Calculator | Error initializing Config | %s", err)
 	}
 
-	var toJourneySavers []queueProtocol.ProducerProtocolInterface
 	qMiddleware := middleware.NewQueueMiddleware(config.RabbitAddress)
-	qTopicFactory := queuefactory.NewTopicFactory(qMiddleware, []string{""}, config.OutputQueueName)
-	qFanoutFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.InputQueueName, "")
+	qTopicFactory := queuefactory.NewTopicFactory(qMiddleware, []string{""}, config.OutputQueueName, config.Compressions)
+	qFanoutFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.InputQueueName, "", config.Compressions)
 	inputQueue := qFanoutFactory.CreateConsumer(fmt.Sprintf("%v-%v", config.InputQueueName, config.ID))
 	chkHandler := checkpointer.NewCheckpointerHandler()
-	for i := uint(0); i < config.SaversCount; i++ {
-		producer := qTopicFactory.CreateProducer(strconv.Itoa(int(i)))
-		toJourneySavers = append(toJourneySavers, producer)
-	}
-	avgCalculator := NewAvgCalculator(toJourneySavers, inputQueue, config, chkHandler)
+	toJourneySavers := queueProtocol.NewKeyedOutputs(config.OutputQueueName, config.SaversCount, func(idx int) queueProtocol.ProducerProtocolInterface {
+		return qTopicFactory.CreateProducer(strconv.Itoa(idx))
+	})
+	avgCalculator := avgcalculator.NewAvgCalculator(toJourneySavers, inputQueue, config, chkHandler)
 	chkHandler.RestoreCheckpoint()
 	go avgCalculator.CalculateAvgLoop()
 	endSigHB := heartbeat.StartHeartbeat(config.AddressesHealthCheckers, config.ServiceName)
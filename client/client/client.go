@@ -3,46 +3,120 @@ package client
 import (
 	"client/client/parsers"
 	"github.com/brunograssano/Distribuidos-TP1/common/communication"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/brunograssano/Distribuidos-TP1/common/protocol/sockets"
 	log "github.com/sirupsen/logrus"
 )
 
 // Client Entity that encapsulates the client
 type Client struct {
-	conn *sockets.SocketProtocolHandler
-	conf *ClientConfig
+	conn         *sockets.SocketProtocolHandler
+	conf         *ClientConfig
+	state        *uploadState
+	knownSession bool
 }
 
-// NewClient Initializes a new client
+// NewClient Initializes a new client. The session is the one configured, the one of the saved upload or a new one
 func NewClient(c *ClientConfig) *Client {
 	socket, err := communication.NewActiveTCPSocket(c.ServerAddress)
 	if err != nil {
 		log.Fatalf("Client | action: connect | result: fail | client_id: %v | error: %v", c.ID, err)
 	}
 	log.Infof("Client | Connected to server")
-	return &Client{conn: sockets.NewSocketProtocolHandler(socket), conf: c}
+	state := loadUploadState(c.StateFileName, c.Uuid)
+	knownSession := c.Uuid != "" || state.restored
+	c.Uuid = state.uuid
+	return &Client{conn: sockets.NewSocketProtocolHandlerWithCompression(socket, c.Compression), conf: c, state: state, knownSession: knownSession}
 }
 
-// StartClientLoop Sends the flight rows and airport
-// In case of error it closes the connection and finishes
-func (c *Client) StartClientLoop() {
+// Session Returns the uuid of the session, that can be used to fetch the results later
+func (c *Client) Session() string {
+	return c.conf.Uuid
+}
 
-	defer c.Close()
+// KnownSession Returns true if the session was configured or restored from a previous upload
+func (c *Client) KnownSession() bool {
+	return c.knownSession
+}
 
+// Upload Sends the parameters of the queries, the airports and the flight rows. The progress is kept so a restarted client resumes the upload
+func (c *Client) Upload() error {
+	log.Infof("Client | Uploading session %v", c.Session())
+	sendWithReconnection(c.conn, queryParamsMessage(c.conf))
 	log.Infof("Client | Sending airports file...")
-	err := SendFile(c.conf.AirportFileName, c.conf, c.conn, parsers.AirportsParser{})
+	err := SendFile(c.conf.AirportFileName, c.conf, []*sockets.SocketProtocolHandler{c.conn}, parsers.NewAirportsParser(), c.state)
 	if err != nil {
-		return
+		return err
+	}
+
+	log.Infof("Client | Sending flight rows file through %v connections...", c.conf.Connections)
+	conns := c.openUploadConnections()
+	defer func() {
+		for _, conn := range conns[1:] {
+			conn.Close()
+		}
+	}()
+	return SendFile(c.conf.InputFileName, c.conf, conns, parsers.NewFlightsParser(), c.state)
+}
+
+// openUploadConnections Returns the connection of the client followed by the extra connections used to upload the flights
+func (c *Client) openUploadConnections() []*sockets.SocketProtocolHandler {
+	conns := []*sockets.SocketProtocolHandler{c.conn}
+	for uint(len(conns)) < c.conf.Connections {
+		socket, err := communication.NewActiveTCPSocket(c.conf.ServerAddress)
+		if err != nil {
+			log.Errorf("Client | Error opening connection %v | %v | Uploading with %v connections", len(conns), err, len(conns))
+			break
+		}
+		conns = append(conns, sockets.NewSocketProtocolHandlerWithCompression(socket, c.conf.Compression))
 	}
+	return conns
+}
 
-	log.Infof("Client | Sending flight rows file...")
-	err = SendFile(c.conf.InputFileName, c.conf, c.conn, parsers.FlightsParser{})
+// Results Fetches the results of the queries and writes them to files in the directory,
+// along with the lines of the files that were skipped in the upload
+func (c *Client) Results(queries []int, format ResultsFormat, dir string) error {
+	err := FetchResults(c.Session(), c.conn, queries, format, dir)
 	if err != nil {
-		return
+		return err
 	}
+	status, err := c.Status()
+	if err != nil {
+		log.Errorf("Client | Error getting the skipped lines of the session | %v", err)
+		return nil
+	}
+	return writeSkippedLines(dir, status)
+}
 
-	RequestResults(c.conf.Uuid, c.conn)
+// Status Asks the server where the session is
+func (c *Client) Status() (*dataStructures.DynamicMap, error) {
+	return RequestStatus(c.Session(), c.conn)
+}
+
+// Cancel Aborts the session, so every stage stops processing it and clears its data. The saved upload is removed
+func (c *Client) Cancel() error {
+	log.Infof("Client | Cancelling session %v", c.Session())
+	err := RequestAbort(c.Session(), c.conn)
+	if err != nil {
+		return err
+	}
+	c.state.remove()
+	return nil
+}
 
+// Run Sends the flight rows and airports and then fetches the results of every query requested.
+// The progress of the upload is kept until the results are received
+func (c *Client) Run(format ResultsFormat, dir string) error {
+	err := c.Upload()
+	if err != nil {
+		return err
+	}
+	err = c.Results(c.conf.Params.Queries, format, dir)
+	if err != nil {
+		return err
+	}
+	c.state.remove()
+	return nil
 }
 
 // Close Closes the client connection to the server
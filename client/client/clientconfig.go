@@ -3,8 +3,10 @@ package client
 import (
 	"errors"
 	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
-	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"strings"
@@ -17,9 +19,26 @@ type ClientConfig struct {
 	AirportFileName string
 	ServerAddress   string
 	Batch           uint
-	Uuid            string
+	Encoding        dataStructures.BatchEncoding
+	Compression     serializer.Compression
+	Window          uint
+	Connections     uint
+	StateFileName   string
+	// Uuid Session of the client. If it is empty the one of the saved upload is used, or a new one is created
+	Uuid string
+	// Params Parameters of the queries of the session
+	Params queryparams.QueryParams
 }
 
+// defaultWindow Batches that can be sent without being acknowledged by the server
+const defaultWindow = 16
+
+// defaultConnections Connections used to upload the flights
+const defaultConnections = 1
+
+// defaultStateFileName File where the progress of the upload is saved
+const defaultStateFileName = "upload_state.csv"
+
 // InitEnv Initializes the configuration properties from a config file and environment
 func InitEnv() (*viper.Viper, error) {
 	v := viper.New()
@@ -34,11 +53,22 @@ func InitEnv() (*viper.Viper, error) {
 
 	// Add env variables supported
 	_ = v.BindEnv("id")
+	_ = v.BindEnv("session")
 	_ = v.BindEnv("log", "level")
 	_ = v.BindEnv("input", "file")
 	_ = v.BindEnv("input", "airports")
 	_ = v.BindEnv("input", "batch")
+	_ = v.BindEnv("input", "encoding")
+	_ = v.BindEnv("input", "state")
 	_ = v.BindEnv("server", "address")
+	_ = v.BindEnv("server", "compression")
+	_ = v.BindEnv("server", "window")
+	_ = v.BindEnv("server", "connections")
+	_ = v.BindEnv("query", "stopovers")
+	_ = v.BindEnv("query", "distance_factor")
+	_ = v.BindEnv("query", "fastest")
+	_ = v.BindEnv("query", "prices")
+	_ = v.BindEnv("query", "queries")
 	// Try to read configuration from config file. If config file
 	// does not exist then ReadInConfig will fail but configuration
 	// can be loaded from the environment variables, so we shouldn't
@@ -72,6 +102,10 @@ func GetConfig(env *viper.Viper) (*ClientConfig, error) {
 		return nil, errors.New("missing airports file")
 	}
 
+	if inputFile == StdinInput && inputAirports == StdinInput {
+		return nil, errors.New("only one of the inputs can be read from the standard input")
+	}
+
 	serverAddress := env.GetString("server.address")
 	if serverAddress == "" {
 		return nil, errors.New("missing server address")
@@ -83,13 +117,58 @@ func GetConfig(env *viper.Viper) (*ClientConfig, error) {
 		batch = utils.DefaultBatchLines
 	}
 
-	log.Infof("Client Config | action: config | result: success | id: %s | log_level: %s | inputFile: %v | serverAddress: %v | inputAirports: %v | batch: %v",
+	encoding := dataStructures.RowsEncoding
+	if env.GetString("input.encoding") != "" {
+		var err error
+		encoding, err = dataStructures.ParseBatchEncoding(env.GetString("input.encoding"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	compression := serializer.NoCompression
+	if env.GetString("server.compression") != "" {
+		var err error
+		compression, err = serializer.ParseCompression(env.GetString("server.compression"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	window := env.GetUint("server.window")
+	if window == 0 {
+		log.Warnf("Client Config | Warning Message | Missing window of batches, using default")
+		window = defaultWindow
+	}
+
+	connections := env.GetUint("server.connections")
+	if connections == 0 {
+		connections = defaultConnections
+	}
+
+	stateFileName := env.GetString("input.state")
+	if stateFileName == "" {
+		stateFileName = defaultStateFileName
+	}
+
+	params, err := getQueryParams(env)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Client Config | action: config | result: success | id: %s | log_level: %s | inputFile: %v | serverAddress: %v | inputAirports: %v | batch: %v | encoding: %v | compression: %v | window: %v | connections: %v | stateFile: %v | params: %+v",
 		id,
 		env.GetString("log.level"),
 		inputFile,
 		serverAddress,
 		inputAirports,
-		batch)
+		batch,
+		encoding,
+		compression,
+		window,
+		connections,
+		stateFileName,
+		params)
 
 	return &ClientConfig{
 		ID:              id,
@@ -97,6 +176,41 @@ func GetConfig(env *viper.Viper) (*ClientConfig, error) {
 		ServerAddress:   serverAddress,
 		AirportFileName: inputAirports,
 		Batch:           batch,
-		Uuid:            uuid.New().String(),
+		Encoding:        encoding,
+		Compression:     compression,
+		Window:          window,
+		Connections:     connections,
+		StateFileName:   stateFileName,
+		Uuid:            env.GetString("session"),
+		Params:          params,
 	}, nil
 }
+
+// getQueryParams Returns the parameters of the queries. The ones that are not configured keep the original value
+func getQueryParams(env *viper.Viper) (queryparams.QueryParams, error) {
+	params := queryparams.Default()
+	if env.IsSet("query.stopovers") {
+		params.MinStopovers = env.GetInt("query.stopovers")
+	}
+	if env.IsSet("query.distance_factor") {
+		params.DistanceFactor = float32(env.GetFloat64("query.distance_factor"))
+	}
+	if env.IsSet("query.fastest") {
+		params.FastestFlights = env.GetInt("query.fastest")
+	}
+	if env.GetString("query.prices") != "" {
+		threshold, err := queryparams.ParsePriceThreshold(env.GetString("query.prices"))
+		if err != nil {
+			return params, err
+		}
+		params.PriceThreshold = threshold
+	}
+	if env.GetString("query.queries") != "" {
+		queries, err := queryparams.ParseQueries(env.GetString("query.queries"))
+		if err != nil {
+			return params, err
+		}
+		params.Queries = queries
+	}
+	return params, params.Validate()
+}
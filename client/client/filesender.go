@@ -2,12 +2,14 @@ package client
 
 import (
 	"client/client/parsers"
+	"errors"
 	"fmt"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
-	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
 	socketsProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/sockets"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
+	"io"
+	"sync"
 	"time"
 )
 
@@ -15,104 +17,380 @@ const minimumSleepExpBackoff = 2
 const backoffPower = 2
 const maximumSleepExpBackoff = 16
 
-func resendMessageAndPreviousOne(previousMessage *dataStructures.Message, msg *dataStructures.Message, conn *socketsProtocol.SocketProtocolHandler) error {
-	if previousMessage != nil {
-		err := conn.Write(previousMessage)
+// pendingBatch Batch sent that was not acknowledged by the server yet
+type pendingBatch struct {
+	msg *dataStructures.Message
+	// records Records of the file read until the end of the batch
+	records uint
+	// skipped Records skipped until the end of the batch
+	skipped uint
+	acked   bool
+}
+
+// fileSender Reads the records of an input and hands the batches to the streams of the upload
+type fileSender struct {
+	conf    *ClientConfig
+	tracker *ackTracker
+	// recordsToSkip Records acknowledged in a previous run of the client
+	recordsToSkip uint
+	parser        parsers.Parser
+	skipped       *skippedLines
+	rows          []*dataStructures.DynamicMap
+	messageId     uint
+	recordsRead   uint
+	batches       chan *pendingBatch
+	// eofs EOF of each stream, set once every record was read. Stays empty if the input could not be read
+	eofs []*dataStructures.Message
+}
+
+// SendFile Sends the data of an input as a single upload. The batches are distributed between the connections,
+// that send them concurrently, and each connection finishes with its own EOF. The message ids are taken in the
+// order of the input, so they are unique for the client. The input can be a file, compressed or not, a glob of files
+// that are sent sorted by name, or the standard input (-). The columns are found by the header of each file,
+// and the records that can not be parsed are skipped and reported to the server with the EOF.
+// If the input was partially sent before, only the records that the server did not acknowledge are sent
+func SendFile(FileName string, conf *ClientConfig, conns []*socketsProtocol.SocketProtocolHandler, parser parsers.Parser, state *uploadState) error {
+	progress := state.progressOf(FileName)
+	if progress.done {
+		log.Infof("FileSend | File %v was already sent | Skipping it...", FileName)
+		return nil
+	}
+	paths, err := expandInputs(FileName)
+	if err != nil {
+		return err
+	}
+	sender := &fileSender{
+		conf:          conf,
+		tracker:       &ackTracker{state: state, progress: progress},
+		recordsToSkip: progress.recordsAcked,
+		parser:        parser,
+		skipped:       &skippedLines{count: progress.skipped},
+		rows:          make([]*dataStructures.DynamicMap, 0, conf.Batch),
+		messageId:     progress.nextMessageId,
+		batches:       make(chan *pendingBatch, len(conns)),
+	}
+	if progress.recordsAcked > 0 {
+		log.Infof("FileSend | Resuming %v from record %v", FileName, progress.recordsAcked)
+	}
+	streamsErrors := make(chan error, len(conns))
+	for i, conn := range conns {
+		stream := &uploadStream{id: i, conf: conf, conn: conn, tracker: sender.tracker}
+		go stream.run(sender, streamsErrors)
+	}
+
+	err = sender.readInputs(paths)
+	close(sender.batches)
+	for range conns {
+		streamErr := <-streamsErrors
+		if err == nil {
+			err = streamErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	progress.done = true
+	sender.tracker.saveState()
+	return nil
+}
+
+// readInputs Reads every file of the input and prepares the EOF of each stream
+func (s *fileSender) readInputs(paths []string) error {
+	for _, path := range paths {
+		err := s.sendInput(path)
 		if err != nil {
+			log.Errorf("FileSend | %v | %v", path, err)
 			return err
 		}
 	}
-	return conn.Write(msg)
+	s.flush()
+	if s.skipped.count > 0 {
+		log.Warnf("FileSend | Skipped %v lines", s.skipped.count)
+	}
+	streams := cap(s.batches)
+	for i := 0; i < streams; i++ {
+		summary := s.skipped.toDynMap()
+		summary.AddColumn(utils.UploadStream, dataStructures.NewInt32Column(int32(i)))
+		summary.AddColumn(utils.UploadStreams, dataStructures.NewInt32Column(int32(streams)))
+		eof := dataStructures.NewCompleteMessage(s.parser.GetEofMsgType(), []*dataStructures.DynamicMap{summary}, s.conf.Uuid, s.messageId+uint(i))
+		s.eofs = append(s.eofs, eof)
+	}
+	return nil
 }
 
-// SendFile Sends a file data through a socket
-func SendFile(FileName string, conf *ClientConfig, conn *socketsProtocol.SocketProtocolHandler, parser parsers.Parser) error {
-	reader, err := filemanager.NewFileReader(FileName)
+// sendInput Reads the records of a file of the input, skipping the ones already acknowledged. Each file has its own header
+func (s *fileSender) sendInput(path string) error {
+	in, err := openInput(path)
 	if err != nil {
 		return err
 	}
-	defer utils.CloseFileAndNotifyError(reader.FileManager)
-
-	rows := make([]*dataStructures.DynamicMap, 0, conf.Batch)
-	addedToMsg := uint(0)
-
-	messageId := uint(0)
+	defer utils.CloseFileAndNotifyError(in)
 
-	var previousMessage *dataStructures.Message
-	filemanager.SkipHeader(reader)
-	for reader.CanRead() {
-		line := reader.ReadLine()
-		if addedToMsg >= conf.Batch {
-			msg := dataStructures.NewCompleteMessage(parser.GetMsgType(), rows, conf.Uuid, messageId)
-			messageId++
-			sendWithReconnection(conn, msg, previousMessage)
-			if err != nil {
-
-			}
-			previousMessage = msg
-			addedToMsg = 0
-			rows = make([]*dataStructures.DynamicMap, 0, conf.Batch)
+	records := newRecordsReader(in, s.parser.Separator())
+	header, _, err := records.next()
+	if err != nil {
+		return fmt.Errorf("error reading the header: %v", err)
+	}
+	err = s.parser.ReadHeader(header)
+	if err != nil {
+		return fmt.Errorf("error reading the header: %v", err)
+	}
+	s.skipped.fileName = path
+	for {
+		record, line, err := records.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil && !isRecordError(err) {
+			return err
+		}
+		s.recordsRead++
+		if s.recordsRead <= s.recordsToSkip {
+			continue
 		}
-		dynMap, err := parser.LineToDynMap(line)
 		if err != nil {
-			log.Errorf("FileSend | %v | Skipping line", err)
+			s.skipped.add(line, err)
 			continue
 		}
-		rows = append(rows, dynMap)
-		addedToMsg++
+		dynMap, err := s.parser.RecordToDynMap(record)
+		if err != nil {
+			s.skipped.add(line, err)
+			continue
+		}
+		s.rows = append(s.rows, dynMap)
+		if uint(len(s.rows)) >= s.conf.Batch {
+			s.flush()
+		}
+	}
+}
+
+// flush Hands the rows read as a batch to the next free stream
+func (s *fileSender) flush() {
+	if len(s.rows) == 0 {
+		return
+	}
+	msg := dataStructures.NewCompleteMessage(s.parser.GetMsgType(), s.rows, s.conf.Uuid, s.messageId)
+	msg.Encoding = s.conf.Encoding
+	batch := &pendingBatch{msg: msg, records: s.recordsRead, skipped: s.skipped.count}
+	s.tracker.add(batch)
+	s.batches <- batch
+	s.messageId++
+	s.rows = make([]*dataStructures.DynamicMap, 0, s.conf.Batch)
+}
+
+// ackTracker Batches of every stream in the order of the input. The progress only advances over the batches
+// acknowledged without gaps, so a restarted client sends again the ones after a batch that was not acknowledged
+type ackTracker struct {
+	mutex    sync.Mutex
+	state    *uploadState
+	progress *fileProgress
+	pending  []*pendingBatch
+}
+
+func (t *ackTracker) add(batch *pendingBatch) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.pending = append(t.pending, batch)
+}
+
+// acknowledge Marks the batches as acknowledged and saves the progress if it advanced
+func (t *ackTracker) acknowledge(batches []*pendingBatch) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, batch := range batches {
+		batch.acked = true
+	}
+	acked := 0
+	for acked < len(t.pending) && t.pending[acked].acked {
+		t.progress.recordsAcked = t.pending[acked].records
+		t.progress.skipped = t.pending[acked].skipped
+		t.progress.nextMessageId = t.pending[acked].msg.MessageId + 1
+		acked++
+	}
+	if acked == 0 {
+		return
+	}
+	t.pending = t.pending[acked:]
+	t.saveStateLocked()
+}
+
+func (t *ackTracker) saveState() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.saveStateLocked()
+}
+
+func (t *ackTracker) saveStateLocked() {
+	err := t.state.save()
+	if err != nil {
+		log.Errorf("FileSend | Error saving the progress of the upload | %v", err)
+	}
+}
+
+// uploadStream Sends batches through a connection keeping a window of batches that the server did not acknowledge.
+// After reconnecting every batch of the window is sent again
+type uploadStream struct {
+	id      int
+	conf    *ClientConfig
+	conn    *socketsProtocol.SocketProtocolHandler
+	tracker *ackTracker
+	unacked []*pendingBatch
+}
+
+// run Sends the batches until the input is read, waits for their acknowledgements and finishes with the EOF of the stream
+func (s *uploadStream) run(sender *fileSender, result chan<- error) {
+	for batch := range sender.batches {
+		s.send(batch)
+	}
+	for len(s.unacked) > 0 {
+		s.receiveAck()
 	}
-	err = reader.Err()
+	if sender.eofs == nil {
+		result <- nil
+		return
+	}
+	result <- s.sendEOFAndWaitForACK(sender.eofs[s.id])
+}
+
+// send Sends the batch, waiting for acknowledgements while the window is full
+func (s *uploadStream) send(batch *pendingBatch) {
+	s.unacked = append(s.unacked, batch)
+	err := s.conn.Write(batch.msg)
 	if err != nil {
-		log.Errorf("FileSend | %v", err)
-		return err
+		log.Errorf("FileSend | Stream %v | Error trying to send file | %v | Trying to reconnect...", s.id, err)
+		s.reconnect()
 	}
-	if addedToMsg > 0 {
-		msg := dataStructures.NewCompleteMessage(parser.GetMsgType(), rows, conf.Uuid, messageId)
-		messageId++
-		sendWithReconnection(conn, msg, previousMessage)
-		previousMessage = msg
+	for uint(len(s.unacked)) >= s.conf.Window {
+		s.receiveAck()
 	}
-	return sendEOFAndWaitForACK(conn, parser, conf, messageId, previousMessage)
 }
 
-func sendEOFAndWaitForACK(conn *socketsProtocol.SocketProtocolHandler, parser parsers.Parser, conf *ClientConfig, messageId uint, previousMessage *dataStructures.Message) error {
-	eofMsg := dataStructures.NewCompleteMessage(parser.GetEofMsgType(), []*dataStructures.DynamicMap{}, conf.Uuid, messageId)
-	sendWithReconnection(conn, eofMsg, previousMessage)
-	msg, err := conn.Read()
+// receiveAck Waits for the next acknowledgement of the server
+func (s *uploadStream) receiveAck() {
+	msg, err := s.conn.Read()
 	if err != nil {
-		log.Errorf("FileSend | Error sending EOF to server | Retrying...")
-		err = sendEOFAndWaitForACK(conn, parser, conf, messageId, previousMessage)
-		if err != nil {
-			return err
+		log.Errorf("FileSend | Stream %v | Error waiting for ACK of batches | %v | Trying to reconnect...", s.id, err)
+		s.reconnect()
+		return
+	}
+	if msg.TypeMessage == dataStructures.Busy {
+		s.waitUntilAdmitted(msg)
+		s.reconnect()
+		return
+	}
+	if msg.TypeMessage != dataStructures.BatchAck {
+		log.Warnf("FileSend | Stream %v | Expected an ACK of a batch, got message of type %v | Skipping it...", s.id, msg.TypeMessage)
+		return
+	}
+	s.acknowledge(msg.MessageId)
+}
+
+// acknowledge Removes from the window the batches up to the one acknowledged
+func (s *uploadStream) acknowledge(messageId uint) {
+	acked := 0
+	for acked < len(s.unacked) && s.unacked[acked].msg.MessageId <= messageId {
+		acked++
+	}
+	if acked == 0 {
+		return
+	}
+	s.tracker.acknowledge(s.unacked[:acked])
+	s.unacked = s.unacked[acked:]
+}
+
+// waitUntilAdmitted Waits the time that the server asked for when it rejected the upload. The server closes the connection,
+// so the batches of the window are sent again after reconnecting
+func (s *uploadStream) waitUntilAdmitted(busy *dataStructures.Message) {
+	retryAfter := time.Duration(minimumSleepExpBackoff) * time.Second
+	if len(busy.DynMaps) > 0 {
+		millis, err := busy.DynMaps[0].GetAsInt64(utils.RetryAfter)
+		if err == nil {
+			retryAfter = time.Duration(millis) * time.Millisecond
 		}
-		return nil
 	}
-	if msg.TypeMessage == dataStructures.EofAck {
-		log.Infof("FileSend | Got ACK for the sent EOF | Finishing File Send Loop...")
-		return nil
+	log.Infof("FileSend | Stream %v | Server busy with other clients | Retrying in %v...", s.id, retryAfter)
+	time.Sleep(retryAfter)
+}
+
+// reconnect Reconnects with the server and sends again the parameters of the queries, in case the server restarted,
+// the batches that were not acknowledged and the extra messages
+func (s *uploadStream) reconnect(extra ...*dataStructures.Message) {
+	toResend := []*dataStructures.Message{queryParamsMessage(s.conf)}
+	for _, batch := range s.unacked {
+		toResend = append(toResend, batch.msg)
+	}
+	toResend = append(toResend, extra...)
+	reconnectAndSend(s.conn, toResend...)
+}
+
+func (s *uploadStream) sendEOFAndWaitForACK(eofMsg *dataStructures.Message) error {
+	err := s.conn.Write(eofMsg)
+	if err != nil {
+		log.Errorf("FileSend | Stream %v | Error sending EOF to server | %v | Trying to reconnect...", s.id, err)
+		s.reconnect(eofMsg)
+	}
+	for {
+		msg, err := s.conn.Read()
+		if err != nil {
+			log.Errorf("FileSend | Stream %v | Error waiting for ACK of EOF | Retrying...", s.id)
+			s.reconnect(eofMsg)
+			continue
+		}
+		if msg.TypeMessage == dataStructures.EofAck {
+			log.Infof("FileSend | Stream %v | Got ACK for the sent EOF | Finishing File Send Loop...", s.id)
+			return nil
+		}
+		if msg.TypeMessage == dataStructures.Busy {
+			s.waitUntilAdmitted(msg)
+			s.reconnect(eofMsg)
+			continue
+		}
+		if msg.TypeMessage != dataStructures.BatchAck {
+			return fmt.Errorf("got unexpected type of message")
+		}
 	}
-	return fmt.Errorf("got unexpected type of message")
 }
 
-func sendWithReconnection(conn *socketsProtocol.SocketProtocolHandler, msg *dataStructures.Message, previousMessage *dataStructures.Message) {
+// queryParamsMessage Returns the message with the parameters of the queries of the session
+func queryParamsMessage(conf *ClientConfig) *dataStructures.Message {
+	return dataStructures.NewCompleteMessage(dataStructures.QueryParams, []*dataStructures.DynamicMap{conf.Params.ToDynMap()}, conf.Uuid, 0)
+}
+
+// sendWithReconnection Sends the message, reconnecting with the server if it fails
+func sendWithReconnection(conn *socketsProtocol.SocketProtocolHandler, msg *dataStructures.Message) {
 	err := conn.Write(msg)
 	if err != nil {
-		log.Errorf("FileSend | Error trying to send file | %v | Trying to reconnect...", err)
-		currSleep := minimumSleepExpBackoff
-		for {
-			err = conn.Reconnect()
+		log.Errorf("FileSend | Error trying to send message | %v | Trying to reconnect...", err)
+		reconnectAndSend(conn, msg)
+	}
+}
+
+// reconnectAndSend Reconnects with the server with exponential backoff until the messages can be sent
+func reconnectAndSend(conn *socketsProtocol.SocketProtocolHandler, msgs ...*dataStructures.Message) {
+	currSleep := minimumSleepExpBackoff
+	for {
+		err := conn.Reconnect()
+		if err == nil {
+			log.Infof("FileSend | Reconnected with server | Resending %v messages...", len(msgs))
+			err = writeAll(conn, msgs)
 			if err == nil {
-				log.Infof("FileSend | Reconnected with server | Resending Previous and Current Message...")
-				err = resendMessageAndPreviousOne(previousMessage, msg, conn)
-				if err == nil {
-					break
-				}
-			}
-			time.Sleep(time.Duration(currSleep) * time.Second)
-			currSleep = currSleep * backoffPower
-			if currSleep > maximumSleepExpBackoff {
-				currSleep = maximumSleepExpBackoff
+				return
 			}
 		}
+		time.Sleep(time.Duration(currSleep) * time.Second)
+		currSleep = currSleep * backoffPower
+		if currSleep > maximumSleepExpBackoff {
+			currSleep = maximumSleepExpBackoff
+		}
+	}
+}
+
+func writeAll(conn *socketsProtocol.SocketProtocolHandler, msgs []*dataStructures.Message) error {
+	for _, msg := range msgs {
+		err := conn.Write(msg)
+		if err != nil {
+			return err
+		}
 	}
+	return nil
 }
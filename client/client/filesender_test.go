@@ -0,0 +1,33 @@
+package client
+
+import (
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func newTestBatch(messageId uint, records uint) *pendingBatch {
+	msg := dataStructures.NewCompleteMessage(dataStructures.FlightRows, []*dataStructures.DynamicMap{}, "client-uuid", messageId)
+	return &pendingBatch{msg: msg, records: records}
+}
+
+func TestAckTrackerOnlyAdvancesOverTheBatchesAcknowledgedWithoutGaps(t *testing.T) {
+	state := loadUploadState(filepath.Join(t.TempDir(), "upload_state.csv"), "client-uuid")
+	progress := state.progressOf("/data/flights.csv")
+	tracker := &ackTracker{state: state, progress: progress}
+	batches := []*pendingBatch{newTestBatch(0, 100), newTestBatch(1, 200), newTestBatch(2, 300)}
+	for _, batch := range batches {
+		tracker.add(batch)
+	}
+
+	tracker.acknowledge(batches[1:2])
+	assert.Equal(t, &fileProgress{}, progress)
+
+	tracker.acknowledge(batches[0:1])
+	assert.Equal(t, &fileProgress{recordsAcked: 200, nextMessageId: 2}, progress)
+
+	tracker.acknowledge(batches[2:3])
+	assert.Equal(t, &fileProgress{recordsAcked: 300, nextMessageId: 3}, progress)
+	assert.Empty(t, tracker.pending)
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// StdinInput Name of the input that reads from the standard input
+const StdinInput = "-"
+
+var gzipMagic = []byte{0x1f, 0x8b}
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+var bzip2Magic = []byte("BZh")
+
+// magicBytesLength Bytes needed to detect the compression of every format
+const magicBytesLength = 4
+
+// expandInputs Returns the files of the input, sorted by name if it is a glob. The standard input is a single input
+func expandInputs(pattern string) ([]string, error) {
+	if pattern == StdinInput || !strings.ContainsAny(pattern, "*?[") {
+		return []string{pattern}, nil
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no files match %v", pattern)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// input Stream of an input, decompressed if it was compressed
+type input struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// openInput Opens the file, or the standard input, detecting by its first bytes if it is compressed with gzip, zstd or bzip2
+func openInput(path string) (*input, error) {
+	in := &input{}
+	var source io.Reader = os.Stdin
+	if path != StdinInput {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		in.closers = append(in.closers, file)
+		source = file
+	}
+	buffered := bufio.NewReader(source)
+	magic, err := buffered.Peek(magicBytesLength)
+	if err != nil && err != io.EOF {
+		_ = in.Close()
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		log.Infof("FileSend | %v is compressed with gzip", path)
+		reader, err := gzip.NewReader(buffered)
+		if err != nil {
+			_ = in.Close()
+			return nil, err
+		}
+		in.closers = append(in.closers, reader)
+		in.Reader = reader
+	case bytes.HasPrefix(magic, zstdMagic):
+		log.Infof("FileSend | %v is compressed with zstd", path)
+		reader, err := zstd.NewReader(buffered)
+		if err != nil {
+			_ = in.Close()
+			return nil, err
+		}
+		in.closers = append(in.closers, reader.IOReadCloser())
+		in.Reader = reader
+	case bytes.HasPrefix(magic, bzip2Magic):
+		log.Infof("FileSend | %v is compressed with bzip2", path)
+		in.Reader = bzip2.NewReader(buffered)
+	default:
+		in.Reader = buffered
+	}
+	return in, nil
+}
+
+// Close Closes the decompressor and the file
+func (in *input) Close() error {
+	var err error
+	for i := len(in.closers) - 1; i >= 0; i-- {
+		if closeErr := in.closers[i].Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const inputContent = "a,b\n1,2\n"
+
+// bzip2Content inputContent compressed with bzip2, as the standard library can not compress it
+const bzip2Content = "425a6839314159265359bf87407f00000359000010000430003000200030c00869b28823278bb9229c28485fc3a03f80"
+
+func readInput(t *testing.T, path string) string {
+	in, err := openInput(path)
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, in.Close()) }()
+	content, err := io.ReadAll(in)
+	assert.Nil(t, err)
+	return string(content)
+}
+
+func TestOpenInputDetectsTheCompressionByTheMagicBytes(t *testing.T) {
+	dir := t.TempDir()
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	_, _ = gzipWriter.Write([]byte(inputContent))
+	assert.Nil(t, gzipWriter.Close())
+
+	zstdWriter, err := zstd.NewWriter(nil)
+	assert.Nil(t, err)
+	zstdCompressed := zstdWriter.EncodeAll([]byte(inputContent), nil)
+
+	bzip2Compressed, err := hex.DecodeString(bzip2Content)
+	assert.Nil(t, err)
+
+	files := map[string][]byte{
+		"plain.csv":    []byte(inputContent),
+		"flights.gz":   gzipped.Bytes(),
+		"flights.zst":  zstdCompressed,
+		"flights.data": bzip2Compressed,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.WriteFile(path, content, 0644))
+		assert.Equal(t, inputContent, readInput(t, path), name)
+	}
+}
+
+func TestOpenInputOfAnEmptyFileReturnsNoContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.csv")
+	assert.Nil(t, os.WriteFile(path, []byte{}, 0644))
+	assert.Equal(t, "", readInput(t, path))
+}
+
+func TestExpandInputsReturnsTheFilesOfTheGlobSorted(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"2023-02.csv.gz", "2023-01.csv.gz", "airports.csv"} {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte{}, 0644))
+	}
+	paths, err := expandInputs(filepath.Join(dir, "2023-*.csv.gz"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "2023-01.csv.gz"), filepath.Join(dir, "2023-02.csv.gz")}, paths)
+
+	paths, err = expandInputs(StdinInput)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{StdinInput}, paths)
+
+	_, err = expandInputs(filepath.Join(dir, "2024-*.csv"))
+	assert.Error(t, err)
+}
@@ -1,105 +1,73 @@
 package parsers
 
 import (
-	"fmt"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
-	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"strconv"
-	"strings"
 )
 
-const flightFileColumnCount = 27
+const flightsSeparator = ','
 
-const legIdPos = 0
-const searchDatePos = 1
-const flightDatePos = 2
-const startingAirportPos = 3
-const destinationAirportPos = 4
-const fareBasisCodePos = 5
-const travelDurationPos = 6
-const elapsedDaysPos = 7
-const isBasicEconomyPos = 8
-const isRefundablePos = 9
-const isNonStopPos = 10
-const baseFarePos = 11
-const totalFarePos = 12
-const seatsRemainingPos = 13
-const totalTravelDistancePos = 14
-const segmentsDepartureTimeEpochSecondsPos = 15
-const segmentsDepartureTimeRawPos = 16
-const segmentsArrivalTimeEpochSecondsPos = 17
-const segmentsArrivalTimeRawPos = 18
-const segmentsArrivalAirportCodePos = 19
-const segmentsDepartureAirportCodePos = 20
-const segmentsAirlineNamePos = 21
-const segmentsAirlineCodePos = 22
-const segmentsEquipmentDescriptionPos = 23
-const segmentsDurationInSecondsPos = 24
-const segmentsDistancePos = 25
-const segmentsCabinCodePos = 26
+// flightColumns Columns of the flights file by the names of its header
+var flightColumns = []fileColumn{
+	{header: "legId", key: utils.LegId, parse: stringValue},
+	{header: "searchDate", key: utils.SearchDate, parse: stringValue},
+	{header: "flightDate", key: utils.FlightDate, parse: stringValue},
+	{header: "startingAirport", key: utils.StartingAirport, parse: stringValue},
+	{header: "destinationAirport", key: utils.DestinationAirport, parse: stringValue},
+	{header: "fareBasisCode", key: utils.FareBasisCode, parse: stringValue},
+	{header: "travelDuration", key: utils.TravelDuration, parse: stringValue},
+	{header: "elapsedDays", key: utils.ElapsedDays, parse: stringValue},
+	{header: "isBasicEconomy", key: utils.IsBasicEconomy, parse: stringValue},
+	{header: "isRefundable", key: utils.IsRefundable, parse: stringValue},
+	{header: "isNonStop", key: utils.IsNonStop, parse: stringValue},
+	{header: "baseFare", key: utils.BaseFare, parse: stringValue},
+	{header: "totalFare", key: utils.TotalFare, parse: float32Value},
+	{header: "seatsRemaining", key: utils.SeatsRemaining, parse: stringValue},
+	{header: "totalTravelDistance", key: utils.TotalTravelDistance, parse: totalTravelDistance},
+	{header: "segmentsDepartureTimeEpochSeconds", key: utils.SegmentsDepartureTimeEpochSeconds, parse: stringValue},
+	{header: "segmentsDepartureTimeRaw", key: utils.SegmentsDepartureTimeRaw, parse: stringValue},
+	{header: "segmentsArrivalTimeEpochSeconds", key: utils.SegmentsArrivalTimeEpochSeconds, parse: stringValue},
+	{header: "segmentsArrivalTimeRaw", key: utils.SegmentsArrivalTimeRaw, parse: stringValue},
+	{header: "segmentsArrivalAirportCode", key: utils.SegmentsArrivalAirportCode, parse: stringValue},
+	{header: "segmentsDepartureAirportCode", key: utils.SegmentsDepartureAirportCode, parse: stringValue},
+	{header: "segmentsAirlineName", key: utils.SegmentsAirlineName, parse: stringValue},
+	{header: "segmentsAirlineCode", key: utils.SegmentsAirlineCode, parse: stringValue},
+	{header: "segmentsEquipmentDescription", key: utils.SegmentsEquipmentDescription, parse: stringValue},
+	{header: "segmentsDurationInSeconds", key: utils.SegmentsDurationInSeconds, parse: stringValue},
+	{header: "segmentsDistance", key: utils.SegmentsDistance, parse: stringValue},
+	{header: "segmentsCabinCode", key: utils.SegmentsCabinCode, parse: stringValue},
+}
 
-type FlightsParser struct{}
+type FlightsParser struct {
+	headerParser
+}
 
-// LineToDynMap Parses a line from the flights file
-func (a FlightsParser) LineToDynMap(line string) (*dataStructures.DynamicMap, error) {
-	fields := strings.Split(line, utils.CommaSeparator)
-	dynMap := dataStructures.NewDynamicMap(make(map[string][]byte))
-	if len(fields) != flightFileColumnCount {
-		return nil, fmt.Errorf("flights file has incorrect format: %v columns", len(fields))
-	}
-	dynMap.AddColumn(utils.LegId, serializer.SerializeString(fields[legIdPos]))
-	dynMap.AddColumn(utils.SearchDate, serializer.SerializeString(fields[searchDatePos]))
-	dynMap.AddColumn(utils.FlightDate, serializer.SerializeString(fields[flightDatePos]))
-	dynMap.AddColumn(utils.StartingAirport, serializer.SerializeString(fields[startingAirportPos]))
-	dynMap.AddColumn(utils.DestinationAirport, serializer.SerializeString(fields[destinationAirportPos]))
-	dynMap.AddColumn(utils.FareBasisCode, serializer.SerializeString(fields[fareBasisCodePos]))
-	dynMap.AddColumn(utils.TravelDuration, serializer.SerializeString(fields[travelDurationPos]))
-	dynMap.AddColumn(utils.ElapsedDays, serializer.SerializeString(fields[elapsedDaysPos]))
-	dynMap.AddColumn(utils.IsBasicEconomy, serializer.SerializeString(fields[isBasicEconomyPos]))
-	dynMap.AddColumn(utils.IsRefundable, serializer.SerializeString(fields[isRefundablePos]))
-	dynMap.AddColumn(utils.IsNonStop, serializer.SerializeString(fields[isNonStopPos]))
-	dynMap.AddColumn(utils.BaseFare, serializer.SerializeString(fields[baseFarePos]))
+func NewFlightsParser() *FlightsParser {
+	return &FlightsParser{headerParser{columns: flightColumns}}
+}
 
-	totalFare, err := strconv.ParseFloat(fields[totalFarePos], 32)
+// totalTravelDistance Parses the distance of the flight. It can be empty, in that case it is sent as zero
+func totalTravelDistance(value string) (dataStructures.Column, error) {
+	if value == "" {
+		return dataStructures.NewFloat32Column(0), nil
+	}
+	distance, err := strconv.ParseFloat(value, 32)
 	if err != nil {
-		return nil, fmt.Errorf("totalFare conversion to float: %v", err)
+		log.Warnf("FlightsParser | Error converting totalTravelDistance | %v | Will be sent as zero", err)
 	}
-	dynMap.AddColumn(utils.TotalFare, serializer.SerializeFloat(float32(totalFare)))
-	dynMap.AddColumn(utils.SeatsRemaining, serializer.SerializeString(fields[seatsRemainingPos]))
-	dynMap.AddColumn(utils.TotalTravelDistance, serializer.SerializeFloat(a.getTotalTravelDistance(fields)))
-	dynMap.AddColumn(utils.SegmentsDepartureTimeEpochSeconds, serializer.SerializeString(fields[segmentsDepartureTimeEpochSecondsPos]))
-	dynMap.AddColumn(utils.SegmentsDepartureTimeRaw, serializer.SerializeString(fields[segmentsDepartureTimeRawPos]))
-	dynMap.AddColumn(utils.SegmentsArrivalTimeEpochSeconds, serializer.SerializeString(fields[segmentsArrivalTimeEpochSecondsPos]))
-	dynMap.AddColumn(utils.SegmentsArrivalTimeRaw, serializer.SerializeString(fields[segmentsArrivalTimeRawPos]))
-	dynMap.AddColumn(utils.SegmentsArrivalAirportCode, serializer.SerializeString(fields[segmentsArrivalAirportCodePos]))
-	dynMap.AddColumn(utils.SegmentsDepartureAirportCode, serializer.SerializeString(fields[segmentsDepartureAirportCodePos]))
-	dynMap.AddColumn(utils.SegmentsAirlineName, serializer.SerializeString(fields[segmentsAirlineNamePos]))
-	dynMap.AddColumn(utils.SegmentsAirlineCode, serializer.SerializeString(fields[segmentsAirlineCodePos]))
-	dynMap.AddColumn(utils.SegmentsEquipmentDescription, serializer.SerializeString(fields[segmentsEquipmentDescriptionPos]))
-	dynMap.AddColumn(utils.SegmentsDurationInSeconds, serializer.SerializeString(fields[segmentsDurationInSecondsPos]))
-	dynMap.AddColumn(utils.SegmentsDistance, serializer.SerializeString(fields[segmentsDistancePos]))
-	dynMap.AddColumn(utils.SegmentsCabinCode, serializer.SerializeString(fields[segmentsCabinCodePos]))
-
-	return dynMap, nil
+	return dataStructures.NewFloat32Column(float32(distance)), nil
 }
 
-func (a FlightsParser) getTotalTravelDistance(fields []string) float32 {
-	if fields[totalTravelDistancePos] != "" {
-		totalTravelDistance, err := strconv.ParseFloat(fields[totalTravelDistancePos], 32)
-		if err != nil {
-			log.Warnf("FlightsParser | Error converting totalTravelDistance | %v | Will be sent as zero", err)
-		}
-		return float32(totalTravelDistance)
-	}
-	return 0
+func (a *FlightsParser) Separator() rune {
+	return flightsSeparator
 }
 
-func (a FlightsParser) GetEofMsgType() int {
+func (a *FlightsParser) GetEofMsgType() int {
 	return dataStructures.EOFFlightRows
 }
 
-func (a FlightsParser) GetMsgType() int {
+func (a *FlightsParser) GetMsgType() int {
 	return dataStructures.FlightRows
 }
@@ -0,0 +1,95 @@
+package parsers
+
+import (
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"strconv"
+	"strings"
+)
+
+const byteOrderMark = "\ufeff"
+
+// fileColumn Column of a file, with its name in the header and the one it has in the dynamic map
+type fileColumn struct {
+	header string
+	key    string
+	parse  func(value string) (dataStructures.Column, error)
+}
+
+// headerParser Converts the records of a file to dynamic maps finding the position of each column by the header,
+// so the columns can be in any order and the file can have other columns
+type headerParser struct {
+	columns   []fileColumn
+	positions []int
+	fields    int
+}
+
+// ReadHeader Finds the position of each column. The names are compared ignoring case and surrounding spaces
+func (p *headerParser) ReadHeader(header []string) error {
+	indexes := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, byteOrderMark)
+		}
+		indexes[normalizeColumnName(name)] = i
+	}
+	var missing []string
+	p.positions = make([]int, len(p.columns))
+	for i, column := range p.columns {
+		position, exists := indexes[normalizeColumnName(column.header)]
+		if !exists {
+			missing = append(missing, column.header)
+			continue
+		}
+		p.positions[i] = position
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the header is missing the columns %v", strings.Join(missing, ", "))
+	}
+	p.fields = len(header)
+	return nil
+}
+
+// RecordToDynMap Converts the record to a dynamic map. The record must have as many fields as the header
+func (p *headerParser) RecordToDynMap(record []string) (*dataStructures.DynamicMap, error) {
+	if p.positions == nil {
+		return nil, fmt.Errorf("the header was not read")
+	}
+	if len(record) != p.fields {
+		return nil, fmt.Errorf("the record has %v fields, expected %v", len(record), p.fields)
+	}
+	dynMap := dataStructures.NewDynamicMap(make(map[string]dataStructures.Column, len(p.columns)))
+	for i, column := range p.columns {
+		value, err := column.parse(record[p.positions[i]])
+		if err != nil {
+			return nil, fmt.Errorf("column %v: %v", column.header, err)
+		}
+		dynMap.AddColumn(column.key, value)
+	}
+	return dynMap, nil
+}
+
+func normalizeColumnName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func stringValue(value string) (dataStructures.Column, error) {
+	return dataStructures.NewStringColumn(value), nil
+}
+
+func float32Value(value string) (dataStructures.Column, error) {
+	number, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		return dataStructures.Column{}, fmt.Errorf("conversion to float: %v", err)
+	}
+	return dataStructures.NewFloat32Column(float32(number)), nil
+}
+
+// int32OrZero Parses the value as an int, using zero if it can not be parsed
+func int32OrZero(value string) (dataStructures.Column, error) {
+	number, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		number = 0
+	}
+	return dataStructures.NewInt32Column(int32(number)), nil
+}
@@ -0,0 +1,69 @@
+package parsers
+
+import (
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAirportsParserMapsTheColumnsByTheHeader(t *testing.T) {
+	parser := NewAirportsParser()
+	header := []string{byteOrderMark + "coordinates", "Latitude", "Longitude", "Airport Code", "Airport Name", "City Name", "Country Name",
+		"Country Code", "World Area Code", "City Name geo_name_id", "Country Name geo_name_id", "Extra"}
+	assert.Nil(t, parser.ReadHeader(header))
+
+	row, err := parser.RecordToDynMap([]string{"-34.8, -58.5", "-34.8", "-58.5", "EZE", "Ministro Pistarini; Ezeiza", "Buenos Aires",
+		"Argentina", "AR", "353", "3435910", "3865483", "ignored"})
+	assert.Nil(t, err)
+	code, _ := row.GetAsString(utils.AirportCode)
+	assert.Equal(t, "EZE", code)
+	name, _ := row.GetAsString(utils.AirportName)
+	assert.Equal(t, "Ministro Pistarini; Ezeiza", name)
+	latitude, _ := row.GetAsFloat(utils.Latitude)
+	assert.Equal(t, float32(-34.8), latitude)
+	area, _ := row.GetAsInt(utils.WorldAreaCode)
+	assert.Equal(t, 353, area)
+	assert.Equal(t, uint32(len(airportColumns)), row.GetColumnCount())
+}
+
+func TestReadHeaderWithMissingColumnsReturnsError(t *testing.T) {
+	parser := NewFlightsParser()
+	err := parser.ReadHeader([]string{"legId", "totalFare"})
+	assert.ErrorContains(t, err, "startingAirport")
+}
+
+func TestRecordToDynMapWithOtherAmountOfFieldsReturnsError(t *testing.T) {
+	parser := NewAirportsParser()
+	header := make([]string, 0, len(airportColumns))
+	for _, column := range airportColumns {
+		header = append(header, column.header)
+	}
+	assert.Nil(t, parser.ReadHeader(header))
+	_, err := parser.RecordToDynMap([]string{"EZE", "Ezeiza"})
+	assert.ErrorContains(t, err, "2 fields")
+}
+
+func TestFlightsParserWithInvalidTotalFareReturnsErrorAndEmptyDistanceIsZero(t *testing.T) {
+	parser := NewFlightsParser()
+	header := make([]string, 0, len(flightColumns))
+	for _, column := range flightColumns {
+		header = append(header, column.header)
+	}
+	assert.Nil(t, parser.ReadHeader(header))
+
+	record := make([]string, len(flightColumns))
+	record[12] = "not a number"
+	_, err := parser.RecordToDynMap(record)
+	assert.ErrorContains(t, err, "totalFare")
+
+	record[12] = "250.5"
+	row, err := parser.RecordToDynMap(record)
+	assert.Nil(t, err)
+	distance, _ := row.GetAsFloat(utils.TotalTravelDistance)
+	assert.Equal(t, float32(0), distance)
+}
+
+func TestRecordToDynMapBeforeReadingTheHeaderReturnsError(t *testing.T) {
+	_, err := NewFlightsParser().RecordToDynMap([]string{"a"})
+	assert.Error(t, err)
+}
@@ -5,7 +5,14 @@ import (
 )
 
 type Parser interface {
-	LineToDynMap(line string) (*dataStructures.DynamicMap, error)
+	// ReadHeader Maps the columns of the file by the names of its header
+	ReadHeader(header []string) error
+
+	// RecordToDynMap Converts a record of the file to a dynamic map
+	RecordToDynMap(record []string) (*dataStructures.DynamicMap, error)
+
+	// Separator Returns the separator of the fields of the file
+	Separator() rune
 
 	GetEofMsgType() int
 
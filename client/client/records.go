@@ -0,0 +1,72 @@
+package client
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	log "github.com/sirupsen/logrus"
+	"io"
+)
+
+// maxSkippedLinesErrors Amount of errors of the skipped lines sent to the server in the summary
+const maxSkippedLinesErrors = 10
+
+// recordsReader Reads the records of a file following RFC 4180, so the fields can be quoted and have
+// separators, escaped quotes or new lines inside
+type recordsReader struct {
+	reader *csv.Reader
+}
+
+func newRecordsReader(input io.Reader, separator rune) *recordsReader {
+	reader := csv.NewReader(input)
+	reader.Comma = separator
+	reader.FieldsPerRecord = -1
+	return &recordsReader{reader: reader}
+}
+
+// next Returns the next record and the line of the file where it starts. At the end of the file returns io.EOF.
+// A record that does not follow the format returns a *csv.ParseError, and the next one can still be read
+func (r *recordsReader) next() ([]string, int, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, parseErr.StartLine, err
+		}
+		return nil, 0, err
+	}
+	line, _ := r.reader.FieldPos(0)
+	return record, line, nil
+}
+
+// isRecordError Returns true if the error is of a record of the file, so it can be skipped
+func isRecordError(err error) bool {
+	var parseErr *csv.ParseError
+	return errors.As(err, &parseErr)
+}
+
+// skippedLines Summary of the lines of a file that could not be parsed
+type skippedLines struct {
+	fileName string
+	count    uint
+	errors   []string
+}
+
+// add Registers the line as skipped. Only the first errors are kept for the summary
+func (s *skippedLines) add(line int, err error) {
+	log.Warnf("FileSend | %v | line %v: %v | Skipping line", s.fileName, line, err)
+	s.count++
+	if len(s.errors) < maxSkippedLinesErrors {
+		s.errors = append(s.errors, fmt.Sprintf("%v line %v: %v", s.fileName, line, err))
+	}
+}
+
+// toDynMap Returns the summary to send to the server with the EOF of the file
+func (s *skippedLines) toDynMap() *dataStructures.DynamicMap {
+	summary := dataStructures.NewDynamicMap(make(map[string]dataStructures.Column))
+	summary.AddColumn(utils.SkippedLines, dataStructures.NewInt64Column(int64(s.count)))
+	summary.AddColumn(utils.SkippedLinesErrors, dataStructures.NewListColumn(s.errors))
+	return summary
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRecordsReaderHandlesQuotedFieldsAndReturnsTheLineOfEachRecord(t *testing.T) {
+	input := "a,b\n\"x, y\",\"say \"\"hi\"\"\"\n\"multi\nline\",z\nlast,row\n"
+	records := newRecordsReader(strings.NewReader(input), ',')
+
+	record, line, err := records.next()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, record)
+	assert.Equal(t, 1, line)
+
+	record, line, err = records.next()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"x, y", "say \"hi\""}, record)
+	assert.Equal(t, 2, line)
+
+	record, line, err = records.next()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"multi\nline", "z"}, record)
+	assert.Equal(t, 3, line)
+
+	record, line, err = records.next()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"last", "row"}, record)
+	assert.Equal(t, 5, line)
+
+	_, _, err = records.next()
+	assert.True(t, errors.Is(err, io.EOF))
+}
+
+func TestRecordsReaderContinuesAfterAnInvalidRecord(t *testing.T) {
+	records := newRecordsReader(strings.NewReader("a;b\nbad\"quote;c\nd;e\n"), ';')
+	_, _, _ = records.next()
+
+	_, line, err := records.next()
+	assert.True(t, isRecordError(err))
+	assert.Equal(t, 2, line)
+
+	record, line, err := records.next()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"d", "e"}, record)
+	assert.Equal(t, 3, line)
+}
+
+func TestSkippedLinesKeepsTheFirstErrors(t *testing.T) {
+	skipped := &skippedLines{fileName: "flights.csv"}
+	for i := 0; i < maxSkippedLinesErrors+5; i++ {
+		skipped.add(i+2, errors.New("invalid"))
+	}
+	assert.Equal(t, uint(maxSkippedLinesErrors+5), skipped.count)
+	assert.Len(t, skipped.errors, maxSkippedLinesErrors)
+	assert.Equal(t, "flights.csv line 2: invalid", skipped.errors[0])
+}
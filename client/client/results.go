@@ -0,0 +1,102 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/getters"
+	socketsProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/sockets"
+	log "github.com/sirupsen/logrus"
+)
+
+// AllQueries Queries that the system answers
+var AllQueries = []int{1, 2, 3, 4}
+
+// errNotRequested The session did not request the query, so it has no results
+var errNotRequested = errors.New("the query was not requested by the session")
+
+// errAborted The session was cancelled, so it has no results
+var errAborted = errors.New("the session was aborted")
+
+// FetchResults Requests the results of each query and writes them to a file in the directory.
+// The queries that the session did not request are skipped
+func FetchResults(uuid string, conn *socketsProtocol.SocketProtocolHandler, queries []int, format ResultsFormat, dir string) error {
+	log.Infof("Results | Requesting results")
+	for _, query := range queries {
+		writer, err := newResultsWriter(format, dir, query)
+		if err != nil {
+			return err
+		}
+		rows, err := fetchQueryResults(uuid, conn, query, writer)
+		closeErr := writer.Close()
+		if errors.Is(err, errNotRequested) {
+			log.Warnf("Results | Query %v was not requested by the session | Skipping it...", query)
+			if err = removeResultsFile(format, dir, query); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error writing results of query %v: %v", query, err)
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+		log.Infof("Results | Wrote %v rows of query %v to %v", rows, query, dir)
+	}
+	return nil
+}
+
+// fetchQueryResults Receives the results of a query until the EOF. If the connection fails they are requested again
+// from the last row received. Returns the amount of rows received
+func fetchQueryResults(uuid string, conn *socketsProtocol.SocketProtocolHandler, query int, writer resultsWriter) (int, error) {
+	row := 0
+	sendWithReconnection(conn, getters.GetExerciseMessageWithRow(uuid, query, row))
+	for {
+		msg, err := conn.Read()
+		if err != nil {
+			log.Errorf("Results | Error reading results | %v", err)
+			sendWithReconnection(conn, getters.GetExerciseMessageWithRow(uuid, query, row))
+			continue
+		}
+		if msg.TypeMessage == dataStructures.EOFGetter {
+			return row, nil
+		}
+		if msg.TypeMessage == dataStructures.NotRequested {
+			return row, errNotRequested
+		}
+		if msg.TypeMessage == dataStructures.Abort {
+			return row, errAborted
+		}
+		if err = writer.Write(msg.DynMaps); err != nil {
+			return row, err
+		}
+		row += len(msg.DynMaps)
+	}
+}
+
+// RequestStatus Asks the server where the session is
+func RequestStatus(uuid string, conn *socketsProtocol.SocketProtocolHandler) (*dataStructures.DynamicMap, error) {
+	sendWithReconnection(conn, dataStructures.NewCompleteMessage(dataStructures.GetStatus, []*dataStructures.DynamicMap{}, uuid, 0))
+	msg, err := conn.Read()
+	if err != nil {
+		return nil, err
+	}
+	if msg.TypeMessage != dataStructures.Status || len(msg.DynMaps) == 0 {
+		return nil, fmt.Errorf("got unexpected type of message %v", msg.TypeMessage)
+	}
+	return msg.DynMaps[0], nil
+}
+
+// RequestAbort Asks the server to cancel the session, so the system stops processing it and forgets its data
+func RequestAbort(uuid string, conn *socketsProtocol.SocketProtocolHandler) error {
+	sendWithReconnection(conn, dataStructures.NewCompleteMessage(dataStructures.Abort, []*dataStructures.DynamicMap{}, uuid, 0))
+	msg, err := conn.Read()
+	if err != nil {
+		return err
+	}
+	if msg.TypeMessage != dataStructures.Abort {
+		return fmt.Errorf("got unexpected type of message %v", msg.TypeMessage)
+	}
+	return nil
+}
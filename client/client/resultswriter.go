@@ -0,0 +1,184 @@
+package client
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ResultsFormat Format of the files where the results are written
+type ResultsFormat string
+
+const (
+	CSVFormat   ResultsFormat = "csv"
+	JSONLFormat ResultsFormat = "jsonl"
+)
+
+// ParseResultsFormat Returns the format with that name
+func ParseResultsFormat(name string) (ResultsFormat, error) {
+	switch ResultsFormat(name) {
+	case CSVFormat, JSONLFormat:
+		return ResultsFormat(name), nil
+	}
+	return "", fmt.Errorf("unknown results format %v, expected csv or jsonl", name)
+}
+
+// resultsWriter Writes the rows of the results of a query to a file
+type resultsWriter interface {
+	Write(rows []*dataStructures.DynamicMap) error
+	Close() error
+}
+
+// resultsFileName Returns the path of the file of the results of the query
+func resultsFileName(format ResultsFormat, dir string, query int) string {
+	return filepath.Join(dir, fmt.Sprintf("results_ex%v.%v", query, format))
+}
+
+// removeResultsFile Removes the file of the results of the query
+func removeResultsFile(format ResultsFormat, dir string, query int) error {
+	return os.Remove(resultsFileName(format, dir, query))
+}
+
+// newResultsWriter Creates the file of the results of the query in the directory, replacing it if it exists
+func newResultsWriter(format ResultsFormat, dir string, query int) (resultsWriter, error) {
+	const outputDirPerm = 0755
+	if err := os.MkdirAll(dir, outputDirPerm); err != nil {
+		return nil, err
+	}
+	file, err := os.Create(resultsFileName(format, dir, query))
+	if err != nil {
+		return nil, err
+	}
+	if format == JSONLFormat {
+		return &jsonlResultsWriter{file: file, encoder: json.NewEncoder(file)}, nil
+	}
+	return &csvResultsWriter{file: file, writer: csv.NewWriter(file)}, nil
+}
+
+// csvResultsWriter Writes the rows as csv. The header has the columns of the first row sorted by name
+type csvResultsWriter struct {
+	file    *os.File
+	writer  *csv.Writer
+	columns []string
+}
+
+func (w *csvResultsWriter) Write(rows []*dataStructures.DynamicMap) error {
+	for _, row := range rows {
+		currentMap := row.GetCurrentMap()
+		if w.columns == nil {
+			for column := range currentMap {
+				w.columns = append(w.columns, column)
+			}
+			sort.Strings(w.columns)
+			if err := w.writer.Write(w.columns); err != nil {
+				return err
+			}
+		}
+		record := make([]string, len(w.columns))
+		for i, key := range w.columns {
+			column, exists := currentMap[key]
+			if !exists {
+				continue
+			}
+			value, err := serializer.ColumnToString(column)
+			if err != nil {
+				return fmt.Errorf("column %v: %v", key, err)
+			}
+			record[i] = value
+		}
+		if err := w.writer.Write(record); err != nil {
+			return err
+		}
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvResultsWriter) Close() error {
+	w.writer.Flush()
+	return w.file.Close()
+}
+
+// jsonlResultsWriter Writes each row as a json object in its own line, keeping the type of the values
+type jsonlResultsWriter struct {
+	file    *os.File
+	encoder *json.Encoder
+}
+
+func (w *jsonlResultsWriter) Write(rows []*dataStructures.DynamicMap) error {
+	for _, row := range rows {
+		object := make(map[string]any)
+		for key := range row.GetCurrentMap() {
+			value, err := columnValue(row, key)
+			if err != nil {
+				return fmt.Errorf("column %v: %v", key, err)
+			}
+			object[key] = value
+		}
+		if err := w.encoder.Encode(object); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *jsonlResultsWriter) Close() error {
+	return w.file.Close()
+}
+
+// columnValue Returns the value of the column with the go type of its column type
+func columnValue(row *dataStructures.DynamicMap, key string) (any, error) {
+	columnType, err := row.GetType(key)
+	if err != nil {
+		return nil, err
+	}
+	switch columnType {
+	case dataStructures.Int32Type:
+		return row.GetAsInt(key)
+	case dataStructures.Int64Type:
+		return row.GetAsInt64(key)
+	case dataStructures.Float32Type:
+		return row.GetAsFloat(key)
+	case dataStructures.Float64Type:
+		return row.GetAsFloat64(key)
+	case dataStructures.StringType:
+		return row.GetAsString(key)
+	case dataStructures.BoolType:
+		return row.GetAsBool(key)
+	case dataStructures.TimestampType:
+		return row.GetAsTimestamp(key)
+	case dataStructures.ListType:
+		return row.GetAsList(key)
+	}
+	return nil, fmt.Errorf("unknown column type %v", columnType)
+}
+
+// writeSkippedLines Writes to the directory the summary of the lines that were skipped in the upload of the session.
+// If no line was skipped the file is not created
+func writeSkippedLines(dir string, status *dataStructures.DynamicMap) error {
+	airports, errAirports := status.GetAsInt64(utils.SkippedAirportLines)
+	flights, errFlights := status.GetAsInt64(utils.SkippedFlightLines)
+	if errAirports != nil || errFlights != nil || airports+flights == 0 {
+		return nil
+	}
+	lineErrors, err := status.GetAsList(utils.SkippedLinesErrors)
+	if err != nil {
+		return err
+	}
+	log.Warnf("Results | %v lines of the airports and %v of the flights were skipped in the upload", airports, flights)
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("airports: %v%vflights: %v%v", airports, utils.NewLine, flights, utils.NewLine))
+	for _, lineError := range lineErrors {
+		builder.WriteString(lineError + utils.NewLine)
+	}
+	const skippedLinesPerm = 0644
+	return os.WriteFile(filepath.Join(dir, "skipped_lines.txt"), []byte(builder.String()), skippedLinesPerm)
+}
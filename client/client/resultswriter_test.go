@@ -0,0 +1,53 @@
+package client
+
+import (
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func resultRows() []*dataStructures.DynamicMap {
+	return []*dataStructures.DynamicMap{
+		dataStructures.NewDynamicMap(map[string]dataStructures.Column{
+			"legId":  dataStructures.NewStringColumn("abc"),
+			"stops":  dataStructures.NewInt32Column(3),
+			"direct": dataStructures.NewBoolColumn(false),
+		}),
+		dataStructures.NewDynamicMap(map[string]dataStructures.Column{
+			"legId":  dataStructures.NewStringColumn("d,e"),
+			"stops":  dataStructures.NewInt32Column(1),
+			"direct": dataStructures.NewBoolColumn(true),
+		}),
+	}
+}
+
+func TestCSVResultsWriterWritesTheHeaderAndTheRows(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := newResultsWriter(CSVFormat, dir, 2)
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Write(resultRows()))
+	assert.Nil(t, writer.Close())
+
+	content, err := os.ReadFile(filepath.Join(dir, "results_ex2.csv"))
+	assert.Nil(t, err)
+	assert.Equal(t, "direct,legId,stops\nfalse,abc,3\ntrue,\"d,e\",1\n", string(content))
+}
+
+func TestJSONLResultsWriterKeepsTheTypesOfTheValues(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := newResultsWriter(JSONLFormat, dir, 1)
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Write(resultRows()))
+	assert.Nil(t, writer.Close())
+
+	content, err := os.ReadFile(filepath.Join(dir, "results_ex1.jsonl"))
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"direct\":false,\"legId\":\"abc\",\"stops\":3}\n{\"direct\":true,\"legId\":\"d,e\",\"stops\":1}\n", string(content))
+}
+
+func TestParseResultsFormatWithAnUnknownFormatReturnsError(t *testing.T) {
+	_, err := ParseResultsFormat("xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// fileProgress Progress of the upload of a file. Only the records of the batches acknowledged by the server are counted,
+// along with the records skipped until them
+type fileProgress struct {
+	recordsAcked  uint
+	nextMessageId uint
+	done          bool
+	skipped       uint
+}
+
+// uploadState Progress of the upload saved in a file, so a restarted client resumes it instead of sending everything again.
+// The first line of the file is the uuid of the client, followed by a line per file with the format
+// recordsAcked,nextMessageId,done,skipped,path
+type uploadState struct {
+	fileName string
+	uuid     string
+	files    map[string]*fileProgress
+	// restored True if the progress was read from the file
+	restored bool
+}
+
+// loadUploadState Restores the progress saved in the file. If there is no progress, or it belongs to another session
+// than the one received, a new upload is started. Without a session a new one is created
+func loadUploadState(fileName string, session string) *uploadState {
+	if filemanager.DirectoryExists(fileName) {
+		restored, err := readUploadState(fileName)
+		if err != nil {
+			log.Errorf("UploadState | Error restoring the upload from %v, starting a new one | %v", fileName, err)
+		} else if session == "" || session == restored.uuid {
+			log.Infof("UploadState | Resuming session %v", restored.uuid)
+			return restored
+		}
+	}
+	if session == "" {
+		session = uuid.New().String()
+	}
+	return &uploadState{fileName: fileName, uuid: session, files: make(map[string]*fileProgress)}
+}
+
+func readUploadState(fileName string) (*uploadState, error) {
+	reader, err := filemanager.NewFileReader(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.CloseFileAndNotifyError(reader.FileManager)
+	if !reader.CanRead() {
+		return nil, fmt.Errorf("the file is empty")
+	}
+	state := &uploadState{fileName: fileName, uuid: reader.ReadLine(), files: make(map[string]*fileProgress), restored: true}
+	for reader.CanRead() {
+		fields := strings.SplitN(reader.ReadLine(), utils.CommaSeparator, 5)
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("invalid line: %v", reader.ReadLine())
+		}
+		recordsAcked, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		nextMessageId, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		done, err := strconv.ParseBool(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		skipped, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		state.files[fields[4]] = &fileProgress{recordsAcked: uint(recordsAcked), nextMessageId: uint(nextMessageId), done: done, skipped: uint(skipped)}
+	}
+	return state, reader.Err()
+}
+
+// progressOf Returns the progress of the file, starting it if it was not sent before
+func (s *uploadState) progressOf(path string) *fileProgress {
+	progress, exists := s.files[path]
+	if !exists {
+		progress = &fileProgress{}
+		s.files[path] = progress
+	}
+	return progress
+}
+
+// save Writes the progress to a temporal file that then replaces the previous one, so a crash does not leave it half written
+func (s *uploadState) save() error {
+	var builder strings.Builder
+	builder.WriteString(s.uuid + utils.NewLine)
+	for path, progress := range s.files {
+		builder.WriteString(fmt.Sprintf("%v,%v,%v,%v,%v%v", progress.recordsAcked, progress.nextMessageId, progress.done, progress.skipped, path, utils.NewLine))
+	}
+	tmpFileName := s.fileName + ".tmp"
+	const stateFilePerm = 0644
+	err := os.WriteFile(tmpFileName, []byte(builder.String()), stateFilePerm)
+	if err != nil {
+		return err
+	}
+	return filemanager.RenameFile(tmpFileName, s.fileName)
+}
+
+// remove Deletes the saved progress once the upload and the results are finished
+func (s *uploadState) remove() {
+	if filemanager.DirectoryExists(s.fileName) {
+		_ = filemanager.DeleteFile(s.fileName)
+	}
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestUploadStateSavedIsRestoredWithTheProgressOfEachFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "upload_state.csv")
+	state := loadUploadState(fileName, "client-uuid")
+	airports := state.progressOf("/data/airports.csv")
+	airports.recordsAcked = 300
+	airports.nextMessageId = 3
+	airports.done = true
+	flights := state.progressOf("/data/flights,2023.csv")
+	flights.recordsAcked = 1500
+	flights.skipped = 2
+	flights.nextMessageId = 15
+	assert.Nil(t, state.save())
+
+	restored := loadUploadState(fileName, "")
+	assert.True(t, restored.restored)
+	assert.Equal(t, "client-uuid", restored.uuid)
+	assert.Equal(t, &fileProgress{recordsAcked: 300, nextMessageId: 3, done: true}, restored.progressOf("/data/airports.csv"))
+	assert.Equal(t, &fileProgress{recordsAcked: 1500, nextMessageId: 15, skipped: 2}, restored.progressOf("/data/flights,2023.csv"))
+
+	another := loadUploadState(fileName, "another-uuid")
+	assert.Equal(t, "another-uuid", another.uuid)
+	assert.Equal(t, &fileProgress{}, another.progressOf("/data/airports.csv"))
+
+	restored.remove()
+	assert.False(t, loadUploadState(fileName, "").restored)
+}
+
+func TestUploadStateWithoutSavedProgressStartsANewUpload(t *testing.T) {
+	state := loadUploadState(filepath.Join(t.TempDir(), "missing.csv"), "client-uuid")
+	assert.Equal(t, "client-uuid", state.uuid)
+	assert.Equal(t, &fileProgress{}, state.progressOf("/data/airports.csv"))
+}
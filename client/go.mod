@@ -1,3 +1,5 @@
 module client
 
 go 1.21
+
+require github.com/klauspost/compress v1.17.0
@@ -2,18 +2,113 @@ package main
 
 import (
 	"client/client"
+	"flag"
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"os"
+	"sort"
 )
 
+const usage = `Usage: client [command] [flags]
+
+Commands:
+  run       Uploads the files and writes the results of the requested queries (default)
+  upload    Uploads the files and prints the session
+  results   Writes the results of the session to files
+  status    Prints the stage of the session
+  cancel    Aborts the session, the system stops processing it and forgets its data
+
+Flags:
+  --session   Session to use. By default the one of the saved upload, or a new one
+  --query     Query of the results, 0 for the ones requested in query.queries (results)
+  --format    Format of the results files, csv or jsonl (run, results)
+  --out       Directory of the results files (run, results)
+`
+
+const defaultOutDir = "results"
+
+// command Subcommand selected and its flags
+type command struct {
+	name    string
+	session string
+	query   int
+	format  client.ResultsFormat
+	outDir  string
+}
+
+func parseCommand(args []string) *command {
+	cmd := &command{name: "run"}
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		cmd.name = args[0]
+		args = args[1:]
+	}
+	flags := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flags.StringVar(&cmd.session, "session", "", "session to use")
+	var format string
+	if cmd.name == "results" {
+		flags.IntVar(&cmd.query, "query", 0, "query of the results, 0 for the requested ones")
+	}
+	if cmd.name == "results" || cmd.name == "run" {
+		flags.StringVar(&format, "format", string(client.CSVFormat), "format of the results files")
+		flags.StringVar(&cmd.outDir, "out", defaultOutDir, "directory of the results files")
+	}
+	switch cmd.name {
+	case "run", "upload", "results", "status", "cancel":
+	default:
+		log.Fatalf("Main - Client | Unknown command %v\n%v", cmd.name, usage)
+	}
+	_ = flags.Parse(args)
+	if format != "" {
+		var err error
+		cmd.format, err = client.ParseResultsFormat(format)
+		if err != nil {
+			log.Fatalf("Main - Client | %v", err)
+		}
+	}
+	if cmd.query < 0 || cmd.query > len(client.AllQueries) {
+		log.Fatalf("Main - Client | Invalid query %v, expected a value between 0 and %v", cmd.query, len(client.AllQueries))
+	}
+	return cmd
+}
+
+// queries Returns the queries of the results, by default the ones requested
+func (cmd *command) queries(requested []int) []int {
+	if cmd.query == 0 {
+		return requested
+	}
+	return []int{cmd.query}
+}
+
 func handleSignals(sigs chan os.Signal, c *client.Client) {
 	<-sigs
 	c.Close()
 }
 
+func printStatus(session string, status *dataStructures.DynamicMap) {
+	fmt.Printf("session: %v\n", session)
+	columns := status.GetCurrentMap()
+	keys := make([]string, 0, len(columns))
+	for key := range columns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, err := serializer.ColumnToString(columns[key])
+		if err != nil {
+			log.Errorf("Main - Client | Error printing %v | %v", key, err)
+			continue
+		}
+		fmt.Printf("%v: %v\n", key, value)
+	}
+}
+
 func main() {
 	sigs := utils.CreateSignalListener()
+	cmd := parseCommand(os.Args[1:])
 
 	env, err := client.InitEnv()
 	if err != nil {
@@ -24,7 +119,43 @@ func main() {
 	if err != nil {
 		log.Fatalf("Main - Client | Error initializing config | %s", err)
 	}
+	if cmd.session != "" {
+		clientConfig.Uuid = cmd.session
+	}
 	c := client.NewClient(clientConfig)
+	defer c.Close()
 	go handleSignals(sigs, c)
-	c.StartClientLoop()
+
+	switch cmd.name {
+	case "upload":
+		err = c.Upload()
+		if err == nil {
+			fmt.Println(c.Session())
+		}
+	case "results", "status", "cancel":
+		if !c.KnownSession() {
+			log.Fatalf("Main - Client | Missing session, use --session or upload the files first")
+		}
+		if cmd.name == "results" {
+			err = c.Results(cmd.queries(clientConfig.Params.Queries), cmd.format, cmd.outDir)
+			break
+		}
+		if cmd.name == "cancel" {
+			err = c.Cancel()
+			if err == nil {
+				fmt.Printf("session %v cancelled\n", c.Session())
+			}
+			break
+		}
+		var status *dataStructures.DynamicMap
+		status, err = c.Status()
+		if err == nil {
+			printStatus(c.Session(), status)
+		}
+	default:
+		err = c.Run(cmd.format, cmd.outDir)
+	}
+	if err != nil {
+		log.Fatalf("Main - Client | Error running %v | %v", cmd.name, err)
+	}
 }
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	"github.com/sirupsen/logrus"
+	"time"
 )
 
 // InitLogger Receives the log level to be set in logrus as a string. This method
@@ -21,3 +24,28 @@ func InitLogger(logLevel string) error {
 	logrus.SetLevel(level)
 	return nil
 }
+
+// GetCompressions Receives the compression to use for each queue or exchange, as a map from its name to the
+// name of the algorithm, and returns it to be passed to the producers. Returns an error if an algorithm is not valid
+func GetCompressions(names map[string]string) (queues.Compressions, error) {
+	compressions, err := queues.NewCompressions(names)
+	if err != nil {
+		return nil, err
+	}
+	for name, compression := range compressions {
+		logrus.Infof("Config | Messages sent to %v will be compressed with %v", name, compression)
+	}
+	return compressions, nil
+}
+
+// InitRetryPolicy Sets the policy of the messages that the consumers created afterward retry later.
+// The values that are zero keep their default. Returns an error if the policy is not valid
+func InitRetryPolicy(delay time.Duration, maxDelay time.Duration, maxAttempts int) error {
+	policy, err := middleware.NewRetryPolicy(delay, maxDelay, maxAttempts)
+	if err != nil {
+		return err
+	}
+	queues.SetRetryPolicy(policy)
+	logrus.Infof("Config | Messages are retried after %v, doubling up to %v, at most %v times", policy.Delay, policy.MaxDelay, policy.MaxAttempts)
+	return nil
+}
@@ -0,0 +1,36 @@
+package data_structures
+
+import "fmt"
+
+// BatchEncoding Format used to encode the rows of a message. It travels in the header of the message
+type BatchEncoding byte
+
+const (
+	// RowsEncoding Each row is encoded with the names and types of its columns
+	RowsEncoding BatchEncoding = iota
+	// ColumnarEncoding The schema is encoded once per message followed by a vector of values for each column
+	ColumnarEncoding
+)
+
+var batchEncodingNames = map[BatchEncoding]string{
+	RowsEncoding:     "rows",
+	ColumnarEncoding: "columnar",
+}
+
+func (e BatchEncoding) String() string {
+	name, exists := batchEncodingNames[e]
+	if !exists {
+		return fmt.Sprintf("unknown(%d)", byte(e))
+	}
+	return name
+}
+
+// ParseBatchEncoding Returns the encoding with the name received
+func ParseBatchEncoding(name string) (BatchEncoding, error) {
+	for encoding, encodingName := range batchEncodingNames {
+		if encodingName == name {
+			return encoding, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown batch encoding %v", name)
+}
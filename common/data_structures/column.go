@@ -0,0 +1,159 @@
+package data_structures
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ColumnType Type tag of the value of a column
+type ColumnType byte
+
+const (
+	Int32Type ColumnType = iota + 1
+	Int64Type
+	Float32Type
+	Float64Type
+	StringType
+	BoolType
+	TimestampType
+	ListType
+)
+
+var columnTypeNames = map[ColumnType]string{
+	Int32Type:     "int32",
+	Int64Type:     "int64",
+	Float32Type:   "float32",
+	Float64Type:   "float64",
+	StringType:    "string",
+	BoolType:      "bool",
+	TimestampType: "timestamp",
+	ListType:      "list",
+}
+
+// fixedSizes Size in bytes of the values of the types that are not variable
+var fixedSizes = map[ColumnType]int{
+	Int32Type:     4,
+	Int64Type:     8,
+	Float32Type:   4,
+	Float64Type:   8,
+	BoolType:      1,
+	TimestampType: 8,
+}
+
+func (t ColumnType) String() string {
+	name, exists := columnTypeNames[t]
+	if !exists {
+		return fmt.Sprintf("unknown(%d)", byte(t))
+	}
+	return name
+}
+
+// ColumnTypeSize Returns the size of the values of the type, or false if it is variable
+func ColumnTypeSize(columnType ColumnType) (int, bool) {
+	size, isFixed := fixedSizes[columnType]
+	return size, isFixed
+}
+
+// ParseColumnType Returns the type with the name received
+func ParseColumnType(name string) (ColumnType, error) {
+	for columnType, typeName := range columnTypeNames {
+		if typeName == name {
+			return columnType, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown column type %v", name)
+}
+
+// Column Value of a column of a DynamicMap with the tag of its type
+type Column struct {
+	Type  ColumnType
+	Value []byte
+}
+
+// NewColumn Creates a column from its type and encoded value, validating that the value is valid for the type
+func NewColumn(columnType ColumnType, value []byte) (Column, error) {
+	if _, exists := columnTypeNames[columnType]; !exists {
+		return Column{}, fmt.Errorf("unknown column type %d", byte(columnType))
+	}
+	if size, isFixed := fixedSizes[columnType]; isFixed && len(value) != size {
+		return Column{}, fmt.Errorf("a %v value has %v bytes, got %v", columnType, size, len(value))
+	}
+	if columnType == ListType {
+		if _, err := decodeList(value); err != nil {
+			return Column{}, err
+		}
+	}
+	return Column{Type: columnType, Value: value}, nil
+}
+
+func NewInt32Column(value int32) Column {
+	return Column{Type: Int32Type, Value: binary.BigEndian.AppendUint32(nil, uint32(value))}
+}
+
+func NewInt64Column(value int64) Column {
+	return Column{Type: Int64Type, Value: binary.BigEndian.AppendUint64(nil, uint64(value))}
+}
+
+func NewFloat32Column(value float32) Column {
+	return Column{Type: Float32Type, Value: binary.BigEndian.AppendUint32(nil, math.Float32bits(value))}
+}
+
+func NewFloat64Column(value float64) Column {
+	return Column{Type: Float64Type, Value: binary.BigEndian.AppendUint64(nil, math.Float64bits(value))}
+}
+
+func NewStringColumn(value string) Column {
+	return Column{Type: StringType, Value: []byte(value)}
+}
+
+func NewBoolColumn(value bool) Column {
+	if value {
+		return Column{Type: BoolType, Value: []byte{1}}
+	}
+	return Column{Type: BoolType, Value: []byte{0}}
+}
+
+// NewTimestampColumn The timestamp is kept with nanoseconds precision in UTC
+func NewTimestampColumn(value time.Time) Column {
+	return Column{Type: TimestampType, Value: binary.BigEndian.AppendUint64(nil, uint64(value.UnixNano()))}
+}
+
+// NewListColumn A list of strings, encoded as the amount of elements followed by the length and bytes of each one
+func NewListColumn(values []string) Column {
+	encoded := binary.BigEndian.AppendUint32(nil, uint32(len(values)))
+	for _, value := range values {
+		encoded = binary.BigEndian.AppendUint32(encoded, uint32(len(value)))
+		encoded = append(encoded, value...)
+	}
+	return Column{Type: ListType, Value: encoded}
+}
+
+func decodeList(encoded []byte) ([]string, error) {
+	if len(encoded) < 4 {
+		return nil, fmt.Errorf("list of %v bytes does not have its length", len(encoded))
+	}
+	count := int(binary.BigEndian.Uint32(encoded[0:4]))
+	offset := 4
+	if count > (len(encoded)-offset)/4 {
+		return nil, fmt.Errorf("list of %v elements does not fit in %v bytes", count, len(encoded))
+	}
+	values := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		if len(encoded)-offset < 4 {
+			return nil, fmt.Errorf("element %v of the list is out of bounds", i)
+		}
+		length := int(binary.BigEndian.Uint32(encoded[offset : offset+4]))
+		offset += 4
+		if length > len(encoded)-offset {
+			return nil, fmt.Errorf("element %v of the list is out of bounds", i)
+		}
+		values = append(values, string(encoded[offset:offset+length]))
+		offset += length
+	}
+	if offset != len(encoded) {
+		return nil, fmt.Errorf("%v bytes left after the last element of the list", len(encoded)-offset)
+	}
+	return values, nil
+}
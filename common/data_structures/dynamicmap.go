@@ -4,43 +4,93 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+	"time"
 )
 
 type DynamicMap struct {
-	cols map[string][]byte
+	cols map[string]Column
 }
 
-func NewDynamicMap(dynMap map[string][]byte) *DynamicMap {
+func NewDynamicMap(dynMap map[string]Column) *DynamicMap {
 	return &DynamicMap{
 		cols: dynMap,
 	}
 }
 
-func (dm *DynamicMap) GetAsInt(colName string) (int, error) {
-	data, exists := dm.cols[colName]
+// getTyped Returns the value of the column if it exists and has the type expected
+func (dm *DynamicMap) getTyped(colName string, columnType ColumnType) ([]byte, error) {
+	column, exists := dm.cols[colName]
 	if !exists {
-		return 0, fmt.Errorf("column %v does not exist", colName)
+		return nil, fmt.Errorf("column %v does not exist", colName)
+	}
+	if column.Type != columnType {
+		return nil, fmt.Errorf("column %v is of type %v, not %v", colName, column.Type, columnType)
+	}
+	return column.Value, nil
+}
+
+func (dm *DynamicMap) GetAsInt(colName string) (int, error) {
+	data, err := dm.getTyped(colName, Int32Type)
+	if err != nil {
+		return 0, err
 	}
-	intValue := int(binary.BigEndian.Uint32(data))
-	return intValue, nil
+	return int(int32(binary.BigEndian.Uint32(data))), nil
+}
+
+func (dm *DynamicMap) GetAsInt64(colName string) (int64, error) {
+	data, err := dm.getTyped(colName, Int64Type)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(data)), nil
 }
 
 func (dm *DynamicMap) GetAsFloat(colName string) (float32, error) {
-	data, exists := dm.cols[colName]
-	if !exists {
-		return 0, fmt.Errorf("column %v does not exist", colName)
+	data, err := dm.getTyped(colName, Float32Type)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float32frombits(binary.BigEndian.Uint32(data)), nil
+}
+
+func (dm *DynamicMap) GetAsFloat64(colName string) (float64, error) {
+	data, err := dm.getTyped(colName, Float64Type)
+	if err != nil {
+		return 0, err
 	}
-	floatValue := math.Float32frombits(binary.BigEndian.Uint32(data))
-	return floatValue, nil
+	return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
 }
 
 func (dm *DynamicMap) GetAsString(colName string) (string, error) {
-	data, exists := dm.cols[colName]
-	if !exists {
-		return "", fmt.Errorf("column %v does not exist", colName)
+	data, err := dm.getTyped(colName, StringType)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (dm *DynamicMap) GetAsBool(colName string) (bool, error) {
+	data, err := dm.getTyped(colName, BoolType)
+	if err != nil {
+		return false, err
+	}
+	return data[0] != 0, nil
+}
+
+func (dm *DynamicMap) GetAsTimestamp(colName string) (time.Time, error) {
+	data, err := dm.getTyped(colName, TimestampType)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(data))).UTC(), nil
+}
+
+func (dm *DynamicMap) GetAsList(colName string) ([]string, error) {
+	data, err := dm.getTyped(colName, ListType)
+	if err != nil {
+		return nil, err
 	}
-	strVal := string(data)
-	return strVal, nil
+	return decodeList(data)
 }
 
 // GetAsBytes
@@ -50,11 +100,20 @@ func (dm *DynamicMap) GetAsString(colName string) (string, error) {
 	Returns error on lack of existence of the column
 */
 func (dm *DynamicMap) GetAsBytes(colName string) ([]byte, error) {
-	data, exists := dm.cols[colName]
+	column, exists := dm.cols[colName]
 	if !exists {
 		return nil, fmt.Errorf("column %v does not exist", colName)
 	}
-	return data, nil
+	return column.Value, nil
+}
+
+// GetType Returns the type of the column, or error if it does not exist
+func (dm *DynamicMap) GetType(colName string) (ColumnType, error) {
+	column, exists := dm.cols[colName]
+	if !exists {
+		return 0, fmt.Errorf("column %v does not exist", colName)
+	}
+	return column.Type, nil
 }
 
 // ReduceToColumns
@@ -63,7 +122,7 @@ func (dm *DynamicMap) GetAsBytes(colName string) ([]byte, error) {
 	Returns error if a column does not exist
 */
 func (dm *DynamicMap) ReduceToColumns(columns []string) (*DynamicMap, error) {
-	reducedRow := make(map[string][]byte)
+	reducedRow := make(map[string]Column)
 	for _, col := range columns {
 		data, exists := dm.cols[col]
 		if !exists {
@@ -75,17 +134,13 @@ func (dm *DynamicMap) ReduceToColumns(columns []string) (*DynamicMap, error) {
 }
 
 func (dm *DynamicMap) GetColumnCount() uint32 {
-	totalLong := 0
-	for _, _ = range dm.cols {
-		totalLong += 1
-	}
-	return uint32(totalLong)
+	return uint32(len(dm.cols))
 }
 
-func (dm *DynamicMap) GetCurrentMap() map[string][]byte {
+func (dm *DynamicMap) GetCurrentMap() map[string]Column {
 	return dm.cols
 }
 
-func (dm *DynamicMap) AddColumn(key string, value []byte) {
+func (dm *DynamicMap) AddColumn(key string, value Column) {
 	dm.cols[key] = value
 }
@@ -1,16 +1,14 @@
 package data_structures
 
 import (
-	"encoding/binary"
 	"github.com/stretchr/testify/assert"
-	"math"
 	"testing"
+	"time"
 )
 
 func TestGetAsAllGetsOfNonExistentColumnShouldThrowError(t *testing.T) {
-	dynMap := make(map[string][]byte)
-	dynMap["test"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test"], uint32(32))
+	dynMap := make(map[string]Column)
+	dynMap["test"] = NewInt32Column(32)
 	row := NewDynamicMap(dynMap)
 	_, err := row.GetAsInt("non_existent")
 	assert.Error(t, err, "GetAsInt should have thrown error")
@@ -26,9 +24,8 @@ func TestGetAsAllGetsOfNonExistentColumnShouldThrowError(t *testing.T) {
 }
 
 func TestGetAsIntAnIntColumn(t *testing.T) {
-	dynMap := make(map[string][]byte)
-	dynMap["test"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test"], uint32(32))
+	dynMap := make(map[string]Column)
+	dynMap["test"] = NewInt32Column(32)
 	row := NewDynamicMap(dynMap)
 	val, err := row.GetAsInt("test")
 
@@ -37,9 +34,8 @@ func TestGetAsIntAnIntColumn(t *testing.T) {
 }
 
 func TestGetAsFloatAFloat32Column(t *testing.T) {
-	dynMap := make(map[string][]byte)
-	dynMap["test"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test"], math.Float32bits(32.0))
+	dynMap := make(map[string]Column)
+	dynMap["test"] = NewFloat32Column(32.0)
 	row := NewDynamicMap(dynMap)
 	val, err := row.GetAsFloat("test")
 
@@ -48,8 +44,8 @@ func TestGetAsFloatAFloat32Column(t *testing.T) {
 }
 
 func TestGetAsStringAStringColumn(t *testing.T) {
-	dynMap := make(map[string][]byte)
-	dynMap["test"] = []byte("stringval")
+	dynMap := make(map[string]Column)
+	dynMap["test"] = NewStringColumn("stringval")
 	row := NewDynamicMap(dynMap)
 	val, err := row.GetAsString("test")
 
@@ -58,14 +54,13 @@ func TestGetAsStringAStringColumn(t *testing.T) {
 }
 
 func TestShouldReturnANewRowWithOnlyOneColumnWhenReducingTheRow(t *testing.T) {
-	dynMap := make(map[string][]byte)
+	dynMap := make(map[string]Column)
 	const columnToRemove1 = "col1"
 	const columnToRemove2 = "col2"
 	const columnToKeep = "col3"
-	dynMap[columnToRemove1] = []byte("Some data")
-	dynMap[columnToKeep] = []byte("More data")
-	dynMap[columnToRemove2] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap[columnToRemove2], uint32(5))
+	dynMap[columnToRemove1] = NewStringColumn("Some data")
+	dynMap[columnToKeep] = NewStringColumn("More data")
+	dynMap[columnToRemove2] = NewInt32Column(5)
 	row := NewDynamicMap(dynMap)
 
 	keepCols := []string{columnToKeep}
@@ -84,13 +79,12 @@ func TestShouldReturnANewRowWithOnlyOneColumnWhenReducingTheRow(t *testing.T) {
 }
 
 func TestShouldReturnAnErrorIfAColumnDoesNotExistWhenReducingTheRow(t *testing.T) {
-	dynMap := make(map[string][]byte)
+	dynMap := make(map[string]Column)
 	const columnToRemove1 = "col1"
 	const columnToRemove2 = "col2"
 	const columnToKeepThatIsNotSaved = "col3"
-	dynMap[columnToRemove1] = []byte("Some data")
-	dynMap[columnToRemove2] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap[columnToRemove2], uint32(5))
+	dynMap[columnToRemove1] = NewStringColumn("Some data")
+	dynMap[columnToRemove2] = NewInt32Column(5)
 	row := NewDynamicMap(dynMap)
 
 	keepCols := []string{columnToKeepThatIsNotSaved}
@@ -99,7 +93,7 @@ func TestShouldReturnAnErrorIfAColumnDoesNotExistWhenReducingTheRow(t *testing.T
 }
 
 func TestGetColumnCountWithZeroColumnsReturnZero(t *testing.T) {
-	dynMap := make(map[string][]byte)
+	dynMap := make(map[string]Column)
 	row := NewDynamicMap(dynMap)
 	colCount := row.GetColumnCount()
 	assert.Equalf(t, uint32(0), colCount, "Column count should be 0 and returned %v", colCount)
@@ -107,18 +101,18 @@ func TestGetColumnCountWithZeroColumnsReturnZero(t *testing.T) {
 }
 
 func TestGetColumnCountWithTwoColumnsShouldReturnTwo(t *testing.T) {
-	dynMap := make(map[string][]byte)
-	dynMap["test"] = make([]byte, 4)
-	dynMap["test_2"] = make([]byte, 4)
+	dynMap := make(map[string]Column)
+	dynMap["test"] = NewInt32Column(0)
+	dynMap["test_2"] = NewInt32Column(0)
 	row := NewDynamicMap(dynMap)
 	colCount := row.GetColumnCount()
 	assert.Equalf(t, uint32(2), colCount, "Column count should be 2 and returned %v", colCount)
 }
 
 func TestGetColumnCountWithTwoColumnsShouldReturnTwoAndAfterReduceToOneShouldReturnOne(t *testing.T) {
-	dynMap := make(map[string][]byte)
-	dynMap["test"] = make([]byte, 4)
-	dynMap["test_2"] = make([]byte, 4)
+	dynMap := make(map[string]Column)
+	dynMap["test"] = NewInt32Column(0)
+	dynMap["test_2"] = NewInt32Column(0)
 	row := NewDynamicMap(dynMap)
 	colCount := row.GetColumnCount()
 	assert.Equalf(t, uint32(2), colCount, "Column count should be 2 and returned %v", colCount)
@@ -129,3 +123,61 @@ func TestGetColumnCountWithTwoColumnsShouldReturnTwoAndAfterReduceToOneShouldRet
 	colCount = newRow.GetColumnCount()
 	assert.Equalf(t, uint32(1), colCount, "Column count should be 1 and returned %v", colCount)
 }
+
+func TestGetAsAnotherTypeThanTheOneOfTheColumnShouldThrowError(t *testing.T) {
+	dynMap := make(map[string]Column)
+	dynMap["int"] = NewInt32Column(32)
+	dynMap["string"] = NewStringColumn("1234")
+	row := NewDynamicMap(dynMap)
+
+	_, err := row.GetAsFloat("int")
+	assert.Error(t, err, "GetAsFloat of an int column should have thrown error")
+
+	_, err = row.GetAsString("int")
+	assert.Error(t, err, "GetAsString of an int column should have thrown error")
+
+	_, err = row.GetAsInt("string")
+	assert.Error(t, err, "GetAsInt of a string column should have thrown error")
+}
+
+func TestGetAsEachTypeReturnsTheValueSaved(t *testing.T) {
+	timestamp := time.Date(2022, time.April, 16, 10, 30, 0, 500, time.UTC)
+	dynMap := make(map[string]Column)
+	dynMap["int64"] = NewInt64Column(-1 << 40)
+	dynMap["float64"] = NewFloat64Column(1234.5678)
+	dynMap["bool"] = NewBoolColumn(true)
+	dynMap["timestamp"] = NewTimestampColumn(timestamp)
+	dynMap["list"] = NewListColumn([]string{"ATL", "", "EZE"})
+	row := NewDynamicMap(dynMap)
+
+	int64Val, err := row.GetAsInt64("int64")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1<<40), int64Val)
+
+	float64Val, err := row.GetAsFloat64("float64")
+	assert.Nil(t, err)
+	assert.Equal(t, 1234.5678, float64Val)
+
+	boolVal, err := row.GetAsBool("bool")
+	assert.Nil(t, err)
+	assert.True(t, boolVal)
+
+	timestampVal, err := row.GetAsTimestamp("timestamp")
+	assert.Nil(t, err)
+	assert.True(t, timestamp.Equal(timestampVal), "The value saved was different: %v", timestampVal)
+
+	listVal, err := row.GetAsList("list")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ATL", "", "EZE"}, listVal)
+}
+
+func TestNewColumnWithAValueOfAnotherSizeThanTheTypeShouldThrowError(t *testing.T) {
+	_, err := NewColumn(Int32Type, []byte{1, 2})
+	assert.Error(t, err)
+
+	_, err = NewColumn(ListType, []byte{0, 0, 0, 2, 0, 0, 0, 1})
+	assert.Error(t, err)
+
+	_, err = NewColumn(ColumnType(99), []byte{})
+	assert.Error(t, err)
+}
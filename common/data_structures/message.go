@@ -6,6 +6,9 @@ type Message struct {
 	MessageId   uint
 	RowId       uint16
 	DynMaps     []*DynamicMap
+	Encoding    BatchEncoding
+	// Params Parameters of the queries of the client. The messages created from another one keep them
+	Params *DynamicMap
 }
 
 func NewMessageWithoutData(oldMessage *Message) *Message {
@@ -15,6 +18,8 @@ func NewMessageWithoutData(oldMessage *Message) *Message {
 		MessageId:   oldMessage.MessageId,
 		RowId:       oldMessage.RowId,
 		DynMaps:     make([]*DynamicMap, 0),
+		Encoding:    oldMessage.Encoding,
+		Params:      oldMessage.Params,
 	}
 }
 
@@ -25,6 +30,8 @@ func NewMessageWithData(oldMessage *Message, data []*DynamicMap) *Message {
 		MessageId:   oldMessage.MessageId,
 		RowId:       oldMessage.RowId,
 		DynMaps:     data,
+		Encoding:    oldMessage.Encoding,
+		Params:      oldMessage.Params,
 	}
 }
 
@@ -35,6 +42,8 @@ func NewMessageWithDataAndRowId(oldMessage *Message, data []*DynamicMap, rowId u
 		MessageId:   oldMessage.MessageId,
 		RowId:       rowId,
 		DynMaps:     data,
+		Encoding:    oldMessage.Encoding,
+		Params:      oldMessage.Params,
 	}
 }
 
@@ -45,6 +54,8 @@ func NewTypeMessageWithoutData(messageType int, oldMessage *Message) *Message {
 		MessageId:   oldMessage.MessageId,
 		RowId:       oldMessage.RowId,
 		DynMaps:     make([]*DynamicMap, 0),
+		Encoding:    oldMessage.Encoding,
+		Params:      oldMessage.Params,
 	}
 }
 
@@ -55,6 +66,8 @@ func NewTypeMessageWithoutDataAndMsgId(messageType int, oldMessage *Message, msg
 		MessageId:   msgId,
 		RowId:       oldMessage.RowId,
 		DynMaps:     make([]*DynamicMap, 0),
+		Encoding:    oldMessage.Encoding,
+		Params:      oldMessage.Params,
 	}
 }
 
@@ -65,6 +78,8 @@ func NewTypeMessageWithData(messageType int, oldMessage *Message, data []*Dynami
 		MessageId:   oldMessage.MessageId,
 		RowId:       oldMessage.RowId,
 		DynMaps:     data,
+		Encoding:    oldMessage.Encoding,
+		Params:      oldMessage.Params,
 	}
 }
 
@@ -75,6 +90,8 @@ func NewTypeMessageWithDataAndMsgId(messageType int, oldMessage *Message, data [
 		MessageId:   msgId,
 		RowId:       oldMessage.RowId,
 		DynMaps:     data,
+		Encoding:    oldMessage.Encoding,
+		Params:      oldMessage.Params,
 	}
 }
 
@@ -85,6 +102,8 @@ func NewTypeMessageWithDataRowIdAndMsgId(messageType int, oldMessage *Message, d
 		MessageId:   msgId,
 		RowId:       rowId,
 		DynMaps:     data,
+		Encoding:    oldMessage.Encoding,
+		Params:      oldMessage.Params,
 	}
 }
 
@@ -112,3 +131,21 @@ const EOFGetter = 6
 const FinalAvgMsg = 7
 const HeartBeat = 8
 const EofAck = 9
+const BatchAck = 10
+const GetStatus = 11
+const Status = 12
+const QueryParams = 13
+const NotRequested = 14
+const Abort = 15
+const Busy = 16
+const RowsReport = 17
+const LeaveMembership = 18
+const GetMembership = 19
+const Membership = 20
+const DeadLetter = 21
+const ClearClient = 22
+
+// IsKnownMessageType Returns true if the type is one of the types of message of the system
+func IsKnownMessageType(typeMessage int) bool {
+	return typeMessage >= Airports && typeMessage <= ClearClient
+}
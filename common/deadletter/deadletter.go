@@ -0,0 +1,110 @@
+package deadletter
+
+import (
+	"encoding/base64"
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"os"
+	"time"
+)
+
+const queueSuffix = "_dlq"
+
+// node Replica that rejects the messages. The containers use their name as hostname
+var node, _ = os.Hostname()
+
+// QueueName Returns the dead-letter queue of the stage that consumes the queue
+func QueueName(inputQueueName string) string {
+	return inputQueueName + queueSuffix
+}
+
+// Info What is known of a dead letter besides its rows
+type Info struct {
+	Stage  string
+	Node   string
+	Reason string
+	// Type Type of the original message
+	Type      int
+	Time      time.Time
+	ClientId  string
+	MessageId uint
+	RowId     uint16
+	Rows      int
+	// Raw The original message could not be deserialized, so only its bytes are kept
+	Raw bool
+}
+
+// New Creates the dead letter of the rows of the message. It keeps the header of the message, so it can be replayed
+// as it was received. The first dynamic map has the information of the dead letter and the others are the rows
+func New(msg *dataStructures.Message, rows []*dataStructures.DynamicMap, stage string, reason error) *dataStructures.Message {
+	info := infoDynMap(msg.TypeMessage, stage, reason)
+	return dataStructures.NewTypeMessageWithData(dataStructures.DeadLetter, msg, append([]*dataStructures.DynamicMap{info}, rows...))
+}
+
+// NewRaw Creates the dead letter of a message that could not be deserialized, with its bytes
+func NewRaw(bytes []byte, stage string, reason error) *dataStructures.Message {
+	info := infoDynMap(-1, stage, reason)
+	info.AddColumn(utils.DeadLetterRaw, dataStructures.NewStringColumn(base64.StdEncoding.EncodeToString(bytes)))
+	return &dataStructures.Message{TypeMessage: dataStructures.DeadLetter, DynMaps: []*dataStructures.DynamicMap{info}}
+}
+
+func infoDynMap(typeMessage int, stage string, reason error) *dataStructures.DynamicMap {
+	info := dataStructures.NewDynamicMap(make(map[string]dataStructures.Column))
+	info.AddColumn(utils.DeadLetterStage, dataStructures.NewStringColumn(stage))
+	info.AddColumn(utils.DeadLetterNode, dataStructures.NewStringColumn(node))
+	info.AddColumn(utils.DeadLetterReason, dataStructures.NewStringColumn(fmt.Sprint(reason)))
+	info.AddColumn(utils.DeadLetterType, dataStructures.NewInt64Column(int64(typeMessage)))
+	info.AddColumn(utils.DeadLetterTime, dataStructures.NewTimestampColumn(time.Now()))
+	return info
+}
+
+// InfoOf Returns the information of the dead letter
+func InfoOf(deadLetter *dataStructures.Message) (*Info, error) {
+	if deadLetter.TypeMessage != dataStructures.DeadLetter || len(deadLetter.DynMaps) == 0 {
+		return nil, fmt.Errorf("the message of type %v is not a dead letter", deadLetter.TypeMessage)
+	}
+	dynMap := deadLetter.DynMaps[0]
+	stage, err := dynMap.GetAsString(utils.DeadLetterStage)
+	if err != nil {
+		return nil, err
+	}
+	reason, err := dynMap.GetAsString(utils.DeadLetterReason)
+	if err != nil {
+		return nil, err
+	}
+	typeMessage, err := dynMap.GetAsInt64(utils.DeadLetterType)
+	if err != nil {
+		return nil, err
+	}
+	nodeName, _ := dynMap.GetAsString(utils.DeadLetterNode)
+	rejectedAt, _ := dynMap.GetAsTimestamp(utils.DeadLetterTime)
+	_, errRaw := dynMap.GetAsString(utils.DeadLetterRaw)
+	return &Info{
+		Stage:     stage,
+		Node:      nodeName,
+		Reason:    reason,
+		Type:      int(typeMessage),
+		Time:      rejectedAt,
+		ClientId:  deadLetter.ClientId,
+		MessageId: deadLetter.MessageId,
+		RowId:     deadLetter.RowId,
+		Rows:      len(deadLetter.DynMaps) - 1,
+		Raw:       errRaw == nil,
+	}, nil
+}
+
+// Original Returns the message that was rejected, as the stage received it. For the raw dead letters it returns
+// the bytes received instead, as the message could not be deserialized
+func Original(deadLetter *dataStructures.Message) (*dataStructures.Message, []byte, error) {
+	info, err := InfoOf(deadLetter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Raw {
+		raw, _ := deadLetter.DynMaps[0].GetAsString(utils.DeadLetterRaw)
+		bytes, err := base64.StdEncoding.DecodeString(raw)
+		return nil, bytes, err
+	}
+	return dataStructures.NewTypeMessageWithData(info.Type, deadLetter, deadLetter.DynMaps[1:]), nil, nil
+}
@@ -0,0 +1,61 @@
+package deadletter
+
+import (
+	"errors"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTheDeadLetterKeepsTheOriginalMessageAndTheReason(t *testing.T) {
+	row := dataStructures.NewDynamicMap(map[string]dataStructures.Column{
+		utils.StartingAirport: dataStructures.NewStringColumn("EZE"),
+	})
+	msg := &dataStructures.Message{
+		TypeMessage: dataStructures.FlightRows,
+		ClientId:    "client",
+		MessageId:   42,
+		RowId:       3,
+		DynMaps:     []*dataStructures.DynamicMap{row},
+	}
+
+	received, err := serializer.DeserializeMsg(serializer.SerializeMsg(New(msg, msg.DynMaps, "filter", errors.New("missing column"))))
+	assert.Nil(t, err)
+	info, err := InfoOf(received)
+	assert.Nil(t, err)
+	assert.Equal(t, "filter", info.Stage)
+	assert.Equal(t, "missing column", info.Reason)
+	assert.Equal(t, dataStructures.FlightRows, info.Type)
+	assert.Equal(t, "client", info.ClientId)
+	assert.Equal(t, 1, info.Rows)
+	assert.False(t, info.Raw)
+
+	original, raw, err := Original(received)
+	assert.Nil(t, err)
+	assert.Nil(t, raw)
+	assert.Equal(t, dataStructures.FlightRows, original.TypeMessage)
+	assert.Equal(t, uint(42), original.MessageId)
+	assert.Equal(t, uint16(3), original.RowId)
+	starting, err := original.DynMaps[0].GetAsString(utils.StartingAirport)
+	assert.Nil(t, err)
+	assert.Equal(t, "EZE", starting)
+}
+
+func TestTheRawDeadLetterKeepsTheBytesReceived(t *testing.T) {
+	received, err := serializer.DeserializeMsg(serializer.SerializeMsg(NewRaw([]byte{1, 2, 3}, "filter", errors.New("malformed"))))
+	assert.Nil(t, err)
+	info, err := InfoOf(received)
+	assert.Nil(t, err)
+	assert.True(t, info.Raw)
+	assert.Equal(t, 0, info.Rows)
+
+	original, raw, err := Original(received)
+	assert.Nil(t, err)
+	assert.Nil(t, original)
+	assert.Equal(t, []byte{1, 2, 3}, raw)
+
+	_, err = InfoOf(&dataStructures.Message{TypeMessage: dataStructures.FlightRows})
+	assert.NotNil(t, err)
+}
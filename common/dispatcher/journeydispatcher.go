@@ -1,44 +1,56 @@
 package dispatcher
 
 import (
+	"errors"
+	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
-	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
-	"hash/fnv"
 )
 
 // JourneyDispatcher Struct that dispatches journey messages
 type JourneyDispatcher struct {
 	id            int
-	channels      []queueProtocol.ProducerProtocolInterface
 	input         queueProtocol.ConsumerProtocolInterface
 	prodToInput   queueProtocol.ProducerProtocolInterface
+	eof           *queueProtocol.EOFReporter
 	checkpointer  *checkpointer.CheckpointerHandler
 	totalEofNodes uint
-	eofId         string
+	// inputStage Name of the stage of the dispatcher in the view of each client.
+	// Empty if the stage is not in the views, so the configured nodes are used
+	inputStage  string
+	partitioner Partitioner
+	keyColumns  []string
 }
 
-// NewJourneyDispatcher Creates a new dispatcher
+// NewJourneyDispatcher Creates a new dispatcher. The EOF of the clients goes to the coordinator, that sends it to the output channels.
+// The rows are split between the output channels in the view of each client by the key columns of the partitioning
 func NewJourneyDispatcher(
 	id uint,
 	input queueProtocol.ConsumerProtocolInterface,
 	prodToInput queueProtocol.ProducerProtocolInterface,
-	outputChannels []queueProtocol.ProducerProtocolInterface,
+	toCoordinator queueProtocol.ProducerProtocolInterface,
+	outputChannels *queueProtocol.KeyedOutputs,
 	chkHandler *checkpointer.CheckpointerHandler,
 	totalEofNodes uint,
 	eofId string,
+	inputStage string,
+	partitioning *PartitioningConfig,
 ) *JourneyDispatcher {
 	chkHandler.AddCheckpointable(input, int(id))
+	eofReporter := queueProtocol.NewKeyedEOFReporter(eofId, input, toCoordinator, outputChannels)
 	return &JourneyDispatcher{
 		id:            int(id),
 		input:         input,
-		channels:      outputChannels,
 		prodToInput:   prodToInput,
+		eof:           eofReporter,
 		checkpointer:  chkHandler,
 		totalEofNodes: totalEofNodes,
-		eofId:         eofId,
+		inputStage:    inputStage,
+		partitioner:   partitioning.NewPartitioner(),
+		keyColumns:    partitioning.KeyColumns,
 	}
 }
 
@@ -46,28 +58,47 @@ func NewJourneyDispatcher(
 func (jd *JourneyDispatcher) DispatchLoop() {
 	log.Infof("JourneyDispatcher %v | Started Journey Dispatcher loop", jd.id)
 	for {
-		msg, ok := jd.input.Pop()
-		if !ok {
+		msg, err := jd.input.Pop()
+		if errors.Is(err, queueProtocol.ErrConsumerClosed) {
 			log.Infof("JourneyDispatcher %v | Input queue closed, stopping...", jd.id)
 			return
 		}
+		if err != nil {
+			log.Errorf("JourneyDispatcher %v | Skipping message | %v", jd.id, err)
+			continue
+		}
 		log.Debugf("JourneyDispatcher %v | Received message, dispatching its rows to Journey Savers", jd.id)
 		jd.dispatch(msg)
 	}
 }
 
-// dispatch Executes the msg dispatch by calculating the sha256 hash
-// on the starting airport and destination airport on each row of the message
+// dispatch Handles the message. The rows are sent to the output channel of their key
 func (jd *JourneyDispatcher) dispatch(message *dataStructures.Message) {
 	if message.TypeMessage == dataStructures.EOFFlightRows {
-		err := queueProtocol.HandleEOF(message, jd.prodToInput, jd.channels, jd.eofId, jd.totalEofNodes)
+		err := jd.eof.HandleEOF(message)
 		if err != nil {
 			log.Errorf("JourneyDispatcher | Error handling EOF | %v", err)
 		}
+	} else if message.TypeMessage == dataStructures.Abort {
+		log.Infof("JourneyDispatcher %v | Received Abort of client %v. Clearing its data...", jd.id, message.ClientId)
+		jd.input.ClearData(message.ClientId)
+		err := jd.eof.HandleAbort(message, jd.prodToInput, membership.SizeOf(message, jd.inputStage, jd.totalEofNodes))
+		if err != nil {
+			log.Errorf("JourneyDispatcher | Error handling Abort | %v", err)
+		}
+	} else if message.TypeMessage == dataStructures.ClearClient {
+		log.Infof("JourneyDispatcher %v | Client %v finished. Clearing its data...", jd.id, message.ClientId)
+		jd.input.ClearData(message.ClientId)
+		err := jd.eof.HandleClear(message, jd.prodToInput, membership.SizeOf(message, jd.inputStage, jd.totalEofNodes))
+		if err != nil {
+			log.Errorf("JourneyDispatcher | Error handling Clear | %v", err)
+		}
 	} else if message.TypeMessage == dataStructures.FlightRows {
 		jd.dispatchFlightRows(message)
+		_ = jd.eof.ReportBatch(message)
 	} else {
 		log.Warnf("JourneyDispatcher %v | Warning Message | Unknown message received | Skipping it...", jd.id)
+		jd.input.DeadLetter(message.DynMaps, fmt.Errorf("unknown type of message %v", message.TypeMessage))
 	}
 	err := jd.checkpointer.DoCheckpoint(jd.id)
 	if err != nil {
@@ -75,28 +106,20 @@ func (jd *JourneyDispatcher) dispatch(message *dataStructures.Message) {
 	}
 }
 
+// dispatchFlightRows Sends each row to one of the output channels of the nodes of the output stage in the view of the client
 func (jd *JourneyDispatcher) dispatchFlightRows(message *dataStructures.Message) {
+	channels := jd.eof.OutputsOf(message)
+	channelsCount := len(channels)
 	for idx, row := range message.DynMaps {
-		startingAirport, err := row.GetAsBytes(utils.StartingAirport)
-		if err != nil {
-			log.Errorf("JourneyDispatcher %v | Error getting starting airport. Skipping row | %v", jd.id, err)
-			continue
-		}
-		destAirport, err := row.GetAsBytes(utils.DestinationAirport)
+		key, err := KeyOf(row, jd.keyColumns)
 		if err != nil {
-			log.Errorf("JourneyDispatcher %v | Error getting destination airport. Skipping row | %v", jd.id, err)
+			log.Errorf("JourneyDispatcher %v | Error getting the key of the row. Skipping row | %v", jd.id, err)
+			jd.input.DeadLetter([]*dataStructures.DynamicMap{row}, err)
 			continue
 		}
-		var bytesToHash []byte
-		bytesToHash = append(bytesToHash, startingAirport...)
-		bytesToHash = append(bytesToHash, destAirport...)
-		hasher := fnv.New32a()
-		_, _ = hasher.Write(bytesToHash)
-		hashRes := int(hasher.Sum32())
-		log.Debugf("JourneyDispatcher %v | Deciding where to dispatch. hashRes is: %v; Len of channels is: %v", jd.id, hashRes, len(jd.channels))
-		resultIndex := hashRes % len(jd.channels)
-		log.Debugf("JourneyDispatcher %v | Dispatching to Node #%v...", jd.id, resultIndex)
-		err = jd.channels[resultIndex].Send(
+		resultIndex := jd.partitioner.Partition(key, channelsCount)
+		log.Debugf("JourneyDispatcher %v | Dispatching to Node #%v of %v...", jd.id, resultIndex, channelsCount)
+		err = channels[resultIndex].Send(
 			dataStructures.NewMessageWithDataAndRowId(message, []*dataStructures.DynamicMap{row}, uint16(idx)),
 		)
 		if err != nil {
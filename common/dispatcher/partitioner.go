@@ -0,0 +1,121 @@
+package dispatcher
+
+import (
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// Partitioner Decides which of the partitions receives a key
+type Partitioner interface {
+	// Partition Returns the partition of the key, from 0 to partitions-1
+	Partition(key []byte, partitions int) int
+}
+
+// ModuloPartitioner Uses the hash of the key modulo the partitions. Changing the partitions moves almost every key
+type ModuloPartitioner struct{}
+
+func (m ModuloPartitioner) Partition(key []byte, partitions int) int {
+	return int(hashOf(key)) % partitions
+}
+
+// HashRing Consistent hashing with virtual nodes. Each partition owns the arcs of the ring that end in its points,
+// so adding a partition only moves to it the keys of its arcs, and removing one only moves the keys that it had
+type HashRing struct {
+	virtualNodes int
+	mutex        sync.Mutex
+	rings        map[int]*ring
+}
+
+// ring Points of the partitions sorted by their position in the ring
+type ring struct {
+	points []uint32
+	owners []int
+}
+
+// NewHashRing Creates a ring where each partition has virtualNodes points
+func NewHashRing(virtualNodes int) *HashRing {
+	return &HashRing{
+		virtualNodes: max(virtualNodes, 1),
+		rings:        make(map[int]*ring),
+	}
+}
+
+func (h *HashRing) Partition(key []byte, partitions int) int {
+	if partitions <= 1 {
+		return 0
+	}
+	r := h.ringOf(partitions)
+	hash := ringHashOf(key)
+	idx := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+	if idx == len(r.points) {
+		idx = 0
+	}
+	return r.owners[idx]
+}
+
+// ringOf Returns the ring of the partitions. The points of a partition do not depend on the amount
+// of partitions, so the ring of each amount is built once and kept
+func (h *HashRing) ringOf(partitions int) *ring {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if r, exists := h.rings[partitions]; exists {
+		return r
+	}
+	type point struct {
+		position uint32
+		owner    int
+	}
+	points := make([]point, 0, partitions*h.virtualNodes)
+	for partition := 0; partition < partitions; partition++ {
+		for virtualNode := 0; virtualNode < h.virtualNodes; virtualNode++ {
+			points = append(points, point{ringHashOf([]byte(fmt.Sprintf("%v-%v", partition, virtualNode))), partition})
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].position == points[j].position {
+			return points[i].owner < points[j].owner
+		}
+		return points[i].position < points[j].position
+	})
+	r := &ring{points: make([]uint32, len(points)), owners: make([]int, len(points))}
+	for idx, p := range points {
+		r.points[idx] = p.position
+		r.owners[idx] = p.owner
+	}
+	h.rings[partitions] = r
+	return r
+}
+
+func hashOf(key []byte) uint32 {
+	hasher := fnv.New32a()
+	_, _ = hasher.Write(key)
+	return hasher.Sum32()
+}
+
+// ringHashOf Mixes the bits of the hash of the key, as the keys and the names of the points only differ in a few
+// characters and their hashes would be too close in the ring
+func ringHashOf(key []byte) uint32 {
+	hash := hashOf(key)
+	hash ^= hash >> 16
+	hash *= 0x85ebca6b
+	hash ^= hash >> 13
+	hash *= 0xc2b2ae35
+	hash ^= hash >> 16
+	return hash
+}
+
+// KeyOf Returns the key of the row, the bytes of its key columns one after the other
+func KeyOf(row *dataStructures.DynamicMap, keyColumns []string) ([]byte, error) {
+	var key []byte
+	for _, column := range keyColumns {
+		value, err := row.GetAsBytes(column)
+		if err != nil {
+			return nil, fmt.Errorf("error getting column %v of the key: %v", column, err)
+		}
+		key = append(key, value...)
+	}
+	return key, nil
+}
@@ -0,0 +1,84 @@
+package dispatcher
+
+import (
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func journeyKeys(amount int) [][]byte {
+	var keys [][]byte
+	for i := 0; i < amount; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("A%03vB%03v", i%97, i)))
+	}
+	return keys
+}
+
+func TestTheRingOnlyMovesKeysToTheNewPartitionWhenOneIsAdded(t *testing.T) {
+	ring := NewHashRing(DefaultVirtualNodes)
+	moved := 0
+	for _, key := range journeyKeys(5000) {
+		before := ring.Partition(key, 6)
+		after := ring.Partition(key, 7)
+		assert.Less(t, before, 6)
+		if before != after {
+			assert.Equal(t, 6, after, "A key can only move to the new partition")
+			moved++
+		}
+	}
+	assert.Less(t, moved, 5000/7*2, "About a seventh of the keys should move")
+	assert.Greater(t, moved, 0)
+}
+
+func TestTheRingSplitsTheKeysBetweenEveryPartition(t *testing.T) {
+	ring := NewHashRing(DefaultVirtualNodes)
+	keysByPartition := make(map[int]int)
+	for _, key := range journeyKeys(6000) {
+		keysByPartition[ring.Partition(key, 6)]++
+	}
+	assert.Len(t, keysByPartition, 6)
+	for partition, keys := range keysByPartition {
+		assert.Greater(t, keys, 1000/2, "The partition %v has too few keys", partition)
+		assert.Less(t, keys, 1000*2, "The partition %v has too many keys", partition)
+	}
+	assert.Equal(t, ring.Partition([]byte("EZEJFK"), 6), NewHashRing(DefaultVirtualNodes).Partition([]byte("EZEJFK"), 6))
+}
+
+func TestTheModuloPartitionerKeepsTheRoutesOfTheAirports(t *testing.T) {
+	row := dataStructures.NewDynamicMap(map[string]dataStructures.Column{
+		utils.StartingAirport:    dataStructures.NewStringColumn("EZE"),
+		utils.DestinationAirport: dataStructures.NewStringColumn("JFK"),
+	})
+	key, err := KeyOf(row, JourneyColumns)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("EZEJFK"), key)
+	assert.Equal(t, int(hashOf([]byte("EZEJFK"))%6), ModuloPartitioner{}.Partition(key, 6))
+
+	_, err = KeyOf(row, []string{"legId"})
+	assert.NotNil(t, err)
+}
+
+func TestTheKeyCanOnlyUseTheColumnsOfTheJourney(t *testing.T) {
+	c, err := NewPartitioningConfig("", 0, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "ring,100,startingAirport;destinationAirport", c.String())
+	assert.True(t, c.KeepsJourneys())
+
+	c, err = NewPartitioningConfig(ModuloPartitionerKind, 0, utils.StartingAirport)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{utils.StartingAirport}, c.KeyColumns)
+	assert.True(t, c.KeepsJourneys())
+
+	c, err = NewPartitioningConfig("", 0, "startingAirport,totalFare")
+	assert.NotNil(t, err, "The rows of a journey can have different fares")
+	assert.Nil(t, c)
+	_, err = NewPartitioningConfig(RingPartitioner, 0, utils.TotalFare)
+	assert.NotNil(t, err)
+
+	_, err = NewPartitioningConfig("", 0, "legId")
+	assert.NotNil(t, err)
+	_, err = NewPartitioningConfig("random", 0, "")
+	assert.NotNil(t, err)
+}
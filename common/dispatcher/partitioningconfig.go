@@ -0,0 +1,86 @@
+package dispatcher
+
+import (
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"slices"
+	"strings"
+)
+
+const RingPartitioner = "ring"
+const ModuloPartitionerKind = "modulo"
+const DefaultVirtualNodes = 100
+
+// JourneyColumns Columns of the journey of a row. A key with only these columns sends the rows of a journey
+// to the same saver
+var JourneyColumns = []string{utils.StartingAirport, utils.DestinationAirport}
+
+// RowColumns Columns of the rows of the query 4, that the rows of the query 3 also have. The key can only use the
+// ones of the journey
+var RowColumns = []string{utils.StartingAirport, utils.DestinationAirport, utils.TotalFare}
+
+// PartitioningConfig How the rows are split between the outputs of a dispatcher
+type PartitioningConfig struct {
+	// Kind Partitioner used, the consistent hash ring or the hash modulo the outputs
+	Kind string
+	// VirtualNodes Points of each output in the ring
+	VirtualNodes uint
+	// KeyColumns Columns of the row that are hashed
+	KeyColumns []string
+}
+
+// NewPartitioningConfig Validates the partitioning of the dispatchers. The empty values take the default one.
+// A key with columns that are not of the journey is rejected, as it splits the rows of a journey between the savers
+func NewPartitioningConfig(kind string, virtualNodes uint, keyColumns string) (*PartitioningConfig, error) {
+	if kind == "" {
+		kind = RingPartitioner
+	}
+	if kind != RingPartitioner && kind != ModuloPartitionerKind {
+		return nil, fmt.Errorf("invalid partitioner %v, it must be %v or %v", kind, RingPartitioner, ModuloPartitionerKind)
+	}
+	if virtualNodes == 0 {
+		virtualNodes = DefaultVirtualNodes
+	}
+	columns := JourneyColumns
+	if keyColumns != "" {
+		columns = strings.Split(keyColumns, utils.CommaSeparator)
+	}
+	for _, column := range columns {
+		if !slices.Contains(RowColumns, column) {
+			return nil, fmt.Errorf("invalid key column %v, the key can only use the columns %v", column, RowColumns)
+		}
+	}
+	c := &PartitioningConfig{Kind: kind, VirtualNodes: virtualNodes, KeyColumns: columns}
+	if !c.KeepsJourneys() {
+		return nil, fmt.Errorf("invalid key columns %v, the key can only use the columns of the journey %v so the rows of a journey go to the same saver", columns, JourneyColumns)
+	}
+	return c, nil
+}
+
+// KeepsJourneys Returns true if the key only uses the columns of the journey, so the rows of a journey go to the same saver
+func (c *PartitioningConfig) KeepsJourneys() bool {
+	for _, column := range c.KeyColumns {
+		if !slices.Contains(JourneyColumns, column) {
+			return false
+		}
+	}
+	return true
+}
+
+// LegacyPartitioning Returns the partitioning used before the partitioner could be configured
+func LegacyPartitioning() *PartitioningConfig {
+	return &PartitioningConfig{Kind: ModuloPartitionerKind, VirtualNodes: DefaultVirtualNodes, KeyColumns: JourneyColumns}
+}
+
+// NewPartitioner Creates the partitioner of the configuration
+func (c *PartitioningConfig) NewPartitioner() Partitioner {
+	if c.Kind == ModuloPartitionerKind {
+		return ModuloPartitioner{}
+	}
+	return NewHashRing(int(c.VirtualNodes))
+}
+
+// String Returns the configuration as kind,virtualNodes,column;column
+func (c *PartitioningConfig) String() string {
+	return fmt.Sprintf("%v,%v,%v", c.Kind, c.VirtualNodes, strings.Join(c.KeyColumns, utils.DotCommaSeparator))
+}
@@ -3,20 +3,33 @@ package duplicates
 import (
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol"
 )
 
 const maxMessagesPerClient = 1000
 
 type DuplicateDetector interface {
 	checkpointer.Checkpointable
+	protocol.DataCleaner
 	IsDuplicate(message *dataStructures.Message) bool
+	WasSeen(message *dataStructures.Message) bool
 	SaveMessageSeen(message *dataStructures.Message)
+	RemoveLastMessageSeen()
+}
+
+// seenMessage Row of the message seen before the last one was saved, so it can be restored
+type seenMessage struct {
+	clientId  string
+	messageId uint
+	rowId     uint16
+	existed   bool
 }
 
 type DuplicatesHandler struct {
 	//Structure ClientID -> MessageID -> RowID
 	lastMessagesSeen map[string]map[uint]uint16
 	queueName        string
+	lastSaved        *seenMessage
 }
 
 func NewDuplicatesHandler(queueName string) *DuplicatesHandler {
@@ -63,14 +76,41 @@ func (dh *DuplicatesHandler) IsDuplicate(message *dataStructures.Message) bool {
 	return isDuplicate
 }
 
+// WasSeen Returns true if the message is in the messages seen from the client. Unlike IsDuplicate, the messages older
+// than the ones seen are not duplicates, as a retried message can wait while newer messages fill the window
+func (dh *DuplicatesHandler) WasSeen(message *dataStructures.Message) bool {
+	lastRowSeenFromMessage, exists := dh.lastMessagesSeen[message.ClientId][message.MessageId]
+	return exists && lastRowSeenFromMessage >= message.RowId
+}
+
 func (dh *DuplicatesHandler) SaveMessageSeen(message *dataStructures.Message) {
 	_, exists := dh.lastMessagesSeen[message.ClientId]
 	if !exists {
 		dh.lastMessagesSeen[message.ClientId] = make(map[uint]uint16)
 	}
-	_, exists = dh.lastMessagesSeen[message.ClientId][message.MessageId]
+	previousRow, exists := dh.lastMessagesSeen[message.ClientId][message.MessageId]
 	if !exists {
 		dh.deleteOldMessagesFromMap(message.ClientId)
 	}
+	dh.lastSaved = &seenMessage{clientId: message.ClientId, messageId: message.MessageId, rowId: previousRow, existed: exists}
 	dh.lastMessagesSeen[message.ClientId][message.MessageId] = message.RowId
 }
+
+// RemoveLastMessageSeen Forgets the last message saved, as it was rejected and will be received again
+func (dh *DuplicatesHandler) RemoveLastMessageSeen() {
+	if dh.lastSaved == nil {
+		return
+	}
+	messages, exists := dh.lastMessagesSeen[dh.lastSaved.clientId]
+	if exists && dh.lastSaved.existed {
+		messages[dh.lastSaved.messageId] = dh.lastSaved.rowId
+	} else if exists {
+		delete(messages, dh.lastSaved.messageId)
+	}
+	dh.lastSaved = nil
+}
+
+// ClearData Forgets the messages seen from the client
+func (dh *DuplicatesHandler) ClearData(clientId string) {
+	delete(dh.lastMessagesSeen, clientId)
+}
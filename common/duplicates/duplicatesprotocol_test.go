@@ -136,3 +136,21 @@ func TestShouldThrowDuplicateWhenMessageIdIsLessThanLeastKeyAndMapIsFull(t *test
 	assert.True(t, exists, "The message should exist")
 	assert.Equalf(t, uint16(100), row, "Expected and got row differ. Expected was: 5, got: %v", row)
 }
+
+func TestClearDataForgetsTheMessagesSeenFromTheClient(t *testing.T) {
+	duplicateDetector := NewDuplicatesHandler("cola")
+	msg := &dataStructures.Message{
+		TypeMessage: dataStructures.FlightRows,
+		ClientId:    "cliente",
+		MessageId:   3,
+		RowId:       1,
+		DynMaps:     []*dataStructures.DynamicMap{},
+	}
+	duplicateDetector.SaveMessageSeen(msg)
+	assert.True(t, duplicateDetector.IsDuplicate(msg), "The message was already seen")
+
+	duplicateDetector.ClearData("cliente")
+	_, exists := duplicateDetector.lastMessagesSeen["cliente"]
+	assert.False(t, exists, "The client registry should not exist")
+	assert.False(t, duplicateDetector.IsDuplicate(msg), "The message should not be a duplicate after clearing")
+}
@@ -0,0 +1,60 @@
+package filemanager
+
+import (
+	"bufio"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"os"
+)
+
+// FileTailer Reader of a file that can still be written by another process.
+// A line is only returned once it is complete, so a partial write is read when it is finished
+type FileTailer struct {
+	FileManager
+	reader  *bufio.Reader
+	partial string
+	text    string
+	err     error
+}
+
+// NewFileTailer Opens a file to read the lines appended to it.
+// The file will be opened in READ ONLY mode
+func NewFileTailer(filename string) (*FileTailer, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		log.Errorf("FileTailer | action: open_file | result: fail | file_name: %v | error: %v", filename, err)
+		return nil, err
+	}
+	log.Debugf("FileTailer | action: opened_file | file_name: %v", filename)
+	return &FileTailer{
+		FileManager: FileManager{file: f, filename: filename},
+		reader:      bufio.NewReader(f),
+	}, nil
+}
+
+// CanRead Returns true if there is a complete line to read, that can be obtained by calling ReadLine.
+// False means that the end of the written content was reached, it can be called again once more lines are written
+func (f *FileTailer) CanRead() bool {
+	str, err := f.reader.ReadString('\n')
+	f.partial += str
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			f.err = err
+		}
+		return false
+	}
+	f.text = f.partial[:len(f.partial)-1]
+	f.partial = ""
+	return true
+}
+
+// ReadLine Returns the line read, without the line break
+func (f *FileTailer) ReadLine() string {
+	return f.text
+}
+
+// Err Returns an error if it was encountered. Reaching the end of the file is not an error
+func (f *FileTailer) Err() error {
+	return f.err
+}
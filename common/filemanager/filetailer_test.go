@@ -0,0 +1,53 @@
+package filemanager
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileTailerReadsTheLinesAppendedAfterReachingTheEnd(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "results.csv")
+	writer, err := NewFileWriter(filename)
+	assert.Nil(t, err)
+	defer writer.Close()
+	assert.Nil(t, writer.WriteLine("first\n"))
+
+	tailer, err := NewFileTailer(filename)
+	assert.Nil(t, err)
+	defer tailer.Close()
+	assert.True(t, tailer.CanRead())
+	assert.Equal(t, "first", tailer.ReadLine())
+	assert.False(t, tailer.CanRead())
+
+	assert.Nil(t, writer.WriteLine("sec"))
+	assert.False(t, tailer.CanRead(), "A partial line should not be read")
+	assert.Nil(t, writer.WriteLine("ond\nthird\n"))
+	assert.True(t, tailer.CanRead())
+	assert.Equal(t, "second", tailer.ReadLine())
+	assert.True(t, tailer.CanRead())
+	assert.Equal(t, "third", tailer.ReadLine())
+	assert.False(t, tailer.CanRead())
+	assert.Nil(t, tailer.Err())
+}
+
+func TestFileTailerKeepsReadingAFileThatWasMoved(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "results.csv")
+	writer, err := NewFileWriter(filename)
+	assert.Nil(t, err)
+	assert.Nil(t, writer.WriteLine("first\n"))
+
+	tailer, err := NewFileTailer(filename)
+	assert.Nil(t, err)
+	defer tailer.Close()
+	assert.True(t, tailer.CanRead())
+
+	assert.Nil(t, writer.WriteLine("second\n"))
+	assert.Nil(t, writer.Close())
+	assert.Nil(t, os.Rename(filename, filepath.Join(dir, "moved.csv")))
+	assert.True(t, tailer.CanRead())
+	assert.Equal(t, "second", tailer.ReadLine())
+	assert.False(t, tailer.CanRead())
+}
@@ -28,6 +28,26 @@ func MoveFiles(files []string, folderName string) error {
 	return nil
 }
 
+// MoveFilesBetweenFolders Moves the files from a folder to another, creating the destination if it does not exist.
+// The source folder is deleted once it is empty
+func MoveFilesBetweenFolders(files []string, sourceFolder string, folderName string) error {
+	if _, err := os.Stat(folderName); os.IsNotExist(err) {
+		err := os.Mkdir(folderName, os.ModePerm)
+		if err != nil && !os.IsExist(err) {
+			log.Errorf("FileMover | Error creating directory %v | %v", folderName, err)
+			return err
+		}
+	}
+	for _, file := range files {
+		err := os.Rename(fmt.Sprintf("%v/%v", sourceFolder, file), fmt.Sprintf("%v/%v", folderName, file))
+		if err != nil {
+			log.Errorf("FileMover | Error moving file '%v/%v' to '%v/%v' | %v", sourceFolder, file, folderName, file, err)
+			return err
+		}
+	}
+	return DeleteFile(sourceFolder)
+}
+
 func RenameFile(file string, newName string) error {
 	err := os.Rename(file, newName)
 	if err != nil {
@@ -46,6 +66,18 @@ func DeleteFile(file string) error {
 	return nil
 }
 
+// DeleteIfExists Deletes the files and folders, with their content. The ones that do not exist are skipped
+func DeleteIfExists(paths ...string) error {
+	for _, path := range paths {
+		err := os.RemoveAll(path)
+		if err != nil {
+			log.Errorf("FileDeleter | Error deleting %v | %v", path, err)
+			return err
+		}
+	}
+	return nil
+}
+
 func DirectoryExists(file string) bool {
 	if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
 		return false
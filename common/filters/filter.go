@@ -1,129 +1,99 @@
 package filters
 
 import (
+	"cmp"
 	"errors"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
-	"reflect"
-	"strings"
+	"time"
 )
 
-type Filter struct {
-	FilterInterface
-}
+type Filter struct{}
 
 func NewFilter() *Filter {
 	return &Filter{}
 }
 
-func (filter *Filter) Equals(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error) {
-	typeOfData := reflect.TypeOf(valueOfCompare)
-	switch typeOfData.Kind() {
-	case reflect.Int:
-		rowData, err := row.GetAsInt(colName)
-		if err != nil {
-			return false, err
-		}
-		compareCasted := valueOfCompare.(int)
-		return compareCasted == rowData, nil
-	case reflect.String:
-		rowData, err := row.GetAsString(colName)
-		if err != nil {
-			return false, err
-		}
-		compareCasted := valueOfCompare.(string)
-		return compareCasted == rowData, nil
-	case reflect.Float32:
-		rowData, err := row.GetAsFloat(colName)
-		if err != nil {
-			return false, err
-		}
-		compareCasted := valueOfCompare.(float32)
-		return compareCasted == rowData, nil
-	default:
-		return false, errors.New("type of comparison value is not valid")
+// holds Returns true if the result of a comparison meets the operator
+func (operator Operator) holds(comparison int) bool {
+	switch operator {
+	case EqualsOperator:
+		return comparison == 0
+	case NotEqualsOperator:
+		return comparison != 0
+	case GreaterOperator:
+		return comparison > 0
+	case GreaterOrEqualsOperator:
+		return comparison >= 0
+	case LessOperator:
+		return comparison < 0
 	}
+	return comparison <= 0
 }
 
-func (filter *Filter) Greater(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error) {
-	typeOfData := reflect.TypeOf(valueOfCompare)
-	switch typeOfData.Kind() {
-	case reflect.Int:
+// compareColumn Compares the value of the column with the value received, read with the type of the value.
+// Returns a negative number if the column is less, zero if they are equal and a positive number if it is greater
+func compareColumn(row *dataStructures.DynamicMap, colName string, valueOfCompare any) (int, error) {
+	switch compareCasted := valueOfCompare.(type) {
+	case int:
 		rowData, err := row.GetAsInt(colName)
-		if err != nil {
-			return false, err
-		}
-		compareCasted := valueOfCompare.(int)
-		return compareCasted < rowData, nil
-	case reflect.String:
-		rowData, err := row.GetAsString(colName)
-		if err != nil {
-			return false, err
-		}
-		compareCasted := valueOfCompare.(string)
-		return strings.Compare(compareCasted, rowData) < 0, nil
-	case reflect.Float32:
+		return cmp.Compare(rowData, compareCasted), err
+	case int64:
+		rowData, err := row.GetAsInt64(colName)
+		return cmp.Compare(rowData, compareCasted), err
+	case float32:
 		rowData, err := row.GetAsFloat(colName)
-		if err != nil {
-			return false, err
-		}
-		compareCasted := valueOfCompare.(float32)
-		return compareCasted < rowData, nil
+		return cmp.Compare(rowData, compareCasted), err
+	case float64:
+		rowData, err := row.GetAsFloat64(colName)
+		return cmp.Compare(rowData, compareCasted), err
+	case string:
+		rowData, err := row.GetAsString(colName)
+		return cmp.Compare(rowData, compareCasted), err
+	case bool:
+		rowData, err := row.GetAsBool(colName)
+		return compareBools(rowData, compareCasted), err
+	case time.Time:
+		rowData, err := row.GetAsTimestamp(colName)
+		return rowData.Compare(compareCasted), err
 	default:
-		return false, errors.New("type of comparison value is not valid")
+		return 0, errors.New("type of comparison value is not valid")
 	}
 }
 
-func (filter *Filter) Less(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error) {
-	typeOfData := reflect.TypeOf(valueOfCompare)
-	switch typeOfData.Kind() {
-	case reflect.Int:
-		rowData, err := row.GetAsInt(colName)
-		if err != nil {
-			return false, err
-		}
-		compareCasted := valueOfCompare.(int)
-		return compareCasted > rowData, nil
-	case reflect.String:
-		rowData, err := row.GetAsString(colName)
-		if err != nil {
-			return false, err
-		}
-		compareCasted := valueOfCompare.(string)
-		return strings.Compare(compareCasted, rowData) > 0, nil
-	case reflect.Float32:
-		rowData, err := row.GetAsFloat(colName)
-		if err != nil {
-			return false, err
-		}
-		compareCasted := valueOfCompare.(float32)
-		return compareCasted > rowData, nil
-	default:
-		return false, errors.New("type of comparison value is not valid")
+func compareBools(rowData bool, compareCasted bool) int {
+	if rowData == compareCasted {
+		return 0
+	}
+	if compareCasted {
+		return -1
 	}
+	return 1
 }
 
-func (filter *Filter) GreaterOrEquals(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error) {
-	eq, err := filter.Equals(row, valueOfCompare, colName)
+func (filter *Filter) compare(row *dataStructures.DynamicMap, valueOfCompare any, colName string, operator Operator) (bool, error) {
+	comparison, err := compareColumn(row, colName, valueOfCompare)
 	if err != nil {
 		return false, err
 	}
-	gr, err2 := filter.Greater(row, valueOfCompare, colName)
-	if err2 != nil {
-		return false, err2
-	}
+	return operator.holds(comparison), nil
+}
 
-	return eq || gr, nil
+func (filter *Filter) Equals(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error) {
+	return filter.compare(row, valueOfCompare, colName, EqualsOperator)
 }
 
-func (filter *Filter) LessOrEquals(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error) {
-	eq, err := filter.Equals(row, valueOfCompare, colName)
-	if err != nil {
-		return false, err
-	}
-	less, err2 := filter.Less(row, valueOfCompare, colName)
-	if err2 != nil {
-		return false, err2
-	}
+func (filter *Filter) Greater(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error) {
+	return filter.compare(row, valueOfCompare, colName, GreaterOperator)
+}
+
+func (filter *Filter) Less(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error) {
+	return filter.compare(row, valueOfCompare, colName, LessOperator)
+}
 
-	return eq || less, nil
+func (filter *Filter) GreaterOrEquals(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error) {
+	return filter.compare(row, valueOfCompare, colName, GreaterOrEqualsOperator)
+}
+
+func (filter *Filter) LessOrEquals(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error) {
+	return filter.compare(row, valueOfCompare, colName, LessOrEqualsOperator)
 }
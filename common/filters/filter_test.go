@@ -1,18 +1,16 @@
 package filters
 
 import (
-	"encoding/binary"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/stretchr/testify/assert"
-	"math"
 	"testing"
 )
 
 func TestFilterEqualsShouldThrowErrorWhenColumnNotFound(t *testing.T) {
 	expectedString := "test_string"
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = []byte("test_string")
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewStringColumn("test_string")
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Equals(row, expectedString+"_shall not pass", "test_column_not_ex")
@@ -23,9 +21,9 @@ func TestFilterEqualsShouldThrowErrorWhenColumnNotFound(t *testing.T) {
 
 func TestFilterEqualsWithString(t *testing.T) {
 	expectedString := "test_string"
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = []byte("test_string")
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewStringColumn("test_string")
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Equals(row, expectedString, "test_column")
@@ -36,9 +34,9 @@ func TestFilterEqualsWithString(t *testing.T) {
 
 func TestFilterEqualsIsFalseWithString(t *testing.T) {
 	expectedString := "test_string"
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = []byte(expectedString)
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewStringColumn(expectedString)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Equals(row, expectedString+"_shall not pass", "test_column")
@@ -49,9 +47,9 @@ func TestFilterEqualsIsFalseWithString(t *testing.T) {
 
 func TestFilterGreaterThanWithStringIsTrue(t *testing.T) {
 	expectedString := "string"
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = []byte(expectedString)
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewStringColumn(expectedString)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Greater(row, "strinf", "test_column")
@@ -62,9 +60,9 @@ func TestFilterGreaterThanWithStringIsTrue(t *testing.T) {
 
 func TestFilterGreaterThanWithStringIsFalse(t *testing.T) {
 	expectedString := "string"
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = []byte(expectedString)
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewStringColumn(expectedString)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Greater(row, "string", "test_column")
@@ -78,9 +76,9 @@ func TestFilterGreaterThanWithStringIsFalse(t *testing.T) {
 
 func TestFilterLessThanWithStringIsTrue(t *testing.T) {
 	expectedString := "string"
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = []byte(expectedString)
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewStringColumn(expectedString)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Less(row, "strinh", "test_column")
@@ -91,9 +89,9 @@ func TestFilterLessThanWithStringIsTrue(t *testing.T) {
 
 func TestFilterLessThanWithStringIsFalse(t *testing.T) {
 	expectedString := "string"
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = []byte(expectedString)
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewStringColumn(expectedString)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Less(row, "string", "test_column")
@@ -107,9 +105,9 @@ func TestFilterLessThanWithStringIsFalse(t *testing.T) {
 
 func TestFilterLessOrEqualThanWithStringIsTrue(t *testing.T) {
 	expectedString := "string"
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = []byte(expectedString)
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewStringColumn(expectedString)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.LessOrEquals(row, "strinh", "test_column")
@@ -123,9 +121,9 @@ func TestFilterLessOrEqualThanWithStringIsTrue(t *testing.T) {
 
 func TestFilterLessOrEqualThanWithStringIsFalse(t *testing.T) {
 	expectedString := "string"
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = []byte(expectedString)
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewStringColumn(expectedString)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.LessOrEquals(row, "strinf", "test_column")
@@ -135,9 +133,9 @@ func TestFilterLessOrEqualThanWithStringIsFalse(t *testing.T) {
 
 func TestFilterGreaterOrEqualThanWithStringIsTrue(t *testing.T) {
 	expectedString := "string"
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = []byte(expectedString)
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewStringColumn(expectedString)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.GreaterOrEquals(row, "strinf", "test_column")
@@ -151,9 +149,9 @@ func TestFilterGreaterOrEqualThanWithStringIsTrue(t *testing.T) {
 
 func TestFilterGreaterOrEqualThanWithStringIsFalse(t *testing.T) {
 	expectedString := "string"
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = []byte(expectedString)
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewStringColumn(expectedString)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.GreaterOrEquals(row, "strinh", "test_column")
@@ -163,10 +161,9 @@ func TestFilterGreaterOrEqualThanWithStringIsFalse(t *testing.T) {
 
 func TestFilterEqualsWithFloatIsTrue(t *testing.T) {
 	expectedFloat := float32(5.3252)
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], math.Float32bits(expectedFloat))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewFloat32Column(expectedFloat)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Equals(row, expectedFloat, "test_column")
@@ -176,10 +173,9 @@ func TestFilterEqualsWithFloatIsTrue(t *testing.T) {
 
 func TestFilterEqualsWithFloatIsFalse(t *testing.T) {
 	expectedFloat := float32(5.3252)
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], math.Float32bits(expectedFloat+1.5432))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewFloat32Column(expectedFloat + 1.5432)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Equals(row, expectedFloat, "test_column")
@@ -189,10 +185,9 @@ func TestFilterEqualsWithFloatIsFalse(t *testing.T) {
 
 func TestFilterGreaterThanWithFloatIsTrue(t *testing.T) {
 	expectedFloat := float32(6.1234)
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], math.Float32bits(expectedFloat))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewFloat32Column(expectedFloat)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Greater(row, expectedFloat-2, "test_column")
@@ -202,10 +197,9 @@ func TestFilterGreaterThanWithFloatIsTrue(t *testing.T) {
 
 func TestFilterGreaterThanWithFloatIsFalse(t *testing.T) {
 	expectedFloat := float32(6.4242)
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], math.Float32bits(expectedFloat))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewFloat32Column(expectedFloat)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Greater(row, expectedFloat, "test_column")
@@ -219,10 +213,9 @@ func TestFilterGreaterThanWithFloatIsFalse(t *testing.T) {
 
 func TestFilterLessThanWithFloatIsTrue(t *testing.T) {
 	expectedFloat := float32(6.1234)
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], math.Float32bits(expectedFloat))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewFloat32Column(expectedFloat)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Less(row, expectedFloat+2, "test_column")
@@ -232,10 +225,9 @@ func TestFilterLessThanWithFloatIsTrue(t *testing.T) {
 
 func TestFilterLessThanWithFloatIsFalse(t *testing.T) {
 	expectedFloat := float32(6.4242)
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], math.Float32bits(expectedFloat))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewFloat32Column(expectedFloat)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Less(row, expectedFloat, "test_column")
@@ -248,10 +240,9 @@ func TestFilterLessThanWithFloatIsFalse(t *testing.T) {
 
 func TestFilterLessOrEqualWithFloatIsTrue(t *testing.T) {
 	expectedFloat := float32(6.1234)
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], math.Float32bits(expectedFloat))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewFloat32Column(expectedFloat)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.LessOrEquals(row, expectedFloat+2, "test_column")
@@ -265,10 +256,9 @@ func TestFilterLessOrEqualWithFloatIsTrue(t *testing.T) {
 
 func TestFilterLessOrEqualWithFloatIsFalse(t *testing.T) {
 	expectedFloat := float32(6.4242)
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], math.Float32bits(expectedFloat))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewFloat32Column(expectedFloat)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.LessOrEquals(row, expectedFloat-2, "test_column")
@@ -278,10 +268,9 @@ func TestFilterLessOrEqualWithFloatIsFalse(t *testing.T) {
 
 func TestFilterGreaterOrEqualWithFloatIsTrue(t *testing.T) {
 	expectedFloat := float32(6.1234)
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], math.Float32bits(expectedFloat))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewFloat32Column(expectedFloat)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.GreaterOrEquals(row, expectedFloat-2, "test_column")
@@ -295,10 +284,9 @@ func TestFilterGreaterOrEqualWithFloatIsTrue(t *testing.T) {
 
 func TestFilterGreaterOrEqualWithFloatIsFalse(t *testing.T) {
 	expectedFloat := float32(6.4242)
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], math.Float32bits(expectedFloat))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewFloat32Column(expectedFloat)
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.GreaterOrEquals(row, expectedFloat+2, "test_column")
@@ -308,10 +296,9 @@ func TestFilterGreaterOrEqualWithFloatIsFalse(t *testing.T) {
 
 func TestFilterEqualsWithIntIsTrue(t *testing.T) {
 	expectedInt := 6
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], uint32(expectedInt))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewInt32Column(int32(expectedInt))
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Equals(row, expectedInt, "test_column")
@@ -322,10 +309,9 @@ func TestFilterEqualsWithIntIsTrue(t *testing.T) {
 
 func TestFilterEqualsWithIntIsFalse(t *testing.T) {
 	expectedInt := 6
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], uint32(expectedInt+1))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewInt32Column(int32(expectedInt + 1))
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Equals(row, expectedInt, "test_column")
@@ -335,10 +321,9 @@ func TestFilterEqualsWithIntIsFalse(t *testing.T) {
 
 func TestFilterGreaterThanWithIntIsTrue(t *testing.T) {
 	expectedInt := 6
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], uint32(expectedInt))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewInt32Column(int32(expectedInt))
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Greater(row, expectedInt-2, "test_column")
@@ -349,10 +334,9 @@ func TestFilterGreaterThanWithIntIsTrue(t *testing.T) {
 
 func TestFilterGreaterThanWithIntIsFalse(t *testing.T) {
 	expectedInt := 6
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], uint32(expectedInt))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewInt32Column(int32(expectedInt))
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 
@@ -367,10 +351,9 @@ func TestFilterGreaterThanWithIntIsFalse(t *testing.T) {
 
 func TestFilterLessThanWithIntIsTrue(t *testing.T) {
 	expectedInt := 6
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], uint32(expectedInt))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewInt32Column(int32(expectedInt))
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Less(row, expectedInt+2, "test_column")
@@ -380,10 +363,9 @@ func TestFilterLessThanWithIntIsTrue(t *testing.T) {
 
 func TestFilterLessThanWithIntIsFalse(t *testing.T) {
 	expectedInt := 6
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], uint32(expectedInt))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewInt32Column(int32(expectedInt))
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.Less(row, expectedInt, "test_column")
@@ -397,10 +379,9 @@ func TestFilterLessThanWithIntIsFalse(t *testing.T) {
 
 func TestFilterGreaterOrEqualsWithIntIsTrue(t *testing.T) {
 	expectedInt := 6
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], uint32(expectedInt))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewInt32Column(int32(expectedInt))
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.GreaterOrEquals(row, expectedInt-2, "test_column")
@@ -414,10 +395,9 @@ func TestFilterGreaterOrEqualsWithIntIsTrue(t *testing.T) {
 
 func TestFilterGreaterOrEqualsWithIntIsFalse(t *testing.T) {
 	expectedInt := 6
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], uint32(expectedInt))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewInt32Column(int32(expectedInt))
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.GreaterOrEquals(row, expectedInt+2, "test_column")
@@ -428,10 +408,9 @@ func TestFilterGreaterOrEqualsWithIntIsFalse(t *testing.T) {
 
 func TestFilterLessOrEqualsWithIntIsTrue(t *testing.T) {
 	expectedInt := 6
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], uint32(expectedInt))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewInt32Column(int32(expectedInt))
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.LessOrEquals(row, expectedInt+2, "test_column")
@@ -446,10 +425,9 @@ func TestFilterLessOrEqualsWithIntIsTrue(t *testing.T) {
 
 func TestFilterLessOrEqualThanWithIntIsFalse(t *testing.T) {
 	expectedInt := 6
-	dynMap := make(map[string][]byte)
-	dynMap["test_column"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test_column"], uint32(expectedInt))
-	dynMap["test_col2"] = []byte("test_not_pass_string")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test_column"] = dataStructures.NewInt32Column(int32(expectedInt))
+	dynMap["test_col2"] = dataStructures.NewStringColumn("test_not_pass_string")
 	filter := NewFilter()
 	row := dataStructures.NewDynamicMap(dynMap)
 	retVal, err := filter.LessOrEquals(row, expectedInt-2, "test_column")
@@ -2,10 +2,37 @@ package filters
 
 import dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 
+// Predicate Condition over a row. Fails if a column is missing or has another type than the expected
+type Predicate func(row *dataStructures.DynamicMap) (bool, error)
+
+// Operator Comparison between the value of a column and another value
+type Operator int
+
+const (
+	EqualsOperator Operator = iota
+	NotEqualsOperator
+	GreaterOperator
+	GreaterOrEqualsOperator
+	LessOperator
+	LessOrEqualsOperator
+)
+
 type FilterInterface interface {
-	Equals(row dataStructures.DynamicMap, valueOfCompare interface{}, colName string) (bool, error)
-	GreaterThan(row dataStructures.DynamicMap, valueOfCompare interface{}, colName string) (bool, error)
-	LessThan(row dataStructures.DynamicMap, valueOfCompare interface{}, colName string) (bool, error)
-	GreaterOrEquals(row dataStructures.DynamicMap, valueOfCompare interface{}, colName string) (bool, error)
-	LessOrEquals(row dataStructures.DynamicMap, valueOfCompare interface{}, colName string) (bool, error)
+	Equals(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error)
+	Greater(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error)
+	Less(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error)
+	GreaterOrEquals(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error)
+	LessOrEquals(row *dataStructures.DynamicMap, valueOfCompare any, colName string) (bool, error)
+
+	Compare(colName string, operator Operator, valueOfCompare any) Predicate
+	CompareColumns(colName string, operator Operator, otherColName string) Predicate
+	CompareColumnsScaled(colName string, operator Operator, otherColName string, factor float64) Predicate
+	In(colName string, values ...any) Predicate
+	Between(colName string, low any, high any) Predicate
+	HasPrefix(colName string, prefix string) Predicate
+	Contains(colName string, substring string) Predicate
+	Matches(colName string, pattern string) (Predicate, error)
+	And(predicates ...Predicate) Predicate
+	Or(predicates ...Predicate) Predicate
+	Not(predicate Predicate) Predicate
 }
@@ -0,0 +1,174 @@
+package filters
+
+import (
+	"cmp"
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"regexp"
+	"strings"
+)
+
+// Compare Predicate that compares the value of the column with the value received
+func (filter *Filter) Compare(colName string, operator Operator, valueOfCompare any) Predicate {
+	return func(row *dataStructures.DynamicMap) (bool, error) {
+		return filter.compare(row, valueOfCompare, colName, operator)
+	}
+}
+
+// columnValue Returns the value of the column with the type used by the comparisons
+func columnValue(row *dataStructures.DynamicMap, colName string) (any, error) {
+	columnType, err := row.GetType(colName)
+	if err != nil {
+		return nil, err
+	}
+	switch columnType {
+	case dataStructures.Int32Type:
+		return row.GetAsInt(colName)
+	case dataStructures.Int64Type:
+		return row.GetAsInt64(colName)
+	case dataStructures.Float32Type:
+		return row.GetAsFloat(colName)
+	case dataStructures.Float64Type:
+		return row.GetAsFloat64(colName)
+	case dataStructures.StringType:
+		return row.GetAsString(colName)
+	case dataStructures.BoolType:
+		return row.GetAsBool(colName)
+	case dataStructures.TimestampType:
+		return row.GetAsTimestamp(colName)
+	}
+	return nil, fmt.Errorf("column %v of type %v can not be compared", colName, columnType)
+}
+
+// CompareColumns Predicate that compares the values of two columns of the row. Both columns must have the same type
+func (filter *Filter) CompareColumns(colName string, operator Operator, otherColName string) Predicate {
+	return func(row *dataStructures.DynamicMap) (bool, error) {
+		otherValue, err := columnValue(row, otherColName)
+		if err != nil {
+			return false, err
+		}
+		return filter.compare(row, otherValue, colName, operator)
+	}
+}
+
+// numericValue Returns the value of a numeric column of any size
+func numericValue(row *dataStructures.DynamicMap, colName string) (float64, error) {
+	value, err := columnValue(row, colName)
+	if err != nil {
+		return 0, err
+	}
+	switch casted := value.(type) {
+	case int:
+		return float64(casted), nil
+	case int64:
+		return float64(casted), nil
+	case float32:
+		return float64(casted), nil
+	case float64:
+		return casted, nil
+	}
+	return 0, fmt.Errorf("column %v is not numeric", colName)
+}
+
+// CompareColumnsScaled Predicate that compares a numeric column with another one multiplied by the factor
+func (filter *Filter) CompareColumnsScaled(colName string, operator Operator, otherColName string, factor float64) Predicate {
+	return func(row *dataStructures.DynamicMap) (bool, error) {
+		value, err := numericValue(row, colName)
+		if err != nil {
+			return false, err
+		}
+		otherValue, err := numericValue(row, otherColName)
+		if err != nil {
+			return false, err
+		}
+		return operator.holds(cmp.Compare(value, factor*otherValue)), nil
+	}
+}
+
+// In Predicate that is true if the value of the column is one of the values received
+func (filter *Filter) In(colName string, values ...any) Predicate {
+	return func(row *dataStructures.DynamicMap) (bool, error) {
+		for _, value := range values {
+			equals, err := filter.Equals(row, value, colName)
+			if err != nil || equals {
+				return equals, err
+			}
+		}
+		return false, nil
+	}
+}
+
+// Between Predicate that is true if the value of the column is between low and high, both included
+func (filter *Filter) Between(colName string, low any, high any) Predicate {
+	return filter.And(filter.Compare(colName, GreaterOrEqualsOperator, low), filter.Compare(colName, LessOrEqualsOperator, high))
+}
+
+func (filter *Filter) matchString(colName string, matches func(string) bool) Predicate {
+	return func(row *dataStructures.DynamicMap) (bool, error) {
+		rowData, err := row.GetAsString(colName)
+		if err != nil {
+			return false, err
+		}
+		return matches(rowData), nil
+	}
+}
+
+// HasPrefix Predicate that is true if the string column starts with the prefix
+func (filter *Filter) HasPrefix(colName string, prefix string) Predicate {
+	return filter.matchString(colName, func(rowData string) bool {
+		return strings.HasPrefix(rowData, prefix)
+	})
+}
+
+// Contains Predicate that is true if the string column contains the substring
+func (filter *Filter) Contains(colName string, substring string) Predicate {
+	return filter.matchString(colName, func(rowData string) bool {
+		return strings.Contains(rowData, substring)
+	})
+}
+
+// Matches Predicate that is true if the string column matches the regular expression. Fails if the pattern is invalid
+func (filter *Filter) Matches(colName string, pattern string) (Predicate, error) {
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return filter.matchString(colName, regex.MatchString), nil
+}
+
+// And Predicate that is true if all the predicates are. Stops evaluating at the first one that is false
+func (filter *Filter) And(predicates ...Predicate) Predicate {
+	return func(row *dataStructures.DynamicMap) (bool, error) {
+		for _, predicate := range predicates {
+			result, err := predicate(row)
+			if err != nil || !result {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+// Or Predicate that is true if any of the predicates is. Stops evaluating at the first one that is true
+func (filter *Filter) Or(predicates ...Predicate) Predicate {
+	return func(row *dataStructures.DynamicMap) (bool, error) {
+		for _, predicate := range predicates {
+			result, err := predicate(row)
+			if err != nil || result {
+				return result, err
+			}
+		}
+		return false, nil
+	}
+}
+
+// Not Predicate that negates the one received
+func (filter *Filter) Not(predicate Predicate) Predicate {
+	return func(row *dataStructures.DynamicMap) (bool, error) {
+		result, err := predicate(row)
+		if err != nil {
+			return false, err
+		}
+		return !result, nil
+	}
+}
@@ -0,0 +1,125 @@
+package filters
+
+import (
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func createFlightRow() *dataStructures.DynamicMap {
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["totalStopovers"] = dataStructures.NewInt32Column(3)
+	dynMap["totalTravelDistance"] = dataStructures.NewFloat32Column(1000)
+	dynMap["directDistance"] = dataStructures.NewFloat32Column(200)
+	dynMap["route"] = dataStructures.NewStringColumn("ATL-JFK")
+	dynMap["startingAirport"] = dataStructures.NewStringColumn("ATL")
+	dynMap["destinationAirport"] = dataStructures.NewStringColumn("JFK")
+	return dataStructures.NewDynamicMap(dynMap)
+}
+
+func TestFilterImplementsFilterInterface(t *testing.T) {
+	var filter FilterInterface = NewFilter()
+	assert.NotNil(t, filter)
+}
+
+func TestAndOrNotComposeThePredicates(t *testing.T) {
+	filter := NewFilter()
+	row := createFlightRow()
+	threeStopovers := filter.Compare("totalStopovers", GreaterOrEqualsOperator, 3)
+	fromJFK := filter.Compare("startingAirport", EqualsOperator, "JFK")
+
+	result, err := filter.And(threeStopovers, fromJFK)(row)
+	assert.Nil(t, err)
+	assert.False(t, result)
+
+	result, err = filter.Or(threeStopovers, fromJFK)(row)
+	assert.Nil(t, err)
+	assert.True(t, result)
+
+	result, err = filter.And(threeStopovers, filter.Not(fromJFK))(row)
+	assert.Nil(t, err)
+	assert.True(t, result)
+}
+
+func TestAndStopsAtTheFirstFalsePredicate(t *testing.T) {
+	filter := NewFilter()
+	row := createFlightRow()
+	missingColumn := filter.Compare("missing", EqualsOperator, 3)
+
+	result, err := filter.And(filter.Compare("totalStopovers", LessOperator, 3), missingColumn)(row)
+	assert.Nil(t, err)
+	assert.False(t, result)
+
+	_, err = filter.And(filter.Compare("totalStopovers", EqualsOperator, 3), missingColumn)(row)
+	assert.Error(t, err)
+}
+
+func TestInAndBetween(t *testing.T) {
+	filter := NewFilter()
+	row := createFlightRow()
+
+	result, err := filter.In("startingAirport", "EZE", "ATL")(row)
+	assert.Nil(t, err)
+	assert.True(t, result)
+
+	result, err = filter.In("startingAirport", "EZE", "JFK")(row)
+	assert.Nil(t, err)
+	assert.False(t, result)
+
+	result, err = filter.Between("totalStopovers", 1, 3)(row)
+	assert.Nil(t, err)
+	assert.True(t, result)
+
+	result, err = filter.Between("totalTravelDistance", float32(0), float32(999.5))(row)
+	assert.Nil(t, err)
+	assert.False(t, result)
+}
+
+func TestStringPredicates(t *testing.T) {
+	filter := NewFilter()
+	row := createFlightRow()
+
+	result, err := filter.HasPrefix("route", "ATL-")(row)
+	assert.Nil(t, err)
+	assert.True(t, result)
+
+	result, err = filter.Contains("route", "EZE")(row)
+	assert.Nil(t, err)
+	assert.False(t, result)
+
+	matches, err := filter.Matches("route", "^[A-Z]{3}-JFK$")
+	assert.Nil(t, err)
+	result, err = matches(row)
+	assert.Nil(t, err)
+	assert.True(t, result)
+
+	_, err = filter.HasPrefix("totalStopovers", "3")(row)
+	assert.Error(t, err)
+
+	_, err = filter.Matches("route", "[A-Z")
+	assert.Error(t, err)
+}
+
+func TestColumnToColumnComparisons(t *testing.T) {
+	filter := NewFilter()
+	row := createFlightRow()
+
+	result, err := filter.CompareColumns("startingAirport", NotEqualsOperator, "destinationAirport")(row)
+	assert.Nil(t, err)
+	assert.True(t, result)
+
+	result, err = filter.CompareColumns("totalTravelDistance", GreaterOperator, "directDistance")(row)
+	assert.Nil(t, err)
+	assert.True(t, result)
+
+	result, err = filter.CompareColumnsScaled("totalTravelDistance", GreaterOperator, "directDistance", 5)(row)
+	assert.Nil(t, err)
+	assert.False(t, result)
+
+	result, err = filter.CompareColumnsScaled("totalTravelDistance", GreaterOperator, "totalStopovers", 4)(row)
+	assert.Nil(t, err)
+	assert.True(t, result)
+
+	_, err = filter.CompareColumns("totalStopovers", EqualsOperator, "route")(row)
+	assert.Error(t, err)
+}
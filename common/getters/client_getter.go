@@ -6,11 +6,14 @@ import (
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
 	socketsProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/sockets"
-	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
+	"time"
 )
 
+// streamPollInterval Time to wait before reading again a result that is being written
+const streamPollInterval = 200 * time.Millisecond
+
 type ClientGetter struct {
 	config   *GetterConfig
 	sph      *socketsProtocol.SocketProtocolHandler
@@ -38,12 +41,10 @@ func (c *ClientGetter) HandleClientGetter() {
 	defer c.sph.Close()
 
 	c.clientId = msg.ClientId
-	if filemanager.DirectoryExists(msg.ClientId) {
-		rowNum, err := msg.DynMaps[0].GetAsInt(utils.NumberOfRow)
-		if err != nil {
-			log.Errorf("Client Getter | Error trying to get number of row as int from message | %v", err)
-		}
-		c.sendResults(uint(rowNum))
+	if c.resultsAreReady() {
+		c.sendResults(c.rowToResumeFrom(msg))
+	} else if c.config.Streaming {
+		c.streamResults(c.rowToResumeFrom(msg))
 	} else {
 		c.askLaterForResults()
 	}
@@ -51,6 +52,33 @@ func (c *ClientGetter) HandleClientGetter() {
 	close(c.join)
 }
 
+// rowToResumeFrom Returns the amount of rows that the client already received
+func (c *ClientGetter) rowToResumeFrom(msg *dataStructures.Message) uint {
+	if len(msg.DynMaps) == 0 {
+		return 0
+	}
+	rowNum, err := msg.DynMaps[0].GetAsInt(utils.NumberOfRow)
+	if err != nil {
+		log.Errorf("Client Getter | Error trying to get number of row as int from message | %v", err)
+	}
+	return uint(rowNum)
+}
+
+// resultsAreReady Checks that every result file of the getter was moved to the folder of the client.
+// The folder can be shared with other services, so its existence alone is not enough
+func (c *ClientGetter) resultsAreReady() bool {
+	for _, filename := range c.config.FileNames {
+		if !filemanager.DirectoryExists(c.resultFileName(filename)) {
+			return false
+		}
+	}
+	return filemanager.DirectoryExists(c.clientId)
+}
+
+func (c *ClientGetter) resultFileName(filename string) string {
+	return fmt.Sprintf("%v/%v_%v.csv", c.clientId, filename, c.clientId)
+}
+
 // askLaterForResults Tells the client to wait and finishes the connection
 func (c *ClientGetter) askLaterForResults() {
 	log.Infof("Client Getter %v | Client asked for results when they are not ready. Answer 'Later'", c.clientId)
@@ -66,33 +94,20 @@ func (c *ClientGetter) askLaterForResults() {
 // sendResults Sends the saved results to the client
 func (c *ClientGetter) sendResults(rowNum uint) {
 	log.Infof("Client Getter %v | Sending results to client", c.clientId)
-	var currBatch []*dataStructures.DynamicMap
-	curLengthOfBatch := 0
-	currRowNum := uint(0)
+	sender := newRowsSender(c, rowNum)
 	for _, filename := range c.config.FileNames {
-		reader, err := filemanager.NewFileReader(fmt.Sprintf("%v/%v_%v.csv", c.clientId, filename, c.clientId))
+		reader, err := filemanager.NewFileReader(c.resultFileName(filename))
 		if err != nil {
 			log.Errorf("Client Getter %v | Error trying to open file: %v | %v | Skipping it...", c.clientId, filename, err)
 			continue
 		}
 		for reader.CanRead() {
-			select {
-			case <-c.stop:
+			if c.stopped() {
 				log.Warnf("Client Getter %v | Received signal while sending file, stopping transfer", c.clientId)
+				utils.CloseFileAndNotifyError(reader.FileManager)
 				return
-			default:
-			}
-			if currRowNum < rowNum {
-				currRowNum++
-				continue
-			}
-			line := reader.ReadLine()
-			currBatch = append(currBatch, serializer.DeserializeFromString(line))
-			curLengthOfBatch++
-			if uint(curLengthOfBatch) >= c.config.MaxLinesPerSend {
-				c.sendBatch(currBatch)
-				currBatch = make([]*dataStructures.DynamicMap, 0)
 			}
+			sender.addLine(reader.ReadLine())
 		}
 		err = reader.Err()
 		if err != nil {
@@ -100,12 +115,97 @@ func (c *ClientGetter) sendResults(rowNum uint) {
 		}
 		utils.CloseFileAndNotifyError(reader.FileManager)
 	}
-	if curLengthOfBatch > 0 {
-		c.sendBatch(currBatch)
+	sender.flush()
+	c.sendEOF()
+}
+
+// stopped Returns true if the getter is closing
+func (c *ClientGetter) stopped() bool {
+	select {
+	case <-c.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitOrStop Waits before reading again the results. Returns true if the getter is closing
+func (c *ClientGetter) waitOrStop() bool {
+	select {
+	case <-c.stop:
+		return true
+	case <-time.After(streamPollInterval):
+		return false
+	}
+}
+
+// streamResults Sends the results while the saver writes them, tailing the file in progress of each result.
+// Each file ends when the saver moves it to the folder of the client, then the next one is streamed
+func (c *ClientGetter) streamResults(rowNum uint) {
+	log.Infof("Client Getter %v | Streaming results to client", c.clientId)
+	sender := newRowsSender(c, rowNum)
+	for _, filename := range c.config.FileNames {
+		if !c.streamFile(filename, sender) {
+			log.Warnf("Client Getter %v | Received signal while streaming file, stopping transfer", c.clientId)
+			return
+		}
 	}
 	c.sendEOF()
 }
 
+// openResultsTailer Opens the results when they are ready or the file in progress if the saver is writing them.
+// Waits until one of them exists. Returns false if the getter is closing
+func (c *ClientGetter) openResultsTailer(filename string) (*filemanager.FileTailer, bool) {
+	inProgressFileName := fmt.Sprintf("%v_%v.csv", filename, c.clientId)
+	for {
+		for _, file := range []string{c.resultFileName(filename), inProgressFileName} {
+			if !filemanager.DirectoryExists(file) {
+				continue
+			}
+			// The file in progress can be moved between the check and the opening, in that case it is checked again
+			tailer, err := filemanager.NewFileTailer(file)
+			if err == nil {
+				return tailer, true
+			}
+		}
+		if c.waitOrStop() {
+			return nil, false
+		}
+	}
+}
+
+// streamFile Sends the rows of a result as they are written. The file is moved to the folder of the client once
+// the saver finishes, so reading it again after that returns the last rows. Returns false if the getter is closing
+func (c *ClientGetter) streamFile(filename string, sender *rowsSender) bool {
+	tailer, ok := c.openResultsTailer(filename)
+	if !ok {
+		return false
+	}
+	defer utils.CloseFileAndNotifyError(tailer.FileManager)
+	finished := false
+	for {
+		for tailer.CanRead() {
+			if c.stopped() {
+				return false
+			}
+			sender.addLine(tailer.ReadLine())
+		}
+		if err := tailer.Err(); err != nil {
+			log.Errorf("Client Getter %v | Error reading file in progress | %v", c.clientId, err)
+			return true
+		}
+		sender.flush()
+		if finished {
+			return true
+		}
+		finished = filemanager.DirectoryExists(c.resultFileName(filename))
+		if !finished && c.waitOrStop() {
+			return false
+		}
+	}
+}
+
+// sendEOF Tells the client that it received every result, so they can be removed later
 func (c *ClientGetter) sendEOF() {
 	log.Infof("Client Getter %v | Sending EOF to client...", c.clientId)
 	err := c.sph.Write(&dataStructures.Message{
@@ -114,6 +214,10 @@ func (c *ClientGetter) sendEOF() {
 	})
 	if err != nil {
 		log.Errorf("Client Getter %v | Error trying to send EOF | %v", c.clientId, err)
+		return
+	}
+	if c.config.Collector != nil {
+		c.config.Collector.Fetched(c.clientId)
 	}
 }
 
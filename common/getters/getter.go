@@ -28,6 +28,7 @@ func NewGetter(getterConf *GetterConfig) (*Getter, error) {
 func (g *Getter) ReturnResults() {
 	defer utils.CloseSocketAndNotifyError(g.server)
 	defer log.Infof("Getter | Finishing Return Loop...")
+	defer g.stopClients()
 	for {
 		socket, err := g.server.Accept()
 		if err != nil {
@@ -38,7 +39,7 @@ func (g *Getter) ReturnResults() {
 		joinChannel := make(chan bool, 1)
 		g.joinChannels = append(g.joinChannels, joinChannel)
 		g.stopChannels = append(g.stopChannels, stopChannel)
-		client := NewClientGetter(socket, g.c, joinChannel, stopChannel)
+		client := NewClientGetter(socket, g.c, stopChannel, joinChannel)
 		go client.HandleClientGetter()
 		g.clearChannels()
 	}
@@ -61,6 +62,15 @@ func (g *Getter) clearChannels() {
 	}
 }
 
+// stopClients Signals the clients that are still being served to stop
+func (g *Getter) stopClients() {
+	for _, stopChannel := range g.stopChannels {
+		close(stopChannel)
+	}
+	g.stopChannels = []chan bool{}
+	g.joinChannels = []chan bool{}
+}
+
 // Close Stops the execution of the getter server
 func (g *Getter) Close() {
 	log.Infof("Getter | Sending signal to stop...")
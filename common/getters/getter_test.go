@@ -1,10 +1,14 @@
 package getters
 
 import (
+	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/communication"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
 	socketsProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/sockets"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/stretchr/testify/assert"
+	"os"
 	"testing"
 	"time"
 )
@@ -37,3 +41,67 @@ func TestShouldReturnLaterMessage(t *testing.T) {
 		t.Errorf("Timeout! Should have finished by now...")
 	}
 }
+
+func createSavedLine(route string) string {
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["route"] = dataStructures.NewStringColumn(route)
+	return serializer.SerializeToString(dataStructures.NewDynamicMap(dynMap))
+}
+
+func readResultsMessage(t *testing.T, socketProtocol *socketsProtocol.SocketProtocolHandler) *dataStructures.Message {
+	msgChan := make(chan *dataStructures.Message, 1)
+	go func() {
+		msg, err := socketProtocol.Read()
+		assert.Nilf(t, err, "Error receiving msg: %v", err)
+		msgChan <- msg
+	}()
+	select {
+	case msg := <-msgChan:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Timeout! Should have received the results by now...")
+	}
+	return nil
+}
+
+func TestStreamingSendsTheRowsWhileTheyAreSavedAndResumesFromTheRowReceived(t *testing.T) {
+	workingDir, err := os.Getwd()
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chdir(t.TempDir()))
+	defer func() { _ = os.Chdir(workingDir) }()
+
+	clientId := "streamclient"
+	inProgressFile := fmt.Sprintf("results_%v.csv", clientId)
+	writer, err := filemanager.NewFileWriter(inProgressFile)
+	assert.Nil(t, err)
+	assert.Nil(t, writer.WriteLine(createSavedLine("ATL-JFK")+createSavedLine("EZE-MIA")+createSavedLine("LAX-SFO")))
+
+	config := NewGetterConfig("1", []string{"results"}, "127.0.0.1:45679", 10, true, nil)
+	getter, err := NewGetter(config)
+	assert.Nil(t, err)
+	go getter.ReturnResults()
+	defer getter.Close()
+
+	conn, err := communication.NewActiveTCPSocket("127.0.0.1:45679")
+	assert.Nilf(t, err, "Error connecting to getter: %v", err)
+	socketProtocol := socketsProtocol.NewSocketProtocolHandler(conn)
+	defer socketProtocol.Close()
+	assert.Nil(t, socketProtocol.Write(GetExerciseMessageWithRow(clientId, 1, 1)))
+
+	msg := readResultsMessage(t, socketProtocol)
+	assert.Equal(t, dataStructures.FlightRows, msg.TypeMessage)
+	assert.Len(t, msg.DynMaps, 2, "The first row was already received")
+	route, err := msg.DynMaps[0].GetAsString("route")
+	assert.Nil(t, err)
+	assert.Equal(t, "EZE-MIA", route)
+
+	assert.Nil(t, writer.WriteLine(createSavedLine("ORD-DEN")))
+	assert.Nil(t, writer.Close())
+	msg = readResultsMessage(t, socketProtocol)
+	assert.Equal(t, dataStructures.FlightRows, msg.TypeMessage)
+	assert.Len(t, msg.DynMaps, 1)
+
+	assert.Nil(t, filemanager.MoveFiles([]string{inProgressFile}, clientId))
+	msg = readResultsMessage(t, socketProtocol)
+	assert.Equal(t, dataStructures.EOFGetter, msg.TypeMessage)
+}
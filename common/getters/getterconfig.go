@@ -1,17 +1,25 @@
 package getters
 
+import "github.com/brunograssano/Distribuidos-TP1/common/retention"
+
 type GetterConfig struct {
 	ID              string
 	FileNames       []string
 	Address         string
 	MaxLinesPerSend uint
+	// Streaming Sends the results while they are written instead of waiting for all of them
+	Streaming bool
+	// Collector Removes the results after they are fetched. Without it they are kept
+	Collector *retention.Collector
 }
 
-func NewGetterConfig(ID string, toReadFileNames []string, address string, linesPerSend uint) *GetterConfig {
+func NewGetterConfig(ID string, toReadFileNames []string, address string, linesPerSend uint, streaming bool, collector *retention.Collector) *GetterConfig {
 	return &GetterConfig{
 		ID:              ID,
 		FileNames:       toReadFileNames,
 		Address:         address,
 		MaxLinesPerSend: linesPerSend,
+		Streaming:       streaming,
+		Collector:       collector,
 	}
 }
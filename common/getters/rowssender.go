@@ -0,0 +1,47 @@
+package getters
+
+import (
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	log "github.com/sirupsen/logrus"
+)
+
+// rowsSender Groups the saved rows in batches to send them to the client.
+// The first rows are skipped if the client already received them before reconnecting
+type rowsSender struct {
+	getter     *ClientGetter
+	batch      []*dataStructures.DynamicMap
+	rowsToSkip uint
+	currRowNum uint
+}
+
+func newRowsSender(getter *ClientGetter, rowsToSkip uint) *rowsSender {
+	return &rowsSender{getter: getter, rowsToSkip: rowsToSkip}
+}
+
+// addLine Adds a saved line to the batch, sending it if it is full
+func (s *rowsSender) addLine(line string) {
+	if s.currRowNum < s.rowsToSkip {
+		s.currRowNum++
+		return
+	}
+	row, err := serializer.DeserializeFromString(line)
+	if err != nil {
+		log.Errorf("Client Getter %v | Error parsing saved line, skipping it | %v", s.getter.clientId, err)
+		return
+	}
+	s.currRowNum++
+	s.batch = append(s.batch, row)
+	if uint(len(s.batch)) >= s.getter.config.MaxLinesPerSend {
+		s.flush()
+	}
+}
+
+// flush Sends the rows of the batch if there are any
+func (s *rowsSender) flush() {
+	if len(s.batch) == 0 {
+		return
+	}
+	s.getter.sendBatch(s.batch)
+	s.batch = make([]*dataStructures.DynamicMap, 0)
+}
@@ -3,44 +3,86 @@ package heartbeat
 import (
 	"github.com/brunograssano/Distribuidos-TP1/common/communication"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	"github.com/brunograssano/Distribuidos-TP1/common/protocol/sockets"
-	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
+	"sync"
 	"time"
 )
 
-func sendHeartbeat(address string, name string) {
+func sendToHealthChecker(address string, msg *dataStructures.Message) {
 	sock, err := communication.NewActiveTCPSocket(address)
 	if err != nil {
-		log.Errorf("HeartBeat Signal | Error conecting to send heartbeat to %v | Err: %v", address, err)
+		log.Errorf("HeartBeat Signal | Error conecting to send message of type %v to %v | Err: %v", msg.TypeMessage, address, err)
 		return
 	}
 	sph := sockets.NewSocketProtocolHandler(sock)
-	mapOfContainer := make(map[string][]byte)
-	mapOfContainer[utils.ServiceName] = serializer.SerializeString(name)
-	err = sph.Write(
-		&dataStructures.Message{
-			TypeMessage: dataStructures.HeartBeat,
-			DynMaps:     []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(mapOfContainer)},
-		},
-	)
+	err = sph.Write(msg)
 	if err != nil {
-		log.Errorf("HeartBeat Signal | Error sending heartbeat to %v | Err: %v", address, err)
+		log.Errorf("HeartBeat Signal | Error sending message of type %v to %v | Err: %v", msg.TypeMessage, address, err)
 	}
 	sph.Close()
 }
 
-func heartBeatLoop(addressesHealthCheckers []string, containerName string, timePerHeartbeatInSeconds uint32, endSignal chan bool) {
+// heartbeatMessage Returns the heartbeat of the container. The replicas of a stage also register in it
+func heartbeatMessage(name string, member *membership.Member) *dataStructures.Message {
+	mapOfContainer := make(map[string]dataStructures.Column)
+	mapOfContainer[utils.ServiceName] = dataStructures.NewStringColumn(name)
+	if member != nil {
+		mapOfContainer[utils.MemberStage] = dataStructures.NewStringColumn(member.Stage)
+		mapOfContainer[utils.MemberNodes] = dataStructures.NewInt64Column(int64(member.Nodes))
+		if member.Keyed {
+			mapOfContainer[utils.MemberFirstKey] = dataStructures.NewInt64Column(int64(member.FirstKey))
+		}
+	}
+	return &dataStructures.Message{
+		TypeMessage: dataStructures.HeartBeat,
+		DynMaps:     []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(mapOfContainer)},
+	}
+}
+
+// leave Tells every health checker that the replica leaves its stage, so they stop restarting it
+func leave(addressesHealthCheckers []string, name string) {
+	mapOfContainer := make(map[string]dataStructures.Column)
+	mapOfContainer[utils.ServiceName] = dataStructures.NewStringColumn(name)
+	msg := &dataStructures.Message{
+		TypeMessage: dataStructures.LeaveMembership,
+		DynMaps:     []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(mapOfContainer)},
+	}
+	var wg sync.WaitGroup
+	for _, address := range addressesHealthCheckers {
+		wg.Add(1)
+		go func(address string) {
+			defer wg.Done()
+			sendToHealthChecker(address, msg)
+		}(address)
+	}
+	wg.Wait()
+	log.Infof("HeartBeat Loop | Left the stage %v", name)
+}
+
+func heartBeatLoop(addressesHealthCheckers []string, containerName string, member *membership.Member, timePerHeartbeatInSeconds uint32, endSignal chan bool) {
+	var inFlight sync.WaitGroup
 	for {
 		log.Debugf("HeartBeat Loop | Sending heartbeat...")
 		for i := 0; i < len(addressesHealthCheckers); i++ {
-			go sendHeartbeat(addressesHealthCheckers[i], containerName)
+			inFlight.Add(1)
+			go func(address string) {
+				defer inFlight.Done()
+				sendToHealthChecker(address, heartbeatMessage(containerName, member))
+			}(addressesHealthCheckers[i])
 		}
 		timeout := time.After(time.Duration(timePerHeartbeatInSeconds) * time.Second)
 		select {
 		case <-endSignal:
 			log.Infof("HeartBeat Loop | Closing heartbeat goroutine")
+			if member != nil {
+				// A heartbeat that arrives after the leave would register the replica again
+				inFlight.Wait()
+				leave(addressesHealthCheckers, containerName)
+				endSignal <- true
+			}
 			return
 		case <-timeout:
 			// Do nothing, just wait till next heartbeat
@@ -1,9 +1,25 @@
 package heartbeat
 
+import "github.com/brunograssano/Distribuidos-TP1/common/membership"
+
 const timePerHeartbeat = uint32(5)
 
 func StartHeartbeat(HealthCheckers []string, Name string) chan bool {
 	endSigHB := make(chan bool, 1)
-	go heartBeatLoop(HealthCheckers, Name, timePerHeartbeat, endSigHB)
+	go heartBeatLoop(HealthCheckers, Name, nil, timePerHeartbeat, endSigHB)
+	return endSigHB
+}
+
+// StartMemberHeartbeat Starts the heartbeats of a replica of a stage, that registers it in the membership of the health checkers
+func StartMemberHeartbeat(HealthCheckers []string, Name string, member membership.Member) chan bool {
+	member.Name = Name
+	endSigHB := make(chan bool)
+	go heartBeatLoop(HealthCheckers, Name, &member, timePerHeartbeat, endSigHB)
 	return endSigHB
 }
+
+// StopMemberHeartbeat Stops the heartbeats of the replica and waits until it left its stage
+func StopMemberHeartbeat(endSigHB chan bool) {
+	endSigHB <- true
+	<-endSigHB
+}
@@ -0,0 +1,97 @@
+package membership
+
+import (
+	"sort"
+	"sync"
+)
+
+// Member Replica registered in a stage
+type Member struct {
+	Name  string
+	Stage string
+	// Nodes Goroutines of the replica consuming the input of the stage
+	Nodes uint
+	// Keyed The nodes consume the routing keys from FirstKey to FirstKey+Nodes-1, as the journey savers do
+	Keyed    bool
+	FirstKey uint
+}
+
+// Registry Replicas registered in each stage. It is safe to use from many goroutines
+type Registry struct {
+	mutex   sync.Mutex
+	members map[string]Member
+	epoch   uint
+}
+
+func NewRegistry() *Registry {
+	return &Registry{members: make(map[string]Member)}
+}
+
+// Join Registers the replica, or updates it if it changed. Returns true if the epoch changed
+func (r *Registry) Join(member Member) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	previous, exists := r.members[member.Name]
+	if exists && previous == member {
+		return false
+	}
+	r.members[member.Name] = member
+	r.epoch++
+	return true
+}
+
+// Leave Removes the replica from its stage. Returns true if it was registered
+func (r *Registry) Leave(name string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, exists := r.members[name]; !exists {
+		return false
+	}
+	delete(r.members, name)
+	r.epoch++
+	return true
+}
+
+// IsMember Returns true if the replica is registered
+func (r *Registry) IsMember(name string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, exists := r.members[name]
+	return exists
+}
+
+// View Returns the current view. The size of a stage is the sum of the nodes of its replicas. For keyed stages
+// it is the amount of routing keys consumed from zero without gaps, so the producers only use keys with a consumer
+func (r *Registry) View() *View {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	view := NewView()
+	view.Epoch = r.epoch
+	keyedStages := make(map[string][]Member)
+	for _, member := range r.members {
+		if member.Keyed {
+			keyedStages[member.Stage] = append(keyedStages[member.Stage], member)
+			continue
+		}
+		view.Sizes[member.Stage] += member.Nodes
+	}
+	for stage, members := range keyedStages {
+		if size := contiguousKeys(members); size > 0 {
+			view.Sizes[stage] = size
+		}
+	}
+	return view
+}
+
+// contiguousKeys Returns the amount of routing keys consumed from zero until the first key without a consumer
+func contiguousKeys(members []Member) uint {
+	sort.Slice(members, func(i, j int) bool { return members[i].FirstKey < members[j].FirstKey })
+	covered := uint(0)
+	for _, member := range members {
+		if member.FirstKey > covered {
+			break
+		}
+		covered = max(covered, member.FirstKey+member.Nodes)
+	}
+	return covered
+}
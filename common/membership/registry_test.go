@@ -0,0 +1,47 @@
+package membership
+
+import (
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTheSizeOfAStageIsTheSumOfTheNodesOfItsReplicas(t *testing.T) {
+	registry := NewRegistry()
+	assert.True(t, registry.Join(Member{Name: "filter-1", Stage: "filters", Nodes: 4}))
+	assert.True(t, registry.Join(Member{Name: "filter-2", Stage: "filters", Nodes: 2}))
+	assert.False(t, registry.Join(Member{Name: "filter-2", Stage: "filters", Nodes: 2}), "The epoch should not change with the same replica")
+
+	view := registry.View()
+	assert.Equal(t, uint(2), view.Epoch)
+	assert.Equal(t, uint(6), view.Sizes["filters"])
+
+	assert.True(t, registry.Leave("filter-1"))
+	assert.False(t, registry.Leave("filter-1"))
+	view = registry.View()
+	assert.Equal(t, uint(3), view.Epoch)
+	assert.Equal(t, uint(2), view.Sizes["filters"])
+}
+
+func TestAKeyedStageOnlyCountsTheKeysWithoutGaps(t *testing.T) {
+	registry := NewRegistry()
+	registry.Join(Member{Name: "saver-1", Stage: "savers", Nodes: 3, Keyed: true, FirstKey: 0})
+	registry.Join(Member{Name: "saver-3", Stage: "savers", Nodes: 3, Keyed: true, FirstKey: 6})
+	assert.Equal(t, uint(3), registry.View().Sizes["savers"], "The keys after the gap have no consumer before them")
+
+	registry.Join(Member{Name: "saver-2", Stage: "savers", Nodes: 3, Keyed: true, FirstKey: 3})
+	assert.Equal(t, uint(9), registry.View().Sizes["savers"])
+}
+
+func TestTheSizeOfTheStageIsTheOneOfTheViewPinnedToTheClient(t *testing.T) {
+	view := NewView()
+	view.Epoch = 4
+	view.Sizes["filters"] = 3
+	view.Sizes["savers"] = 20
+	msg := &dataStructures.Message{Params: view.ToDynMap()}
+
+	assert.Equal(t, uint(3), SizeOf(msg, "filters", 12))
+	assert.Equal(t, uint(20), SizeOf(msg, "savers", 12), "The replicas that joined should be used")
+	assert.Equal(t, uint(5), SizeOf(msg, "reducers", 5), "A stage without nodes in the view should use the configured size")
+	assert.Equal(t, uint(5), SizeOf(&dataStructures.Message{}, "filters", 5), "A client without a view should use the configured size")
+}
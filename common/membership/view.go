@@ -0,0 +1,72 @@
+package membership
+
+import (
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"strings"
+)
+
+// View Nodes registered in each stage at some moment. The epoch changes each time a replica joins or leaves
+type View struct {
+	Epoch uint
+	// Sizes Nodes of each stage, by the name of the queue or exchange that the stage consumes
+	Sizes map[string]uint
+}
+
+// NewView Creates an empty view
+func NewView() *View {
+	return &View{Sizes: make(map[string]uint)}
+}
+
+// AddToDynMap Adds the epoch and the size of each stage as columns of the dynamic map
+func (v *View) AddToDynMap(dynMap *dataStructures.DynamicMap) {
+	dynMap.AddColumn(utils.MembershipEpoch, dataStructures.NewInt64Column(int64(v.Epoch)))
+	for stage, size := range v.Sizes {
+		dynMap.AddColumn(utils.MembershipSizePrefix+stage, dataStructures.NewInt64Column(int64(size)))
+	}
+}
+
+// ToDynMap Returns the view as a dynamic map
+func (v *View) ToDynMap() *dataStructures.DynamicMap {
+	dynMap := dataStructures.NewDynamicMap(make(map[string]dataStructures.Column))
+	v.AddToDynMap(dynMap)
+	return dynMap
+}
+
+// FromDynMap Reads the view of the dynamic map. Returns nil if it does not have one
+func FromDynMap(dynMap *dataStructures.DynamicMap) *View {
+	if dynMap == nil {
+		return nil
+	}
+	epoch, err := dynMap.GetAsInt64(utils.MembershipEpoch)
+	if err != nil {
+		return nil
+	}
+	view := NewView()
+	view.Epoch = uint(epoch)
+	for column := range dynMap.GetCurrentMap() {
+		stage, isSize := strings.CutPrefix(column, utils.MembershipSizePrefix)
+		if !isSize {
+			continue
+		}
+		size, err := dynMap.GetAsInt64(column)
+		if err == nil && size > 0 {
+			view.Sizes[stage] = uint(size)
+		}
+	}
+	return view
+}
+
+// SizeOf Returns the nodes of the stage in the view pinned to the client of the message. The clients that started
+// without a view, or before any node of the stage registered, use the configured nodes
+func SizeOf(msg *dataStructures.Message, stage string, configured uint) uint {
+	view := FromDynMap(msg.Params)
+	if view == nil {
+		return configured
+	}
+	size, exists := view.Sizes[stage]
+	if !exists {
+		return configured
+	}
+	return size
+}
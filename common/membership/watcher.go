@@ -0,0 +1,99 @@
+package membership
+
+import (
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/communication"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/sockets"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// Fetch Asks the health checker at the address for its view
+func Fetch(address string) (*View, error) {
+	sock, err := communication.NewActiveTCPSocket(address)
+	if err != nil {
+		return nil, err
+	}
+	sph := sockets.NewSocketProtocolHandler(sock)
+	defer sph.Close()
+	err = sph.Write(&dataStructures.Message{TypeMessage: dataStructures.GetMembership, DynMaps: []*dataStructures.DynamicMap{}})
+	if err != nil {
+		return nil, err
+	}
+	msg, err := sph.Read()
+	if err != nil {
+		return nil, err
+	}
+	if msg.TypeMessage != dataStructures.Membership || len(msg.DynMaps) == 0 {
+		return nil, fmt.Errorf("unexpected answer of type %v", msg.TypeMessage)
+	}
+	view := FromDynMap(msg.DynMaps[0])
+	if view == nil {
+		return nil, fmt.Errorf("the answer does not have a view")
+	}
+	return view, nil
+}
+
+// Watcher Keeps the last view of the health checkers. Each one numbers its own epochs, so the view is
+// taken from the first that answers, trying the others when it fails
+type Watcher struct {
+	addresses []string
+	period    time.Duration
+	mutex     sync.Mutex
+	current   *View
+	endSignal chan bool
+}
+
+func NewWatcher(addresses []string, period time.Duration) *Watcher {
+	return &Watcher{
+		addresses: addresses,
+		period:    period,
+		endSignal: make(chan bool, 1),
+	}
+}
+
+// WatchLoop Fetches the view periodically until the watcher is closed
+func (w *Watcher) WatchLoop() {
+	for {
+		w.refresh()
+		select {
+		case <-w.endSignal:
+			log.Infof("Membership Watcher | Closing watcher goroutine")
+			return
+		case <-time.After(w.period):
+		}
+	}
+}
+
+func (w *Watcher) refresh() {
+	for _, address := range w.addresses {
+		view, err := Fetch(address)
+		if err != nil {
+			log.Debugf("Membership Watcher | Error fetching the view of %v | %v", address, err)
+			continue
+		}
+		w.mutex.Lock()
+		if w.current == nil || w.current.Epoch != view.Epoch {
+			log.Infof("Membership Watcher | New view of %v | Epoch: %v | Sizes: %v", address, view.Epoch, view.Sizes)
+		}
+		w.current = view
+		w.mutex.Unlock()
+		return
+	}
+	if w.Current() != nil {
+		log.Warnf("Membership Watcher | Warning Message | No health checker answered with its view. Keeping the previous one")
+	}
+}
+
+// Current Returns the last view fetched, or nil if no health checker answered yet
+func (w *Watcher) Current() *View {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.current
+}
+
+func (w *Watcher) Close() {
+	w.endSignal <- true
+}
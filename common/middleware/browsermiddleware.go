@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"fmt"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Browser Reads the messages of a queue one by one, without waiting for new ones. The messages that are
+// not acked return to the queue when the browser is closed, so reading them does not remove them
+type Browser struct {
+	rabbitMQChannel *amqp.Channel
+	queue           amqp.Queue
+}
+
+// CreateBrowser Creates a browser of the queue in its own channel, so closing it only returns its messages
+func (qm *QueueMiddleware) CreateBrowser(name string, durable bool) (*Browser, error) {
+	channel, err := qm.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("error creating channel of browser: %v", err)
+	}
+	return &Browser{
+		rabbitMQChannel: channel,
+		queue:           CreateQueue(channel, name, durable),
+	}, nil
+}
+
+// Next Returns the next message of the queue and its delivery tag. Returns false if there are no more messages
+func (b *Browser) Next() ([]byte, uint64, bool, error) {
+	msg, ok, err := b.rabbitMQChannel.Get(b.queue.Name, false)
+	if err != nil || !ok {
+		return nil, 0, false, err
+	}
+	return msg.Body, msg.DeliveryTag, true, nil
+}
+
+// Ack Removes the message of the delivery tag from the queue
+func (b *Browser) Ack(deliveryTag uint64) error {
+	return b.rabbitMQChannel.Ack(deliveryTag, false)
+}
+
+func (b *Browser) GetName() string {
+	return b.queue.Name
+}
+
+// Close Closes the channel of the browser. The messages read that were not acked return to the queue
+func (b *Browser) Close() error {
+	return b.rabbitMQChannel.Close()
+}
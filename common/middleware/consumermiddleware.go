@@ -40,6 +40,23 @@ func (queue *Consumer) Pop() ([]byte, bool) {
 	return msg.Body, ok
 }
 
+// Attempt Returns the times that the last message consumed was retried, from its header
+func (queue *Consumer) Attempt() int {
+	if queue.lastMessageConsumed == nil {
+		return 0
+	}
+	switch attempt := queue.lastMessageConsumed.Headers[attemptHeader].(type) {
+	case int32:
+		return int(attempt)
+	case int64:
+		return int(attempt)
+	case int:
+		return attempt
+	default:
+		return 0
+	}
+}
+
 func (queue *Consumer) BindTo(nameExchange string, routingKey string, kind string) error {
 	err := queue.rabbitMQChannel.ExchangeDeclare(
 		nameExchange,
@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+)
+
+const (
+	DirectExchange = "direct"
+	FanoutExchange = "fanout"
+	TopicExchange  = "topic"
+)
+
+// InMemoryBroker In-process replacement of RabbitMQ. It holds the queues, exchanges and bindings
+// shared by every InMemoryQueueMiddleware created from it
+type InMemoryBroker struct {
+	mutex     sync.Mutex
+	queues    map[string]*inMemoryQueue
+	exchanges map[string]*inMemoryExchange
+}
+
+type inMemoryBinding struct {
+	queue      *inMemoryQueue
+	routingKey string
+}
+
+type inMemoryExchange struct {
+	name     string
+	kind     string
+	durable  bool
+	bindings []inMemoryBinding
+}
+
+func NewInMemoryBroker() *InMemoryBroker {
+	return &InMemoryBroker{
+		queues:    make(map[string]*inMemoryQueue),
+		exchanges: make(map[string]*inMemoryExchange),
+	}
+}
+
+// declareQueue Returns the queue with the given name, creating it if it does not exist.
+// As in RabbitMQ, redeclaring a queue with a different durability is an error
+func (b *InMemoryBroker) declareQueue(name string, durable bool) (*inMemoryQueue, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	queue, exists := b.queues[name]
+	if !exists {
+		queue = newInMemoryQueue(name, durable)
+		b.queues[name] = queue
+		return queue, nil
+	}
+	if queue.durable != durable {
+		return nil, fmt.Errorf("queue %v already declared with durable=%v", name, queue.durable)
+	}
+	return queue, nil
+}
+
+// declareExchange Returns the exchange with the given name, creating it if it does not exist.
+// Redeclaring an exchange with a different kind or durability is an error
+func (b *InMemoryBroker) declareExchange(name string, kind string, durable bool) (*inMemoryExchange, error) {
+	if kind != DirectExchange && kind != FanoutExchange && kind != TopicExchange {
+		return nil, fmt.Errorf("unknown exchange kind %v", kind)
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	exchange, exists := b.exchanges[name]
+	if !exists {
+		exchange = &inMemoryExchange{name: name, kind: kind, durable: durable}
+		b.exchanges[name] = exchange
+		return exchange, nil
+	}
+	if exchange.kind != kind || exchange.durable != durable {
+		return nil, fmt.Errorf("exchange %v already declared as %v with durable=%v", name, exchange.kind, exchange.durable)
+	}
+	return exchange, nil
+}
+
+// bind Binds the queue to the exchange with the routing key. Repeated bindings are ignored
+func (b *InMemoryBroker) bind(queue *inMemoryQueue, exchangeName string, routingKey string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	exchange, exists := b.exchanges[exchangeName]
+	if !exists {
+		return fmt.Errorf("exchange %v does not exist", exchangeName)
+	}
+	for _, binding := range exchange.bindings {
+		if binding.queue == queue && binding.routingKey == routingKey {
+			return nil
+		}
+	}
+	exchange.bindings = append(exchange.bindings, inMemoryBinding{queue: queue, routingKey: routingKey})
+	return nil
+}
+
+// publishToQueue Publishes using the default exchange, the routing key is the name of the queue.
+// As in RabbitMQ, if the queue does not exist the message is dropped. The attempt is the retry of the message, 0 if it is new
+func (b *InMemoryBroker) publishToQueue(queueName string, data []byte, attempt int) {
+	b.mutex.Lock()
+	queue, exists := b.queues[queueName]
+	b.mutex.Unlock()
+	if !exists {
+		log.Warnf("InMemoryBroker | Queue %v does not exist, dropping message", queueName)
+		return
+	}
+	queue.push(data, attempt)
+}
+
+// publishToExchange Delivers a copy of the message to each queue bound to the exchange whose binding matches the routing key.
+// A queue receives at most one copy even if more than one of its bindings match
+func (b *InMemoryBroker) publishToExchange(exchangeName string, routingKey string, data []byte) error {
+	b.mutex.Lock()
+	exchange, exists := b.exchanges[exchangeName]
+	if !exists {
+		b.mutex.Unlock()
+		return fmt.Errorf("exchange %v does not exist", exchangeName)
+	}
+	var destinations []*inMemoryQueue
+	for _, binding := range exchange.bindings {
+		if !exchange.routes(binding.routingKey, routingKey) || containsQueue(destinations, binding.queue) {
+			continue
+		}
+		destinations = append(destinations, binding.queue)
+	}
+	b.mutex.Unlock()
+
+	for _, queue := range destinations {
+		queue.push(data, 0)
+	}
+	return nil
+}
+
+// Restart Simulates a restart of the broker. Non durable queues and exchanges are deleted,
+// and the unacknowledged messages of the durable queues are put back in the queue
+func (b *InMemoryBroker) Restart() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for name, queue := range b.queues {
+		if !queue.durable {
+			queue.delete()
+			delete(b.queues, name)
+			continue
+		}
+		queue.requeueAll()
+	}
+	for name, exchange := range b.exchanges {
+		if !exchange.durable {
+			delete(b.exchanges, name)
+			continue
+		}
+		var bindings []inMemoryBinding
+		for _, binding := range exchange.bindings {
+			if binding.queue.durable {
+				bindings = append(bindings, binding)
+			}
+		}
+		exchange.bindings = bindings
+	}
+}
+
+// QueueLength Returns the amount of messages ready to be consumed in the queue
+func (b *InMemoryBroker) QueueLength(name string) int {
+	b.mutex.Lock()
+	queue, exists := b.queues[name]
+	b.mutex.Unlock()
+	if !exists {
+		return 0
+	}
+	return queue.length()
+}
+
+func (e *inMemoryExchange) routes(bindingKey string, routingKey string) bool {
+	switch e.kind {
+	case FanoutExchange:
+		return true
+	case DirectExchange:
+		return bindingKey == routingKey
+	default:
+		return topicMatches(strings.Split(bindingKey, "."), strings.Split(routingKey, "."))
+	}
+}
+
+// topicMatches Matches the words of a topic routing key against a binding pattern.
+// '*' substitutes exactly one word and '#' zero or more words
+func topicMatches(pattern []string, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+	if pattern[0] == "#" {
+		for i := 0; i <= len(words); i++ {
+			if topicMatches(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(words) == 0 {
+		return false
+	}
+	if pattern[0] != "*" && pattern[0] != words[0] {
+		return false
+	}
+	return topicMatches(pattern[1:], words[1:])
+}
+
+func containsQueue(queues []*inMemoryQueue, queue *inMemoryQueue) bool {
+	for _, q := range queues {
+		if q == queue {
+			return true
+		}
+	}
+	return false
+}
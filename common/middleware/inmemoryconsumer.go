@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"fmt"
+	"sync"
+)
+
+// InMemoryConsumer Consumer of a queue of the InMemoryBroker
+type InMemoryConsumer struct {
+	ConsumerInterface
+	broker              *InMemoryBroker
+	queue               *inMemoryQueue
+	mutex               sync.Mutex
+	lastMessageConsumed *inMemoryDelivery
+	pendingTags         []uint64
+	closed              bool
+}
+
+func NewInMemoryConsumer(broker *InMemoryBroker, name string, durable bool) *InMemoryConsumer {
+	queue, err := broker.declareQueue(name, durable)
+	FailOnError(err, "Failed to declare in memory queue.")
+	return &InMemoryConsumer{
+		broker: broker,
+		queue:  queue,
+	}
+}
+
+func (c *InMemoryConsumer) Pop() ([]byte, bool) {
+	delivery, ok := c.queue.pop(&c.closed)
+	if !ok {
+		return nil, false
+	}
+	c.mutex.Lock()
+	c.lastMessageConsumed = &delivery
+	c.pendingTags = append(c.pendingTags, delivery.deliveryTag)
+	c.mutex.Unlock()
+	return delivery.body, true
+}
+
+// Attempt Returns the times that the last message consumed was retried
+func (c *InMemoryConsumer) Attempt() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.lastMessageConsumed == nil {
+		return 0
+	}
+	return c.lastMessageConsumed.attempt
+}
+
+func (c *InMemoryConsumer) BindTo(nameExchange string, routingKey string, kind string) error {
+	_, err := c.broker.declareExchange(nameExchange, kind, true)
+	FailOnError(err, fmt.Sprintf("Failed to declare the Exchange %v in memory", nameExchange))
+	err = c.broker.bind(c.queue, nameExchange, routingKey)
+	if err != nil {
+		return fmt.Errorf("error binding queue to exchange: %v", err)
+	}
+	return nil
+}
+
+// SignalFinishedMessage Acks the last consumed message, or puts it back in the queue if it was not processed correctly
+func (c *InMemoryConsumer) SignalFinishedMessage(processedCorrectly bool) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.lastMessageConsumed == nil {
+		return nil
+	}
+	deliveredId := c.lastMessageConsumed.deliveryTag
+	if !processedCorrectly {
+		c.queue.reject(deliveredId, true)
+	} else {
+		c.queue.ack(deliveredId)
+	}
+	c.removePendingTag(deliveredId)
+	return nil
+}
+
+func (c *InMemoryConsumer) GetName() string {
+	return c.queue.name
+}
+
+// Close Stops the consumer. The messages that were not acked return to the queue, as RabbitMQ does when a channel is closed
+func (c *InMemoryConsumer) Close() {
+	c.mutex.Lock()
+	pending := c.pendingTags
+	c.pendingTags = nil
+	c.lastMessageConsumed = nil
+	c.mutex.Unlock()
+	c.queue.closeConsumer(&c.closed, pending)
+}
+
+func (c *InMemoryConsumer) removePendingTag(deliveryTag uint64) {
+	for i, tag := range c.pendingTags {
+		if tag == deliveryTag {
+			c.pendingTags = append(c.pendingTags[:i], c.pendingTags[i+1:]...)
+			return
+		}
+	}
+}
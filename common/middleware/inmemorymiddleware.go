@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	log "github.com/sirupsen/logrus"
+	"sync"
+)
+
+// InMemoryQueueMiddleware QueueMiddlewareI backed by an InMemoryBroker. Several middlewares can share
+// the same broker, in the same way that several services share the same RabbitMQ
+type InMemoryQueueMiddleware struct {
+	broker    *InMemoryBroker
+	mutex     sync.Mutex
+	consumers []*InMemoryConsumer
+}
+
+func NewInMemoryQueueMiddleware(broker *InMemoryBroker) *InMemoryQueueMiddleware {
+	log.Infof("InMemoryQueueMiddleware | Created in memory middleware")
+	return &InMemoryQueueMiddleware{broker: broker}
+}
+
+func (qm *InMemoryQueueMiddleware) CreateConsumer(name string, durable bool) ConsumerInterface {
+	consumer := NewInMemoryConsumer(qm.broker, name, durable)
+	qm.mutex.Lock()
+	qm.consumers = append(qm.consumers, consumer)
+	qm.mutex.Unlock()
+	return consumer
+}
+
+func (qm *InMemoryQueueMiddleware) CreateProducer(name string, durable bool) ProducerInterface {
+	return NewInMemoryProducer(qm.broker, name, durable)
+}
+
+func (qm *InMemoryQueueMiddleware) CreateExchangeProducer(nameExchange string, routingKey string, typeExchange string, durable bool) ProducerInterface {
+	return NewInMemoryExchangeProducer(qm.broker, nameExchange, routingKey, typeExchange, durable)
+}
+
+func (qm *InMemoryQueueMiddleware) CreateRetryProducer(queueName string, policy RetryPolicy) RetryProducerInterface {
+	return NewInMemoryRetryProducer(qm.broker, queueName, policy)
+}
+
+func (qm *InMemoryQueueMiddleware) QueueLength(queueName string) (int, error) {
+	return qm.broker.QueueLength(queueName), nil
+}
+
+// Close Closes the consumers created by this middleware. The queues and exchanges remain in the broker
+func (qm *InMemoryQueueMiddleware) Close() {
+	qm.mutex.Lock()
+	consumers := qm.consumers
+	qm.consumers = nil
+	qm.mutex.Unlock()
+	for _, consumer := range consumers {
+		consumer.Close()
+	}
+}
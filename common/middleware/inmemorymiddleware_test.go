@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func popWithTimeout(t *testing.T, consumer ConsumerInterface) []byte {
+	result := make(chan []byte, 1)
+	go func() {
+		msg, ok := consumer.Pop()
+		if ok {
+			result <- msg
+		}
+	}()
+	select {
+	case msg := <-result:
+		return msg
+	case <-time.After(1 * time.Second):
+		t.Fatalf("Timeout! Should have received a message from %v", consumer.GetName())
+	}
+	return nil
+}
+
+func TestShouldReceiveTheMessagesSentToTheQueueInOrder(t *testing.T) {
+	qm := NewInMemoryQueueMiddleware(NewInMemoryBroker())
+	defer qm.Close()
+	consumer := qm.CreateConsumer("queue", true)
+	producer := qm.CreateProducer("queue", true)
+	assert.Nil(t, producer.Send([]byte("first")))
+	assert.Nil(t, producer.Send([]byte("second")))
+
+	assert.Equal(t, []byte("first"), popWithTimeout(t, consumer))
+	assert.Nil(t, consumer.SignalFinishedMessage(true))
+	assert.Equal(t, []byte("second"), popWithTimeout(t, consumer))
+	assert.Nil(t, consumer.SignalFinishedMessage(true))
+}
+
+func TestShouldRequeueTheMessageAtTheHeadIfItIsRejected(t *testing.T) {
+	broker := NewInMemoryBroker()
+	qm := NewInMemoryQueueMiddleware(broker)
+	defer qm.Close()
+	consumer := qm.CreateConsumer("queue", true)
+	producer := qm.CreateProducer("queue", true)
+	assert.Nil(t, producer.Send([]byte("first")))
+	assert.Nil(t, producer.Send([]byte("second")))
+
+	assert.Equal(t, []byte("first"), popWithTimeout(t, consumer))
+	assert.Nil(t, consumer.SignalFinishedMessage(false))
+	assert.Equal(t, 2, broker.QueueLength("queue"))
+	assert.Equal(t, []byte("first"), popWithTimeout(t, consumer))
+	assert.Nil(t, consumer.SignalFinishedMessage(true))
+	assert.Equal(t, []byte("second"), popWithTimeout(t, consumer))
+}
+
+func TestShouldDeliverTheMessageToAllTheQueuesBoundToAFanoutExchange(t *testing.T) {
+	qm := NewInMemoryQueueMiddleware(NewInMemoryBroker())
+	defer qm.Close()
+	firstConsumer := qm.CreateConsumer("first", true)
+	secondConsumer := qm.CreateConsumer("second", true)
+	producer := qm.CreateExchangeProducer("exchange", "", FanoutExchange, true)
+	assert.Nil(t, firstConsumer.BindTo("exchange", "", FanoutExchange))
+	assert.Nil(t, secondConsumer.BindTo("exchange", "", FanoutExchange))
+
+	assert.Nil(t, producer.Send([]byte("msg")))
+
+	assert.Equal(t, []byte("msg"), popWithTimeout(t, firstConsumer))
+	assert.Equal(t, []byte("msg"), popWithTimeout(t, secondConsumer))
+}
+
+func TestShouldRouteByRoutingKeyInADirectExchange(t *testing.T) {
+	broker := NewInMemoryBroker()
+	qm := NewInMemoryQueueMiddleware(broker)
+	defer qm.Close()
+	firstConsumer := qm.CreateConsumer("first", true)
+	secondConsumer := qm.CreateConsumer("second", true)
+	assert.Nil(t, firstConsumer.BindTo("exchange", "0", DirectExchange))
+	assert.Nil(t, secondConsumer.BindTo("exchange", "1", DirectExchange))
+	producer := qm.CreateExchangeProducer("exchange", "1", DirectExchange, true)
+
+	assert.Nil(t, producer.Send([]byte("msg")))
+
+	assert.Equal(t, []byte("msg"), popWithTimeout(t, secondConsumer))
+	assert.Equal(t, 0, broker.QueueLength("first"))
+}
+
+func TestShouldDeliverOnlyOneCopyToAQueueWithManyMatchingTopicBindings(t *testing.T) {
+	broker := NewInMemoryBroker()
+	qm := NewInMemoryQueueMiddleware(broker)
+	defer qm.Close()
+	consumer := qm.CreateConsumer("queue", true)
+	assert.Nil(t, consumer.BindTo("exchange", "ex1", TopicExchange))
+	assert.Nil(t, consumer.BindTo("exchange", "ex.#", TopicExchange))
+	assert.Nil(t, consumer.BindTo("exchange", "*.*", TopicExchange))
+
+	assert.Nil(t, qm.CreateExchangeProducer("exchange", "ex.1", TopicExchange, true).Send([]byte("msg")))
+	assert.Nil(t, qm.CreateExchangeProducer("exchange", "other", TopicExchange, true).Send([]byte("other")))
+
+	assert.Equal(t, 1, broker.QueueLength("queue"))
+	assert.Equal(t, []byte("msg"), popWithTimeout(t, consumer))
+}
+
+func TestTopicPatternsMatchAsInRabbitMQ(t *testing.T) {
+	exchange := &inMemoryExchange{kind: TopicExchange}
+	assert.True(t, exchange.routes("ex1", "ex1"))
+	assert.True(t, exchange.routes("ex.*", "ex.1"))
+	assert.False(t, exchange.routes("ex.*", "ex.1.2"))
+	assert.True(t, exchange.routes("ex.#", "ex"))
+	assert.True(t, exchange.routes("ex.#", "ex.1.2"))
+	assert.True(t, exchange.routes("#.2", "ex.1.2"))
+	assert.False(t, exchange.routes("ex2", "ex1"))
+}
+
+func TestShouldPanicIfTheQueueIsRedeclaredWithADifferentDurability(t *testing.T) {
+	qm := NewInMemoryQueueMiddleware(NewInMemoryBroker())
+	defer qm.Close()
+	qm.CreateProducer("queue", true)
+	assert.Panics(t, func() { qm.CreateConsumer("queue", false) })
+}
+
+func TestShouldReturnTheUnackedMessagesToTheQueueWhenTheMiddlewareIsClosed(t *testing.T) {
+	broker := NewInMemoryBroker()
+	qm := NewInMemoryQueueMiddleware(broker)
+	consumer := qm.CreateConsumer("queue", true)
+	assert.Nil(t, qm.CreateProducer("queue", true).Send([]byte("msg")))
+	assert.Equal(t, []byte("msg"), popWithTimeout(t, consumer))
+
+	qm.Close()
+	_, ok := consumer.Pop()
+	assert.False(t, ok)
+
+	otherQm := NewInMemoryQueueMiddleware(broker)
+	defer otherQm.Close()
+	assert.Equal(t, []byte("msg"), popWithTimeout(t, otherQm.CreateConsumer("queue", true)))
+}
+
+func TestShouldUnblockAWaitingConsumerWhenTheMiddlewareIsClosed(t *testing.T) {
+	qm := NewInMemoryQueueMiddleware(NewInMemoryBroker())
+	consumer := qm.CreateConsumer("queue", true)
+	finished := make(chan bool, 1)
+	go func() {
+		_, ok := consumer.Pop()
+		finished <- ok
+	}()
+	qm.Close()
+	select {
+	case ok := <-finished:
+		assert.False(t, ok)
+	case <-time.After(1 * time.Second):
+		t.Errorf("Timeout! The consumer should have been unblocked")
+	}
+}
+
+func TestShouldOnlyKeepTheDurableQueuesAfterARestart(t *testing.T) {
+	broker := NewInMemoryBroker()
+	qm := NewInMemoryQueueMiddleware(broker)
+	defer qm.Close()
+	assert.Nil(t, qm.CreateProducer("durable", true).Send([]byte("msg")))
+	assert.Nil(t, qm.CreateProducer("transient", false).Send([]byte("msg")))
+
+	broker.Restart()
+
+	assert.Equal(t, 1, broker.QueueLength("durable"))
+	assert.Equal(t, 0, broker.QueueLength("transient"))
+}
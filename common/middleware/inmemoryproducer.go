@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+)
+
+// InMemoryProducer Publishes into a queue of the InMemoryBroker using the default exchange
+type InMemoryProducer struct {
+	ProducerInterface
+	broker *InMemoryBroker
+	name   string
+}
+
+func NewInMemoryProducer(broker *InMemoryBroker, name string, durable bool) *InMemoryProducer {
+	_, err := broker.declareQueue(name, durable)
+	FailOnError(err, "Failed to declare in memory queue.")
+	return &InMemoryProducer{
+		broker: broker,
+		name:   name,
+	}
+}
+
+func (p *InMemoryProducer) Send(data []byte) error {
+	p.broker.publishToQueue(p.name, data, 0)
+	return nil
+}
+
+func (p *InMemoryProducer) GetName() string {
+	return p.name
+}
+
+// InMemoryExchangeProducer Publishes into an exchange of the InMemoryBroker with a fixed routing key
+type InMemoryExchangeProducer struct {
+	ProducerInterface
+	broker     *InMemoryBroker
+	name       string
+	routingKey string
+}
+
+func NewInMemoryExchangeProducer(broker *InMemoryBroker, nameEx string, routingKey string, typeEx string, durable bool) *InMemoryExchangeProducer {
+	_, err := broker.declareExchange(nameEx, typeEx, durable)
+	FailOnError(err, fmt.Sprintf("Failed to declare the Exchange %v in memory", nameEx))
+	log.Infof("InMemoryExchangeProducer | Created new exchange %v in memory", nameEx)
+	return &InMemoryExchangeProducer{
+		broker:     broker,
+		name:       nameEx,
+		routingKey: routingKey,
+	}
+}
+
+func (exProd *InMemoryExchangeProducer) Send(data []byte) error {
+	err := exProd.broker.publishToExchange(exProd.name, exProd.routingKey, data)
+	if err != nil {
+		return fmt.Errorf("failed to publish content into exchange: %v", err)
+	}
+	return nil
+}
+
+func (exProd *InMemoryExchangeProducer) GetName() string {
+	return exProd.name
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"sort"
+	"sync"
+)
+
+// inMemoryMessage Body of a message and the times that it was retried, as the header that RabbitMQ keeps
+type inMemoryMessage struct {
+	body    []byte
+	attempt int
+}
+
+type inMemoryDelivery struct {
+	deliveryTag uint64
+	inMemoryMessage
+}
+
+// inMemoryQueue Queue of the InMemoryBroker. Delivered messages stay as unacked until
+// the consumer acks them or rejects them, in which case they are put back at the head
+type inMemoryQueue struct {
+	name            string
+	durable         bool
+	mutex           sync.Mutex
+	cond            *sync.Cond
+	ready           []inMemoryMessage
+	unacked         map[uint64]inMemoryMessage
+	nextDeliveryTag uint64
+	deleted         bool
+}
+
+func newInMemoryQueue(name string, durable bool) *inMemoryQueue {
+	queue := &inMemoryQueue{
+		name:    name,
+		durable: durable,
+		unacked: make(map[uint64]inMemoryMessage),
+	}
+	queue.cond = sync.NewCond(&queue.mutex)
+	return queue
+}
+
+func (q *inMemoryQueue) push(data []byte, attempt int) {
+	body := make([]byte, len(data))
+	copy(body, data)
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.deleted {
+		return
+	}
+	q.ready = append(q.ready, inMemoryMessage{body: body, attempt: attempt})
+	q.cond.Signal()
+}
+
+// pop Blocks until there is a message or the consumer is closed. Returns false if the consumer was closed or the queue deleted
+func (q *inMemoryQueue) pop(consumerClosed *bool) (inMemoryDelivery, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for len(q.ready) == 0 && !*consumerClosed && !q.deleted {
+		q.cond.Wait()
+	}
+	if *consumerClosed || q.deleted {
+		return inMemoryDelivery{}, false
+	}
+	msg := q.ready[0]
+	q.ready = q.ready[1:]
+	q.nextDeliveryTag++
+	q.unacked[q.nextDeliveryTag] = msg
+	return inMemoryDelivery{deliveryTag: q.nextDeliveryTag, inMemoryMessage: msg}, true
+}
+
+func (q *inMemoryQueue) ack(deliveryTag uint64) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	delete(q.unacked, deliveryTag)
+}
+
+// reject Puts the message back at the head of the queue if requeue is true, otherwise it is discarded
+func (q *inMemoryQueue) reject(deliveryTag uint64, requeue bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	msg, exists := q.unacked[deliveryTag]
+	if !exists {
+		return
+	}
+	delete(q.unacked, deliveryTag)
+	if requeue && !q.deleted {
+		q.ready = append([]inMemoryMessage{msg}, q.ready...)
+		q.cond.Signal()
+	}
+}
+
+// closeConsumer Marks the consumer as closed, requeues its unacked deliveries and wakes up the waiting consumers
+func (q *inMemoryQueue) closeConsumer(consumerClosed *bool, deliveryTags []uint64) {
+	q.mutex.Lock()
+	*consumerClosed = true
+	q.mutex.Unlock()
+	for _, tag := range deliveryTags {
+		q.reject(tag, true)
+	}
+	q.mutex.Lock()
+	q.cond.Broadcast()
+	q.mutex.Unlock()
+}
+
+func (q *inMemoryQueue) requeueAll() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	tags := make([]uint64, 0, len(q.unacked))
+	for tag := range q.unacked {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+	var pending []inMemoryMessage
+	for _, tag := range tags {
+		pending = append(pending, q.unacked[tag])
+		delete(q.unacked, tag)
+	}
+	q.ready = append(pending, q.ready...)
+	q.cond.Broadcast()
+}
+
+func (q *inMemoryQueue) delete() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.deleted = true
+	q.ready = nil
+	q.unacked = make(map[uint64]inMemoryMessage)
+	q.cond.Broadcast()
+}
+
+func (q *inMemoryQueue) length() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.ready)
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import "time"
+
+// InMemoryRetryProducer Publishes the messages back into a queue of the InMemoryBroker after the delay of their attempt,
+// with the attempt alongside the bytes.
+// Unlike the delay queues of RabbitMQ, the messages waiting are lost if the process ends
+type InMemoryRetryProducer struct {
+	broker *InMemoryBroker
+	name   string
+	policy RetryPolicy
+}
+
+func NewInMemoryRetryProducer(broker *InMemoryBroker, queueName string, policy RetryPolicy) *InMemoryRetryProducer {
+	return &InMemoryRetryProducer{
+		broker: broker,
+		name:   queueName,
+		policy: policy,
+	}
+}
+
+func (r *InMemoryRetryProducer) SendAfter(data []byte, attempt int) error {
+	time.AfterFunc(r.policy.DelayOf(attempt), func() {
+		r.broker.publishToQueue(r.name, data, attempt)
+	})
+	return nil
+}
+
+func (r *InMemoryRetryProducer) Policy() RetryPolicy {
+	return r.policy
+}
+
+func (r *InMemoryRetryProducer) GetName() string {
+	return r.name
+}
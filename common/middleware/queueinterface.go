@@ -7,7 +7,17 @@ type ProducerInterface interface {
 
 type ConsumerInterface interface {
 	Pop() ([]byte, bool)
+	// Attempt Returns the times that the last message popped was retried, 0 if it was not
+	Attempt() int
 	BindTo(nameExchange string, routingKey string, kind string) error
 	SignalFinishedMessage(processedCorrectly bool) error
 	GetName() string
 }
+
+// RetryProducerInterface Sends the messages back to a queue after the delay of their attempt.
+// The attempt travels with the message, so any consumer of the queue gets it with Attempt
+type RetryProducerInterface interface {
+	SendAfter(data []byte, attempt int) error
+	Policy() RetryPolicy
+	GetName() string
+}
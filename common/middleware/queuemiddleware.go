@@ -9,6 +9,9 @@ type QueueMiddlewareI interface {
 	CreateConsumer(name string, durable bool) ConsumerInterface
 	CreateProducer(name string, durable bool) ProducerInterface
 	CreateExchangeProducer(nameExchange string, routingKey string, typeExchange string, durable bool) ProducerInterface
+	CreateRetryProducer(queueName string, policy RetryPolicy) RetryProducerInterface
+	// QueueLength Returns the messages waiting in the queue, that were not delivered to a consumer
+	QueueLength(queueName string) (int, error)
 	Close()
 }
 
@@ -43,6 +46,19 @@ func (qm *QueueMiddleware) CreateExchangeProducer(nameExchange string, routingKe
 	return NewExchangeProducer(qm.channel, nameExchange, routingKey, typeExchange, durable)
 }
 
+func (qm *QueueMiddleware) CreateRetryProducer(queueName string, policy RetryPolicy) RetryProducerInterface {
+	return NewRetryProducer(qm.channel, queueName, policy)
+}
+
+// QueueLength Returns the messages ready in the queue. The queue has to be declared, otherwise RabbitMQ closes the channel
+func (qm *QueueMiddleware) QueueLength(queueName string) (int, error) {
+	queue, err := qm.channel.QueueDeclarePassive(queueName, true, false, false, false, nil)
+	if err != nil {
+		return 0, err
+	}
+	return queue.Messages, nil
+}
+
 func (qm *QueueMiddleware) Close() {
 	err := qm.channel.Close()
 	if err != nil {
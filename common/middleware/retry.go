@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+)
+
+// RetryPolicy How the messages that can not be processed yet are retried. The delay doubles
+// on each attempt up to MaxDelay, and after MaxAttempts the message is not retried anymore
+type RetryPolicy struct {
+	Delay       time.Duration
+	MaxDelay    time.Duration
+	MaxAttempts int
+}
+
+const defaultRetryDelay = 500 * time.Millisecond
+const defaultRetryMaxDelay = 30 * time.Second
+const defaultRetryAttempts = 10
+
+// DefaultRetryPolicy Returns the policy used when the stage does not configure one
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{Delay: defaultRetryDelay, MaxDelay: defaultRetryMaxDelay, MaxAttempts: defaultRetryAttempts}
+}
+
+// NewRetryPolicy Creates a policy, using the default values for the ones that are zero
+func NewRetryPolicy(delay time.Duration, maxDelay time.Duration, maxAttempts int) (RetryPolicy, error) {
+	policy := DefaultRetryPolicy()
+	if delay < 0 || maxDelay < 0 || maxAttempts < 0 {
+		return policy, fmt.Errorf("the retry delays and attempts can not be negative")
+	}
+	if delay > 0 {
+		policy.Delay = delay
+	}
+	if maxDelay > 0 {
+		policy.MaxDelay = maxDelay
+	}
+	if maxAttempts > 0 {
+		policy.MaxAttempts = maxAttempts
+	}
+	if policy.MaxDelay < policy.Delay {
+		return policy, fmt.Errorf("the max retry delay %v is lower than the delay %v", policy.MaxDelay, policy.Delay)
+	}
+	return policy, nil
+}
+
+// DelayOf Returns the delay before the attempt, starting from 1
+func (p RetryPolicy) DelayOf(attempt int) time.Duration {
+	delay := p.Delay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// delayQueueName Returns the queue where the messages wait the delay before going back to the queue
+func delayQueueName(queueName string, delay time.Duration) string {
+	return fmt.Sprintf("%v_retry_%v", queueName, delay.Milliseconds())
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTheRetryDelayDoublesUpToTheMaxDelay(t *testing.T) {
+	policy, err := NewRetryPolicy(100*time.Millisecond, time.Second, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, defaultRetryAttempts, policy.MaxAttempts)
+	assert.Equal(t, 100*time.Millisecond, policy.DelayOf(1))
+	assert.Equal(t, 200*time.Millisecond, policy.DelayOf(2))
+	assert.Equal(t, 800*time.Millisecond, policy.DelayOf(4))
+	assert.Equal(t, time.Second, policy.DelayOf(5))
+	assert.Equal(t, time.Second, policy.DelayOf(50))
+	assert.Equal(t, "input_retry_200", delayQueueName("input", policy.DelayOf(2)))
+
+	_, err = NewRetryPolicy(time.Second, 100*time.Millisecond, 0)
+	assert.NotNil(t, err)
+	_, err = NewRetryPolicy(0, 0, -1)
+	assert.NotNil(t, err)
+}
+
+func TestTheInMemoryRetryGoesBackToTheQueueAfterTheDelay(t *testing.T) {
+	broker := NewInMemoryBroker()
+	qMiddleware := NewInMemoryQueueMiddleware(broker)
+	defer qMiddleware.Close()
+	consumer := qMiddleware.CreateConsumer("input", true)
+	policy, err := NewRetryPolicy(20*time.Millisecond, 0, 0)
+	assert.Nil(t, err)
+	retries := qMiddleware.CreateRetryProducer("input", policy)
+
+	start := time.Now()
+	assert.Nil(t, retries.SendAfter([]byte("retry"), 2))
+	assert.Equal(t, 0, broker.QueueLength("input"), "The message waits the delay")
+	data, ok := consumer.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, []byte("retry"), data)
+	assert.GreaterOrEqual(t, time.Since(start), policy.DelayOf(2))
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"time"
+)
+
+// attemptHeader Header with the times that the message was retried. RabbitMQ keeps the headers when it dead-letters
+// the message from the delay queue, so it arrives to the consumer of the queue
+const attemptHeader = "x-retry-attempt"
+
+// RetryProducer Sends the messages to a queue with a TTL for each delay of the policy. RabbitMQ dead-letters the
+// expired messages to the default exchange with the name of the queue as routing key, so they go back to the queue.
+// As every message of a delay queue has the same TTL, they expire in order. The delay queues are declared when
+// they are first used, so the stages that never retry do not have them
+type RetryProducer struct {
+	rabbitMQChannel *amqp.Channel
+	name            string
+	policy          RetryPolicy
+	declared        map[time.Duration]bool
+}
+
+func NewRetryProducer(channel *amqp.Channel, queueName string, policy RetryPolicy) *RetryProducer {
+	return &RetryProducer{
+		rabbitMQChannel: channel,
+		name:            queueName,
+		policy:          policy,
+		declared:        make(map[time.Duration]bool),
+	}
+}
+
+func (r *RetryProducer) declareDelayQueue(delay time.Duration) error {
+	if r.declared[delay] {
+		return nil
+	}
+	_, err := r.rabbitMQChannel.QueueDeclare(
+		delayQueueName(r.name, delay),
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		amqp.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": r.name,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare the retry queue of %v with delay %v: %v", r.name, delay, err)
+	}
+	r.declared[delay] = true
+	return nil
+}
+
+func (r *RetryProducer) SendAfter(data []byte, attempt int) error {
+	delay := r.policy.DelayOf(attempt)
+	if err := r.declareDelayQueue(delay); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), TimeoutSeconds*time.Second)
+	defer cancel()
+	err := r.rabbitMQChannel.PublishWithContext(ctx,
+		"",                            // exchange
+		delayQueueName(r.name, delay), // routing key
+		false,                         // mandatory
+		false,                         // immediate
+		amqp.Publishing{
+			Headers:      amqp.Table{attemptHeader: int32(attempt)},
+			ContentType:  BinaryDataMime,
+			Body:         data,
+			DeliveryMode: amqp.Persistent,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish the retry of %v: %v", r.name, err)
+	}
+	return nil
+}
+
+func (r *RetryProducer) Policy() RetryPolicy {
+	return r.policy
+}
+
+func (r *RetryProducer) GetName() string {
+	return r.name
+}
@@ -17,18 +17,19 @@ func NewConsumerChannel(consumerChan chan *dataStructures.Message) *ConsumerChan
 	}
 }
 
-func (c *ConsumerChannel) Pop() (*dataStructures.Message, bool) {
+func (c *ConsumerChannel) Pop() (*dataStructures.Message, error) {
 	msg, ok := <-c.consumerChan
-	if ok {
-		if msg.TypeMessage == dataStructures.FlightRows {
-			_, exists := c.recvCountByClient[msg.ClientId]
-			if !exists {
-				c.recvCountByClient[msg.ClientId] = 0
-			}
-			c.recvCountByClient[msg.ClientId] += len(msg.DynMaps)
+	if !ok {
+		return nil, ErrConsumerClosed
+	}
+	if msg.TypeMessage == dataStructures.FlightRows {
+		_, exists := c.recvCountByClient[msg.ClientId]
+		if !exists {
+			c.recvCountByClient[msg.ClientId] = 0
 		}
+		c.recvCountByClient[msg.ClientId] += len(msg.DynMaps)
 	}
-	return msg, ok
+	return msg, nil
 }
 
 func (c *ConsumerChannel) GetReceivedMessages(clientId string) int {
@@ -44,5 +45,12 @@ func (c *ConsumerChannel) ClearData(clientId string) {
 	delete(c.recvCountByClient, clientId)
 }
 
-func (c *ConsumerChannel) SetStatusOfLastMessage(status bool) {
+// RejectLastMessage The channels can not requeue, so the message is treated as processed
+func (c *ConsumerChannel) RejectLastMessage(reason error) bool {
+	log.Errorf("ConsumerChannel | The message can not be requeued | %v", reason)
+	return false
+}
+
+func (c *ConsumerChannel) DeadLetter(rows []*dataStructures.DynamicMap, reason error) {
+	log.Errorf("ConsumerChannel | Discarding %v rows | %v", len(rows), reason)
 }
@@ -0,0 +1,31 @@
+package queues
+
+import (
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	"strings"
+)
+
+// Compressions Compression of the messages that a service sends to each queue or exchange. The names are case
+// insensitive, as viper lowers the keys of the config. The consumers detect the compression from the header of
+// each message, so they do not need to be configured
+type Compressions map[string]serializer.Compression
+
+// NewCompressions Receives the compression of each queue or exchange, as a map from its name to the name of the
+// algorithm. Returns an error if an algorithm is not valid
+func NewCompressions(names map[string]string) (Compressions, error) {
+	compressions := make(Compressions)
+	for name, compressionName := range names {
+		compression, err := serializer.ParseCompression(compressionName)
+		if err != nil {
+			return nil, fmt.Errorf("compression of %v: %v", name, err)
+		}
+		compressions[strings.ToLower(name)] = compression
+	}
+	return compressions, nil
+}
+
+// Of Returns the compression of the messages sent to the queue or exchange. The ones without it are not compressed
+func (c Compressions) Of(name string) serializer.Compression {
+	return c[strings.ToLower(name)]
+}
@@ -0,0 +1,283 @@
+package queues
+
+import (
+	"errors"
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+)
+
+// coordinatorId Id of the coordinator in its checkpointer. It has its own checkpointer, apart from the ones of the nodes
+const coordinatorId = 0
+
+// clientRows Rows of a client that the nodes of the stage received and sent to each output
+type clientRows struct {
+	received int
+	emitted  []int
+	// eof EOF of the client, nil until a node hands it. It has the rows sent to the stage
+	eof      *dataStructures.Message
+	expected int
+	// receivedByNode Rows of the client received by each node since it started, as counted by its consumer
+	receivedByNode map[string]int
+	// reported Batches whose report was counted. A batch processed twice, even by different nodes, is counted once
+	reported reportedBatches
+}
+
+// EOFCoordinator Coordinator side of the EOF protocol. One per stage consumes the reports of the nodes and the EOF of each
+// client, and once the rows received by the nodes reach the ones that the previous stage sent, it sends the EOF to each output
+// with the rows that the nodes sent to it. The next stage does the same with them, so no EOF passes the rows of its client.
+// Once a client finishes or aborts, it sends a clear through the nodes, so every one forgets the client, and when it
+// comes back the coordinator forgets it too
+type EOFCoordinator struct {
+	name     string
+	consumer ConsumerProtocolInterface
+	toNodes  ProducerProtocolInterface
+	outputs  []ProducerProtocolInterface
+	clients  map[string]*clientRows
+	// finished Clients whose EOF was sent or that aborted, until every node cleared their data
+	finished     map[string]bool
+	checkpointer *checkpointer.CheckpointerHandler
+	// keyed Outputs to the nodes of the next stage in the view of each client. Nil if the outputs are fixed
+	keyed *KeyedOutputs
+}
+
+// NewEOFCoordinator Creates the coordinator of the stage. The outputs go in the same order as the ones of the nodes,
+// and the name identifies its checkpoints, so it has to be the name of the queue it consumes. The clears go to the
+// nodes through toNodes, a producer of the queue they consume
+func NewEOFCoordinator(
+	name string,
+	consumer ConsumerProtocolInterface,
+	toNodes ProducerProtocolInterface,
+	outputs []ProducerProtocolInterface,
+	chkHandler *checkpointer.CheckpointerHandler,
+) *EOFCoordinator {
+	c := &EOFCoordinator{
+		name:         name,
+		consumer:     consumer,
+		toNodes:      toNodes,
+		outputs:      outputs,
+		clients:      make(map[string]*clientRows),
+		finished:     make(map[string]bool),
+		checkpointer: chkHandler,
+	}
+	chkHandler.AddCheckpointable(consumer, coordinatorId)
+	chkHandler.AddCheckpointable(c, coordinatorId)
+	return c
+}
+
+// NewKeyedEOFCoordinator Creates the coordinator of a stage whose outputs are the nodes of the next stage in the view
+// of each client. The outputs grow as the reports and the views of the clients have more of them
+func NewKeyedEOFCoordinator(
+	name string,
+	consumer ConsumerProtocolInterface,
+	toNodes ProducerProtocolInterface,
+	outputs *KeyedOutputs,
+	chkHandler *checkpointer.CheckpointerHandler,
+) *EOFCoordinator {
+	c := &EOFCoordinator{
+		name:         name,
+		consumer:     consumer,
+		toNodes:      toNodes,
+		outputs:      outputs.All(0),
+		clients:      make(map[string]*clientRows),
+		finished:     make(map[string]bool),
+		checkpointer: chkHandler,
+		keyed:        outputs,
+	}
+	chkHandler.AddCheckpointable(consumer, coordinatorId)
+	chkHandler.AddCheckpointable(c, coordinatorId)
+	return c
+}
+
+// growOutputs Adds keyed outputs until there are as many as the given count
+func (c *EOFCoordinator) growOutputs(count int) {
+	if c.keyed != nil {
+		c.outputs = c.keyed.All(count)
+	}
+}
+
+// CoordinateLoop Consumes the reports and the EOF of the nodes until the queue is closed
+func (c *EOFCoordinator) CoordinateLoop() {
+	log.Infof("EOFCoordinator %v | Started coordinating the EOF", c.name)
+	for {
+		msg, err := c.consumer.Pop()
+		if errors.Is(err, ErrConsumerClosed) {
+			log.Infof("EOFCoordinator %v | Closing goroutine...", c.name)
+			return
+		}
+		if err != nil {
+			log.Errorf("EOFCoordinator %v | Skipping message | %v", c.name, err)
+			continue
+		}
+		c.handleMessage(msg)
+		err = c.checkpointer.DoCheckpoint(coordinatorId)
+		if err != nil {
+			log.Errorf("EOFCoordinator %v | Error on checkpointing | %v", c.name, err)
+		}
+	}
+}
+
+func (c *EOFCoordinator) handleMessage(msg *dataStructures.Message) {
+	if msg.TypeMessage == dataStructures.Abort {
+		log.Infof("EOFCoordinator %v | Client %v aborted. Discarding its rows...", c.name, msg.ClientId)
+		delete(c.clients, msg.ClientId)
+		c.finish(msg)
+		return
+	}
+	if msg.TypeMessage == dataStructures.ClearClient {
+		log.Infof("EOFCoordinator %v | Every node cleared the data of client %v. Forgetting it...", c.name, msg.ClientId)
+		delete(c.clients, msg.ClientId)
+		delete(c.finished, msg.ClientId)
+		return
+	}
+	if c.finished[msg.ClientId] {
+		log.Debugf("EOFCoordinator %v | Client %v already finished. Skipping message of type %v...", c.name, msg.ClientId, msg.TypeMessage)
+		return
+	}
+	if msg.TypeMessage == dataStructures.RowsReport {
+		c.addReport(msg)
+	} else if msg.TypeMessage == dataStructures.EOFFlightRows {
+		c.addEOF(msg)
+	} else {
+		log.Warnf("EOFCoordinator %v | Warning Message | Unknown message type %v received. Skipping it...", c.name, msg.TypeMessage)
+		return
+	}
+	c.sendEOFIfReconciled(msg.ClientId)
+}
+
+func (c *EOFCoordinator) rowsOf(clientId string) *clientRows {
+	rows, exists := c.clients[clientId]
+	if !exists {
+		rows = &clientRows{emitted: make([]int, len(c.outputs)), receivedByNode: make(map[string]int), reported: make(reportedBatches)}
+		c.clients[clientId] = rows
+	}
+	return rows
+}
+
+// addReport Adds the rows of a batch processed by a node
+func (c *EOFCoordinator) addReport(msg *dataStructures.Message) {
+	if len(msg.DynMaps) == 0 {
+		log.Errorf("EOFCoordinator %v | Report of client %v without data. Skipping it...", c.name, msg.ClientId)
+		return
+	}
+	report := msg.DynMaps[0]
+	received, err := report.GetAsInt64(utils.ReceivedRows)
+	if err != nil {
+		log.Errorf("EOFCoordinator %v | Error getting the rows received of the report | %v | Skipping it...", c.name, err)
+		return
+	}
+	emitted, err := report.GetAsList(utils.EmittedRows)
+	if err == nil {
+		c.growOutputs(len(emitted))
+	}
+	// The keyed outputs of the node can be less than the ones of the coordinator, as they grow with the clients that each one got
+	if err != nil || len(emitted) > len(c.outputs) || (c.keyed == nil && len(emitted) != len(c.outputs)) {
+		log.Errorf("EOFCoordinator %v | The report has the rows of %v outputs instead of %v | %v | Skipping it...", c.name, len(emitted), len(c.outputs), err)
+		return
+	}
+	nodeId, errNode := report.GetAsString(utils.NodeId)
+	rows := c.rowsOf(msg.ClientId)
+	if !rows.reported.add(msg.MessageId, msg.RowId) {
+		log.Warnf("EOFCoordinator %v | Report of batch %v-%v of client %v was already counted | Node: %v | Skipping it...", c.name, msg.MessageId, msg.RowId, msg.ClientId, nodeId)
+		return
+	}
+	rows.received += int(received)
+	rows.emitted = padRows(rows.emitted, len(c.outputs))
+	for idx, emittedToOutput := range emitted {
+		count, err := strconv.Atoi(emittedToOutput)
+		if err != nil {
+			log.Errorf("EOFCoordinator %v | Error converting the rows sent to output %v | %v", c.name, idx, err)
+			continue
+		}
+		rows.emitted[idx] += count
+	}
+	nodeReceived, errReceived := report.GetAsInt64(utils.NodeReceivedRows)
+	if errNode == nil && errReceived == nil {
+		rows.receivedByNode[nodeId] = int(nodeReceived)
+	}
+}
+
+// addEOF Saves the EOF of the client with the rows that the previous stage sent
+func (c *EOFCoordinator) addEOF(msg *dataStructures.Message) {
+	rows := c.rowsOf(msg.ClientId)
+	expected := int64(0)
+	var err error
+	if len(msg.DynMaps) > 0 {
+		expected, err = msg.DynMaps[0].GetAsInt64(utils.ExpectedRows)
+	}
+	if len(msg.DynMaps) == 0 || err != nil {
+		log.Warnf("EOFCoordinator %v | EOF of client %v without the rows expected. It is sent without waiting for the rows | %v", c.name, msg.ClientId, err)
+	}
+	rows.eof = msg
+	rows.expected = int(expected)
+	log.Infof("EOFCoordinator %v | Got EOF of client %v | Rows expected: %v | Rows received: %v", c.name, msg.ClientId, rows.expected, rows.received)
+}
+
+// sendEOFIfReconciled Sends the EOF of the client to each output if the nodes received every row sent to the stage
+func (c *EOFCoordinator) sendEOFIfReconciled(clientId string) {
+	rows := c.clients[clientId]
+	if rows == nil || rows.eof == nil || rows.received < rows.expected {
+		return
+	}
+	if rows.received > rows.expected {
+		log.Warnf("EOFCoordinator %v | Client %v | The nodes received %v rows, but %v were expected", c.name, clientId, rows.received, rows.expected)
+	}
+	log.Infof("EOFCoordinator %v | Rows of client %v reconciled | Rows received: %v | Rows sent to each output: %v | Rows received by each node: %v",
+		c.name, clientId, rows.received, rows.emitted, rows.receivedByNode)
+	if c.keyed != nil {
+		c.growOutputs(len(c.keyed.Of(rows.eof)))
+	}
+	rows.emitted = padRows(rows.emitted, len(c.outputs))
+	for idx, output := range c.outputs {
+		err := sendEOFWithRows(output, rows.eof, idx, rows.emitted[idx])
+		if err != nil {
+			log.Errorf("EOFCoordinator %v | Error sending EOF of client %v to output %v | %v", c.name, clientId, idx, err)
+		}
+	}
+	delete(c.clients, clientId)
+	c.finish(rows.eof)
+}
+
+// finish Marks the client of the message as finished and sends the clear of its data to the nodes. The ids of the
+// client seen by the coordinator are cleared too, so the clear is not taken as a duplicate when it comes back
+func (c *EOFCoordinator) finish(msg *dataStructures.Message) {
+	c.finished[msg.ClientId] = true
+	c.consumer.ClearData(msg.ClientId)
+	dynMapData := make(map[string]dataStructures.Column)
+	dynMapData[utils.NodesVisited] = dataStructures.NewStringColumn("")
+	err := c.toNodes.Send(&dataStructures.Message{
+		TypeMessage: dataStructures.ClearClient,
+		DynMaps:     []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMapData)},
+		ClientId:    msg.ClientId,
+		MessageId:   msg.MessageId,
+		// The node that got the message saw its row id
+		RowId:  msg.RowId + 1,
+		Params: msg.Params,
+	})
+	if err != nil {
+		log.Errorf("EOFCoordinator %v | Error sending the clear of client %v to the nodes | %v", c.name, msg.ClientId, err)
+	}
+}
+
+// padRows Adds outputs without rows until there are as many as the given count
+func padRows(rows []int, count int) []int {
+	for len(rows) < count {
+		rows = append(rows, 0)
+	}
+	return rows
+}
+
+func sendEOFWithRows(prodOutputQueue ProducerProtocolInterface, message *dataStructures.Message, rowId int, rows int) error {
+	dynMapData := make(map[string]dataStructures.Column)
+	dynMapData[utils.ExpectedRows] = dataStructures.NewInt64Column(int64(rows))
+	return prodOutputQueue.Send(&dataStructures.Message{
+		TypeMessage: dataStructures.EOFFlightRows,
+		DynMaps:     []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMapData)},
+		ClientId:    message.ClientId,
+		MessageId:   message.MessageId,
+		RowId:       uint16(rowId),
+		Params:      message.Params,
+	})
+}
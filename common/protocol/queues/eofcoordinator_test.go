@@ -0,0 +1,258 @@
+package queues
+
+import (
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/duplicates"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func inTempDir(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+func newTestConsumer(t *testing.T, name string) *ConsumerQueueProtocolHandler {
+	qMiddleware := middleware.NewInMemoryQueueMiddleware(middleware.NewInMemoryBroker())
+	t.Cleanup(qMiddleware.Close)
+	return NewConsumerQueueProtocolHandler(qMiddleware.CreateConsumer(name, true), duplicates.NewDuplicatesHandler(name), nil, nil)
+}
+
+func newTestCoordinator(t *testing.T, outputs []ProducerProtocolInterface) *EOFCoordinator {
+	return newTestCoordinatorWithNodes(t, NewProducerChannel(make(chan *dataStructures.Message, 10)), outputs)
+}
+
+func newTestCoordinatorWithNodes(t *testing.T, toNodes ProducerProtocolInterface, outputs []ProducerProtocolInterface) *EOFCoordinator {
+	name := CoordinatorQueueName("input")
+	return NewEOFCoordinator(name, newTestConsumer(t, name), toNodes, outputs, checkpointer.NewCheckpointerHandler())
+}
+
+func newTestReport(t *testing.T, clientId string, messageId uint, received int, emitted ...int) *dataStructures.Message {
+	return newTestReportOfNode(t, "node", clientId, messageId, 0, received, emitted...)
+}
+
+func newTestReportOfNode(t *testing.T, nodeId string, clientId string, messageId uint, rowId uint16, received int, emitted ...int) *dataStructures.Message {
+	reports := make(chan *dataStructures.Message, 1)
+	var outputs []ProducerProtocolInterface
+	for range emitted {
+		outputs = append(outputs, NewProducerChannel(make(chan *dataStructures.Message, 1)))
+	}
+	reporter := NewEOFReporter(nodeId, newTestConsumer(t, "input"), NewProducerChannel(reports), outputs)
+	for idx, rows := range emitted {
+		batch := dataStructures.NewCompleteMessage(dataStructures.FlightRows, make([]*dataStructures.DynamicMap, rows), clientId, messageId)
+		assert.Nil(t, reporter.Outputs()[idx].Send(batch))
+	}
+	batch := dataStructures.NewCompleteMessage(dataStructures.FlightRows, make([]*dataStructures.DynamicMap, received), clientId, messageId)
+	batch.RowId = rowId
+	assert.Nil(t, reporter.ReportBatch(batch))
+	return <-reports
+}
+
+func newTestEOF(clientId string, expected int64) *dataStructures.Message {
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap[utils.ExpectedRows] = dataStructures.NewInt64Column(expected)
+	return dataStructures.NewCompleteMessage(dataStructures.EOFFlightRows, []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMap)}, clientId, 100)
+}
+
+func TestTheReportHasTheRowsSentToEachOutputWhileProcessingTheBatch(t *testing.T) {
+	report := newTestReport(t, "cliente", 7, 5, 3, 0)
+
+	assert.Equal(t, dataStructures.RowsReport, report.TypeMessage)
+	assert.Equal(t, uint(7), report.MessageId, "The report should keep the id of the batch")
+	received, err := report.DynMaps[0].GetAsInt64(utils.ReceivedRows)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), received)
+	emitted, err := report.DynMaps[0].GetAsList(utils.EmittedRows)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"3", "0"}, emitted)
+}
+
+func TestTheCoordinatorSendsTheEOFOnceTheNodesReceivedTheRowsExpected(t *testing.T) {
+	inTempDir(t)
+	out := make(chan *dataStructures.Message, 1)
+	coordinator := newTestCoordinator(t, []ProducerProtocolInterface{NewProducerChannel(out)})
+
+	coordinator.handleMessage(newTestEOF("cliente", 5))
+	coordinator.handleMessage(newTestReport(t, "cliente", 1, 3, 2))
+	assert.Empty(t, out, "The EOF should wait for the rows that were not processed")
+
+	coordinator.handleMessage(newTestReport(t, "cliente", 2, 2, 1))
+	eof := <-out
+	assert.Equal(t, dataStructures.EOFFlightRows, eof.TypeMessage)
+	expected, err := eof.DynMaps[0].GetAsInt64(utils.ExpectedRows)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), expected, "The next stage should expect the rows sent to it")
+
+	coordinator.handleMessage(newTestReport(t, "cliente", 3, 1, 1))
+	assert.Empty(t, out, "The EOF should be sent once")
+}
+
+func TestTheCoordinatorDiscardsTheEOFOfAnAbortedClient(t *testing.T) {
+	inTempDir(t)
+	out := make(chan *dataStructures.Message, 1)
+	coordinator := newTestCoordinator(t, []ProducerProtocolInterface{NewProducerChannel(out)})
+
+	coordinator.handleMessage(newTestEOF("cliente", 2))
+	coordinator.handleMessage(dataStructures.NewCompleteMessage(dataStructures.Abort, nil, "cliente", 0))
+	coordinator.handleMessage(newTestReport(t, "cliente", 1, 2, 2))
+	assert.Empty(t, out, "The EOF of the aborted client should not be sent")
+}
+
+func TestTheCoordinatorRestoresTheRowsAndTheEOFOfItsCheckpoint(t *testing.T) {
+	inTempDir(t)
+	out := make(chan *dataStructures.Message, 1)
+	coordinator := newTestCoordinator(t, []ProducerProtocolInterface{NewProducerChannel(out)})
+	coordinator.handleMessage(newTestEOF("cliente", 4))
+	coordinator.handleMessage(newTestReport(t, "cliente", 1, 3, 3))
+	coordinator.handleMessage(newTestEOF("terminado", 0))
+	<-out
+	assert.Nil(t, coordinator.checkpointer.DoCheckpoint(coordinatorId))
+
+	restored := newTestCoordinator(t, []ProducerProtocolInterface{NewProducerChannel(out)})
+	restored.checkpointer.RestoreCheckpoint()
+	assert.True(t, restored.finished["terminado"], "The finished clients should be restored")
+	restored.handleMessage(newTestReport(t, "cliente", 2, 1, 0))
+	eof := <-out
+	assert.Equal(t, "cliente", eof.ClientId)
+	expected, err := eof.DynMaps[0].GetAsInt64(utils.ExpectedRows)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), expected)
+}
+
+func TestTheClearOfAFinishedClientGoesThroughTheNodesAndBackToTheCoordinator(t *testing.T) {
+	inTempDir(t)
+	out := make(chan *dataStructures.Message, 1)
+	toNodes := make(chan *dataStructures.Message, 1)
+	toCoordinator := make(chan *dataStructures.Message, 1)
+	coordinator := newTestCoordinatorWithNodes(t, NewProducerChannel(toNodes), []ProducerProtocolInterface{NewProducerChannel(out)})
+	coordinator.handleMessage(newTestEOF("cliente", 0))
+	<-out
+
+	clear := <-toNodes
+	assert.Equal(t, dataStructures.ClearClient, clear.TypeMessage)
+	assert.Equal(t, "cliente", clear.ClientId)
+	for _, nodeId := range []string{"node-0", "node-1"} {
+		reporter := NewEOFReporter(nodeId, newTestConsumer(t, "input"), NewProducerChannel(toCoordinator), nil)
+		assert.Nil(t, reporter.HandleClear(clear, NewProducerChannel(toNodes), 2))
+		if nodeId == "node-0" {
+			clear = <-toNodes
+		}
+	}
+	assert.Empty(t, toNodes, "The last node should not send the clear to the nodes again")
+	assert.True(t, coordinator.finished["cliente"], "The client should be finished until every node cleared it")
+
+	coordinator.handleMessage(<-toCoordinator)
+	assert.Empty(t, coordinator.finished, "The client should be forgotten once every node cleared it")
+	assert.Empty(t, coordinator.clients)
+}
+
+func TestTheCoordinatorSendsTheClearOfAnAbortedClient(t *testing.T) {
+	inTempDir(t)
+	toNodes := make(chan *dataStructures.Message, 1)
+	coordinator := newTestCoordinatorWithNodes(t, NewProducerChannel(toNodes), []ProducerProtocolInterface{})
+
+	coordinator.handleMessage(dataStructures.NewCompleteMessage(dataStructures.Abort, nil, "cliente", 0))
+	clear := <-toNodes
+	assert.Equal(t, dataStructures.ClearClient, clear.TypeMessage)
+	assert.Equal(t, "cliente", clear.ClientId)
+}
+
+func TestTheCoordinatorCountsOnceTheReportsOfTheNodesThatProcessedPartsOfTheSameMessage(t *testing.T) {
+	inTempDir(t)
+	qMiddleware := middleware.NewInMemoryQueueMiddleware(middleware.NewInMemoryBroker())
+	t.Cleanup(qMiddleware.Close)
+	name := CoordinatorQueueName("input")
+	toCoordinator := NewProducerQueueProtocolHandler(qMiddleware.CreateProducer(name, true), serializer.NoCompression)
+	consumer := NewConsumerQueueProtocolHandler(qMiddleware.CreateConsumer(name, true), duplicates.NewDuplicatesHandler(name), nil, nil)
+	out := make(chan *dataStructures.Message, 1)
+	coordinator := NewEOFCoordinator(name, consumer, NewProducerChannel(make(chan *dataStructures.Message, 10)), []ProducerProtocolInterface{NewProducerChannel(out)}, checkpointer.NewCheckpointerHandler())
+	handleNext := func() {
+		msg, err := consumer.Pop()
+		assert.Nil(t, err)
+		coordinator.handleMessage(msg)
+	}
+
+	assert.Nil(t, toCoordinator.Send(newTestEOF("cliente", 4)))
+	assert.Nil(t, toCoordinator.Send(newTestReportOfNode(t, "nodo-1", "cliente", 1, 2, 1, 1)))
+	assert.Nil(t, toCoordinator.Send(newTestReportOfNode(t, "nodo-2", "cliente", 1, 1, 1, 1)))
+	assert.Nil(t, toCoordinator.Send(newTestReportOfNode(t, "nodo-3", "cliente", 1, 0, 1, 1)))
+	assert.Nil(t, toCoordinator.Send(newTestReportOfNode(t, "nodo-3", "cliente", 1, 1, 1, 1)))
+	// More reports than the window of the detector of the consumer arrive before the one of the last batch
+	newerBatches := 1500
+	for id := 3; id < 3+newerBatches; id++ {
+		assert.Nil(t, toCoordinator.Send(newTestReportOfNode(t, "nodo-1", "cliente", uint(id), 0, 0, 0)))
+	}
+	for i := 0; i < 5+newerBatches; i++ {
+		handleNext()
+	}
+	assert.Empty(t, out, "The report of a batch processed twice should be counted once")
+
+	assert.Nil(t, toCoordinator.Send(newTestReportOfNode(t, "nodo-2", "cliente", 2, 0, 1, 1)))
+	handleNext()
+	eof := <-out
+	expected, err := eof.DynMaps[0].GetAsInt64(utils.ExpectedRows)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), expected)
+}
+
+func TestTheCoordinatorRestoresTheReportedBatchesOfItsCheckpoint(t *testing.T) {
+	inTempDir(t)
+	out := make(chan *dataStructures.Message, 1)
+	coordinator := newTestCoordinator(t, []ProducerProtocolInterface{NewProducerChannel(out)})
+	coordinator.handleMessage(newTestEOF("cliente", 4))
+	coordinator.handleMessage(newTestReportOfNode(t, "nodo-1", "cliente", 1, 0, 1, 1))
+	coordinator.handleMessage(newTestReportOfNode(t, "nodo-2", "cliente", 2, 0, 1, 1))
+	coordinator.handleMessage(newTestReportOfNode(t, "nodo-2", "cliente", 1, 3, 1, 1))
+	assert.Nil(t, coordinator.checkpointer.DoCheckpoint(coordinatorId))
+
+	restored := newTestCoordinator(t, []ProducerProtocolInterface{NewProducerChannel(out)})
+	restored.checkpointer.RestoreCheckpoint()
+	restored.handleMessage(newTestReportOfNode(t, "nodo-1", "cliente", 2, 0, 1, 1))
+	assert.Empty(t, out, "The reports counted before the checkpoint should not be counted again")
+	restored.handleMessage(newTestReportOfNode(t, "nodo-1", "cliente", 3, 0, 1, 1))
+	eof := <-out
+	expected, err := eof.DynMaps[0].GetAsInt64(utils.ExpectedRows)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), expected)
+}
+
+func TestTheKeyedOutputsGrowToTheNodesOfTheViewOfTheClient(t *testing.T) {
+	inTempDir(t)
+	channels := []chan *dataStructures.Message{make(chan *dataStructures.Message, 2), make(chan *dataStructures.Message, 2)}
+	newOutputs := func() *KeyedOutputs {
+		return NewKeyedOutputs("savers", 1, func(idx int) ProducerProtocolInterface { return NewProducerChannel(channels[idx]) })
+	}
+	reports := make(chan *dataStructures.Message, 1)
+	reporter := NewKeyedEOFReporter("node", newTestConsumer(t, "input"), NewProducerChannel(reports), newOutputs())
+	name := CoordinatorQueueName("input")
+	coordinator := NewKeyedEOFCoordinator(name, newTestConsumer(t, name), NewProducerChannel(make(chan *dataStructures.Message, 10)), newOutputs(), checkpointer.NewCheckpointerHandler())
+	view := membership.NewView()
+	view.Sizes["savers"] = 2
+	batch := dataStructures.NewCompleteMessage(dataStructures.FlightRows, make([]*dataStructures.DynamicMap, 1), "cliente", 1)
+	batch.Params = view.ToDynMap()
+
+	outputs := reporter.OutputsOf(batch)
+	assert.Len(t, outputs, 2, "The client should use the savers of its view, even if more than the configured ones")
+	assert.Nil(t, outputs[1].Send(batch))
+	<-channels[1]
+	assert.Nil(t, reporter.ReportBatch(batch))
+	eof := newTestEOF("cliente", 1)
+	eof.Params = view.ToDynMap()
+	coordinator.handleMessage(eof)
+	coordinator.handleMessage(<-reports)
+
+	for idx, expected := range []int64{0, 1} {
+		eof := <-channels[idx]
+		rows, err := eof.DynMaps[0].GetAsInt64(utils.ExpectedRows)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, rows)
+	}
+}
@@ -0,0 +1,163 @@
+package queues
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
+	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+)
+
+const oldFileCoordinator = "eof_coordinator_chk_old.csv"
+const currFileCoordinator = "eof_coordinator_chk_curr.csv"
+const tmpFileCoordinator = "eof_coordinator_chk_tmp.csv"
+
+const finishedClient = "finished"
+const pendingClient = "pending"
+
+func (c *EOFCoordinator) DoCheckpoint(errors chan error, id int, chkId int) {
+	checkpointer.DoCheckpointWithParser(errors, id, c, c.name, tmpFileCoordinator, chkId)
+}
+
+func (c *EOFCoordinator) Commit(id int, response chan error) {
+	log.Debugf("EOFCoordinator %v | Commiting checkpoint for id: %v", c.name, id)
+	checkpointer.HandleOldFile(id, c.name, oldFileCoordinator)
+	checkpointer.HandleCurrFile(id, c.name, currFileCoordinator, oldFileCoordinator)
+	checkpointer.HandleTmpFile(id, c.name, tmpFileCoordinator, currFileCoordinator)
+	response <- nil
+}
+
+func (c *EOFCoordinator) Abort(id int, response chan error) {
+	checkpointer.DeleteTmpFile(id, c.name, tmpFileCoordinator)
+	response <- nil
+}
+
+func (c *EOFCoordinator) GetCheckpointVersions(id int) [2]int {
+	return checkpointer.GetCurrentValidCheckpoints(id, c.name, currFileCoordinator, oldFileCoordinator)
+}
+
+func (c *EOFCoordinator) RestoreCheckpoint(checkpointToRestore int, id int, result chan error) {
+	checkpointIds := checkpointer.GetCurrentValidCheckpoints(id, c.name, currFileCoordinator, oldFileCoordinator)
+	filesArray := []string{
+		fmt.Sprintf("%v_%v_%v", id, c.name, oldFileCoordinator),
+		fmt.Sprintf("%v_%v_%v", id, c.name, currFileCoordinator),
+	}
+	for idx, chkId := range checkpointIds {
+		if chkId == checkpointToRestore {
+			c.readCheckpointAsState(filesArray[idx])
+			break
+		}
+	}
+	result <- nil
+}
+
+func (c *EOFCoordinator) GetCheckpointString() string {
+	linesToWrite := strings.Builder{}
+	for clientId := range c.finished {
+		//finished,{clientId}
+		linesToWrite.WriteString(fmt.Sprintf("%v,%v\n", finishedClient, clientId))
+	}
+	for clientId, rows := range c.clients {
+		//pending,{clientId},{received},{expected},{emitted;emitted},{eof as base64},{reported batches}
+		var emitted []string
+		for _, emittedToOutput := range rows.emitted {
+			emitted = append(emitted, fmt.Sprint(emittedToOutput))
+		}
+		eof := ""
+		if rows.eof != nil {
+			eof = base64.StdEncoding.EncodeToString(serializer.SerializeMsg(rows.eof))
+		}
+		linesToWrite.WriteString(fmt.Sprintf("%v,%v,%v,%v,%v,%v,%v\n", pendingClient, clientId, rows.received, rows.expected, strings.Join(emitted, utils.DotCommaSeparator), eof, rows.reported))
+	}
+	return linesToWrite.String()
+}
+
+func (c *EOFCoordinator) readCheckpointAsState(fileToRestore string) {
+	if !filemanager.DirectoryExists(fileToRestore) {
+		log.Infof("EOFCoordinator %v | Does not have a checkpoint: %v", c.name, fileToRestore)
+		return
+	}
+	log.Infof("EOFCoordinator %v | Restoring checkpoint: %v", c.name, fileToRestore)
+	fileReader, err := filemanager.NewFileReader(fileToRestore)
+	if err != nil {
+		log.Fatalf("EOFCoordinator %v | Error trying to read checkpoint file: %v | %v", c.name, fileToRestore, err)
+	}
+	defer utils.CloseFileAndNotifyError(fileReader)
+
+	filemanager.SkipHeader(fileReader)
+	for fileReader.CanRead() {
+		fields := strings.Split(fileReader.ReadLine(), utils.CommaSeparator)
+		if len(fields) == 2 && fields[0] == finishedClient {
+			c.finished[fields[1]] = true
+			continue
+		}
+		// The checkpoints of previous versions do not have the reported batches
+		if (len(fields) != 6 && len(fields) != 7) || fields[0] != pendingClient {
+			log.Errorf("EOFCoordinator %v | Error deserializing checkpoint | %v", c.name, fields)
+			continue
+		}
+		if fields[4] != "" {
+			c.growOutputs(len(strings.Split(fields[4], utils.DotCommaSeparator)))
+		}
+		rows, err := parsePendingClient(fields, len(c.outputs), c.keyed != nil)
+		if err != nil {
+			log.Errorf("EOFCoordinator %v | Error deserializing rows of client %v | %v", c.name, fields[1], err)
+			continue
+		}
+		c.clients[fields[1]] = rows
+	}
+	err = fileReader.Err()
+	if err != nil {
+		log.Errorf("EOFCoordinator %v | Error reading from checkpoint: %v | %v", c.name, fileToRestore, err)
+	}
+	log.Infof("EOFCoordinator %v | Restored checkpoint successfully: %v | Pending clients: %v | Finished clients: %v", c.name, fileToRestore, len(c.clients), len(c.finished))
+}
+
+func parsePendingClient(fields []string, outputs int, keyed bool) (*clientRows, error) {
+	received, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, err
+	}
+	expected, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return nil, err
+	}
+	rows := &clientRows{received: received, expected: expected, emitted: make([]int, outputs), receivedByNode: make(map[string]int), reported: make(reportedBatches)}
+	if len(fields) == 7 {
+		rows.reported, err = parseReportedBatches(fields[6])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if fields[4] != "" {
+		emitted := strings.Split(fields[4], utils.DotCommaSeparator)
+		// The keyed outputs only grow, so a client can have the rows of less outputs than the ones created
+		if len(emitted) > outputs || (!keyed && len(emitted) != outputs) {
+			// The outputs changed while the client was pending, as when the savers are rebalanced. The rows sent to
+			// each output before are not known by the new ones, so they start from zero
+			log.Warnf("EOFCoordinator | Warning Message | Client %v has the rows of %v outputs instead of %v | Counting its rows to each output again", fields[1], len(emitted), outputs)
+			emitted = nil
+		}
+		for idx, emittedToOutput := range emitted {
+			rows.emitted[idx], err = strconv.Atoi(emittedToOutput)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if fields[5] != "" {
+		eofBytes, err := base64.StdEncoding.DecodeString(fields[5])
+		if err != nil {
+			return nil, err
+		}
+		rows.eof, err = serializer.DeserializeMsg(eofBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
@@ -3,51 +3,64 @@ package queues
 import (
 	"fmt"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
-	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"strings"
 )
 
 func sendEOFToOutput(prodOutputQueue ProducerProtocolInterface, message *dataStructures.Message, rowId int) error {
-	dynMapData := make(map[string][]byte)
-	dynMapData[utils.NodesVisited] = serializer.SerializeString("")
+	dynMapData := make(map[string]dataStructures.Column)
+	dynMapData[utils.NodesVisited] = dataStructures.NewStringColumn("")
 	err := prodOutputQueue.Send(&dataStructures.Message{
-		TypeMessage: dataStructures.EOFFlightRows,
+		TypeMessage: message.TypeMessage,
 		DynMaps:     []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMapData)},
 		ClientId:    message.ClientId,
 		MessageId:   message.MessageId,
 		RowId:       uint16(rowId),
+		Params:      message.Params,
 	})
 	return err
 }
 
 func sendEOFToInput(prodInputQueue ProducerProtocolInterface, message *dataStructures.Message, nodes string) error {
-	dynMapData := make(map[string][]byte)
-	dynMapData[utils.NodesVisited] = serializer.SerializeString(nodes)
+	dynMapData := make(map[string]dataStructures.Column)
+	dynMapData[utils.NodesVisited] = dataStructures.NewStringColumn(nodes)
 	err := prodInputQueue.Send(&dataStructures.Message{
-		TypeMessage: dataStructures.EOFFlightRows,
+		TypeMessage: message.TypeMessage,
 		DynMaps:     []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMapData)},
 		ClientId:    message.ClientId,
 		MessageId:   message.MessageId,
 		//Mutates the row id to avoid discarding before it is correctly handled
-		RowId: message.RowId + 1,
+		RowId:  message.RowId + 1,
+		Params: message.Params,
 	})
 	return err
 }
 
-// HandleEOF Function that handles the EOF message, decides if it sends the message to the consumed queue or passes it to the next step
-func HandleEOF(
+// HandleAbort Function that handles the abort of a client. It is sent to the consumed queue until every node
+// of the stage has seen it, so each one can clear the data of the client, and then it is passed to the next step
+func HandleAbort(
 	message *dataStructures.Message,
 	prodInputQueue ProducerProtocolInterface,
 	prodOutputQueues []ProducerProtocolInterface,
 	nodeId string,
-	quantityOfEOF uint,
+	quantityOfNodes uint,
 ) error {
-	if message.TypeMessage != dataStructures.EOFFlightRows {
-		return fmt.Errorf("type is not EOF")
+	if message.TypeMessage != dataStructures.Abort {
+		return fmt.Errorf("type is not Abort")
 	}
+	return forwardThroughNodes(message, prodInputQueue, prodOutputQueues, nodeId, quantityOfNodes)
+}
 
+// forwardThroughNodes Adds the node to the visited ones and sends the message to the next step if every node saw it,
+// or to the consumed queue otherwise
+func forwardThroughNodes(
+	message *dataStructures.Message,
+	prodInputQueue ProducerProtocolInterface,
+	prodOutputQueues []ProducerProtocolInterface,
+	nodeId string,
+	quantityOfNodes uint,
+) error {
 	nodes, err := message.DynMaps[0].GetAsString(utils.NodesVisited)
 	if err != nil {
 		log.Errorf("EOFHandler %v | Error getting nodes visited | %v", nodeId, err)
@@ -61,18 +74,18 @@ func HandleEOF(
 	if !amIInArray(nodes, nodeId) {
 		nodes = fmt.Sprintf("%v%v%v", nodes, separator, nodeId)
 	}
-	if len(strings.Split(nodes, utils.CommaSeparator)) == int(quantityOfEOF) {
-		log.Infof("EOF Handler %v | Sending EOF to next services...", nodeId)
+	if len(strings.Split(nodes, utils.CommaSeparator)) == int(quantityOfNodes) {
+		log.Infof("EOF Handler %v | Sending message of type %v to next services...", nodeId, message.TypeMessage)
 		for idx, outQueue := range prodOutputQueues {
 			err = sendEOFToOutput(outQueue, message, idx)
 			if err != nil {
-				log.Errorf("EOF Handler %v | Error sending EOF", nodeId)
+				log.Errorf("EOF Handler %v | Error sending message of type %v", nodeId, message.TypeMessage)
 				return err
 			}
 		}
 		return nil
 	}
-	log.Infof("EOF Handler %v | Enqueueing EOF again...", nodeId)
+	log.Infof("EOF Handler %v | Enqueueing message of type %v again...", nodeId, message.TypeMessage)
 	return sendEOFToInput(prodInputQueue, message, nodes)
 }
 
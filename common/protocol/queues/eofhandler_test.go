@@ -2,7 +2,6 @@ package queues
 
 import (
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
-	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -21,22 +20,22 @@ func (m *mockProducerQueueProtocolHandler) Send(msg *dataStructures.Message) err
 	return nil
 }
 
-func TestShouldSendEOFToTheSameStepIfDoesNotMeetTheTotalNodes(t *testing.T) {
+func TestShouldSendAbortToTheSameStepIfDoesNotMeetTheTotalNodes(t *testing.T) {
 	outNext := make(chan *dataStructures.Message, 1)
 	outSame := make(chan *dataStructures.Message, 1)
 	totalNodes := 3
-	dynMap := make(map[string][]byte)
-	dynMap[utils.NodesVisited] = serializer.SerializeString("")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap[utils.NodesVisited] = dataStructures.NewStringColumn("")
 	msg := &dataStructures.Message{
-		TypeMessage: dataStructures.EOFFlightRows,
+		TypeMessage: dataStructures.Abort,
 		DynMaps:     []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMap)},
 	}
 
 	nextStep := &mockProducerQueueProtocolHandler{outputChannel: outNext}
 	sameStep := &mockProducerQueueProtocolHandler{outputChannel: outSame}
 	go func() {
-		err := HandleEOF(msg, sameStep, []ProducerProtocolInterface{nextStep}, "id_prueba", uint(totalNodes))
-		assert.Nil(t, err, "Should not have thrown error handling EOF.")
+		err := HandleAbort(msg, sameStep, []ProducerProtocolInterface{nextStep}, "id_prueba", uint(totalNodes))
+		assert.Nil(t, err, "Should not have thrown error handling Abort.")
 	}()
 	select {
 	case messageReceivedInNextStep := <-outSame:
@@ -50,21 +49,21 @@ func TestShouldSendEOFToTheSameStepIfDoesNotMeetTheTotalNodes(t *testing.T) {
 	}
 }
 
-func TestShouldSendEOFToTheNextStepIfItVisitedAll(t *testing.T) {
+func TestShouldSendAbortToTheNextStepIfItVisitedAll(t *testing.T) {
 	outNext := make(chan *dataStructures.Message)
 	outSame := make(chan *dataStructures.Message)
-	dynMap := make(map[string][]byte)
-	dynMap[utils.NodesVisited] = serializer.SerializeString("1,2,3")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap[utils.NodesVisited] = dataStructures.NewStringColumn("1,2,3")
 	msg := &dataStructures.Message{
-		TypeMessage: dataStructures.EOFFlightRows,
+		TypeMessage: dataStructures.Abort,
 		DynMaps:     []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMap)},
 	}
 
 	nextStep := &mockProducerQueueProtocolHandler{outputChannel: outNext}
 	sameStep := &mockProducerQueueProtocolHandler{outputChannel: outSame}
 	go func() {
-		err := HandleEOF(msg, sameStep, []ProducerProtocolInterface{nextStep}, "4", 4)
-		assert.Nil(t, err, "Should not have thrown error handling EOF.")
+		err := HandleAbort(msg, sameStep, []ProducerProtocolInterface{nextStep}, "4", 4)
+		assert.Nil(t, err, "Should not have thrown error handling Abort.")
 	}()
 	select {
 	case messageReceivedInNextStep := <-outNext:
@@ -78,22 +77,22 @@ func TestShouldSendEOFToTheNextStepIfItVisitedAll(t *testing.T) {
 	}
 }
 
-func TestShouldSendEOFToTheSameStepWithoutAddingNodesIfItIsAlreadyInList(t *testing.T) {
+func TestShouldSendAbortToTheSameStepWithoutAddingNodesIfItIsAlreadyInList(t *testing.T) {
 	outNext := make(chan *dataStructures.Message, 1)
 	outSame := make(chan *dataStructures.Message, 1)
 	totalNodes := 3
-	dynMap := make(map[string][]byte)
-	dynMap[utils.NodesVisited] = serializer.SerializeString("id_prueba")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap[utils.NodesVisited] = dataStructures.NewStringColumn("id_prueba")
 	msg := &dataStructures.Message{
-		TypeMessage: dataStructures.EOFFlightRows,
+		TypeMessage: dataStructures.Abort,
 		DynMaps:     []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMap)},
 	}
 
 	nextStep := &mockProducerQueueProtocolHandler{outputChannel: outNext}
 	sameStep := &mockProducerQueueProtocolHandler{outputChannel: outSame}
 	go func() {
-		err := HandleEOF(msg, sameStep, []ProducerProtocolInterface{nextStep}, "id_prueba", uint(totalNodes))
-		assert.Nil(t, err, "Should not have thrown error handling EOF.")
+		err := HandleAbort(msg, sameStep, []ProducerProtocolInterface{nextStep}, "id_prueba", uint(totalNodes))
+		assert.Nil(t, err, "Should not have thrown error handling Abort.")
 	}()
 	select {
 	case messageReceivedInNextStep := <-outSame:
@@ -106,3 +105,35 @@ func TestShouldSendEOFToTheSameStepWithoutAddingNodesIfItIsAlreadyInList(t *test
 		t.Errorf("Timeout! Should have finished by now...")
 	}
 }
+
+func TestShouldSendAbortToTheNextStepKeepingItsTypeIfItVisitedAll(t *testing.T) {
+	outNext := make(chan *dataStructures.Message, 1)
+	outSame := make(chan *dataStructures.Message, 1)
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap[utils.NodesVisited] = dataStructures.NewStringColumn("1")
+	msg := &dataStructures.Message{
+		TypeMessage: dataStructures.Abort,
+		ClientId:    "cliente",
+		DynMaps:     []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMap)},
+	}
+
+	nextStep := &mockProducerQueueProtocolHandler{outputChannel: outNext}
+	sameStep := &mockProducerQueueProtocolHandler{outputChannel: outSame}
+	go func() {
+		err := HandleAbort(msg, sameStep, []ProducerProtocolInterface{nextStep}, "2", 2)
+		assert.Nil(t, err, "Should not have thrown error handling Abort.")
+	}()
+	select {
+	case messageReceivedInNextStep := <-outNext:
+		assert.Equalf(t, dataStructures.Abort, messageReceivedInNextStep.TypeMessage, "Expected an Abort, got: %v", messageReceivedInNextStep.TypeMessage)
+		assert.Equal(t, "cliente", messageReceivedInNextStep.ClientId)
+	case <-time.After(1 * time.Second):
+		t.Errorf("Timeout! Should have finished by now...")
+	}
+}
+
+func TestHandleAbortShouldFailIfTheMessageIsNotAnAbort(t *testing.T) {
+	msg := &dataStructures.Message{TypeMessage: dataStructures.EOFFlightRows}
+	err := HandleAbort(msg, nil, nil, "1", 1)
+	assert.NotNil(t, err, "Should have thrown error handling an EOF as Abort.")
+}
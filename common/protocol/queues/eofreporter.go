@@ -0,0 +1,169 @@
+package queues
+
+import (
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// CoordinatorQueueName Returns the queue of the coordinator of the EOF of the stage that consumes the input queue
+func CoordinatorQueueName(inputQueueName string) string {
+	return fmt.Sprintf("%v_eof", inputQueueName)
+}
+
+// countingProducer Producer that counts by client the rows of the flights sent through it
+type countingProducer struct {
+	producer ProducerProtocolInterface
+	rows     map[string]int
+}
+
+func (p *countingProducer) Send(msg *dataStructures.Message) error {
+	err := p.producer.Send(msg)
+	if err == nil && msg.TypeMessage == dataStructures.FlightRows {
+		p.rows[msg.ClientId] += len(msg.DynMaps)
+	}
+	return err
+}
+
+// takeRows Returns the rows sent of the client since the last call
+func (p *countingProducer) takeRows(clientId string) int {
+	rows := p.rows[clientId]
+	delete(p.rows, clientId)
+	return rows
+}
+
+// EOFReporter Node side of the EOF protocol. It reports to the coordinator of the stage the rows of each batch
+// that the node processed and the ones that it sent to each output, and hands it the EOF of the clients
+type EOFReporter struct {
+	nodeId        string
+	consumer      ConsumerProtocolInterface
+	toCoordinator ProducerProtocolInterface
+	rawOutputs    []ProducerProtocolInterface
+	outputs       []*countingProducer
+	// keyed Outputs to the nodes of the next stage in the view of each client. Nil if the outputs are fixed
+	keyed *KeyedOutputs
+}
+
+// NewEOFReporter Creates the reporter of the node. The node has to send its rows through the outputs of the reporter, so they are counted
+func NewEOFReporter(
+	nodeId string,
+	consumer ConsumerProtocolInterface,
+	toCoordinator ProducerProtocolInterface,
+	outputs []ProducerProtocolInterface,
+) *EOFReporter {
+	var countingOutputs []*countingProducer
+	for _, output := range outputs {
+		countingOutputs = append(countingOutputs, &countingProducer{producer: output, rows: make(map[string]int)})
+	}
+	return &EOFReporter{
+		nodeId:        nodeId,
+		consumer:      consumer,
+		toCoordinator: toCoordinator,
+		rawOutputs:    outputs,
+		outputs:       countingOutputs,
+	}
+}
+
+// NewKeyedEOFReporter Creates the reporter of a node whose outputs are the nodes of the next stage in the view of each client
+func NewKeyedEOFReporter(
+	nodeId string,
+	consumer ConsumerProtocolInterface,
+	toCoordinator ProducerProtocolInterface,
+	outputs *KeyedOutputs,
+) *EOFReporter {
+	r := NewEOFReporter(nodeId, consumer, toCoordinator, nil)
+	r.keyed = outputs
+	return r
+}
+
+// Outputs Returns the outputs of the node, in the same order they were given. The rows sent through them go in the next report
+func (r *EOFReporter) Outputs() []ProducerProtocolInterface {
+	var outputs []ProducerProtocolInterface
+	for _, output := range r.outputs {
+		outputs = append(outputs, output)
+	}
+	return outputs
+}
+
+// OutputsOf Returns the outputs of the node for the client of the message. If they are keyed, the ones of the nodes of
+// the next stage in the view of the client
+func (r *EOFReporter) OutputsOf(msg *dataStructures.Message) []ProducerProtocolInterface {
+	if r.keyed == nil {
+		return r.Outputs()
+	}
+	rawOutputs := r.keyed.Of(msg)
+	for len(r.outputs) < len(rawOutputs) {
+		r.outputs = append(r.outputs, &countingProducer{producer: rawOutputs[len(r.outputs)], rows: make(map[string]int)})
+	}
+	var outputs []ProducerProtocolInterface
+	for _, output := range r.outputs[:len(rawOutputs)] {
+		outputs = append(outputs, output)
+	}
+	return outputs
+}
+
+// rawOutputsOf Returns the outputs without counting the rows, for the client of the message
+func (r *EOFReporter) rawOutputsOf(msg *dataStructures.Message) []ProducerProtocolInterface {
+	if r.keyed == nil {
+		return r.rawOutputs
+	}
+	return r.keyed.Of(msg)
+}
+
+// ReportBatch Sends to the coordinator the rows of the batch and the ones sent to each output while it was processed.
+// It has to be called before acknowledging the batch, and not for the batches that are requeued
+func (r *EOFReporter) ReportBatch(batch *dataStructures.Message) error {
+	var emitted []string
+	for _, output := range r.outputs {
+		emitted = append(emitted, fmt.Sprint(output.takeRows(batch.ClientId)))
+	}
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap[utils.ReceivedRows] = dataStructures.NewInt64Column(int64(len(batch.DynMaps)))
+	dynMap[utils.EmittedRows] = dataStructures.NewListColumn(emitted)
+	dynMap[utils.NodeId] = dataStructures.NewStringColumn(r.nodeId)
+	dynMap[utils.NodeReceivedRows] = dataStructures.NewInt64Column(int64(r.consumer.GetReceivedMessages(batch.ClientId)))
+	err := r.toCoordinator.Send(&dataStructures.Message{
+		TypeMessage: dataStructures.RowsReport,
+		DynMaps:     []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMap)},
+		ClientId:    batch.ClientId,
+		// The report keeps the ids of the batch, so a batch processed twice is reported once
+		MessageId: batch.MessageId,
+		RowId:     batch.RowId,
+	})
+	if err != nil {
+		log.Errorf("EOFReporter %v | Error sending report of batch %v-%v of client %v | %v", r.nodeId, batch.MessageId, batch.RowId, batch.ClientId, err)
+	}
+	return err
+}
+
+// HandleEOF Hands the EOF to the coordinator, that sends it to the next stage once every row of the client was processed
+func (r *EOFReporter) HandleEOF(message *dataStructures.Message) error {
+	if message.TypeMessage != dataStructures.EOFFlightRows {
+		return fmt.Errorf("type is not EOF")
+	}
+	log.Infof("EOFReporter %v | Sending EOF of client %v to the coordinator...", r.nodeId, message.ClientId)
+	return r.toCoordinator.Send(message)
+}
+
+// HandleAbort Forwards the abort through the nodes of the stage. The last one sends it to the next stage and to the coordinator,
+// so it discards the EOF and the reports of the client
+func (r *EOFReporter) HandleAbort(message *dataStructures.Message, prodInputQueue ProducerProtocolInterface, quantityOfNodes uint) error {
+	for _, output := range r.outputs {
+		output.takeRows(message.ClientId)
+	}
+	outputs := append(append([]ProducerProtocolInterface{}, r.rawOutputsOf(message)...), r.toCoordinator)
+	return HandleAbort(message, prodInputQueue, outputs, r.nodeId, quantityOfNodes)
+}
+
+// HandleClear Forwards the clear of a client through the nodes of the stage, after the node cleared its data.
+// The last one returns it to the coordinator, so it forgets the client
+func (r *EOFReporter) HandleClear(message *dataStructures.Message, prodInputQueue ProducerProtocolInterface, quantityOfNodes uint) error {
+	if message.TypeMessage != dataStructures.ClearClient {
+		return fmt.Errorf("type is not ClearClient")
+	}
+	for _, output := range r.outputs {
+		output.takeRows(message.ClientId)
+	}
+	return forwardThroughNodes(message, prodInputQueue, []ProducerProtocolInterface{r.toCoordinator}, r.nodeId, quantityOfNodes)
+}
@@ -0,0 +1,43 @@
+package queues
+
+import (
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
+)
+
+// KeyedOutputs Outputs to the nodes of a stage that are known by their index, as the journey savers by their routing key.
+// The producer of an index is created the first time that it is used, so the outputs grow with the stage
+type KeyedOutputs struct {
+	// stage Name of the stage in the view of each client
+	stage      string
+	configured uint
+	newOutput  func(idx int) ProducerProtocolInterface
+	outputs    []ProducerProtocolInterface
+}
+
+// NewKeyedOutputs Creates the outputs to the nodes of the stage. The configured ones are created at once, and
+// the clients without a view, or with a view without the stage, use them
+func NewKeyedOutputs(stage string, configured uint, newOutput func(idx int) ProducerProtocolInterface) *KeyedOutputs {
+	o := &KeyedOutputs{stage: stage, configured: configured, newOutput: newOutput}
+	o.growTo(int(configured))
+	return o
+}
+
+func (o *KeyedOutputs) growTo(count int) {
+	for len(o.outputs) < count {
+		o.outputs = append(o.outputs, o.newOutput(len(o.outputs)))
+	}
+}
+
+// Of Returns the outputs to the nodes of the stage in the view pinned to the client of the message
+func (o *KeyedOutputs) Of(msg *dataStructures.Message) []ProducerProtocolInterface {
+	count := int(membership.SizeOf(msg, o.stage, o.configured))
+	o.growTo(count)
+	return o.outputs[:count]
+}
+
+// All Returns every output created, at least as many as the given count
+func (o *KeyedOutputs) All(count int) []ProducerProtocolInterface {
+	o.growTo(count)
+	return o.outputs
+}
@@ -1,7 +1,9 @@
 package queues
 
 import (
+	"fmt"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/deadletter"
 	"github.com/brunograssano/Distribuidos-TP1/common/duplicates"
 	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
@@ -12,20 +14,34 @@ type ConsumerQueueProtocolHandler struct {
 	consumer          middleware.ConsumerInterface
 	status            bool
 	lastMsg           *dataStructures.Message
+	lastBytes         []byte
 	consumedByClients map[string]int
 	duplicatesHandler duplicates.DuplicateDetector
+	// deadLetters Dead-letter queue of the stage. If nil, the messages rejected are only logged
+	deadLetters ProducerProtocolInterface
+	// retries Sends the rejected messages back to the queue after a delay. If nil, they are requeued right away
+	retries middleware.RetryProducerInterface
 }
 
-func NewConsumerQueueProtocolHandler(consumer middleware.ConsumerInterface, duplicatesHandler duplicates.DuplicateDetector) *ConsumerQueueProtocolHandler {
+func NewConsumerQueueProtocolHandler(
+	consumer middleware.ConsumerInterface,
+	duplicatesHandler duplicates.DuplicateDetector,
+	deadLetters ProducerProtocolInterface,
+	retries middleware.RetryProducerInterface,
+) *ConsumerQueueProtocolHandler {
 	return &ConsumerQueueProtocolHandler{
 		consumer:          consumer,
 		lastMsg:           nil,
 		consumedByClients: make(map[string]int),
 		duplicatesHandler: duplicatesHandler,
+		deadLetters:       deadLetters,
+		retries:           retries,
 	}
 }
 
-func (q *ConsumerQueueProtocolHandler) Pop() (*dataStructures.Message, bool) {
+// Pop Returns the next message that is not a duplicate. A malformed message is acked and sent to the dead-letter
+// queue, and its reason is returned so the caller can skip it
+func (q *ConsumerQueueProtocolHandler) Pop() (*dataStructures.Message, error) {
 	var msg *dataStructures.Message
 	for {
 		err := q.notifyStatusOfLastMessage()
@@ -34,19 +50,42 @@ func (q *ConsumerQueueProtocolHandler) Pop() (*dataStructures.Message, bool) {
 		}
 		bytes, ok := q.consumer.Pop()
 		if !ok {
-			return nil, false
+			return nil, ErrConsumerClosed
+		}
+		msg, err = serializer.DeserializeMsg(bytes)
+		if err != nil {
+			// A malformed message will fail again if it is requeued, so it is acked and sent to the dead-letter queue
+			q.sendDeadLetter(deadletter.NewRaw(bytes, q.consumer.GetName(), err))
+			q.lastMsg = nil
+			q.status = true
+			return nil, fmt.Errorf("discarded message from %v: %w", q.consumer.GetName(), err)
 		}
-		msg = serializer.DeserializeMsg(bytes)
 		q.lastMsg = msg
-		if !q.duplicatesHandler.IsDuplicate(msg) {
+		q.lastBytes = bytes
+		if !q.isDuplicate(msg) {
 			break
 		} else {
 			log.Warnf("ConsumerQueueProtocolhandler | Got Duplicated Message: %v-%v-%v| Discarding it...", msg.ClientId, msg.MessageId, msg.RowId)
 		}
 	}
 	q.sumToConsumedByClient(msg)
-	q.duplicatesHandler.SaveMessageSeen(msg)
-	return msg, true
+	if msg.TypeMessage != dataStructures.RowsReport {
+		q.duplicatesHandler.SaveMessageSeen(msg)
+	}
+	return msg, nil
+}
+
+// isDuplicate Returns true if the message was seen. A retried message is only a duplicate if it is in the messages seen,
+// as the newer messages of the client could fill the window of the detector while it waited. The reports of the nodes
+// have the ids of their batches, so the coordinator of the EOF keeps the ones seen instead of the detector
+func (q *ConsumerQueueProtocolHandler) isDuplicate(msg *dataStructures.Message) bool {
+	if msg.TypeMessage == dataStructures.RowsReport {
+		return false
+	}
+	if q.consumer.Attempt() > 0 {
+		return q.duplicatesHandler.WasSeen(msg)
+	}
+	return q.duplicatesHandler.IsDuplicate(msg)
 }
 
 func (q *ConsumerQueueProtocolHandler) sumToConsumedByClient(msg *dataStructures.Message) {
@@ -68,12 +107,70 @@ func (q *ConsumerQueueProtocolHandler) GetReceivedMessages(clientId string) int
 	return count
 }
 
+// ClearData Forgets the messages consumed from the client and the ones seen by the duplicates handler
 func (q *ConsumerQueueProtocolHandler) ClearData(clientId string) {
 	delete(q.consumedByClients, clientId)
+	q.duplicatesHandler.ClearData(clientId)
 }
 
-func (q *ConsumerQueueProtocolHandler) SetStatusOfLastMessage(status bool) {
-	q.status = status
+// RejectLastMessage Retries the last message later, as it can not be processed yet. Returns true if it will be retried.
+// The message waits the delay of the retry policy, that grows on each attempt. The attempt travels with the message,
+// so it does not matter which consumer of the queue gets it. After the max attempts it is sent to the dead-letter queue
+// instead, so the stage has to treat it as processed. Without retries the message is requeued right away and its
+// attempts are not counted
+func (q *ConsumerQueueProtocolHandler) RejectLastMessage(reason error) bool {
+	if q.lastMsg == nil {
+		return false
+	}
+	attempt := q.consumer.Attempt() + 1
+	policy := q.retryPolicy()
+	if attempt >= policy.MaxAttempts {
+		q.DeadLetter(q.lastMsg.DynMaps, fmt.Errorf("rejected %v times: %v", attempt, reason))
+		return false
+	}
+	q.duplicatesHandler.RemoveLastMessageSeen()
+	if q.retries == nil {
+		q.status = false
+		return true
+	}
+	err := q.retries.SendAfter(q.lastBytes, attempt)
+	if err != nil {
+		log.Errorf("ConsumerQueueProtocolHandler | Error sending retry of %v, requeuing it | %v", q.consumer.GetName(), err)
+		q.status = false
+		return true
+	}
+	log.Debugf("ConsumerQueueProtocolHandler | Retrying message %v-%v-%v of %v in %v | Attempt: %v | %v",
+		q.lastMsg.ClientId, q.lastMsg.MessageId, q.lastMsg.RowId, q.consumer.GetName(), policy.DelayOf(attempt), attempt, reason)
+	// The retry is a new message, so the last one is acked as if it was not consumed
+	q.subtractConsumed(q.lastMsg)
+	return true
+}
+
+func (q *ConsumerQueueProtocolHandler) retryPolicy() middleware.RetryPolicy {
+	if q.retries == nil {
+		return GetRetryPolicy()
+	}
+	return q.retries.Policy()
+}
+
+// DeadLetter Sends the rows of the last message to the dead-letter queue, with the header of the message and the reason
+func (q *ConsumerQueueProtocolHandler) DeadLetter(rows []*dataStructures.DynamicMap, reason error) {
+	if q.lastMsg == nil {
+		return
+	}
+	q.sendDeadLetter(deadletter.New(q.lastMsg, rows, q.consumer.GetName(), reason))
+}
+
+func (q *ConsumerQueueProtocolHandler) sendDeadLetter(deadLetter *dataStructures.Message) {
+	log.Warnf("ConsumerQueueProtocolHandler | Warning Message | Sending message %v-%v-%v of %v to the dead-letter queue | Rows: %v",
+		deadLetter.ClientId, deadLetter.MessageId, deadLetter.RowId, q.consumer.GetName(), len(deadLetter.DynMaps)-1)
+	if q.deadLetters == nil {
+		return
+	}
+	err := q.deadLetters.Send(deadLetter)
+	if err != nil {
+		log.Errorf("ConsumerQueueProtocolHandler | Error sending dead letter of %v | %v", q.consumer.GetName(), err)
+	}
 }
 
 func (q *ConsumerQueueProtocolHandler) notifyStatusOfLastMessage() error {
@@ -83,9 +180,15 @@ func (q *ConsumerQueueProtocolHandler) notifyStatusOfLastMessage() error {
 		return err
 	}
 	// Caso de NACK
-	if q.lastMsg != nil && q.lastMsg.TypeMessage == dataStructures.FlightRows && !q.status {
-		q.consumedByClients[q.lastMsg.ClientId] -= len(q.lastMsg.DynMaps)
+	if q.lastMsg != nil && !q.status {
+		q.subtractConsumed(q.lastMsg)
 	}
 	q.status = true
 	return nil
 }
+
+func (q *ConsumerQueueProtocolHandler) subtractConsumed(msg *dataStructures.Message) {
+	if msg.TypeMessage == dataStructures.FlightRows {
+		q.consumedByClients[msg.ClientId] -= len(msg.DynMaps)
+	}
+}
@@ -0,0 +1,155 @@
+package queues
+
+import (
+	"errors"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/deadletter"
+	"github.com/brunograssano/Distribuidos-TP1/common/duplicates"
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPopReturnsTheReasonOfTheMalformedMessagesAndThenTheNextValidOne(t *testing.T) {
+	broker := middleware.NewInMemoryBroker()
+	qMiddleware := middleware.NewInMemoryQueueMiddleware(broker)
+	defer qMiddleware.Close()
+	producer := qMiddleware.CreateProducer("input", true)
+	deadLetters := NewProducerQueueProtocolHandler(qMiddleware.CreateProducer(deadletter.QueueName("input"), true), serializer.NoCompression)
+	consumer := NewConsumerQueueProtocolHandler(qMiddleware.CreateConsumer("input", true), duplicates.NewDuplicatesHandler("input"), deadLetters, nil)
+
+	valid := serializer.SerializeMsg(&dataStructures.Message{TypeMessage: dataStructures.FlightRows, ClientId: "client", MessageId: 1})
+	assert.Nil(t, producer.Send([]byte("not a message")))
+	assert.Nil(t, producer.Send(valid[:len(valid)-1]))
+	assert.Nil(t, producer.Send(valid))
+
+	_, err := consumer.Pop()
+	assert.ErrorIs(t, err, serializer.ErrMalformedMessage)
+	_, err = consumer.Pop()
+	assert.ErrorIs(t, err, serializer.ErrMalformedMessage)
+	msg, err := consumer.Pop()
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), msg.MessageId)
+	assert.Equal(t, "client", msg.ClientId)
+	assert.Nil(t, consumer.notifyStatusOfLastMessage())
+	assert.Equal(t, 0, broker.QueueLength("input"))
+	assert.Equal(t, 2, broker.QueueLength(deadletter.QueueName("input")), "The malformed messages should be dead letters")
+
+	qMiddleware.Close()
+	_, err = consumer.Pop()
+	assert.ErrorIs(t, err, ErrConsumerClosed)
+}
+
+func TestARejectedMessageIsRetriedByAnyConsumerUntilItGoesToTheDeadLetterQueue(t *testing.T) {
+	broker := middleware.NewInMemoryBroker()
+	qMiddleware := middleware.NewInMemoryQueueMiddleware(broker)
+	defer qMiddleware.Close()
+	producer := NewProducerQueueProtocolHandler(qMiddleware.CreateProducer("input", true), serializer.NoCompression)
+	deadLetters := NewProducerQueueProtocolHandler(qMiddleware.CreateProducer(deadletter.QueueName("input"), true), serializer.NoCompression)
+	policy, err := middleware.NewRetryPolicy(time.Millisecond, 4*time.Millisecond, 0)
+	assert.Nil(t, err)
+	var consumers []*ConsumerQueueProtocolHandler
+	for i := 0; i < 2; i++ {
+		retries := qMiddleware.CreateRetryProducer("input", policy)
+		consumers = append(consumers, NewConsumerQueueProtocolHandler(qMiddleware.CreateConsumer("input", true), duplicates.NewDuplicatesHandler("input"), deadLetters, retries))
+	}
+	row := dataStructures.NewDynamicMap(map[string]dataStructures.Column{"legId": dataStructures.NewStringColumn("leg")})
+	assert.Nil(t, producer.Send(&dataStructures.Message{TypeMessage: dataStructures.FlightRows, ClientId: "client", MessageId: 3, DynMaps: []*dataStructures.DynamicMap{row}}))
+
+	for attempt := 1; attempt < policy.MaxAttempts; attempt++ {
+		// The consumers take turns, as the replicas of a stage do, and the attempts still add up
+		consumer := consumers[attempt%2]
+		msg, err := consumer.Pop()
+		assert.Nil(t, err)
+		assert.Equal(t, uint(3), msg.MessageId, "The rejected message should not be a duplicate")
+		assert.Equal(t, attempt-1, consumer.consumer.Attempt())
+		assert.True(t, consumer.RejectLastMessage(errors.New("airports missing")))
+	}
+	consumer := consumers[policy.MaxAttempts%2]
+	_, err = consumer.Pop()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, consumer.GetReceivedMessages("client"))
+	assert.False(t, consumer.RejectLastMessage(errors.New("airports missing")))
+	for _, c := range consumers {
+		assert.Nil(t, c.notifyStatusOfLastMessage())
+	}
+	assert.Equal(t, 0, broker.QueueLength("input"))
+
+	deadLetter, err := NewConsumerQueueProtocolHandler(qMiddleware.CreateConsumer(deadletter.QueueName("input"), true), duplicates.NewDuplicatesHandler("dlq"), nil, nil).Pop()
+	assert.Nil(t, err)
+	info, err := deadletter.InfoOf(deadLetter)
+	assert.Nil(t, err)
+	assert.Equal(t, "input", info.Stage)
+	assert.Equal(t, dataStructures.FlightRows, info.Type)
+	assert.Contains(t, info.Reason, "airports missing")
+	original, _, err := deadletter.Original(deadLetter)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(3), original.MessageId)
+	assert.Len(t, original.DynMaps, 1)
+}
+
+func TestARejectedMessageIsRetriedAfterADelayThatGrowsOnEachAttempt(t *testing.T) {
+	broker := middleware.NewInMemoryBroker()
+	qMiddleware := middleware.NewInMemoryQueueMiddleware(broker)
+	defer qMiddleware.Close()
+	producer := NewProducerQueueProtocolHandler(qMiddleware.CreateProducer("input", true), serializer.NoCompression)
+	deadLetters := NewProducerQueueProtocolHandler(qMiddleware.CreateProducer(deadletter.QueueName("input"), true), serializer.NoCompression)
+	policy, err := middleware.NewRetryPolicy(10*time.Millisecond, 0, 3)
+	assert.Nil(t, err)
+	retries := qMiddleware.CreateRetryProducer("input", policy)
+	consumer := NewConsumerQueueProtocolHandler(qMiddleware.CreateConsumer("input", true), duplicates.NewDuplicatesHandler("input"), deadLetters, retries)
+	row := dataStructures.NewDynamicMap(map[string]dataStructures.Column{"legId": dataStructures.NewStringColumn("leg")})
+	assert.Nil(t, producer.Send(&dataStructures.Message{TypeMessage: dataStructures.FlightRows, ClientId: "client", MessageId: 3, DynMaps: []*dataStructures.DynamicMap{row}}))
+
+	msg, err := consumer.Pop()
+	assert.Nil(t, err)
+	for attempt := 1; attempt < policy.MaxAttempts; attempt++ {
+		assert.True(t, consumer.RejectLastMessage(errors.New("airports missing")))
+		assert.Equal(t, 0, consumer.GetReceivedMessages("client"))
+		assert.Nil(t, consumer.notifyStatusOfLastMessage())
+		assert.Equal(t, 0, broker.QueueLength("input"), "The retry should wait its delay")
+		start := time.Now()
+		msg, err = consumer.Pop()
+		assert.Nil(t, err)
+		assert.Equal(t, uint(3), msg.MessageId, "The retried message should not be a duplicate")
+		assert.GreaterOrEqual(t, time.Since(start), policy.DelayOf(attempt)/2)
+	}
+	assert.False(t, consumer.RejectLastMessage(errors.New("airports missing")))
+	assert.Equal(t, 1, consumer.GetReceivedMessages("client"))
+	assert.Nil(t, consumer.notifyStatusOfLastMessage())
+	assert.Equal(t, 1, broker.QueueLength(deadletter.QueueName("input")))
+}
+
+func TestARetriedMessageIsNotADuplicateAfterNewerMessagesFillTheWindow(t *testing.T) {
+	broker := middleware.NewInMemoryBroker()
+	qMiddleware := middleware.NewInMemoryQueueMiddleware(broker)
+	defer qMiddleware.Close()
+	producer := NewProducerQueueProtocolHandler(qMiddleware.CreateProducer("input", true), serializer.NoCompression)
+	policy, err := middleware.NewRetryPolicy(500*time.Millisecond, 0, 3)
+	assert.Nil(t, err)
+	retries := qMiddleware.CreateRetryProducer("input", policy)
+	consumer := NewConsumerQueueProtocolHandler(qMiddleware.CreateConsumer("input", true), duplicates.NewDuplicatesHandler("input"), nil, retries)
+	assert.Nil(t, producer.Send(&dataStructures.Message{TypeMessage: dataStructures.FlightRows, ClientId: "client", MessageId: 1}))
+
+	_, err = consumer.Pop()
+	assert.Nil(t, err)
+	assert.True(t, consumer.RejectLastMessage(errors.New("airports missing")))
+	// More batches than the window of the detector arrive while the rejected one waits
+	newerBatches := 1500
+	for id := 2; id < 2+newerBatches; id++ {
+		assert.Nil(t, producer.Send(&dataStructures.Message{TypeMessage: dataStructures.FlightRows, ClientId: "client", MessageId: uint(id)}))
+	}
+	for i := 0; i < newerBatches; i++ {
+		msg, err := consumer.Pop()
+		assert.Nil(t, err)
+		assert.NotEqual(t, uint(1), msg.MessageId, "The retry should wait its delay")
+	}
+
+	msg, err := consumer.Pop()
+	assert.Nil(t, err)
+	assert.Equal(t, uint(1), msg.MessageId, "The retried message should not be a duplicate")
+	assert.Equal(t, 1, consumer.consumer.Attempt())
+	assert.Nil(t, consumer.notifyStatusOfLastMessage())
+}
@@ -7,16 +7,19 @@ import (
 )
 
 type ProducerQueueProtocolHandler struct {
-	producer middleware.ProducerInterface
+	producer   middleware.ProducerInterface
+	compressor *serializer.Compressor
 }
 
-func NewProducerQueueProtocolHandler(producer middleware.ProducerInterface) *ProducerQueueProtocolHandler {
+// NewProducerQueueProtocolHandler Creates the producer with its own compressor, that compresses the messages with the compression received
+func NewProducerQueueProtocolHandler(producer middleware.ProducerInterface, compression serializer.Compression) *ProducerQueueProtocolHandler {
 	return &ProducerQueueProtocolHandler{
-		producer: producer,
+		producer:   producer,
+		compressor: serializer.NewCompressor(producer.GetName(), compression),
 	}
 }
 
 func (q *ProducerQueueProtocolHandler) Send(msg *dataStructures.Message) error {
-	bytes := serializer.SerializeMsg(msg)
+	bytes := q.compressor.Compress(serializer.SerializeMsg(msg))
 	return q.producer.Send(bytes)
 }
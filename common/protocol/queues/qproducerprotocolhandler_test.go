@@ -0,0 +1,52 @@
+package queues
+
+import (
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/duplicates"
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestProducerOfACompressedQueueSendsMessagesThatTheConsumerReads(t *testing.T) {
+	compressions, err := NewCompressions(map[string]string{"Compressed_Input": "gzip"})
+	assert.Nil(t, err)
+	broker := middleware.NewInMemoryBroker()
+	qMiddleware := middleware.NewInMemoryQueueMiddleware(broker)
+	t.Cleanup(qMiddleware.Close)
+	producer := NewProducerQueueProtocolHandler(qMiddleware.CreateProducer("compressed_input", true), compressions.Of("compressed_input"))
+	consumer := NewConsumerQueueProtocolHandler(qMiddleware.CreateConsumer("compressed_input", true), duplicates.NewDuplicatesHandler("compressed_input"), nil, nil)
+
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["route"] = dataStructures.NewStringColumn(strings.Repeat("ATL||JFK||", 100))
+	msg := dataStructures.NewCompleteMessage(dataStructures.FlightRows, []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMap)}, "client", 1)
+	assert.Nil(t, producer.Send(msg))
+
+	received, err := consumer.Pop()
+	assert.Nil(t, err)
+	route, err := received.DynMaps[0].GetAsString("route")
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Repeat("ATL||JFK||", 100), route)
+	assert.Greater(t, producer.compressor.Ratio(), 1.0)
+}
+
+func TestTheProducersOfTheSameProcessUseTheCompressionOfTheirQueue(t *testing.T) {
+	compressions, err := NewCompressions(map[string]string{"compressed": "flate"})
+	assert.Nil(t, err)
+	qMiddleware := middleware.NewInMemoryQueueMiddleware(middleware.NewInMemoryBroker())
+	t.Cleanup(qMiddleware.Close)
+	compressed := NewProducerQueueProtocolHandler(qMiddleware.CreateProducer("compressed", true), compressions.Of("compressed"))
+	plain := NewProducerQueueProtocolHandler(qMiddleware.CreateProducer("plain", true), compressions.Of("plain"))
+
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["route"] = dataStructures.NewStringColumn(strings.Repeat("ATL||JFK||", 100))
+	msg := dataStructures.NewCompleteMessage(dataStructures.FlightRows, []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMap)}, "client", 1)
+	assert.Nil(t, compressed.Send(msg))
+	assert.Nil(t, plain.Send(msg))
+
+	assert.Greater(t, compressed.compressor.Ratio(), 1.0)
+	assert.Equal(t, 1.0, plain.compressor.Ratio())
+	_, err = NewCompressions(map[string]string{"compressed": "lz4"})
+	assert.NotNil(t, err)
+}
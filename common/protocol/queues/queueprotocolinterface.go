@@ -1,11 +1,15 @@
 package queues
 
 import (
+	"errors"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/brunograssano/Distribuidos-TP1/common/protocol"
 )
 
+// ErrConsumerClosed Returned by Pop when the queue of the consumer is closed, so there are no more messages
+var ErrConsumerClosed = errors.New("consumer closed")
+
 type ProducerProtocolInterface interface {
 	Send(msg *dataStructures.Message) error
 }
@@ -13,7 +17,10 @@ type ProducerProtocolInterface interface {
 type ConsumerProtocolInterface interface {
 	checkpointer.Checkpointable
 	protocol.DataCleaner
-	Pop() (*dataStructures.Message, bool)
+	// Pop Returns the next message. Returns ErrConsumerClosed if there are no more messages,
+	// or the reason if the message can not be read
+	Pop() (*dataStructures.Message, error)
 	GetReceivedMessages(string) int
-	SetStatusOfLastMessage(bool)
+	RejectLastMessage(reason error) bool
+	DeadLetter(rows []*dataStructures.DynamicMap, reason error)
 }
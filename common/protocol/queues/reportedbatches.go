@@ -0,0 +1,92 @@
+package queues
+
+import (
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const rowIdSeparator = ":"
+const rangesSeparator = "+"
+const rangeLimitsSeparator = "-"
+
+// idRange Message ids from first to last, both included
+type idRange struct {
+	first uint
+	last  uint
+}
+
+// reportedBatches Batches of a client whose report the coordinator counted, as ranges of message ids of each row id.
+// The batches of a client have consecutive ids, so a few ranges keep all of them
+type reportedBatches map[uint16][]idRange
+
+// add Adds the batch. Returns false if it was already reported
+func (r reportedBatches) add(messageId uint, rowId uint16) bool {
+	ranges := r[rowId]
+	idx := sort.Search(len(ranges), func(i int) bool { return ranges[i].last >= messageId })
+	if idx < len(ranges) && ranges[idx].first <= messageId {
+		return false
+	}
+	joinsPrevious := idx > 0 && ranges[idx-1].last+1 == messageId
+	joinsNext := idx < len(ranges) && ranges[idx].first == messageId+1
+	if joinsPrevious && joinsNext {
+		ranges[idx-1].last = ranges[idx].last
+		ranges = slices.Delete(ranges, idx, idx+1)
+	} else if joinsPrevious {
+		ranges[idx-1].last = messageId
+	} else if joinsNext {
+		ranges[idx].first = messageId
+	} else {
+		ranges = slices.Insert(ranges, idx, idRange{first: messageId, last: messageId})
+	}
+	r[rowId] = ranges
+	return true
+}
+
+// String Returns the batches as {rowId}:{first}-{last}+{first}-{last};{rowId}:...
+func (r reportedBatches) String() string {
+	var rows []string
+	for rowId, ranges := range r {
+		var rangesStr []string
+		for _, idRange := range ranges {
+			rangesStr = append(rangesStr, fmt.Sprintf("%v%v%v", idRange.first, rangeLimitsSeparator, idRange.last))
+		}
+		rows = append(rows, fmt.Sprintf("%v%v%v", rowId, rowIdSeparator, strings.Join(rangesStr, rangesSeparator)))
+	}
+	return strings.Join(rows, utils.DotCommaSeparator)
+}
+
+func parseReportedBatches(batchesStr string) (reportedBatches, error) {
+	batches := make(reportedBatches)
+	if batchesStr == "" {
+		return batches, nil
+	}
+	for _, rowStr := range strings.Split(batchesStr, utils.DotCommaSeparator) {
+		rowIdAndRanges := strings.Split(rowStr, rowIdSeparator)
+		if len(rowIdAndRanges) != 2 {
+			return nil, fmt.Errorf("wrong format of reported batches %v", rowStr)
+		}
+		rowId, err := strconv.ParseUint(rowIdAndRanges[0], 10, 16)
+		if err != nil {
+			return nil, err
+		}
+		var ranges []idRange
+		for _, rangeStr := range strings.Split(rowIdAndRanges[1], rangesSeparator) {
+			limits := strings.Split(rangeStr, rangeLimitsSeparator)
+			if len(limits) != 2 {
+				return nil, fmt.Errorf("wrong format of range of reported batches %v", rangeStr)
+			}
+			first, errFirst := strconv.ParseUint(limits[0], 10, 64)
+			last, errLast := strconv.ParseUint(limits[1], 10, 64)
+			if errFirst != nil || errLast != nil {
+				return nil, fmt.Errorf("wrong range of reported batches %v", rangeStr)
+			}
+			ranges = append(ranges, idRange{first: uint(first), last: uint(last)})
+		}
+		batches[uint16(rowId)] = ranges
+	}
+	return batches, nil
+}
@@ -0,0 +1,23 @@
+package queues
+
+import (
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	"sync"
+)
+
+var retryMutex sync.RWMutex
+var retryPolicy = middleware.DefaultRetryPolicy()
+
+// SetRetryPolicy Sets the policy of the retries of the consumers created afterward
+func SetRetryPolicy(policy middleware.RetryPolicy) {
+	retryMutex.Lock()
+	defer retryMutex.Unlock()
+	retryPolicy = policy
+}
+
+// GetRetryPolicy Returns the policy of the retries of the consumers
+func GetRetryPolicy() middleware.RetryPolicy {
+	retryMutex.RLock()
+	defer retryMutex.RUnlock()
+	return retryPolicy
+}
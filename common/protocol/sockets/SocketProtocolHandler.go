@@ -14,12 +14,20 @@ import (
 const sizeOfLen = 4
 
 type SocketProtocolHandler struct {
-	sock communication.TCPSocketInterface
+	sock       communication.TCPSocketInterface
+	compressor *serializer.Compressor
 }
 
 func NewSocketProtocolHandler(sock communication.TCPSocketInterface) *SocketProtocolHandler {
+	return NewSocketProtocolHandlerWithCompression(sock, serializer.NoCompression)
+}
+
+// NewSocketProtocolHandlerWithCompression Creates a handler that compresses the messages it writes.
+// The other side detects it from the header of each message
+func NewSocketProtocolHandlerWithCompression(sock communication.TCPSocketInterface, compression serializer.Compression) *SocketProtocolHandler {
 	return &SocketProtocolHandler{
-		sock: sock,
+		sock:       sock,
+		compressor: serializer.NewCompressor("socket", compression),
 	}
 }
 
@@ -36,7 +44,11 @@ func (sph *SocketProtocolHandler) receiveMessage(length int) (*dataStructures.Me
 	if err != nil {
 		return nil, fmt.Errorf("error receiving message of length %v: %v. Skipping client", length, err)
 	}
-	return serializer.DeserializeMsg(msg), nil
+	message, err := serializer.DeserializeMsg(msg)
+	if err != nil {
+		return nil, fmt.Errorf("error deserializing message of length %v: %w. Skipping client", length, err)
+	}
+	return message, nil
 }
 
 func (sph *SocketProtocolHandler) Read() (*dataStructures.Message, error) {
@@ -79,7 +91,7 @@ func (sph *SocketProtocolHandler) sendMessage(msgBytes []byte) error {
 }
 
 func (sph *SocketProtocolHandler) Write(msg *dataStructures.Message) error {
-	bytesToSend := serializer.SerializeMsg(msg)
+	bytesToSend := sph.compressor.Compress(serializer.SerializeMsg(msg))
 	err := sph.sendLength(bytesToSend)
 	if err != nil {
 		return fmt.Errorf("sending length: %v", err)
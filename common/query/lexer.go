@@ -0,0 +1,95 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	endToken tokenKind = iota
+	numberToken
+	stringToken
+	identifierToken
+	operatorToken
+	leftParenToken
+	rightParenToken
+)
+
+type token struct {
+	kind     tokenKind
+	text     string
+	position int
+}
+
+// keywords Words that are not column names. They are case insensitive
+var keywords = map[string]bool{"AND": true, "OR": true, "NOT": true, "TRUE": true, "FALSE": true}
+
+// operators Ordered so the longest operators are matched first
+var operators = []string{"==", "!=", "<>", "<=", ">=", "=", "<", ">", "+", "-", "*", "/"}
+
+// tokenize Splits the expression in its tokens. The keywords are returned in upper case as operators
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: leftParenToken, text: "(", position: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: rightParenToken, text: ")", position: i})
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: numberToken, text: string(runes[start:i]), position: start})
+		case r == '\'' || r == '"':
+			start := i
+			i++
+			for i < len(runes) && runes[i] != r {
+				i++
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("string starting at %v is not closed", start)
+			}
+			tokens = append(tokens, token{kind: stringToken, text: string(runes[start+1 : i]), position: start})
+			i++
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			word := string(runes[start:i])
+			if keywords[strings.ToUpper(word)] {
+				tokens = append(tokens, token{kind: operatorToken, text: strings.ToUpper(word), position: start})
+			} else {
+				tokens = append(tokens, token{kind: identifierToken, text: word, position: start})
+			}
+		default:
+			operator := matchOperator(string(runes[i:]))
+			if operator == "" {
+				return nil, fmt.Errorf("unexpected character '%c' at %v", r, i)
+			}
+			tokens = append(tokens, token{kind: operatorToken, text: operator, position: i})
+			i += len(operator)
+		}
+	}
+	return append(tokens, token{kind: endToken, position: len(runes)}), nil
+}
+
+func matchOperator(rest string) string {
+	for _, operator := range operators {
+		if strings.HasPrefix(rest, operator) {
+			return operator
+		}
+	}
+	return ""
+}
@@ -0,0 +1,254 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+)
+
+// valueKind Type of the values of the expression. Every numeric column is evaluated as a number
+type valueKind int
+
+const (
+	numberKind valueKind = iota
+	stringKind
+	boolKind
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case numberKind:
+		return "number"
+	case stringKind:
+		return "string"
+	}
+	return "bool"
+}
+
+type value struct {
+	number  float64
+	str     string
+	boolean bool
+}
+
+// node Part of the expression, already type checked
+type node interface {
+	kind() valueKind
+	eval(row *dataStructures.DynamicMap) (value, error)
+}
+
+type literalNode struct {
+	valueKind valueKind
+	value     value
+}
+
+func (n *literalNode) kind() valueKind {
+	return n.valueKind
+}
+
+func (n *literalNode) eval(_ *dataStructures.DynamicMap) (value, error) {
+	return n.value, nil
+}
+
+type columnNode struct {
+	name       string
+	columnType dataStructures.ColumnType
+}
+
+// kindOfColumnType Returns the kind of the values of a column, or false if the type can not be used in an expression
+func kindOfColumnType(columnType dataStructures.ColumnType) (valueKind, bool) {
+	switch columnType {
+	case dataStructures.Int32Type, dataStructures.Int64Type, dataStructures.Float32Type, dataStructures.Float64Type:
+		return numberKind, true
+	case dataStructures.StringType:
+		return stringKind, true
+	case dataStructures.BoolType:
+		return boolKind, true
+	}
+	return 0, false
+}
+
+func (n *columnNode) kind() valueKind {
+	k, _ := kindOfColumnType(n.columnType)
+	return k
+}
+
+func (n *columnNode) eval(row *dataStructures.DynamicMap) (value, error) {
+	switch n.columnType {
+	case dataStructures.Int32Type:
+		v, err := row.GetAsInt(n.name)
+		return value{number: float64(v)}, err
+	case dataStructures.Int64Type:
+		v, err := row.GetAsInt64(n.name)
+		return value{number: float64(v)}, err
+	case dataStructures.Float32Type:
+		v, err := row.GetAsFloat(n.name)
+		return value{number: float64(v)}, err
+	case dataStructures.Float64Type:
+		v, err := row.GetAsFloat64(n.name)
+		return value{number: v}, err
+	case dataStructures.StringType:
+		v, err := row.GetAsString(n.name)
+		return value{str: v}, err
+	case dataStructures.BoolType:
+		v, err := row.GetAsBool(n.name)
+		return value{boolean: v}, err
+	}
+	return value{}, fmt.Errorf("column %v of type %v can not be evaluated", n.name, n.columnType)
+}
+
+type arithmeticNode struct {
+	operator    string
+	left, right node
+}
+
+func newArithmeticNode(operator string, left node, right node) (node, error) {
+	if left.kind() != numberKind || right.kind() != numberKind {
+		return nil, fmt.Errorf("operator %v needs numbers, got %v and %v", operator, left.kind(), right.kind())
+	}
+	return &arithmeticNode{operator: operator, left: left, right: right}, nil
+}
+
+func (n *arithmeticNode) kind() valueKind {
+	return numberKind
+}
+
+func (n *arithmeticNode) eval(row *dataStructures.DynamicMap) (value, error) {
+	left, err := n.left.eval(row)
+	if err != nil {
+		return value{}, err
+	}
+	right, err := n.right.eval(row)
+	if err != nil {
+		return value{}, err
+	}
+	switch n.operator {
+	case "+":
+		return value{number: left.number + right.number}, nil
+	case "-":
+		return value{number: left.number - right.number}, nil
+	case "*":
+		return value{number: left.number * right.number}, nil
+	}
+	if right.number == 0 {
+		return value{}, errors.New("division by zero")
+	}
+	return value{number: left.number / right.number}, nil
+}
+
+type negationNode struct {
+	operand node
+}
+
+func (n *negationNode) kind() valueKind {
+	return numberKind
+}
+
+func (n *negationNode) eval(row *dataStructures.DynamicMap) (value, error) {
+	operand, err := n.operand.eval(row)
+	return value{number: -operand.number}, err
+}
+
+type comparisonNode struct {
+	operator    string
+	left, right node
+}
+
+func newComparisonNode(operator string, left node, right node) (node, error) {
+	if left.kind() != right.kind() {
+		return nil, fmt.Errorf("can not compare a %v with a %v", left.kind(), right.kind())
+	}
+	isEquality := operator == "=" || operator == "==" || operator == "!=" || operator == "<>"
+	if left.kind() == boolKind && !isEquality {
+		return nil, fmt.Errorf("operator %v can not compare bools", operator)
+	}
+	return &comparisonNode{operator: operator, left: left, right: right}, nil
+}
+
+func (n *comparisonNode) kind() valueKind {
+	return boolKind
+}
+
+func (n *comparisonNode) eval(row *dataStructures.DynamicMap) (value, error) {
+	left, err := n.left.eval(row)
+	if err != nil {
+		return value{}, err
+	}
+	right, err := n.right.eval(row)
+	if err != nil {
+		return value{}, err
+	}
+	var comparison int
+	switch n.left.kind() {
+	case numberKind:
+		comparison = compare(left.number < right.number, left.number > right.number)
+	case stringKind:
+		comparison = compare(left.str < right.str, left.str > right.str)
+	case boolKind:
+		comparison = compare(false, left.boolean != right.boolean)
+	}
+	switch n.operator {
+	case "=", "==":
+		return value{boolean: comparison == 0}, nil
+	case "!=", "<>":
+		return value{boolean: comparison != 0}, nil
+	case "<":
+		return value{boolean: comparison < 0}, nil
+	case "<=":
+		return value{boolean: comparison <= 0}, nil
+	case ">":
+		return value{boolean: comparison > 0}, nil
+	}
+	return value{boolean: comparison >= 0}, nil
+}
+
+func compare(less bool, greater bool) int {
+	if less {
+		return -1
+	}
+	if greater {
+		return 1
+	}
+	return 0
+}
+
+// logicalNode AND and OR operators. The right side is only evaluated if needed
+type logicalNode struct {
+	operator    string
+	left, right node
+}
+
+func newLogicalNode(operator string, left node, right node) (node, error) {
+	if left.kind() != boolKind || right.kind() != boolKind {
+		return nil, fmt.Errorf("operator %v needs bools, got %v and %v", operator, left.kind(), right.kind())
+	}
+	return &logicalNode{operator: operator, left: left, right: right}, nil
+}
+
+func (n *logicalNode) kind() valueKind {
+	return boolKind
+}
+
+func (n *logicalNode) eval(row *dataStructures.DynamicMap) (value, error) {
+	left, err := n.left.eval(row)
+	if err != nil {
+		return value{}, err
+	}
+	if (n.operator == "AND" && !left.boolean) || (n.operator == "OR" && left.boolean) {
+		return left, nil
+	}
+	return n.right.eval(row)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) kind() valueKind {
+	return boolKind
+}
+
+func (n *notNode) eval(row *dataStructures.DynamicMap) (value, error) {
+	operand, err := n.operand.eval(row)
+	return value{boolean: !operand.boolean}, err
+}
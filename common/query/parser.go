@@ -0,0 +1,172 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// parser Recursive descent parser that type checks each node against the schema as it is built
+/*
+	Grammar, from lowest to highest precedence:
+		or         := and ("OR" and)*
+		and        := not ("AND" not)*
+		not        := "NOT" not | comparison
+		comparison := additive (("=" | "==" | "!=" | "<>" | "<" | "<=" | ">" | ">=") additive)?
+		additive   := term (("+" | "-") term)*
+		term       := unary (("*" | "/") unary)*
+		unary      := "-" unary | primary
+		primary    := number | string | "TRUE" | "FALSE" | column | "(" or ")"
+*/
+type parser struct {
+	tokens  []token
+	current int
+	schema  Schema
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.current]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.current]
+	if t.kind != endToken {
+		p.current++
+	}
+	return t
+}
+
+// acceptOperator Consumes the next token if it is one of the operators received
+func (p *parser) acceptOperator(operators ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != operatorToken {
+		return "", false
+	}
+	for _, operator := range operators {
+		if t.text == operator {
+			p.current++
+			return operator, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseBinary(p.parseAnd, newLogicalNode, "OR")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseBinary(p.parseNot, newLogicalNode, "AND")
+}
+
+func (p *parser) parseNot() (node, error) {
+	if _, ok := p.acceptOperator("NOT"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if operand.kind() != boolKind {
+			return nil, fmt.Errorf("operator NOT needs a bool, got %v", operand.kind())
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	operator, ok := p.acceptOperator("=", "==", "!=", "<>", "<", "<=", ">", ">=")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	return newComparisonNode(operator, left, right)
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	return p.parseBinary(p.parseTerm, newArithmeticNode, "+", "-")
+}
+
+func (p *parser) parseTerm() (node, error) {
+	return p.parseBinary(p.parseUnary, newArithmeticNode, "*", "/")
+}
+
+// parseBinary Parses left associative operators with the same precedence
+func (p *parser) parseBinary(operand func() (node, error), newNode func(string, node, node) (node, error), operators ...string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.acceptOperator(operators...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left, err = newNode(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if _, ok := p.acceptOperator("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if operand.kind() != numberKind {
+			return nil, fmt.Errorf("operator - needs a number, got %v", operand.kind())
+		}
+		return &negationNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case numberToken:
+		number, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %v at %v", t.text, t.position)
+		}
+		return &literalNode{valueKind: numberKind, value: value{number: number}}, nil
+	case stringToken:
+		return &literalNode{valueKind: stringKind, value: value{str: t.text}}, nil
+	case identifierToken:
+		columnType, exists := p.schema[t.text]
+		if !exists {
+			return nil, fmt.Errorf("unknown column %v at %v", t.text, t.position)
+		}
+		if _, ok := kindOfColumnType(columnType); !ok {
+			return nil, fmt.Errorf("column %v of type %v can not be used in an expression", t.text, columnType)
+		}
+		return &columnNode{name: t.text, columnType: columnType}, nil
+	case leftParenToken:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != rightParenToken {
+			return nil, fmt.Errorf("expected ) at %v", closing.position)
+		}
+		return inner, nil
+	case operatorToken:
+		if t.text == "TRUE" || t.text == "FALSE" {
+			return &literalNode{valueKind: boolKind, value: value{boolean: t.text == "TRUE"}}, nil
+		}
+	case endToken:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %v at %v", t.text, t.position)
+}
@@ -0,0 +1,68 @@
+package query
+
+import (
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"strings"
+)
+
+// Schema Types of the columns that an expression can use
+type Schema map[string]dataStructures.ColumnType
+
+// ParseSchema Parses a schema written as column:type separated by commas, for example "totalStopovers:int32,route:string"
+func ParseSchema(schemaString string) (Schema, error) {
+	schema := make(Schema)
+	for _, column := range strings.Split(schemaString, utils.CommaSeparator) {
+		nameAndType := strings.Split(strings.TrimSpace(column), utils.ColonSeparator)
+		if len(nameAndType) != 2 || nameAndType[0] == "" {
+			return nil, fmt.Errorf("column '%v' of the schema is not in the format column:type", column)
+		}
+		columnType, err := dataStructures.ParseColumnType(nameAndType[1])
+		if err != nil {
+			return nil, fmt.Errorf("column %v of the schema: %v", nameAndType[0], err)
+		}
+		schema[nameAndType[0]] = columnType
+	}
+	return schema, nil
+}
+
+// Predicate Boolean expression compiled against a schema, that is evaluated over each row
+type Predicate struct {
+	expression string
+	root       node
+}
+
+// Compile Parses the expression and checks the types of its columns and operators with the schema.
+// The expression has to be a condition, for example "totalStopovers >= 3 AND totalTravelDistance > 4 * directDistance"
+func Compile(expression string, schema Schema) (*Predicate, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression '%v': %v", expression, err)
+	}
+	p := &parser{tokens: tokens, schema: schema}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression '%v': %v", expression, err)
+	}
+	if t := p.peek(); t.kind != endToken {
+		return nil, fmt.Errorf("invalid expression '%v': unexpected %v at %v", expression, t.text, t.position)
+	}
+	if root.kind() != boolKind {
+		return nil, fmt.Errorf("invalid expression '%v': it is a %v, not a condition", expression, root.kind())
+	}
+	return &Predicate{expression: expression, root: root}, nil
+}
+
+// Evaluate Returns true if the row meets the condition. Fails if a column is missing or has another type than in the schema
+func (p *Predicate) Evaluate(row *dataStructures.DynamicMap) (bool, error) {
+	result, err := p.root.eval(row)
+	if err != nil {
+		return false, err
+	}
+	return result.boolean, nil
+}
+
+func (p *Predicate) String() string {
+	return p.expression
+}
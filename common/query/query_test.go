@@ -0,0 +1,130 @@
+package query
+
+import (
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testSchema = Schema{
+	"totalStopovers":      dataStructures.Int32Type,
+	"totalTravelDistance": dataStructures.Float32Type,
+	"directDistance":      dataStructures.Float32Type,
+	"startingAirport":     dataStructures.StringType,
+	"isNonStop":           dataStructures.BoolType,
+	"segments":            dataStructures.ListType,
+}
+
+func createRow(stopovers int32, totalDistance float32, directDistance float32, airport string) *dataStructures.DynamicMap {
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["totalStopovers"] = dataStructures.NewInt32Column(stopovers)
+	dynMap["totalTravelDistance"] = dataStructures.NewFloat32Column(totalDistance)
+	dynMap["directDistance"] = dataStructures.NewFloat32Column(directDistance)
+	dynMap["startingAirport"] = dataStructures.NewStringColumn(airport)
+	dynMap["isNonStop"] = dataStructures.NewBoolColumn(stopovers == 0)
+	return dataStructures.NewDynamicMap(dynMap)
+}
+
+func evaluate(t *testing.T, expression string, row *dataStructures.DynamicMap) bool {
+	predicate, err := Compile(expression, testSchema)
+	assert.Nil(t, err)
+	result, err := predicate.Evaluate(row)
+	assert.Nil(t, err)
+	return result
+}
+
+func TestCompiledExpressionWithAndAndArithmeticFiltersTheRows(t *testing.T) {
+	expression := "totalStopovers >= 3 AND totalTravelDistance > 4 * directDistance"
+
+	assert.True(t, evaluate(t, expression, createRow(3, 9, 2, "ATL")))
+	assert.False(t, evaluate(t, expression, createRow(2, 9, 2, "ATL")))
+	assert.False(t, evaluate(t, expression, createRow(3, 8, 2, "ATL")))
+}
+
+func TestOperatorsFollowTheirPrecedence(t *testing.T) {
+	row := createRow(1, 10, 2, "ATL")
+
+	assert.True(t, evaluate(t, "totalTravelDistance = 2 + 4 * directDistance", row))
+	assert.True(t, evaluate(t, "totalTravelDistance = (1 + 4) * directDistance", row))
+	assert.True(t, evaluate(t, "totalStopovers = 0 OR totalStopovers = 1 AND startingAirport = 'ATL'", row))
+	assert.False(t, evaluate(t, "(totalStopovers = 0 OR totalStopovers = 1) AND startingAirport = 'EZE'", row))
+	assert.True(t, evaluate(t, "NOT isNonStop and -totalStopovers < 0", row))
+}
+
+func TestComparisonsOfStringsAndBools(t *testing.T) {
+	row := createRow(0, 10, 2, "JFK")
+
+	assert.True(t, evaluate(t, `startingAirport != "ATL"`, row))
+	assert.True(t, evaluate(t, "startingAirport > 'ATL'", row))
+	assert.True(t, evaluate(t, "isNonStop = TRUE", row))
+	assert.False(t, evaluate(t, "isNonStop <> true", row))
+}
+
+func TestCompileWithTypeErrorsReturnsError(t *testing.T) {
+	invalidExpressions := []string{
+		"totalStopovers >= 'three'",
+		"startingAirport + 1 > 2",
+		"totalStopovers AND isNonStop",
+		"isNonStop > false",
+		"NOT totalStopovers",
+		"totalStopovers + 3",
+		"segments = 'ATL'",
+		"unknownColumn > 3",
+	}
+	for _, expression := range invalidExpressions {
+		_, err := Compile(expression, testSchema)
+		assert.Error(t, err, "expression should not compile: %v", expression)
+	}
+}
+
+func TestCompileWithSyntaxErrorsReturnsError(t *testing.T) {
+	invalidExpressions := []string{
+		"",
+		"totalStopovers >=",
+		"(totalStopovers >= 3",
+		"totalStopovers >= 3)",
+		"totalStopovers >= 3 3",
+		"startingAirport = 'ATL",
+		"totalStopovers # 3",
+	}
+	for _, expression := range invalidExpressions {
+		_, err := Compile(expression, testSchema)
+		assert.Error(t, err, "expression should not compile: %v", expression)
+	}
+}
+
+func TestEvaluateRowWithoutAColumnOrWithAnotherTypeReturnsError(t *testing.T) {
+	predicate, err := Compile("totalStopovers >= 3", testSchema)
+	assert.Nil(t, err)
+
+	dynMap := make(map[string]dataStructures.Column)
+	_, err = predicate.Evaluate(dataStructures.NewDynamicMap(dynMap))
+	assert.Error(t, err)
+
+	dynMap["totalStopovers"] = dataStructures.NewStringColumn("3")
+	_, err = predicate.Evaluate(dataStructures.NewDynamicMap(dynMap))
+	assert.Error(t, err)
+}
+
+func TestEvaluateDivisionByZeroReturnsError(t *testing.T) {
+	predicate, err := Compile("totalTravelDistance / directDistance > 2", testSchema)
+	assert.Nil(t, err)
+
+	_, err = predicate.Evaluate(createRow(1, 10, 0, "ATL"))
+	assert.Error(t, err)
+}
+
+func TestParseSchemaReturnsTheTypeOfEachColumn(t *testing.T) {
+	schema, err := ParseSchema("totalStopovers:int32, totalTravelDistance:float32,route:string")
+	assert.Nil(t, err)
+	assert.Equal(t, Schema{
+		"totalStopovers":      dataStructures.Int32Type,
+		"totalTravelDistance": dataStructures.Float32Type,
+		"route":               dataStructures.StringType,
+	}, schema)
+
+	_, err = ParseSchema("totalStopovers:decimal")
+	assert.Error(t, err)
+	_, err = ParseSchema("totalStopovers")
+	assert.Error(t, err)
+}
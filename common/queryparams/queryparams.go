@@ -0,0 +1,248 @@
+package queryparams
+
+import (
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	log "github.com/sirupsen/logrus"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PriceThreshold Prices of a journey used to calculate its average and maximum in the fourth query
+type PriceThreshold string
+
+const (
+	// AboveAveragePrices Only the prices greater than the average of every flight
+	AboveAveragePrices PriceThreshold = "above_avg"
+	// AllPrices Every price of the journey
+	AllPrices PriceThreshold = "all"
+)
+
+const DefaultMinStopovers = 3
+const DefaultDistanceFactor = 4
+const DefaultFastestFlights = 2
+const DefaultPriceThreshold = AboveAveragePrices
+
+// QueriesCount Queries that the system answers, numbered from 1
+const QueriesCount = 4
+
+// QueryParams Parameters of the queries that a client chooses when the session starts
+type QueryParams struct {
+	// MinStopovers Stopovers that a flight needs to be in the results of the first query
+	MinStopovers int
+	// DistanceFactor Times the direct distance that a flight has to exceed to be in the results of the second query
+	DistanceFactor float32
+	// FastestFlights Flights kept for each journey in the third query
+	FastestFlights int
+	// PriceThreshold Prices used for each journey in the fourth query
+	PriceThreshold PriceThreshold
+	// Queries Queries requested by the client, sorted. The stages of the other queries only receive the EOF
+	Queries []int
+}
+
+// Default Returns the parameters of the original queries
+func Default() QueryParams {
+	return QueryParams{
+		MinStopovers:   DefaultMinStopovers,
+		DistanceFactor: DefaultDistanceFactor,
+		FastestFlights: DefaultFastestFlights,
+		PriceThreshold: DefaultPriceThreshold,
+		Queries:        AllQueries(),
+	}
+}
+
+// AllQueries Returns the number of every query
+func AllQueries() []int {
+	queries := make([]int, 0, QueriesCount)
+	for query := 1; query <= QueriesCount; query++ {
+		queries = append(queries, query)
+	}
+	return queries
+}
+
+// ParseQueries Returns the sorted queries of a list of numbers separated by commas or spaces
+func ParseQueries(list string) ([]int, error) {
+	var queries []int
+	for _, field := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ' ' }) {
+		query, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query %v: %v", field, err)
+		}
+		queries = append(queries, query)
+	}
+	sort.Ints(queries)
+	return queries, validateQueries(queries)
+}
+
+func validateQueries(queries []int) error {
+	if len(queries) == 0 {
+		return fmt.Errorf("at least one query must be requested")
+	}
+	for i, query := range queries {
+		if query < 1 || query > QueriesCount {
+			return fmt.Errorf("unknown query %v, expected a value between 1 and %v", query, QueriesCount)
+		}
+		if i > 0 && queries[i-1] == query {
+			return fmt.Errorf("query %v is requested twice", query)
+		}
+	}
+	return nil
+}
+
+// ParseOutputQueries Returns the queries fed by each output of a stage, from a list of outputs separated
+// by commas with the form output:queries, where the queries are separated by spaces. For example "filters:1 3,distances:2"
+func ParseOutputQueries(list string) (map[string][]int, error) {
+	outputQueries := make(map[string][]int)
+	for _, output := range strings.Split(list, ",") {
+		output = strings.TrimSpace(output)
+		if output == "" {
+			continue
+		}
+		name, queriesList, found := strings.Cut(output, ":")
+		if !found {
+			return nil, fmt.Errorf("missing the queries of output %v", output)
+		}
+		queries, err := ParseQueries(queriesList)
+		if err != nil {
+			return nil, fmt.Errorf("invalid queries of output %v: %v", name, err)
+		}
+		outputQueries[name] = queries
+	}
+	return outputQueries, nil
+}
+
+// Requests Returns true if the client requested the query
+func (p QueryParams) Requests(query int) bool {
+	return slices.Contains(p.Queries, query)
+}
+
+// RequestsAny Returns true if the client requested one of the queries
+func (p QueryParams) RequestsAny(queries []int) bool {
+	for _, query := range queries {
+		if p.Requests(query) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePriceThreshold Returns the price threshold with that name
+func ParsePriceThreshold(name string) (PriceThreshold, error) {
+	switch PriceThreshold(name) {
+	case AboveAveragePrices, AllPrices:
+		return PriceThreshold(name), nil
+	}
+	return "", fmt.Errorf("unknown price threshold %v, expected %v or %v", name, AboveAveragePrices, AllPrices)
+}
+
+// Validate Returns an error if a parameter is out of range
+func (p QueryParams) Validate() error {
+	if p.MinStopovers < 0 {
+		return fmt.Errorf("the minimum stopovers can not be negative: %v", p.MinStopovers)
+	}
+	if p.DistanceFactor <= 0 {
+		return fmt.Errorf("the distance factor must be positive: %v", p.DistanceFactor)
+	}
+	if p.FastestFlights <= 0 {
+		return fmt.Errorf("at least one of the fastest flights must be kept: %v", p.FastestFlights)
+	}
+	if _, err := ParsePriceThreshold(string(p.PriceThreshold)); err != nil {
+		return err
+	}
+	return validateQueries(p.Queries)
+}
+
+// ToDynMap Returns the parameters as the dynamic map sent in the messages
+func (p QueryParams) ToDynMap() *dataStructures.DynamicMap {
+	params := dataStructures.NewDynamicMap(make(map[string]dataStructures.Column))
+	params.AddColumn(utils.MinStopovers, dataStructures.NewInt32Column(int32(p.MinStopovers)))
+	params.AddColumn(utils.DistanceFactor, dataStructures.NewFloat32Column(p.DistanceFactor))
+	params.AddColumn(utils.FastestFlights, dataStructures.NewInt32Column(int32(p.FastestFlights)))
+	params.AddColumn(utils.PriceThreshold, dataStructures.NewStringColumn(string(p.PriceThreshold)))
+	queries := make([]string, 0, len(p.Queries))
+	for _, query := range p.Queries {
+		queries = append(queries, strconv.Itoa(query))
+	}
+	params.AddColumn(utils.Queries, dataStructures.NewListColumn(queries))
+	return params
+}
+
+// FromDynMap Reads the parameters of the dynamic map. The ones that are missing keep their default value
+func FromDynMap(dynMap *dataStructures.DynamicMap) (QueryParams, error) {
+	params := Default()
+	columns := dynMap.GetCurrentMap()
+	var err error
+	if _, exists := columns[utils.MinStopovers]; exists {
+		if params.MinStopovers, err = dynMap.GetAsInt(utils.MinStopovers); err != nil {
+			return params, err
+		}
+	}
+	if _, exists := columns[utils.DistanceFactor]; exists {
+		if params.DistanceFactor, err = dynMap.GetAsFloat(utils.DistanceFactor); err != nil {
+			return params, err
+		}
+	}
+	if _, exists := columns[utils.FastestFlights]; exists {
+		if params.FastestFlights, err = dynMap.GetAsInt(utils.FastestFlights); err != nil {
+			return params, err
+		}
+	}
+	if _, exists := columns[utils.PriceThreshold]; exists {
+		threshold, err := dynMap.GetAsString(utils.PriceThreshold)
+		if err != nil {
+			return params, err
+		}
+		params.PriceThreshold = PriceThreshold(threshold)
+	}
+	if _, exists := columns[utils.Queries]; exists {
+		queries, err := dynMap.GetAsList(utils.Queries)
+		if err != nil {
+			return params, err
+		}
+		if params.Queries, err = ParseQueries(strings.Join(queries, ",")); err != nil {
+			return params, err
+		}
+	}
+	return params, params.Validate()
+}
+
+// Registry Parameters of each client seen by a stage. The messages carry the parameters of their client,
+// so the registry is updated with each one and keeps them for the messages that do not have them
+type Registry struct {
+	mutex  sync.Mutex
+	params map[string]QueryParams
+}
+
+func NewRegistry() *Registry {
+	return &Registry{params: make(map[string]QueryParams)}
+}
+
+// Of Returns the parameters of the client of the message. Without parameters the default ones are used
+func (r *Registry) Of(msg *dataStructures.Message) QueryParams {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if msg.Params != nil {
+		params, err := FromDynMap(msg.Params)
+		if err == nil {
+			r.params[msg.ClientId] = params
+			return params
+		}
+		log.Errorf("QueryParams | Invalid parameters of client %v | %v | Using the previous ones", msg.ClientId, err)
+	}
+	params, exists := r.params[msg.ClientId]
+	if !exists {
+		return Default()
+	}
+	return params
+}
+
+// Remove Forgets the parameters of the client
+func (r *Registry) Remove(clientId string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.params, clientId)
+}
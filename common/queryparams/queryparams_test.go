@@ -0,0 +1,82 @@
+package queryparams
+
+import (
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestQueryParamsToDynMapAndBackReturnsTheSameParams(t *testing.T) {
+	params := QueryParams{MinStopovers: 1, DistanceFactor: 2.5, FastestFlights: 5, PriceThreshold: AllPrices, Queries: []int{2, 4}}
+	parsed, err := FromDynMap(params.ToDynMap())
+	assert.Nil(t, err)
+	assert.Equal(t, params, parsed)
+}
+
+func TestFromDynMapKeepsTheDefaultOfTheMissingParams(t *testing.T) {
+	dynMap := dataStructures.NewDynamicMap(map[string]dataStructures.Column{utils.FastestFlights: dataStructures.NewInt32Column(3)})
+	params, err := FromDynMap(dynMap)
+	assert.Nil(t, err)
+	expected := Default()
+	expected.FastestFlights = 3
+	assert.Equal(t, expected, params)
+}
+
+func TestFromDynMapWithInvalidParamsReturnsError(t *testing.T) {
+	params := Default()
+	params.FastestFlights = 0
+	_, err := FromDynMap(params.ToDynMap())
+	assert.Error(t, err)
+
+	params = Default()
+	params.PriceThreshold = "median"
+	_, err = FromDynMap(params.ToDynMap())
+	assert.Error(t, err)
+
+	params = Default()
+	params.Queries = []int{1, 5}
+	_, err = FromDynMap(params.ToDynMap())
+	assert.Error(t, err)
+}
+
+func TestParseQueriesReturnsTheSortedQueries(t *testing.T) {
+	queries, err := ParseQueries("4, 1 3")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 3, 4}, queries)
+
+	_, err = ParseQueries("1,1")
+	assert.Error(t, err)
+	_, err = ParseQueries("")
+	assert.Error(t, err)
+}
+
+func TestParseOutputQueriesReturnsTheQueriesOfEachOutput(t *testing.T) {
+	outputQueries, err := ParseOutputQueries("filters_stopovers:1 3,distance_calculator:2")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]int{"filters_stopovers": {1, 3}, "distance_calculator": {2}}, outputQueries)
+
+	_, err = ParseOutputQueries("filters_stopovers")
+	assert.Error(t, err)
+}
+
+func TestRequestsAnyReturnsTrueIfOneOfTheQueriesWasRequested(t *testing.T) {
+	params := Default()
+	params.Queries = []int{4}
+	assert.True(t, params.RequestsAny([]int{2, 4}))
+	assert.False(t, params.RequestsAny([]int{1, 3}))
+}
+
+func TestRegistryKeepsTheParamsOfEachClient(t *testing.T) {
+	registry := NewRegistry()
+	params := Default()
+	params.MinStopovers = 1
+	withParams := &dataStructures.Message{ClientId: "client-1", Params: params.ToDynMap()}
+	assert.Equal(t, params, registry.Of(withParams))
+
+	assert.Equal(t, params, registry.Of(&dataStructures.Message{ClientId: "client-1"}))
+	assert.Equal(t, Default(), registry.Of(&dataStructures.Message{ClientId: "client-2"}))
+
+	registry.Remove("client-1")
+	assert.Equal(t, Default(), registry.Of(&dataStructures.Message{ClientId: "client-1"}))
+}
@@ -2,25 +2,25 @@ package queuefactory
 
 import (
 	"fmt"
-	"github.com/brunograssano/Distribuidos-TP1/common/duplicates"
 	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	log "github.com/sirupsen/logrus"
 )
 
 type DirectExchangeConsumerSimpleProdQueueFactory struct {
-	qMiddleware middleware.QueueMiddlewareI
-	routingKey  uint
-	counter     uint
+	qMiddleware  middleware.QueueMiddlewareI
+	routingKey   uint
+	counter      uint
+	compressions queues.Compressions
 }
 
-func NewDirectExchangeConsumerSimpleProdQueueFactory(qMiddleware middleware.QueueMiddlewareI, routingKey uint) QueueProtocolFactory {
-	return &DirectExchangeConsumerSimpleProdQueueFactory{qMiddleware: qMiddleware, routingKey: routingKey, counter: uint(0)}
+func NewDirectExchangeConsumerSimpleProdQueueFactory(qMiddleware middleware.QueueMiddlewareI, routingKey uint, compressions queues.Compressions) QueueProtocolFactory {
+	return &DirectExchangeConsumerSimpleProdQueueFactory{qMiddleware: qMiddleware, routingKey: routingKey, counter: uint(0), compressions: compressions}
 }
 
 func (d *DirectExchangeConsumerSimpleProdQueueFactory) CreateProducer(queueName string) queues.ProducerProtocolInterface {
 	p := d.qMiddleware.CreateProducer(queueName, true)
-	return queues.NewProducerQueueProtocolHandler(p)
+	return queues.NewProducerQueueProtocolHandler(p, d.compressions.Of(queueName))
 }
 
 func (d *DirectExchangeConsumerSimpleProdQueueFactory) CreateConsumer(queueName string) queues.ConsumerProtocolInterface {
@@ -31,5 +31,5 @@ func (d *DirectExchangeConsumerSimpleProdQueueFactory) CreateConsumer(queueName
 		log.Fatalf("DirectExchangeConsumerSimpleProdQueueFactory | Error creating consumer: %v", err)
 	}
 	d.counter++
-	return queues.NewConsumerQueueProtocolHandler(consumerQueue, duplicates.NewDuplicatesHandler(consumerQueue.GetName()))
+	return newConsumerProtocolHandler(d.qMiddleware, consumerQueue, d.compressions)
 }
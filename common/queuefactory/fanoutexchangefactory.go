@@ -1,7 +1,6 @@
 package queuefactory
 
 import (
-	"github.com/brunograssano/Distribuidos-TP1/common/duplicates"
 	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	log "github.com/sirupsen/logrus"
@@ -11,15 +10,16 @@ type FanoutExchangeQueueFactory struct {
 	qMiddleware  middleware.QueueMiddlewareI
 	exchangeName string
 	routingKey   string
+	compressions queues.Compressions
 }
 
-func NewFanoutExchangeQueueFactory(qMiddleware middleware.QueueMiddlewareI, exchangeName string, routingKey string) QueueProtocolFactory {
-	return &FanoutExchangeQueueFactory{qMiddleware: qMiddleware, exchangeName: exchangeName, routingKey: routingKey}
+func NewFanoutExchangeQueueFactory(qMiddleware middleware.QueueMiddlewareI, exchangeName string, routingKey string, compressions queues.Compressions) QueueProtocolFactory {
+	return &FanoutExchangeQueueFactory{qMiddleware: qMiddleware, exchangeName: exchangeName, routingKey: routingKey, compressions: compressions}
 }
 
 func (s *FanoutExchangeQueueFactory) CreateProducer(_ string) queues.ProducerProtocolInterface {
 	producer := s.qMiddleware.CreateExchangeProducer(s.exchangeName, s.routingKey, "fanout", true)
-	return queues.NewProducerQueueProtocolHandler(producer)
+	return queues.NewProducerQueueProtocolHandler(producer, s.compressions.Of(s.exchangeName))
 }
 
 func (s *FanoutExchangeQueueFactory) CreateConsumer(queueName string) queues.ConsumerProtocolInterface {
@@ -28,5 +28,5 @@ func (s *FanoutExchangeQueueFactory) CreateConsumer(queueName string) queues.Con
 	if err != nil {
 		log.Fatalf("FanoutExchangeQueueFactory | Error trying to bind the consumer's queue to the exchange | %v", err)
 	}
-	return queues.NewConsumerQueueProtocolHandler(consumer, duplicates.NewDuplicatesHandler(consumer.GetName()))
+	return newConsumerProtocolHandler(s.qMiddleware, consumer, s.compressions)
 }
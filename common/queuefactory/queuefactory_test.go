@@ -0,0 +1,88 @@
+package queuefactory
+
+import (
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newTestMessage(clientId string, msgId uint) *dataStructures.Message {
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["col"] = dataStructures.NewInt32Column(int32(msgId))
+	return dataStructures.NewCompleteMessage(dataStructures.FlightRows, []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMap)}, clientId, msgId)
+}
+
+func popWithTimeout(t *testing.T, consumer queues.ConsumerProtocolInterface) *dataStructures.Message {
+	result := make(chan *dataStructures.Message, 1)
+	go func() {
+		msg, err := consumer.Pop()
+		if err == nil {
+			result <- msg
+		}
+	}()
+	select {
+	case msg := <-result:
+		return msg
+	case <-time.After(1 * time.Second):
+		t.Fatalf("Timeout! Should have received a message")
+	}
+	return nil
+}
+
+func TestSimpleFactoryWithInMemoryMiddleware(t *testing.T) {
+	qm := middleware.NewInMemoryQueueMiddleware(middleware.NewInMemoryBroker())
+	defer qm.Close()
+	factory := NewSimpleQueueFactory(qm, nil)
+	consumer := factory.CreateConsumer("queue")
+	assert.Nil(t, factory.CreateProducer("queue").Send(newTestMessage("client", 1)))
+
+	msg := popWithTimeout(t, consumer)
+	assert.Equal(t, "client", msg.ClientId)
+	assert.Equal(t, uint(1), msg.MessageId)
+	assert.Equal(t, 1, consumer.GetReceivedMessages("client"))
+}
+
+func TestFanoutFactoryWithInMemoryMiddleware(t *testing.T) {
+	qm := middleware.NewInMemoryQueueMiddleware(middleware.NewInMemoryBroker())
+	defer qm.Close()
+	factory := NewFanoutExchangeQueueFactory(qm, "exchange", "", nil)
+	firstConsumer := factory.CreateConsumer("first")
+	secondConsumer := factory.CreateConsumer("second")
+	assert.Nil(t, factory.CreateProducer("").Send(newTestMessage("client", 1)))
+
+	assert.Equal(t, uint(1), popWithTimeout(t, firstConsumer).MessageId)
+	assert.Equal(t, uint(1), popWithTimeout(t, secondConsumer).MessageId)
+}
+
+func TestTopicFactoryWithInMemoryMiddleware(t *testing.T) {
+	broker := middleware.NewInMemoryBroker()
+	qm := middleware.NewInMemoryQueueMiddleware(broker)
+	defer qm.Close()
+	consumer := NewTopicFactory(qm, []string{"ex1", "ex2"}, "exchange", nil).CreateConsumer("saver")
+	NewTopicFactory(qm, []string{"ex3"}, "exchange", nil).CreateConsumer("other")
+	factory := NewTopicFactory(qm, []string{}, "exchange", nil)
+	assert.Nil(t, factory.CreateProducer("ex2").Send(newTestMessage("client", 1)))
+
+	assert.Equal(t, uint(1), popWithTimeout(t, consumer).MessageId)
+	assert.Equal(t, 0, broker.QueueLength("other"))
+}
+
+func TestDirectExchangeFactoriesWithInMemoryMiddleware(t *testing.T) {
+	qm := middleware.NewInMemoryQueueMiddleware(middleware.NewInMemoryBroker())
+	defer qm.Close()
+	consumerFactory := NewDirectExchangeConsumerSimpleProdQueueFactory(qm, 0, nil)
+	firstConsumer := consumerFactory.CreateConsumer("exchange")
+	secondConsumer := consumerFactory.CreateConsumer("exchange")
+	producerFactory := NewDirectExchangeProducerSimpleConsQueueFactory(qm, nil)
+	firstProducer := producerFactory.CreateProducer("exchange")
+	secondProducer := producerFactory.CreateProducer("exchange")
+
+	assert.Nil(t, secondProducer.Send(newTestMessage("client", 2)))
+	assert.Nil(t, firstProducer.Send(newTestMessage("client", 1)))
+
+	assert.Equal(t, uint(1), popWithTimeout(t, firstConsumer).MessageId)
+	assert.Equal(t, uint(2), popWithTimeout(t, secondConsumer).MessageId)
+}
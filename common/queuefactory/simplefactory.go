@@ -1,25 +1,36 @@
 package queuefactory
 
 import (
+	"github.com/brunograssano/Distribuidos-TP1/common/deadletter"
 	"github.com/brunograssano/Distribuidos-TP1/common/duplicates"
 	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 )
 
 type SimpleQueueFactory struct {
-	qMiddleware middleware.QueueMiddlewareI
+	qMiddleware  middleware.QueueMiddlewareI
+	compressions queues.Compressions
 }
 
-func NewSimpleQueueFactory(qMiddleware middleware.QueueMiddlewareI) QueueProtocolFactory {
-	return &SimpleQueueFactory{qMiddleware: qMiddleware}
+func NewSimpleQueueFactory(qMiddleware middleware.QueueMiddlewareI, compressions queues.Compressions) QueueProtocolFactory {
+	return &SimpleQueueFactory{qMiddleware: qMiddleware, compressions: compressions}
 }
 
 func (s *SimpleQueueFactory) CreateProducer(queueName string) queues.ProducerProtocolInterface {
 	producer := s.qMiddleware.CreateProducer(queueName, true)
-	return queues.NewProducerQueueProtocolHandler(producer)
+	return queues.NewProducerQueueProtocolHandler(producer, s.compressions.Of(producer.GetName()))
 }
 
 func (s *SimpleQueueFactory) CreateConsumer(queueName string) queues.ConsumerProtocolInterface {
 	consumer := s.qMiddleware.CreateConsumer(queueName, true)
-	return queues.NewConsumerQueueProtocolHandler(consumer, duplicates.NewDuplicatesHandler(consumer.GetName()))
+	return newConsumerProtocolHandler(s.qMiddleware, consumer, s.compressions)
+}
+
+// newConsumerProtocolHandler Wraps the consumer with its detector of duplicates, the dead-letter queue of the stage
+// and the delayed retries of its queue
+func newConsumerProtocolHandler(qMiddleware middleware.QueueMiddlewareI, consumer middleware.ConsumerInterface, compressions queues.Compressions) queues.ConsumerProtocolInterface {
+	deadLetterQueue := deadletter.QueueName(consumer.GetName())
+	deadLetters := queues.NewProducerQueueProtocolHandler(qMiddleware.CreateProducer(deadLetterQueue, true), compressions.Of(deadLetterQueue))
+	retries := qMiddleware.CreateRetryProducer(consumer.GetName(), queues.GetRetryPolicy())
+	return queues.NewConsumerQueueProtocolHandler(consumer, duplicates.NewDuplicatesHandler(consumer.GetName()), deadLetters, retries)
 }
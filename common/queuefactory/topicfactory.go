@@ -1,7 +1,6 @@
 package queuefactory
 
 import (
-	"github.com/brunograssano/Distribuidos-TP1/common/duplicates"
 	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	log "github.com/sirupsen/logrus"
@@ -11,15 +10,16 @@ type TopicFactory struct {
 	qMiddleware  middleware.QueueMiddlewareI
 	routingKeys  []string
 	exchangeName string
+	compressions queues.Compressions
 }
 
-func NewTopicFactory(qMiddleware middleware.QueueMiddlewareI, routingKeys []string, exchangeName string) QueueProtocolFactory {
-	return &TopicFactory{qMiddleware, routingKeys, exchangeName}
+func NewTopicFactory(qMiddleware middleware.QueueMiddlewareI, routingKeys []string, exchangeName string, compressions queues.Compressions) QueueProtocolFactory {
+	return &TopicFactory{qMiddleware, routingKeys, exchangeName, compressions}
 }
 
 func (t *TopicFactory) CreateProducer(routingKey string) queues.ProducerProtocolInterface {
 	producer := t.qMiddleware.CreateExchangeProducer(t.exchangeName, routingKey, "topic", true)
-	return queues.NewProducerQueueProtocolHandler(producer)
+	return queues.NewProducerQueueProtocolHandler(producer, t.compressions.Of(t.exchangeName))
 }
 
 func (t *TopicFactory) CreateConsumer(queueName string) queues.ConsumerProtocolInterface {
@@ -31,5 +31,5 @@ func (t *TopicFactory) CreateConsumer(queueName string) queues.ConsumerProtocolI
 		}
 	}
 
-	return queues.NewConsumerQueueProtocolHandler(consumer, duplicates.NewDuplicatesHandler(consumer.GetName()))
+	return newConsumerProtocolHandler(t.qMiddleware, consumer, t.compressions)
 }
@@ -0,0 +1,278 @@
+package retention
+
+import (
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Reasons why the artifacts of a client are removed
+const (
+	FetchedReason = "fetched"
+	ExpiredReason = "expired"
+	ManualReason  = "manual"
+)
+
+// PurgeCommand Command of the services that removes the artifacts of a client
+const PurgeCommand = "purge"
+
+// lockSuffix Suffix of the file locked while the index and the artifacts change, next to the index
+const lockSuffix = ".lock"
+
+// clientArtifacts When the artifacts of a client were written and fetched. A zero fetchedAt means that they were not fetched
+type clientArtifacts struct {
+	writtenAt time.Time
+	fetchedAt time.Time
+}
+
+// Collector Removes the artifacts of the clients after their results are fetched or they expire.
+// The clients with artifacts are kept in the index file, so they survive a restart of the service
+// and the purge command run in another process sees them. The changes lock a file next to the index,
+// so the service and the purge command do not overwrite the changes of the other one
+type Collector struct {
+	c         *Config
+	artifacts func(clientId string) []string
+	mutex     sync.Mutex
+	stop      chan bool
+	now       func() time.Time
+}
+
+// NewCollector Creates a collector for the artifacts, that returns the paths of the files and folders of a client
+func NewCollector(c *Config, artifacts func(clientId string) []string) *Collector {
+	return &Collector{c: c, artifacts: artifacts, stop: make(chan bool, 1), now: time.Now}
+}
+
+// Track Registers that the artifacts of the client were written, so they expire from now
+func (col *Collector) Track(clientId string) {
+	col.update(func(clients map[string]*clientArtifacts) {
+		clients[clientId] = &clientArtifacts{writtenAt: col.now()}
+	})
+}
+
+// Fetched Registers that the client received its results
+func (col *Collector) Fetched(clientId string) {
+	col.update(func(clients map[string]*clientArtifacts) {
+		client, exists := clients[clientId]
+		if !exists {
+			client = &clientArtifacts{writtenAt: col.now()}
+			clients[clientId] = client
+		}
+		client.fetchedAt = col.now()
+	})
+}
+
+// Purge Removes the artifacts of the client and records them
+func (col *Collector) Purge(clientId string, reason string) error {
+	unlock, err := col.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	clients := col.readIndex()
+	delete(clients, clientId)
+	err = col.removeArtifacts(clientId, reason)
+	if err != nil {
+		return err
+	}
+	return col.writeIndex(clients)
+}
+
+// CollectLoop Removes periodically the artifacts of the clients that were fetched or expired, until the collector is closed
+func (col *Collector) CollectLoop() {
+	log.Infof("Collector | Started | TTL: %v | Fetched TTL: %v | Interval: %v", col.c.TTL, col.c.FetchedTTL, col.c.Interval)
+	ticker := time.NewTicker(col.c.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-col.stop:
+			log.Infof("Collector | Closing goroutine...")
+			return
+		case <-ticker.C:
+			col.collect()
+		}
+	}
+}
+
+// Close Stops the collection loop
+func (col *Collector) Close() {
+	col.stop <- true
+}
+
+// collect Removes the artifacts of the clients that were fetched or expired
+func (col *Collector) collect() {
+	unlock, err := col.lock()
+	if err != nil {
+		log.Errorf("Collector | Error locking index | %v | Retrying later...", err)
+		return
+	}
+	defer unlock()
+	clients := col.readIndex()
+	now := col.now()
+	for clientId, client := range clients {
+		reason := col.reasonToRemove(client, now)
+		if reason == "" {
+			continue
+		}
+		err := col.removeArtifacts(clientId, reason)
+		if err != nil {
+			log.Errorf("Collector | Error removing artifacts of client %v | %v | Retrying later...", clientId, err)
+			continue
+		}
+		delete(clients, clientId)
+	}
+	err = col.writeIndex(clients)
+	if err != nil {
+		log.Errorf("Collector | Error writing index | %v", err)
+	}
+}
+
+// reasonToRemove Returns why the artifacts of the client have to be removed, or an empty string if they are kept
+func (col *Collector) reasonToRemove(client *clientArtifacts, now time.Time) string {
+	if col.c.FetchedTTL > 0 && !client.fetchedAt.IsZero() && now.Sub(client.fetchedAt) >= col.c.FetchedTTL {
+		return FetchedReason
+	}
+	if col.c.TTL > 0 && now.Sub(client.writtenAt) >= col.c.TTL {
+		return ExpiredReason
+	}
+	return ""
+}
+
+// removeArtifacts Deletes the artifacts of the client that exist and records them with the reason.
+// The folders that are left empty are deleted too, the ones shared with other artifacts are kept
+func (col *Collector) removeArtifacts(clientId string, reason string) error {
+	var removed []string
+	for _, path := range col.artifacts(clientId) {
+		if !filemanager.DirectoryExists(path) {
+			continue
+		}
+		err := filemanager.DeleteIfExists(path)
+		if err != nil {
+			return err
+		}
+		removed = append(removed, path)
+		if folder := filepath.Dir(path); folder != "." {
+			// Fails if the folder is not empty
+			_ = os.Remove(folder)
+		}
+	}
+	log.Infof("Collector | Removed artifacts of client %v | Reason: %v | %v", clientId, reason, removed)
+	writer, err := filemanager.NewFileWriter(col.c.RecordsFile)
+	if err != nil {
+		return err
+	}
+	defer utils.CloseFileAndNotifyError(writer.FileManager)
+	return writer.WriteLine(fmt.Sprintf("%v,%v,%v,%v\n", col.now().Format(time.RFC3339), clientId, reason, strings.Join(removed, utils.DotCommaSeparator)))
+}
+
+// update Applies a change to the clients of the index
+func (col *Collector) update(change func(map[string]*clientArtifacts)) {
+	unlock, err := col.lock()
+	if err != nil {
+		log.Errorf("Collector | Error locking index | %v", err)
+		return
+	}
+	defer unlock()
+	clients := col.readIndex()
+	change(clients)
+	err = col.writeIndex(clients)
+	if err != nil {
+		log.Errorf("Collector | Error writing index | %v", err)
+	}
+}
+
+// lock Locks the index for the other goroutines of the service and for the other processes, as the purge command.
+// Waits until the lock is free, and returns the function that frees it
+func (col *Collector) lock() (func(), error) {
+	col.mutex.Lock()
+	file, err := os.OpenFile(col.c.IndexFile+lockSuffix, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		col.mutex.Unlock()
+		return nil, err
+	}
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+	if err != nil {
+		utils.CloseFileAndNotifyError(file)
+		col.mutex.Unlock()
+		return nil, err
+	}
+	return func() {
+		// Closing the file frees the lock
+		utils.CloseFileAndNotifyError(file)
+		col.mutex.Unlock()
+	}, nil
+}
+
+// readIndex Returns the clients with artifacts. Each line of the index is clientId,writtenAt,fetchedAt in unix seconds
+func (col *Collector) readIndex() map[string]*clientArtifacts {
+	clients := make(map[string]*clientArtifacts)
+	if !filemanager.DirectoryExists(col.c.IndexFile) {
+		return clients
+	}
+	reader, err := filemanager.NewFileReader(col.c.IndexFile)
+	if err != nil {
+		log.Errorf("Collector | Error reading index | %v", err)
+		return clients
+	}
+	defer utils.CloseFileAndNotifyError(reader.FileManager)
+	for reader.CanRead() {
+		fields := strings.Split(reader.ReadLine(), utils.CommaSeparator)
+		if len(fields) != 3 {
+			continue
+		}
+		writtenAt, errWritten := strconv.ParseInt(fields[1], 10, 64)
+		fetchedAt, errFetched := strconv.ParseInt(fields[2], 10, 64)
+		if errWritten != nil || errFetched != nil {
+			log.Warnf("Collector | Invalid line in index of client %v | Skipping it...", fields[0])
+			continue
+		}
+		client := &clientArtifacts{writtenAt: time.Unix(writtenAt, 0)}
+		if fetchedAt != 0 {
+			client.fetchedAt = time.Unix(fetchedAt, 0)
+		}
+		clients[fields[0]] = client
+	}
+	return clients
+}
+
+// writeIndex Replaces the index with the clients. It is written to a temporal file first, so it is never left half written
+func (col *Collector) writeIndex(clients map[string]*clientArtifacts) error {
+	var lines strings.Builder
+	for clientId, client := range clients {
+		fetchedAt := int64(0)
+		if !client.fetchedAt.IsZero() {
+			fetchedAt = client.fetchedAt.Unix()
+		}
+		lines.WriteString(fmt.Sprintf("%v,%v,%v\n", clientId, client.writtenAt.Unix(), fetchedAt))
+	}
+	tmpFile := col.c.IndexFile + utils.TempSuffix
+	err := os.WriteFile(tmpFile, []byte(lines.String()), 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, col.c.IndexFile)
+}
+
+// HandleCommand Runs the purge command if it is the one received, as `purge <clientId>`.
+// Returns true if it was the command, so the service does not start
+func HandleCommand(args []string, col *Collector) bool {
+	if len(args) == 0 || args[0] != PurgeCommand {
+		return false
+	}
+	if len(args) != 2 {
+		log.Errorf("Collector | Usage: %v <clientId>", PurgeCommand)
+		return true
+	}
+	err := col.Purge(args[1], ManualReason)
+	if err != nil {
+		log.Errorf("Collector | Error purging artifacts of client %v | %v", args[1], err)
+	}
+	return true
+}
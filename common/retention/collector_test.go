@@ -0,0 +1,158 @@
+package retention
+
+import (
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestCollector(t *testing.T, ttl time.Duration, fetchedTTL time.Duration) (*Collector, string, *time.Time) {
+	dir := t.TempDir()
+	conf := &Config{
+		TTL:         ttl,
+		FetchedTTL:  fetchedTTL,
+		Interval:    time.Minute,
+		IndexFile:   filepath.Join(dir, fmt.Sprintf(indexFileFormat, "saver")),
+		RecordsFile: filepath.Join(dir, fmt.Sprintf(recordsFileFormat, "saver")),
+	}
+	now := time.Unix(1000, 0)
+	col := NewCollector(conf, func(clientId string) []string {
+		return []string{
+			filepath.Join(dir, clientId, fmt.Sprintf("results_%v.csv", clientId)),
+			filepath.Join(dir, fmt.Sprintf("airports_%v.csv", clientId)),
+		}
+	})
+	col.now = func() time.Time { return now }
+	return col, dir, &now
+}
+
+func createArtifacts(t *testing.T, dir string, clientId string) {
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, clientId), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, clientId, fmt.Sprintf("results_%v.csv", clientId)), []byte("1,2\n"), 0644))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("airports_%v.csv", clientId)), []byte("EZE,1,2\n"), 0644))
+}
+
+func TestArtifactsOfAClientAreRemovedWhenTheyExpire(t *testing.T) {
+	col, dir, now := newTestCollector(t, time.Hour, time.Minute)
+	createArtifacts(t, dir, "cliente")
+	col.Track("cliente")
+
+	*now = now.Add(30 * time.Minute)
+	col.collect()
+	assert.True(t, filemanager.DirectoryExists(filepath.Join(dir, "cliente")), "The artifacts should be kept before the TTL")
+
+	*now = now.Add(30 * time.Minute)
+	col.collect()
+	assert.False(t, filemanager.DirectoryExists(filepath.Join(dir, "cliente")), "The folder should be removed after the TTL")
+	assert.False(t, filemanager.DirectoryExists(filepath.Join(dir, "airports_cliente.csv")), "The airports should be removed after the TTL")
+	assert.Empty(t, col.readIndex(), "The client should not be in the index")
+
+	records, err := os.ReadFile(col.c.RecordsFile)
+	assert.Nil(t, err)
+	assert.Contains(t, string(records), ",cliente,expired,")
+}
+
+func TestArtifactsOfAClientAreRemovedAfterTheResultsAreFetched(t *testing.T) {
+	col, dir, now := newTestCollector(t, time.Hour, time.Minute)
+	createArtifacts(t, dir, "cliente")
+	createArtifacts(t, dir, "otro")
+	col.Track("cliente")
+	col.Track("otro")
+	col.Fetched("cliente")
+
+	*now = now.Add(time.Minute)
+	col.collect()
+	assert.False(t, filemanager.DirectoryExists(filepath.Join(dir, "cliente")), "The results fetched should be removed")
+	assert.True(t, filemanager.DirectoryExists(filepath.Join(dir, "otro")), "The results not fetched should be kept")
+	_, exists := col.readIndex()["otro"]
+	assert.True(t, exists, "The client not fetched should stay in the index")
+}
+
+func TestZeroTTLKeepsTheArtifactsUntilTheyAreFetched(t *testing.T) {
+	col, dir, now := newTestCollector(t, 0, time.Minute)
+	createArtifacts(t, dir, "cliente")
+	col.Track("cliente")
+
+	*now = now.Add(24 * 365 * time.Hour)
+	col.collect()
+	assert.True(t, filemanager.DirectoryExists(filepath.Join(dir, "cliente")), "The artifacts should not expire")
+}
+
+func TestPurgeCommandRemovesTheArtifactsOfTheClient(t *testing.T) {
+	col, dir, _ := newTestCollector(t, time.Hour, time.Minute)
+	createArtifacts(t, dir, "cliente")
+	col.Track("cliente")
+
+	assert.False(t, HandleCommand([]string{"results"}, col), "Other commands should not be handled")
+	assert.True(t, HandleCommand([]string{PurgeCommand, "cliente"}, col), "The purge command should be handled")
+	assert.False(t, filemanager.DirectoryExists(filepath.Join(dir, "cliente")), "The folder should be removed")
+	assert.Empty(t, col.readIndex(), "The client should not be in the index")
+
+	records, err := os.ReadFile(col.c.RecordsFile)
+	assert.Nil(t, err)
+	fields := strings.Split(strings.TrimSpace(string(records)), ",")
+	assert.Equal(t, []string{"cliente", ManualReason}, fields[1:3])
+	assert.Equal(t, 2, len(strings.Split(fields[3], ";")), "Both artifacts should be recorded")
+}
+
+func TestThePurgeCommandWaitsForTheServiceToFinishItsChanges(t *testing.T) {
+	col, dir, _ := newTestCollector(t, time.Hour, time.Minute)
+	createArtifacts(t, dir, "cliente")
+	col.Track("cliente")
+	// The purge command runs in another process, with its own collector of the same index
+	command := NewCollector(col.c, col.artifacts)
+
+	unlock, err := col.lock()
+	assert.Nil(t, err)
+	purged := make(chan bool)
+	go func() {
+		purged <- HandleCommand([]string{PurgeCommand, "cliente"}, command)
+	}()
+	select {
+	case <-purged:
+		assert.Fail(t, "The purge should wait for the lock of the index")
+	case <-time.After(100 * time.Millisecond):
+	}
+	// The service reads and writes the index while it holds the lock
+	clients := col.readIndex()
+	clients["otro"] = &clientArtifacts{writtenAt: col.now()}
+	assert.Nil(t, col.writeIndex(clients))
+	unlock()
+	assert.True(t, <-purged)
+
+	clients = col.readIndex()
+	assert.NotContains(t, clients, "cliente", "The purged client should not be in the index")
+	assert.Contains(t, clients, "otro", "The changes of the service should be kept")
+}
+
+func TestNewConfigUsesTheDefaultsAndRejectsInvalidDurations(t *testing.T) {
+	conf, err := NewConfig("saver", "", "10m", "")
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultTTL, conf.TTL)
+	assert.Equal(t, 10*time.Minute, conf.FetchedTTL)
+	assert.Equal(t, DefaultInterval, conf.Interval)
+	assert.Equal(t, "retention_saver_clients.csv", conf.IndexFile)
+
+	_, err = NewConfig("saver", "-1h", "", "")
+	assert.NotNil(t, err, "A negative TTL should be rejected")
+	_, err = NewConfig("saver", "", "", "0s")
+	assert.NotNil(t, err, "A zero interval should be rejected")
+	_, err = NewConfig("saver", "un dia", "", "")
+	assert.NotNil(t, err, "An invalid duration should be rejected")
+}
+
+func TestFoldersSharedWithOtherArtifactsAreKept(t *testing.T) {
+	col, dir, _ := newTestCollector(t, time.Hour, time.Minute)
+	createArtifacts(t, dir, "cliente")
+	otherResults := filepath.Join(dir, "cliente", "results_ex2_cliente.csv")
+	assert.Nil(t, os.WriteFile(otherResults, []byte("1,2\n"), 0644))
+
+	assert.Nil(t, col.Purge("cliente", ManualReason))
+	assert.False(t, filemanager.DirectoryExists(filepath.Join(dir, "cliente", "results_cliente.csv")), "The results should be removed")
+	assert.True(t, filemanager.DirectoryExists(otherResults), "The results of other services should be kept")
+}
@@ -0,0 +1,68 @@
+package retention
+
+import (
+	"fmt"
+	"time"
+)
+
+const DefaultTTL = 24 * time.Hour
+const DefaultFetchedTTL = time.Hour
+const DefaultInterval = time.Minute
+
+const indexFileFormat = "retention_%v_clients.csv"
+const recordsFileFormat = "retention_%v_purged.csv"
+
+// Config How long the artifacts of the clients are kept
+type Config struct {
+	// TTL Time that the artifacts of a client are kept since they were written. Zero keeps them until they are fetched
+	TTL time.Duration
+	// FetchedTTL Time that the artifacts are kept after the results were fetched, so the client can fetch them again.
+	// Zero keeps them until they expire
+	FetchedTTL time.Duration
+	// Interval Time between the checks of the artifacts
+	Interval time.Duration
+	// IndexFile File with the clients that have artifacts
+	IndexFile string
+	// RecordsFile File where the removed artifacts are recorded
+	RecordsFile string
+}
+
+// NewConfig Parses the durations of the retention of the service. The empty ones take the default value
+func NewConfig(serviceId string, ttl string, fetchedTTL string, interval string) (*Config, error) {
+	parsedTTL, err := parseDuration(ttl, DefaultTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retention ttl: %v", err)
+	}
+	parsedFetchedTTL, err := parseDuration(fetchedTTL, DefaultFetchedTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retention fetched ttl: %v", err)
+	}
+	parsedInterval, err := parseDuration(interval, DefaultInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retention interval: %v", err)
+	}
+	if parsedInterval == 0 {
+		return nil, fmt.Errorf("invalid retention interval: it must be greater than zero")
+	}
+	return &Config{
+		TTL:         parsedTTL,
+		FetchedTTL:  parsedFetchedTTL,
+		Interval:    parsedInterval,
+		IndexFile:   fmt.Sprintf(indexFileFormat, serviceId),
+		RecordsFile: fmt.Sprintf(recordsFileFormat, serviceId),
+	}, nil
+}
+
+func parseDuration(value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("%v is negative", value)
+	}
+	return duration, nil
+}
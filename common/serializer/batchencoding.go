@@ -0,0 +1,68 @@
+package serializer
+
+import (
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+)
+
+// batchEncoder Encodes and decodes the rows of a message in one of the formats of dataStructures.BatchEncoding
+type batchEncoder interface {
+	// encode Returns the rows encoded, or false if the rows can not be represented with this encoding
+	encode(rows []*dataStructures.DynamicMap) ([]byte, bool)
+	// decode Reads nRows rows from the reader
+	decode(reader *bytesReader, nRows int) ([]*dataStructures.DynamicMap, error)
+}
+
+// batchEncoders Encoders available. To add a new format it has to be registered here with its tag
+var batchEncoders = map[dataStructures.BatchEncoding]batchEncoder{
+	dataStructures.RowsEncoding:     rowsEncoder{},
+	dataStructures.ColumnarEncoding: columnarEncoder{},
+}
+
+// encodeRows Encodes the rows with the encoding requested. If it is not possible, falls back to the rows encoding
+func encodeRows(encoding dataStructures.BatchEncoding, rows []*dataStructures.DynamicMap) (dataStructures.BatchEncoding, []byte) {
+	if encoder, exists := batchEncoders[encoding]; exists {
+		if encodedRows, ok := encoder.encode(rows); ok {
+			return encoding, encodedRows
+		}
+	}
+	encodedRows, _ := rowsEncoder{}.encode(rows)
+	return dataStructures.RowsEncoding, encodedRows
+}
+
+// decodeRows Decodes the rows with the encoding written in the header of the message
+func decodeRows(encoding dataStructures.BatchEncoding, reader *bytesReader, nRows int) ([]*dataStructures.DynamicMap, error) {
+	encoder, exists := batchEncoders[encoding]
+	if !exists {
+		return nil, malformed("unknown batch encoding %v", encoding)
+	}
+	return encoder.decode(reader, nRows)
+}
+
+// rowsEncoder Encodes each row after the other with SerializeDynMap
+type rowsEncoder struct{}
+
+func (e rowsEncoder) encode(rows []*dataStructures.DynamicMap) ([]byte, bool) {
+	var encodedRows []byte
+	for _, row := range rows {
+		encodedRows = append(encodedRows, SerializeDynMap(row)...)
+	}
+	return encodedRows, true
+}
+
+func (e rowsEncoder) decode(reader *bytesReader, nRows int) ([]*dataStructures.DynamicMap, error) {
+	// Each row has at least its columns count, so a bigger count can not be valid
+	if nRows > reader.remaining()/4 {
+		return nil, malformed("%v rows do not fit in the %v bytes left", nRows, reader.remaining())
+	}
+	rows := make([]*dataStructures.DynamicMap, 0, nRows)
+	for i := 0; i < nRows; i++ {
+		row, bytesRead, err := DeserializeDynMap(reader.buffer[reader.offset:])
+		if err != nil {
+			return nil, fmt.Errorf("row %v: %w", i, err)
+		}
+		rows = append(rows, row)
+		reader.offset += bytesRead
+	}
+	return rows, nil
+}
@@ -0,0 +1,242 @@
+package serializer
+
+import (
+	"encoding/binary"
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"sort"
+)
+
+// vectorKind How the values of a column are written in the columnar encoding
+type vectorKind byte
+
+const (
+	// plainVector Each value is written after the other, with its length if the type is not of fixed size
+	plainVector vectorKind = iota
+	// dictionaryVector The distinct values are written once, followed by the index in the dictionary of each row
+	dictionaryVector
+)
+
+// maxDictionarySize Amount of distinct values that fit in the indexes of a dictionary vector
+const maxDictionarySize = 1 << 16
+
+const dictionaryIndexSize = 2
+
+// minColumnHeaderSize Length of the key, type and kind of vector of a column without name
+const minColumnHeaderSize = 4 + 1 + 1
+
+// columnarEncoder Encodes the schema once, then a vector with the values of each column.
+// Only rows with the same columns and types can be encoded
+/*
+	Format:
+		columns count
+		for each column, ordered by name:
+			key length | key | type | vector kind | vector
+		plain vector of fixed size type: value of each row
+		plain vector of variable size type: length | value of each row
+		dictionary vector: dictionary size | length | value of each entry, index of each row
+*/
+type columnarEncoder struct{}
+
+func (e columnarEncoder) encode(rows []*dataStructures.DynamicMap) ([]byte, bool) {
+	keys, ok := sharedSchema(rows)
+	if !ok {
+		return nil, false
+	}
+	encodedRows := SerializeUint(uint32(len(keys)))
+	for _, key := range keys {
+		columnType := rows[0].GetCurrentMap()[key].Type
+		encodedRows = append(encodedRows, SerializeUint(uint32(len(key)))...)
+		encodedRows = append(encodedRows, key...)
+		encodedRows = append(encodedRows, byte(columnType))
+		values := make([][]byte, len(rows))
+		for i, row := range rows {
+			values[i] = row.GetCurrentMap()[key].Value
+		}
+		encodedRows = appendVector(encodedRows, columnType, values)
+	}
+	return encodedRows, true
+}
+
+// sharedSchema Returns the ordered names of the columns if every row has the same columns with the same types
+func sharedSchema(rows []*dataStructures.DynamicMap) ([]string, bool) {
+	if len(rows) == 0 || rows[0].GetColumnCount() == 0 {
+		return nil, false
+	}
+	schema := rows[0].GetCurrentMap()
+	for _, row := range rows[1:] {
+		if row.GetColumnCount() != uint32(len(schema)) {
+			return nil, false
+		}
+		for key, column := range row.GetCurrentMap() {
+			schemaColumn, exists := schema[key]
+			if !exists || schemaColumn.Type != column.Type {
+				return nil, false
+			}
+		}
+	}
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, true
+}
+
+// appendVector Writes the values with the kind of vector that takes fewer bytes
+func appendVector(encoded []byte, columnType dataStructures.ColumnType, values [][]byte) []byte {
+	if _, isFixed := dataStructures.ColumnTypeSize(columnType); isFixed {
+		encoded = append(encoded, byte(plainVector))
+		for _, value := range values {
+			encoded = append(encoded, value...)
+		}
+		return encoded
+	}
+
+	plainSize := 0
+	dictionarySize := 4 + dictionaryIndexSize*len(values)
+	indexes := make(map[string]int)
+	var dictionary [][]byte
+	for _, value := range values {
+		plainSize += 4 + len(value)
+		if _, exists := indexes[string(value)]; !exists {
+			indexes[string(value)] = len(dictionary)
+			dictionary = append(dictionary, value)
+			dictionarySize += 4 + len(value)
+		}
+	}
+
+	if len(dictionary) > maxDictionarySize || dictionarySize >= plainSize {
+		encoded = append(encoded, byte(plainVector))
+		for _, value := range values {
+			encoded = binary.BigEndian.AppendUint32(encoded, uint32(len(value)))
+			encoded = append(encoded, value...)
+		}
+		return encoded
+	}
+
+	encoded = append(encoded, byte(dictionaryVector))
+	encoded = binary.BigEndian.AppendUint32(encoded, uint32(len(dictionary)))
+	for _, entry := range dictionary {
+		encoded = binary.BigEndian.AppendUint32(encoded, uint32(len(entry)))
+		encoded = append(encoded, entry...)
+	}
+	for _, value := range values {
+		encoded = binary.BigEndian.AppendUint16(encoded, uint16(indexes[string(value)]))
+	}
+	return encoded
+}
+
+func (e columnarEncoder) decode(reader *bytesReader, nRows int) ([]*dataStructures.DynamicMap, error) {
+	if nRows == 0 {
+		return []*dataStructures.DynamicMap{}, nil
+	}
+	nCols, err := reader.readUint32("columns count")
+	if err != nil {
+		return nil, err
+	}
+	if nCols == 0 {
+		return nil, malformed("%v rows without columns", nRows)
+	}
+	if int(nCols) > reader.remaining()/minColumnHeaderSize {
+		return nil, malformed("%v columns do not fit in the %v bytes left", nCols, reader.remaining())
+	}
+	// Each row takes at least one byte in every vector
+	if nRows > reader.remaining() {
+		return nil, malformed("%v rows do not fit in the %v bytes left", nRows, reader.remaining())
+	}
+
+	rows := make([]map[string]dataStructures.Column, nRows)
+	for i := range rows {
+		rows[i] = make(map[string]dataStructures.Column, nCols)
+	}
+	for i := 0; i < int(nCols); i++ {
+		key, err := reader.readLengthAndBytes("column key")
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := rows[0][string(key)]; exists {
+			return nil, malformed("column %v is repeated", string(key))
+		}
+		columnType, err := reader.readByte("column type")
+		if err != nil {
+			return nil, err
+		}
+		values, err := readVector(reader, dataStructures.ColumnType(columnType), nRows)
+		if err != nil {
+			return nil, fmt.Errorf("column %v: %w", string(key), err)
+		}
+		for j, value := range values {
+			rows[j][string(key)] = value
+		}
+	}
+
+	dynMaps := make([]*dataStructures.DynamicMap, 0, nRows)
+	for _, row := range rows {
+		dynMaps = append(dynMaps, dataStructures.NewDynamicMap(row))
+	}
+	return dynMaps, nil
+}
+
+// readVector Reads the values of a column for each row, validating them with their type
+func readVector(reader *bytesReader, columnType dataStructures.ColumnType, nRows int) ([]dataStructures.Column, error) {
+	kind, err := reader.readByte("vector kind")
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]dataStructures.Column, 0, nRows)
+	switch vectorKind(kind) {
+	case plainVector:
+		size, isFixed := dataStructures.ColumnTypeSize(columnType)
+		for i := 0; i < nRows; i++ {
+			var value []byte
+			if isFixed {
+				value, err = reader.readBytes("value", size)
+			} else {
+				value, err = reader.readLengthAndBytes("value")
+			}
+			if err != nil {
+				return nil, err
+			}
+			column, err := dataStructures.NewColumn(columnType, value)
+			if err != nil {
+				return nil, malformed("%v", err)
+			}
+			columns = append(columns, column)
+		}
+	case dictionaryVector:
+		dictionarySize, err := reader.readUint32("dictionary size")
+		if err != nil {
+			return nil, err
+		}
+		if dictionarySize > maxDictionarySize || int(dictionarySize) > reader.remaining()/4 {
+			return nil, malformed("dictionary of %v entries does not fit in the %v bytes left", dictionarySize, reader.remaining())
+		}
+		dictionary := make([]dataStructures.Column, 0, dictionarySize)
+		for i := 0; i < int(dictionarySize); i++ {
+			value, err := reader.readLengthAndBytes("dictionary entry")
+			if err != nil {
+				return nil, err
+			}
+			column, err := dataStructures.NewColumn(columnType, value)
+			if err != nil {
+				return nil, malformed("%v", err)
+			}
+			dictionary = append(dictionary, column)
+		}
+		for i := 0; i < nRows; i++ {
+			indexBytes, err := reader.readBytes("dictionary index", dictionaryIndexSize)
+			if err != nil {
+				return nil, err
+			}
+			index := int(binary.BigEndian.Uint16(indexBytes))
+			if index >= len(dictionary) {
+				return nil, malformed("index %v is out of the dictionary of %v entries", index, len(dictionary))
+			}
+			columns = append(columns, dictionary[index])
+		}
+	default:
+		return nil, malformed("unknown vector kind %v", kind)
+	}
+	return columns, nil
+}
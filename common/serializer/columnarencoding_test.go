@@ -0,0 +1,166 @@
+package serializer
+
+import (
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var airports = []string{"ATL", "BOS", "CLT", "DEN", "DFW", "DTW", "EWR", "IAD", "JFK", "LAX", "LGA", "MIA", "OAK", "ORD", "PHL", "SFO"}
+
+// createFlightRow Creates a row with the columns sent by the client for each flight
+func createFlightRow(i int) *dataStructures.DynamicMap {
+	starting := airports[i%len(airports)]
+	destination := airports[(i*7+3)%len(airports)]
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["legId"] = dataStructures.NewStringColumn(fmt.Sprintf("%032x", i))
+	dynMap["searchDate"] = dataStructures.NewStringColumn("2022-04-16")
+	dynMap["flightDate"] = dataStructures.NewStringColumn(fmt.Sprintf("2022-04-%02d", 17+i%10))
+	dynMap["startingAirport"] = dataStructures.NewStringColumn(starting)
+	dynMap["destinationAirport"] = dataStructures.NewStringColumn(destination)
+	dynMap["fareBasisCode"] = dataStructures.NewStringColumn(fmt.Sprintf("LA0NX0MC%v", i%5))
+	dynMap["travelDuration"] = dataStructures.NewStringColumn(fmt.Sprintf("PT%vH%vM", 2+i%9, i%60))
+	dynMap["elapsedDays"] = dataStructures.NewStringColumn("0")
+	dynMap["isBasicEconomy"] = dataStructures.NewStringColumn("False")
+	dynMap["isRefundable"] = dataStructures.NewStringColumn("False")
+	dynMap["isNonStop"] = dataStructures.NewStringColumn("False")
+	dynMap["baseFare"] = dataStructures.NewStringColumn(fmt.Sprintf("%v.%02d", 200+i%300, i%100))
+	dynMap["totalFare"] = dataStructures.NewFloat32Column(float32(250+i%300) + 0.5)
+	dynMap["seatsRemaining"] = dataStructures.NewStringColumn(fmt.Sprintf("%v", i%10))
+	dynMap["totalTravelDistance"] = dataStructures.NewFloat32Column(float32(900 + i%1500))
+	dynMap["segmentsDepartureTimeEpochSeconds"] = dataStructures.NewStringColumn(fmt.Sprintf("%v||%v", 1650214620+i*60, 1650223560+i*60))
+	dynMap["segmentsDepartureTimeRaw"] = dataStructures.NewStringColumn("2022-04-17T12:57:00.000-04:00||2022-04-17T15:26:00.000-04:00")
+	dynMap["segmentsArrivalTimeEpochSeconds"] = dataStructures.NewStringColumn(fmt.Sprintf("%v||%v", 1650217980+i*60, 1650229980+i*60))
+	dynMap["segmentsArrivalTimeRaw"] = dataStructures.NewStringColumn("2022-04-17T13:53:00.000-04:00||2022-04-17T17:13:00.000-04:00")
+	dynMap["segmentsArrivalAirportCode"] = dataStructures.NewStringColumn(fmt.Sprintf("ORD||%v", destination))
+	dynMap["segmentsDepartureAirportCode"] = dataStructures.NewStringColumn(fmt.Sprintf("%v||ORD", starting))
+	dynMap["segmentsAirlineName"] = dataStructures.NewStringColumn("United||United")
+	dynMap["segmentsAirlineCode"] = dataStructures.NewStringColumn("UA||UA")
+	dynMap["segmentsEquipmentDescription"] = dataStructures.NewStringColumn("Boeing 737-800||Airbus A320")
+	dynMap["segmentsDurationInSeconds"] = dataStructures.NewStringColumn("3360||6420")
+	dynMap["segmentsDistance"] = dataStructures.NewStringColumn("606||957")
+	dynMap["segmentsCabinCode"] = dataStructures.NewStringColumn("coach||coach")
+	return dataStructures.NewDynamicMap(dynMap)
+}
+
+func createFlightRowsMessage(nRows int, encoding dataStructures.BatchEncoding) *dataStructures.Message {
+	rows := make([]*dataStructures.DynamicMap, 0, nRows)
+	for i := 0; i < nRows; i++ {
+		rows = append(rows, createFlightRow(i))
+	}
+	return &dataStructures.Message{
+		TypeMessage: dataStructures.FlightRows,
+		ClientId:    "7a3f0e0e-client",
+		MessageId:   42,
+		DynMaps:     rows,
+		Encoding:    encoding,
+	}
+}
+
+func TestSerializeAndDeserializeColumnarMessageReturnsTheSameRows(t *testing.T) {
+	msg := createFlightRowsMessage(100, dataStructures.ColumnarEncoding)
+
+	received, err := DeserializeMsg(SerializeMsg(msg))
+
+	assert.Nil(t, err)
+	assert.Equal(t, dataStructures.ColumnarEncoding, received.Encoding)
+	assert.Len(t, received.DynMaps, len(msg.DynMaps))
+	for i, row := range msg.DynMaps {
+		assert.Equal(t, row.GetCurrentMap(), received.DynMaps[i].GetCurrentMap())
+	}
+}
+
+func TestColumnarMessageIsSmallerThanTheOneEncodedByRows(t *testing.T) {
+	columnar := SerializeMsg(createFlightRowsMessage(100, dataStructures.ColumnarEncoding))
+	rows := SerializeMsg(createFlightRowsMessage(100, dataStructures.RowsEncoding))
+
+	assert.Less(t, len(columnar), len(rows)/2)
+}
+
+func TestColumnarMessageWithRowsOfDifferentSchemasIsSentByRows(t *testing.T) {
+	msg := createFlightRowsMessage(2, dataStructures.ColumnarEncoding)
+	msg.DynMaps[1].AddColumn("extra", dataStructures.NewInt32Column(1))
+
+	received, err := DeserializeMsg(SerializeMsg(msg))
+
+	assert.Nil(t, err)
+	assert.Equal(t, dataStructures.RowsEncoding, received.Encoding)
+	assert.Equal(t, msg.DynMaps[1].GetCurrentMap(), received.DynMaps[1].GetCurrentMap())
+}
+
+func TestColumnarMessageWithoutRowsReturnsAnEmptyMessage(t *testing.T) {
+	msg := createFlightRowsMessage(0, dataStructures.ColumnarEncoding)
+
+	received, err := DeserializeMsg(SerializeMsg(msg))
+
+	assert.Nil(t, err)
+	assert.Len(t, received.DynMaps, 0)
+}
+
+func TestColumnarRowsWithADictionaryIndexOutOfRangeReturnsError(t *testing.T) {
+	var encoded []byte
+	encoded = append(encoded, SerializeUint(1)...)
+	encoded = append(encoded, SerializeUint(uint32(len("airport")))...)
+	encoded = append(encoded, []byte("airport")...)
+	encoded = append(encoded, byte(dataStructures.StringType))
+	encoded = append(encoded, byte(dictionaryVector))
+	encoded = append(encoded, SerializeUint(1)...)
+	encoded = append(encoded, SerializeUint(3)...)
+	encoded = append(encoded, []byte("ATL")...)
+	encoded = append(encoded, 0, 0, 0, 1)
+
+	_, err := columnarEncoder{}.decode(&bytesReader{buffer: encoded}, 2)
+	assert.ErrorIs(t, err, ErrMalformedMessage)
+}
+
+func TestDeserializeMessageWithAnUnknownBatchEncodingReturnsError(t *testing.T) {
+	msg := createTestMessage()
+	msg.Encoding = 200
+	bytesMsg := SerializeMsg(msg)
+	body, err := unwrapFrame(bytesMsg)
+	assert.Nil(t, err)
+	encodingOffset := 4 + 4 + 4 + len(msg.ClientId) + 4 + 4
+	body[encodingOffset] = 200
+
+	assertMalformed(t, wrapInFrame(body))
+}
+
+func benchmarkSerializeMsg(b *testing.B, encoding dataStructures.BatchEncoding) {
+	msg := createFlightRowsMessage(300, encoding)
+	b.ReportAllocs()
+	b.ResetTimer()
+	var bytesMsg []byte
+	for i := 0; i < b.N; i++ {
+		bytesMsg = SerializeMsg(msg)
+	}
+	b.ReportMetric(float64(len(bytesMsg)), "bytes/msg")
+}
+
+func benchmarkDeserializeMsg(b *testing.B, encoding dataStructures.BatchEncoding) {
+	bytesMsg := SerializeMsg(createFlightRowsMessage(300, encoding))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DeserializeMsg(bytesMsg); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(bytesMsg)), "bytes/msg")
+}
+
+func BenchmarkSerializeMsgByRows(b *testing.B) {
+	benchmarkSerializeMsg(b, dataStructures.RowsEncoding)
+}
+
+func BenchmarkSerializeMsgColumnar(b *testing.B) {
+	benchmarkSerializeMsg(b, dataStructures.ColumnarEncoding)
+}
+
+func BenchmarkDeserializeMsgByRows(b *testing.B) {
+	benchmarkDeserializeMsg(b, dataStructures.RowsEncoding)
+}
+
+func BenchmarkDeserializeMsgColumnar(b *testing.B) {
+	benchmarkDeserializeMsg(b, dataStructures.ColumnarEncoding)
+}
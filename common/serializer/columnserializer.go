@@ -0,0 +1,75 @@
+package serializer
+
+import (
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const columnName = "value"
+
+// ColumnToString Returns the value of the column as text, in the format expected by columnFromString
+func ColumnToString(column dataStructures.Column) (string, error) {
+	row := dataStructures.NewDynamicMap(map[string]dataStructures.Column{columnName: column})
+	switch column.Type {
+	case dataStructures.Int32Type:
+		value, err := row.GetAsInt(columnName)
+		return strconv.Itoa(value), err
+	case dataStructures.Int64Type:
+		value, err := row.GetAsInt64(columnName)
+		return strconv.FormatInt(value, 10), err
+	case dataStructures.Float32Type:
+		value, err := row.GetAsFloat(columnName)
+		return strconv.FormatFloat(float64(value), 'g', -1, 32), err
+	case dataStructures.Float64Type:
+		value, err := row.GetAsFloat64(columnName)
+		return strconv.FormatFloat(value, 'g', -1, 64), err
+	case dataStructures.StringType:
+		return row.GetAsString(columnName)
+	case dataStructures.BoolType:
+		value, err := row.GetAsBool(columnName)
+		return strconv.FormatBool(value), err
+	case dataStructures.TimestampType:
+		value, err := row.GetAsTimestamp(columnName)
+		return value.Format(time.RFC3339Nano), err
+	case dataStructures.ListType:
+		values, err := row.GetAsList(columnName)
+		return strings.Join(values, utils.DoublePipeSeparator), err
+	}
+	return "", fmt.Errorf("unknown column type %v", column.Type)
+}
+
+// columnFromString Parses the text of a value of the type received
+func columnFromString(columnType dataStructures.ColumnType, value string) (dataStructures.Column, error) {
+	switch columnType {
+	case dataStructures.Int32Type:
+		intValue, err := strconv.ParseInt(value, 10, 32)
+		return dataStructures.NewInt32Column(int32(intValue)), err
+	case dataStructures.Int64Type:
+		intValue, err := strconv.ParseInt(value, 10, 64)
+		return dataStructures.NewInt64Column(intValue), err
+	case dataStructures.Float32Type:
+		floatValue, err := strconv.ParseFloat(value, 32)
+		return dataStructures.NewFloat32Column(float32(floatValue)), err
+	case dataStructures.Float64Type:
+		floatValue, err := strconv.ParseFloat(value, 64)
+		return dataStructures.NewFloat64Column(floatValue), err
+	case dataStructures.StringType:
+		return dataStructures.NewStringColumn(value), nil
+	case dataStructures.BoolType:
+		boolValue, err := strconv.ParseBool(value)
+		return dataStructures.NewBoolColumn(boolValue), err
+	case dataStructures.TimestampType:
+		timestamp, err := time.Parse(time.RFC3339Nano, value)
+		return dataStructures.NewTimestampColumn(timestamp), err
+	case dataStructures.ListType:
+		if value == "" {
+			return dataStructures.NewListColumn([]string{}), nil
+		}
+		return dataStructures.NewListColumn(strings.Split(value, utils.DoublePipeSeparator)), nil
+	}
+	return dataStructures.Column{}, fmt.Errorf("unknown column type %v", columnType)
+}
@@ -0,0 +1,148 @@
+package serializer
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Compression Algorithm used to compress a frame. It travels in the header of the compressed frame
+type Compression byte
+
+const (
+	NoCompression Compression = iota
+	GzipCompression
+	ZlibCompression
+	FlateCompression
+)
+
+var compressionNames = map[Compression]string{
+	NoCompression:    "none",
+	GzipCompression:  "gzip",
+	ZlibCompression:  "zlib",
+	FlateCompression: "flate",
+}
+
+func (c Compression) String() string {
+	name, exists := compressionNames[c]
+	if !exists {
+		return fmt.Sprintf("unknown(%d)", byte(c))
+	}
+	return name
+}
+
+// ParseCompression Returns the compression with the name received
+func ParseCompression(name string) (Compression, error) {
+	for compression, compressionName := range compressionNames {
+		if compressionName == name {
+			return compression, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown compression %v", name)
+}
+
+// compressedFrameMagic Marks the beginning of a compressed frame, "TZ" in ASCII. Nodes that do not know it discard the message
+const compressedFrameMagic = uint16(0x545A)
+
+const algorithmSize = 1
+const compressedHeaderSize = magicSize + algorithmSize + lengthSize
+const compressedOverhead = compressedHeaderSize + checksumSize
+
+// maxDecompressedSize Limit of the size of a decompressed frame, to avoid allocating whatever a corrupted header says
+const maxDecompressedSize = 256 * 1024 * 1024
+
+// CompressFrame Compresses a frame returned by SerializeMsg. If the compressed frame is not smaller, the frame is returned as is
+/*
+	Format:
+		magic | algorithm | length of the decompressed frame | compressed frame | CRC32 of the previous bytes
+*/
+func CompressFrame(frame []byte, compression Compression) ([]byte, error) {
+	if compression == NoCompression {
+		return frame, nil
+	}
+	buffer := bytes.NewBuffer(make([]byte, compressedHeaderSize, len(frame)/2))
+	binary.BigEndian.PutUint16(buffer.Bytes()[0:magicSize], compressedFrameMagic)
+	buffer.Bytes()[magicSize] = byte(compression)
+	binary.BigEndian.PutUint32(buffer.Bytes()[magicSize+algorithmSize:compressedHeaderSize], uint32(len(frame)))
+
+	writer, err := newCompressionWriter(compression, buffer)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = writer.Write(frame); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+	if buffer.Len()+checksumSize >= len(frame) {
+		return frame, nil
+	}
+	compressed := buffer.Bytes()
+	return binary.BigEndian.AppendUint32(compressed, crc32.ChecksumIEEE(compressed)), nil
+}
+
+func newCompressionWriter(compression Compression, writer io.Writer) (io.WriteCloser, error) {
+	switch compression {
+	case GzipCompression:
+		return gzip.NewWriter(writer), nil
+	case ZlibCompression:
+		return zlib.NewWriter(writer), nil
+	case FlateCompression:
+		return flate.NewWriter(writer, flate.DefaultCompression)
+	}
+	return nil, fmt.Errorf("unknown compression %v", compression)
+}
+
+func newCompressionReader(compression Compression, reader io.Reader) (io.ReadCloser, error) {
+	switch compression {
+	case GzipCompression:
+		return gzip.NewReader(reader)
+	case ZlibCompression:
+		return zlib.NewReader(reader)
+	case FlateCompression:
+		return flate.NewReader(reader), nil
+	}
+	return nil, malformed("unknown compression %v", compression)
+}
+
+// isCompressedFrame Returns true if the bytes start with the magic number of a compressed frame
+func isCompressedFrame(bytesMsg []byte) bool {
+	return len(bytesMsg) >= magicSize && binary.BigEndian.Uint16(bytesMsg[0:magicSize]) == compressedFrameMagic
+}
+
+// decompressFrame Validates the compressed frame and returns the frame that was compressed
+func decompressFrame(compressed []byte) ([]byte, error) {
+	if len(compressed) < compressedOverhead {
+		return nil, malformed("compressed frame of %v bytes is shorter than the minimum of %v", len(compressed), compressedOverhead)
+	}
+	checksumOffset := len(compressed) - checksumSize
+	expectedChecksum := binary.BigEndian.Uint32(compressed[checksumOffset:])
+	if checksum := crc32.ChecksumIEEE(compressed[:checksumOffset]); checksum != expectedChecksum {
+		return nil, malformed("checksum %#x of the compressed frame does not match the expected %#x", checksum, expectedChecksum)
+	}
+	compression := Compression(compressed[magicSize])
+	decompressedSize := int(binary.BigEndian.Uint32(compressed[magicSize+algorithmSize : compressedHeaderSize]))
+	if decompressedSize > maxDecompressedSize {
+		return nil, malformed("decompressed size %v is bigger than the maximum of %v", decompressedSize, maxDecompressedSize)
+	}
+
+	reader, err := newCompressionReader(compression, bytes.NewReader(compressed[compressedHeaderSize:checksumOffset]))
+	if err != nil {
+		return nil, malformed("%v", err)
+	}
+	defer reader.Close()
+	frame := make([]byte, decompressedSize)
+	if _, err = io.ReadFull(reader, frame); err != nil {
+		return nil, malformed("error decompressing %v frame: %v", compression, err)
+	}
+	if extra, _ := reader.Read(make([]byte, 1)); extra != 0 {
+		return nil, malformed("decompressed frame is bigger than the %v bytes of its header", decompressedSize)
+	}
+	return frame, nil
+}
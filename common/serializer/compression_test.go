@@ -0,0 +1,64 @@
+package serializer
+
+import (
+	"encoding/binary"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/stretchr/testify/assert"
+	"hash/crc32"
+	"testing"
+)
+
+func TestCompressedMessageIsDeserializedWithEachAlgorithm(t *testing.T) {
+	msg := createFlightRowsMessage(50, dataStructures.RowsEncoding)
+	frame := SerializeMsg(msg)
+	for _, compression := range []Compression{GzipCompression, ZlibCompression, FlateCompression} {
+		compressed, err := CompressFrame(frame, compression)
+		assert.Nil(t, err)
+		assert.Less(t, len(compressed), len(frame), "%v should reduce the size of the message", compression)
+		assert.True(t, isCompressedFrame(compressed))
+
+		received, err := DeserializeMsg(compressed)
+		assert.Nil(t, err, "error deserializing %v message", compression)
+		assert.Equal(t, msg.MessageId, received.MessageId)
+		assert.Len(t, received.DynMaps, len(msg.DynMaps))
+		assert.Equal(t, msg.DynMaps[49].GetCurrentMap(), received.DynMaps[49].GetCurrentMap())
+	}
+}
+
+func TestCompressFrameThatDoesNotGetSmallerReturnsTheSameFrame(t *testing.T) {
+	frame := SerializeMsg(&dataStructures.Message{TypeMessage: dataStructures.EofAck, ClientId: "c"})
+
+	compressed, err := CompressFrame(frame, GzipCompression)
+
+	assert.Nil(t, err)
+	assert.Equal(t, frame, compressed)
+}
+
+func TestDeserializeCompressedMessageWithACorruptedByteReturnsError(t *testing.T) {
+	compressed, err := CompressFrame(SerializeMsg(createFlightRowsMessage(10, dataStructures.RowsEncoding)), GzipCompression)
+	assert.Nil(t, err)
+	compressed[compressedHeaderSize+5] ^= 0xFF
+
+	assertMalformed(t, compressed)
+}
+
+func TestDeserializeCompressedMessageWithAWrongDecompressedSizeReturnsError(t *testing.T) {
+	compressed, err := CompressFrame(SerializeMsg(createFlightRowsMessage(10, dataStructures.RowsEncoding)), ZlibCompression)
+	assert.Nil(t, err)
+	checksumOffset := len(compressed) - checksumSize
+	size := binary.BigEndian.Uint32(compressed[magicSize+algorithmSize : compressedHeaderSize])
+	binary.BigEndian.PutUint32(compressed[magicSize+algorithmSize:compressedHeaderSize], size-1)
+	binary.BigEndian.PutUint32(compressed[checksumOffset:], crc32.ChecksumIEEE(compressed[:checksumOffset]))
+
+	assertMalformed(t, compressed)
+}
+
+func TestCompressorKeepsTheRatioOfTheFramesSent(t *testing.T) {
+	compressor := NewCompressor("test", FlateCompression)
+	frame := SerializeMsg(createFlightRowsMessage(50, dataStructures.RowsEncoding))
+
+	compressed := compressor.Compress(frame)
+
+	assert.InDelta(t, float64(len(frame))/float64(len(compressed)), compressor.Ratio(), 0.001)
+	assert.Greater(t, compressor.Ratio(), 2.0)
+}
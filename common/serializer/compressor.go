@@ -0,0 +1,48 @@
+package serializer
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// compressionLogInterval Amount of messages between each log of the compression ratio
+const compressionLogInterval = 1000
+
+// Compressor Compresses the frames sent to a queue or socket and keeps the compression ratio achieved
+type Compressor struct {
+	name        string
+	compression Compression
+	messages    uint
+	rawBytes    uint64
+	sentBytes   uint64
+}
+
+func NewCompressor(name string, compression Compression) *Compressor {
+	return &Compressor{name: name, compression: compression}
+}
+
+// Compress Returns the frame compressed. If it fails the frame is sent without compression
+func (c *Compressor) Compress(frame []byte) []byte {
+	if c.compression == NoCompression {
+		return frame
+	}
+	compressed, err := CompressFrame(frame, c.compression)
+	if err != nil {
+		log.Errorf("Compressor %v | Error compressing frame with %v, sending it uncompressed | %v", c.name, c.compression, err)
+		compressed = frame
+	}
+	c.messages++
+	c.rawBytes += uint64(len(frame))
+	c.sentBytes += uint64(len(compressed))
+	if c.messages%compressionLogInterval == 0 {
+		log.Infof("Compressor %v | Compression: %v | Messages: %v | Bytes: %v -> %v | Ratio: %.2f", c.name, c.compression, c.messages, c.rawBytes, c.sentBytes, c.Ratio())
+	}
+	return compressed
+}
+
+// Ratio Returns how many times smaller are the bytes sent than the serialized ones
+func (c *Compressor) Ratio() float64 {
+	if c.sentBytes == 0 {
+		return 1
+	}
+	return float64(c.rawBytes) / float64(c.sentBytes)
+}
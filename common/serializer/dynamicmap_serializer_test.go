@@ -7,12 +7,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestSerializeDynMapWithAnIntReturnsTheBytesExpected(t *testing.T) {
-	dynMap := make(map[string][]byte)
-	dynMap["test"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["test"], uint32(32))
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test"] = dataStructures.NewInt32Column(32)
 	row := dataStructures.NewDynamicMap(dynMap)
 
 	serializedRow := SerializeDynMap(row)
@@ -30,6 +30,7 @@ func TestSerializeDynMapWithAnIntReturnsTheBytesExpected(t *testing.T) {
 	bytesExpected = append(bytesExpected, bytesNCols...)
 	bytesExpected = append(bytesExpected, bytesLenKey...)
 	bytesExpected = append(bytesExpected, bytesKey...)
+	bytesExpected = append(bytesExpected, byte(dataStructures.Int32Type))
 	bytesExpected = append(bytesExpected, bytesLenValue...)
 	bytesExpected = append(bytesExpected, bytesValue...)
 
@@ -37,11 +38,10 @@ func TestSerializeDynMapWithAnIntReturnsTheBytesExpected(t *testing.T) {
 }
 
 func TestSerializeDynMapWithTwoColumnsReturnsTheBytesExpected(t *testing.T) {
-	dynMap := make(map[string][]byte)
-	dynMap["test"] = make([]byte, 4)
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["test"] = dataStructures.NewInt32Column(32)
 	stringCol2 := "un string largo para la prueba que debería de igual forma leerse bien su longitud"
-	dynMap["test_string"] = []byte(stringCol2)
-	binary.BigEndian.PutUint32(dynMap["test"], uint32(32))
+	dynMap["test_string"] = dataStructures.NewStringColumn(stringCol2)
 	row := dataStructures.NewDynamicMap(dynMap)
 
 	serializedRow := SerializeDynMap(row)
@@ -59,10 +59,12 @@ func TestSerializeDynMapWithTwoColumnsReturnsTheBytesExpected(t *testing.T) {
 	bytesExpected = append(bytesExpected, bytesNCols...)
 	bytesExpected = append(bytesExpected, bytesLenKey...)
 	bytesExpected = append(bytesExpected, bytesKey...)
+	bytesExpected = append(bytesExpected, byte(dataStructures.Int32Type))
 	bytesExpected = append(bytesExpected, bytesLenValue...)
 	bytesExpected = append(bytesExpected, bytesValue...)
 	bytesExpected = append(bytesExpected, bytesLenKey2...)
 	bytesExpected = append(bytesExpected, bytesKey2...)
+	bytesExpected = append(bytesExpected, byte(dataStructures.StringType))
 	bytesExpected = append(bytesExpected, bytesLenValue2...)
 	bytesExpected = append(bytesExpected, bytesValue2...)
 
@@ -71,7 +73,7 @@ func TestSerializeDynMapWithTwoColumnsReturnsTheBytesExpected(t *testing.T) {
 }
 
 func TestSerializeEmptyDynMapReturnsOnlyColLengthOfZeroAsBytes(t *testing.T) {
-	row := dataStructures.NewDynamicMap(make(map[string][]byte))
+	row := dataStructures.NewDynamicMap(make(map[string]dataStructures.Column))
 	serializedRow := SerializeDynMap(row)
 	bytesExpected := make([]byte, 4)
 	binary.BigEndian.PutUint32(bytesExpected, uint32(0))
@@ -81,11 +83,10 @@ func TestSerializeEmptyDynMapReturnsOnlyColLengthOfZeroAsBytes(t *testing.T) {
 
 func TestDeserializeDynMapWithTwoColumnsReturnsTheExpectedDynMap(t *testing.T) {
 
-	dynMapExpected := make(map[string][]byte)
-	dynMapExpected["test"] = make([]byte, 4)
+	dynMapExpected := make(map[string]dataStructures.Column)
+	dynMapExpected["test"] = dataStructures.NewInt32Column(32)
 	stringCol2 := "un string largo para la prueba que debería de igual forma leerse bien su longitud"
-	dynMapExpected["test_string"] = []byte(stringCol2)
-	binary.BigEndian.PutUint32(dynMapExpected["test"], uint32(32))
+	dynMapExpected["test_string"] = dataStructures.NewStringColumn(stringCol2)
 
 	var bytesSer []byte
 	bytesNCols := make([]byte, 4)
@@ -106,14 +107,17 @@ func TestDeserializeDynMapWithTwoColumnsReturnsTheExpectedDynMap(t *testing.T) {
 	bytesSer = append(bytesSer, bytesNCols...)
 	bytesSer = append(bytesSer, bytesLenKey...)
 	bytesSer = append(bytesSer, bytesKey...)
+	bytesSer = append(bytesSer, byte(dataStructures.Int32Type))
 	bytesSer = append(bytesSer, bytesLenValue...)
 	bytesSer = append(bytesSer, bytesValue...)
 	bytesSer = append(bytesSer, bytesLenKey2...)
 	bytesSer = append(bytesSer, bytesKey2...)
+	bytesSer = append(bytesSer, byte(dataStructures.StringType))
 	bytesSer = append(bytesSer, bytesLenValue2...)
 	bytesSer = append(bytesSer, bytesValue2...)
 
-	rowReceived, _ := DeserializeDynMap(bytesSer)
+	rowReceived, _, err := DeserializeDynMap(bytesSer)
+	assert.Nil(t, err)
 
 	stringCol, errStringCol := rowReceived.GetAsString("test_string")
 	assert.Nil(t, errStringCol, "Got error on casting string col that was string in original.")
@@ -124,3 +128,46 @@ func TestDeserializeDynMapWithTwoColumnsReturnsTheExpectedDynMap(t *testing.T) {
 	assert.Equalf(t, 32, intCol, "Wrong value on intCol expected 32 and got %v.", intCol)
 	assert.Equalf(t, uint32(2), rowReceived.GetColumnCount(), "RowCount expected was 2 and got %v.", rowReceived.GetColumnCount())
 }
+
+func TestSerializeToStringAndDeserializeFromStringKeepsTheValueAndTypeOfEachColumn(t *testing.T) {
+	timestamp := time.Date(2022, time.April, 16, 10, 30, 0, 500, time.UTC)
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["int32"] = dataStructures.NewInt32Column(-32)
+	dynMap["int64"] = dataStructures.NewInt64Column(1 << 40)
+	dynMap["float32"] = dataStructures.NewFloat32Column(1.9)
+	dynMap["float64"] = dataStructures.NewFloat64Column(1234.5678)
+	dynMap["string"] = dataStructures.NewStringColumn("a=b:c")
+	dynMap["bool"] = dataStructures.NewBoolColumn(false)
+	dynMap["timestamp"] = dataStructures.NewTimestampColumn(timestamp)
+	dynMap["list"] = dataStructures.NewListColumn([]string{"ATL", "JFK", "EZE"})
+	row := dataStructures.NewDynamicMap(dynMap)
+
+	line := SerializeToString(row)
+	rowReceived, err := DeserializeFromString(strings.TrimSuffix(line, "\n"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, dynMap, rowReceived.GetCurrentMap())
+}
+
+func TestDeserializeFromStringWithAnUnknownTypeReturnsError(t *testing.T) {
+	_, err := DeserializeFromString("col:decimal=1.5")
+	assert.Error(t, err)
+}
+
+func TestDeserializeFromStringWithAValueThatIsNotOfItsTypeReturnsError(t *testing.T) {
+	_, err := DeserializeFromString("col:int32=not a number")
+	assert.Error(t, err)
+}
+
+func TestDeserializeDynMapWithAValueOfAnotherSizeThanItsTypeReturnsError(t *testing.T) {
+	var bytesSer []byte
+	bytesSer = append(bytesSer, SerializeUint(uint32(1))...)
+	bytesSer = append(bytesSer, SerializeUint(uint32(len("test")))...)
+	bytesSer = append(bytesSer, []byte("test")...)
+	bytesSer = append(bytesSer, byte(dataStructures.Float64Type))
+	bytesSer = append(bytesSer, SerializeUint(uint32(4))...)
+	bytesSer = append(bytesSer, SerializeUint(uint32(32))...)
+
+	_, _, err := DeserializeDynMap(bytesSer)
+	assert.ErrorIs(t, err, ErrMalformedMessage)
+}
@@ -0,0 +1,110 @@
+package serializer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// FrameVersion Version of the format of the messages. It has to be incremented when the body of the message changes
+const FrameVersion = 3
+
+// frameMagic Marks the beginning of a frame, "TP" in ASCII
+const frameMagic = uint16(0x5450)
+
+const magicSize = 2
+const versionSize = 1
+const lengthSize = 4
+const checksumSize = 4
+const frameHeaderSize = magicSize + versionSize + lengthSize
+const frameOverhead = frameHeaderSize + checksumSize
+
+// minColumnSize Length of the key, type and length of the value of an empty column
+const minColumnSize = 4 + 1 + 4
+
+// ErrMalformedMessage Returned when the bytes received are not a valid message
+var ErrMalformedMessage = errors.New("malformed message")
+
+func malformed(format string, args ...any) error {
+	return fmt.Errorf("%w: %v", ErrMalformedMessage, fmt.Sprintf(format, args...))
+}
+
+// wrapInFrame Adds the header with the magic number, the version and the length of the body, and the CRC of the frame at the end
+func wrapInFrame(body []byte) []byte {
+	frame := make([]byte, frameHeaderSize, len(body)+frameOverhead)
+	binary.BigEndian.PutUint16(frame[0:magicSize], frameMagic)
+	frame[magicSize] = FrameVersion
+	binary.BigEndian.PutUint32(frame[magicSize+versionSize:frameHeaderSize], uint32(len(body)))
+	frame = append(frame, body...)
+	return binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame))
+}
+
+// unwrapFrame Validates the header and the CRC of the frame and returns the body
+func unwrapFrame(frame []byte) ([]byte, error) {
+	if len(frame) < frameOverhead {
+		return nil, malformed("frame of %v bytes is shorter than the minimum of %v", len(frame), frameOverhead)
+	}
+	magic := binary.BigEndian.Uint16(frame[0:magicSize])
+	if magic != frameMagic {
+		return nil, malformed("unknown magic number %#x", magic)
+	}
+	version := frame[magicSize]
+	if version != FrameVersion {
+		return nil, malformed("unsupported version %v, expected %v", version, FrameVersion)
+	}
+	bodyLength := int(binary.BigEndian.Uint32(frame[magicSize+versionSize : frameHeaderSize]))
+	if bodyLength != len(frame)-frameOverhead {
+		return nil, malformed("body length %v does not match the %v bytes received", bodyLength, len(frame)-frameOverhead)
+	}
+	checksumOffset := frameHeaderSize + bodyLength
+	expectedChecksum := binary.BigEndian.Uint32(frame[checksumOffset:])
+	if checksum := crc32.ChecksumIEEE(frame[:checksumOffset]); checksum != expectedChecksum {
+		return nil, malformed("checksum %#x does not match the expected %#x", checksum, expectedChecksum)
+	}
+	return frame[frameHeaderSize:checksumOffset], nil
+}
+
+// bytesReader Reads the fields of a buffer checking that they are inside its bounds
+type bytesReader struct {
+	buffer []byte
+	offset int
+}
+
+func (r *bytesReader) remaining() int {
+	return len(r.buffer) - r.offset
+}
+
+func (r *bytesReader) readUint32(field string) (uint32, error) {
+	if r.remaining() < 4 {
+		return 0, malformed("%v at offset %v is out of bounds", field, r.offset)
+	}
+	value := binary.BigEndian.Uint32(r.buffer[r.offset : r.offset+4])
+	r.offset += 4
+	return value, nil
+}
+
+func (r *bytesReader) readBytes(field string, length int) ([]byte, error) {
+	if length < 0 || r.remaining() < length {
+		return nil, malformed("%v of %v bytes at offset %v is out of bounds", field, length, r.offset)
+	}
+	value := r.buffer[r.offset : r.offset+length]
+	r.offset += length
+	return value, nil
+}
+
+func (r *bytesReader) readLengthAndBytes(field string) ([]byte, error) {
+	length, err := r.readUint32(field + " length")
+	if err != nil {
+		return nil, err
+	}
+	return r.readBytes(field, int(length))
+}
+
+func (r *bytesReader) readByte(field string) (byte, error) {
+	value, err := r.readBytes(field, 1)
+	if err != nil {
+		return 0, err
+	}
+	return value[0], nil
+}
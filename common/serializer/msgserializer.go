@@ -7,10 +7,12 @@ import (
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"math"
-	"strconv"
 	"strings"
 )
 
+// SerializeMsg Serializes the message inside a frame with the version of the format and a checksum.
+// The rows are encoded with the encoding of the message, or by rows if they can not be encoded with it.
+// If the message has query parameters they are written after the rows
 func SerializeMsg(msg *dataStructures.Message) []byte {
 	var serializedMsg []byte
 	typeBytes := SerializeUint(uint32(msg.TypeMessage))
@@ -25,40 +27,85 @@ func SerializeMsg(msg *dataStructures.Message) []byte {
 	serializedMsg = append(serializedMsg, uuidBytes...)
 	serializedMsg = append(serializedMsg, messageId...)
 	serializedMsg = append(serializedMsg, rowId...)
-	for _, row := range msg.DynMaps {
-		serializedRow := SerializeDynMap(row)
-		serializedMsg = append(serializedMsg, serializedRow...)
+	encoding, encodedRows := encodeRows(msg.Encoding, msg.DynMaps)
+	serializedMsg = append(serializedMsg, byte(encoding))
+	serializedMsg = append(serializedMsg, encodedRows...)
+	if msg.Params != nil {
+		serializedMsg = append(serializedMsg, SerializeDynMap(msg.Params)...)
 	}
-	return serializedMsg
+	return wrapInFrame(serializedMsg)
 }
 
-func DeserializeMsg(bytesMsg []byte) *dataStructures.Message {
-	offset := 0
-	typeMsg := int(binary.BigEndian.Uint32(bytesMsg[offset : offset+4]))
-	offset += 4
-	nRows := int(binary.BigEndian.Uint32(bytesMsg[offset : offset+4]))
-	offset += 4
-	sizeOfClientId := int(binary.BigEndian.Uint32(bytesMsg[offset : offset+4]))
-	offset += 4
-	clientId := DeserializeString(bytesMsg[offset : offset+sizeOfClientId])
-	offset += sizeOfClientId
-	messageId := uint(binary.BigEndian.Uint32(bytesMsg[offset : offset+4]))
-	offset += 4
-	rowId := uint16(binary.BigEndian.Uint32(bytesMsg[offset : offset+4]))
-	offset += 4
-	var dynMaps []*dataStructures.DynamicMap
-	for i := 0; i < nRows; i++ {
-		dynMap, bytesRead := DeserializeDynMap(bytesMsg[offset:])
-		dynMaps = append(dynMaps, dynMap)
-		offset += bytesRead
+// DeserializeMsg Validates the frame, decompressing it if needed, and deserializes the message. If the bytes are not a valid
+// message an error wrapping ErrMalformedMessage is returned with the reason
+func DeserializeMsg(bytesMsg []byte) (*dataStructures.Message, error) {
+	if isCompressedFrame(bytesMsg) {
+		frame, err := decompressFrame(bytesMsg)
+		if err != nil {
+			return nil, err
+		}
+		bytesMsg = frame
+	}
+	body, err := unwrapFrame(bytesMsg)
+	if err != nil {
+		return nil, err
+	}
+	reader := &bytesReader{buffer: body}
+	typeMsg, err := reader.readUint32("type")
+	if err != nil {
+		return nil, err
+	}
+	if !dataStructures.IsKnownMessageType(int(typeMsg)) {
+		return nil, malformed("unknown message type %v", typeMsg)
+	}
+	nRows, err := reader.readUint32("rows count")
+	if err != nil {
+		return nil, err
+	}
+	clientId, err := reader.readLengthAndBytes("client id")
+	if err != nil {
+		return nil, err
+	}
+	messageId, err := reader.readUint32("message id")
+	if err != nil {
+		return nil, err
+	}
+	rowId, err := reader.readUint32("row id")
+	if err != nil {
+		return nil, err
+	}
+	if rowId > math.MaxUint16 {
+		return nil, malformed("row id %v is out of range", rowId)
+	}
+	encoding, err := reader.readByte("batch encoding")
+	if err != nil {
+		return nil, err
+	}
+	dynMaps, err := decodeRows(dataStructures.BatchEncoding(encoding), reader, int(nRows))
+	if err != nil {
+		return nil, err
+	}
+	var params *dataStructures.DynamicMap
+	if reader.remaining() != 0 {
+		var bytesRead int
+		params, bytesRead, err = DeserializeDynMap(reader.buffer[reader.offset:])
+		if err != nil {
+			return nil, err
+		}
+		reader.offset += bytesRead
+	}
+	if reader.remaining() != 0 {
+		return nil, malformed("%v bytes left after the query parameters", reader.remaining())
 	}
 	return &dataStructures.Message{
-		TypeMessage: typeMsg,
+		TypeMessage: int(typeMsg),
 		DynMaps:     dynMaps,
-		ClientId:    clientId,
-		MessageId:   messageId,
-		RowId:       rowId,
-	}
+		ClientId:    DeserializeString(clientId),
+		MessageId:   uint(messageId),
+		RowId:       uint16(rowId),
+		Encoding:    dataStructures.BatchEncoding(encoding),
+		Params:      params,
+	}, nil
 }
 
 func SerializeDynMap(dynamicMap *dataStructures.DynamicMap) []byte {
@@ -68,53 +115,71 @@ func SerializeDynMap(dynamicMap *dataStructures.DynamicMap) []byte {
 	binary.BigEndian.PutUint32(bytesNCols, mapLength)
 	rowBytes = append(rowBytes, bytesNCols...)
 	currMap := dynamicMap.GetCurrentMap()
-	for key, value := range currMap {
+	for key, column := range currMap {
 		keyLengthBytes := make([]byte, 4)
 		binary.BigEndian.PutUint32(keyLengthBytes, uint32(len(key)))
 		keyBytes := []byte(key)
 		valueLengthBytes := make([]byte, 4)
-		binary.BigEndian.PutUint32(valueLengthBytes, uint32(len(value)))
+		binary.BigEndian.PutUint32(valueLengthBytes, uint32(len(column.Value)))
 		rowBytes = append(rowBytes, keyLengthBytes...)
 		rowBytes = append(rowBytes, keyBytes...)
+		rowBytes = append(rowBytes, byte(column.Type))
 		rowBytes = append(rowBytes, valueLengthBytes...)
-		rowBytes = append(rowBytes, value...)
+		rowBytes = append(rowBytes, column.Value...)
 	}
 	return rowBytes
 }
 
-func DeserializeDynMap(dynamicMapBytes []byte) (*dataStructures.DynamicMap, int) {
-	nCols := int(binary.BigEndian.Uint32(dynamicMapBytes[0:4]))
-	currOffset := 4
-	mapForDynMap := make(map[string][]byte)
-	for i := 0; i < nCols; i++ {
-		lenKey := int(binary.BigEndian.Uint32(dynamicMapBytes[currOffset : currOffset+4]))
-		currOffset += 4
-		key := string(dynamicMapBytes[currOffset : currOffset+lenKey])
-		currOffset += lenKey
-		lenValue := int(binary.BigEndian.Uint32(dynamicMapBytes[currOffset : currOffset+4]))
-		currOffset += 4
-		value := dynamicMapBytes[currOffset : currOffset+lenValue]
-		currOffset += lenValue
-		mapForDynMap[key] = value
-	}
-	return dataStructures.NewDynamicMap(mapForDynMap), currOffset
+// DeserializeDynMap Deserializes a row and returns the amount of bytes read. Fails if a column is out of bounds,
+// repeated, or its value is not valid for its type
+func DeserializeDynMap(dynamicMapBytes []byte) (*dataStructures.DynamicMap, int, error) {
+	reader := &bytesReader{buffer: dynamicMapBytes}
+	nCols, err := reader.readUint32("columns count")
+	if err != nil {
+		return nil, 0, err
+	}
+	// Each column has at least the length of its key, its type and the length of its value
+	if int(nCols) > reader.remaining()/minColumnSize {
+		return nil, 0, malformed("%v columns do not fit in the %v bytes left", nCols, reader.remaining())
+	}
+	mapForDynMap := make(map[string]dataStructures.Column, nCols)
+	for i := 0; i < int(nCols); i++ {
+		key, err := reader.readLengthAndBytes("column key")
+		if err != nil {
+			return nil, 0, err
+		}
+		columnType, err := reader.readByte("column type")
+		if err != nil {
+			return nil, 0, err
+		}
+		value, err := reader.readLengthAndBytes("column value")
+		if err != nil {
+			return nil, 0, err
+		}
+		if _, exists := mapForDynMap[string(key)]; exists {
+			return nil, 0, malformed("column %v is repeated", string(key))
+		}
+		column, err := dataStructures.NewColumn(dataStructures.ColumnType(columnType), value)
+		if err != nil {
+			return nil, 0, malformed("column %v: %v", string(key), err)
+		}
+		mapForDynMap[string(key)] = column
+	}
+	return dataStructures.NewDynamicMap(mapForDynMap), reader.offset, nil
 }
 
+// SerializeToString Serializes the row as a line of key:type=value separated by commas
 func SerializeToString(dynMap *dataStructures.DynamicMap) string {
 	line := strings.Builder{}
 	currMap := dynMap.GetCurrentMap()
 	currCol := 0
 	colCount := dynMap.GetColumnCount()
-	for key, value := range currMap {
-		if isFloatColumn(key) {
-			floatValue := math.Float32frombits(binary.BigEndian.Uint32(value))
-			line.WriteString(fmt.Sprintf("%v=%v", key, floatValue))
-		} else if isIntColumn(key) {
-			intValue := binary.BigEndian.Uint32(value)
-			line.WriteString(fmt.Sprintf("%v=%v", key, intValue))
-		} else {
-			line.WriteString(fmt.Sprintf("%v=%v", key, string(value)))
+	for key, column := range currMap {
+		value, err := ColumnToString(column)
+		if err != nil {
+			log.Errorf("Serializer | Error converting column %v to string | %v", key, err)
 		}
+		line.WriteString(fmt.Sprintf("%v%v%v%v%v", key, utils.ColonSeparator, column.Type, utils.EqualsSeparator, value))
 		if uint32(currCol) != colCount-1 {
 			line.WriteString(utils.CommaSeparator)
 			currCol++
@@ -124,30 +189,34 @@ func SerializeToString(dynMap *dataStructures.DynamicMap) string {
 	return line.String()
 }
 
-func DeserializeFromString(dynMapStr string) *dataStructures.DynamicMap {
+// DeserializeFromString Deserializes a line written by SerializeToString, using the type of each column
+func DeserializeFromString(dynMapStr string) (*dataStructures.DynamicMap, error) {
+	dynMapData := make(map[string]dataStructures.Column)
+	if dynMapStr == "" {
+		return dataStructures.NewDynamicMap(dynMapData), nil
+	}
 	keyValuePairs := strings.Split(dynMapStr, utils.CommaSeparator)
-	dynMapData := make(map[string][]byte)
 	for _, pair := range keyValuePairs {
-		keyValuePair := strings.Split(pair, "=")
-		key := keyValuePair[0]
-		strVal := keyValuePair[1]
-		if isIntColumn(key) {
-			intVal, err := strconv.Atoi(strVal)
-			if err != nil {
-				log.Errorf("Serializer | Error casting column %v to integer | %v", intVal, err)
-			}
-			dynMapData[key] = SerializeUint(uint32(intVal))
-		} else if isFloatColumn(key) {
-			floatVal, err := strconv.ParseFloat(strVal, 32)
-			if err != nil {
-				log.Errorf("Serializer | Error casting column %v to float | %v", floatVal, err)
-			}
-			dynMapData[key] = SerializeFloat(float32(floatVal))
-		} else {
-			dynMapData[key] = []byte(strVal)
+		keyValuePair := strings.SplitN(pair, utils.EqualsSeparator, 2)
+		if len(keyValuePair) != 2 {
+			return nil, fmt.Errorf("column '%v' does not have a value", pair)
+		}
+		typeSeparatorIdx := strings.LastIndex(keyValuePair[0], utils.ColonSeparator)
+		if typeSeparatorIdx < 0 {
+			return nil, fmt.Errorf("column '%v' does not have a type", pair)
+		}
+		key := keyValuePair[0][:typeSeparatorIdx]
+		columnType, err := dataStructures.ParseColumnType(keyValuePair[0][typeSeparatorIdx+1:])
+		if err != nil {
+			return nil, fmt.Errorf("column %v: %v", key, err)
+		}
+		column, err := columnFromString(columnType, keyValuePair[1])
+		if err != nil {
+			return nil, fmt.Errorf("column %v: %v", key, err)
 		}
+		dynMapData[key] = column
 	}
-	return dataStructures.NewDynamicMap(dynMapData)
+	return dataStructures.NewDynamicMap(dynMapData), nil
 }
 
 func DeserializeUDPPacket(packetBytes []byte) *dataStructures.UDPPacket {
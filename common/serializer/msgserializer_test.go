@@ -0,0 +1,135 @@
+package serializer
+
+import (
+	"encoding/binary"
+	"errors"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/stretchr/testify/assert"
+	"hash/crc32"
+	"testing"
+)
+
+func createTestMessage() *dataStructures.Message {
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["legId"] = dataStructures.NewStringColumn("abc123")
+	dynMap["totalFare"] = dataStructures.NewFloat32Column(123.5)
+	dynMap["seatsRemaining"] = dataStructures.NewInt32Column(7)
+	return &dataStructures.Message{
+		TypeMessage: dataStructures.FlightRows,
+		ClientId:    "7a3f0e0e-client",
+		MessageId:   42,
+		RowId:       3,
+		DynMaps:     []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMap), dataStructures.NewDynamicMap(make(map[string]dataStructures.Column))},
+	}
+}
+
+func assertMalformed(t *testing.T, bytesMsg []byte) {
+	msg, err := DeserializeMsg(bytesMsg)
+	assert.Nil(t, msg)
+	assert.True(t, errors.Is(err, ErrMalformedMessage), "Expected a malformed message error, got: %v", err)
+}
+
+func TestSerializeAndDeserializeMessageReturnsTheSameMessage(t *testing.T) {
+	msg := createTestMessage()
+
+	received, err := DeserializeMsg(SerializeMsg(msg))
+
+	assert.Nil(t, err)
+	assert.Equal(t, msg.TypeMessage, received.TypeMessage)
+	assert.Equal(t, msg.ClientId, received.ClientId)
+	assert.Equal(t, msg.MessageId, received.MessageId)
+	assert.Equal(t, msg.RowId, received.RowId)
+	assert.Len(t, received.DynMaps, 2)
+	legId, err := received.DynMaps[0].GetAsString("legId")
+	assert.Nil(t, err)
+	assert.Equal(t, "abc123", legId)
+	fare, err := received.DynMaps[0].GetAsFloat("totalFare")
+	assert.Nil(t, err)
+	assert.Equal(t, float32(123.5), fare)
+	assert.Equal(t, uint32(0), received.DynMaps[1].GetColumnCount())
+}
+
+func TestSerializeAndDeserializeMessageKeepsTheQueryParameters(t *testing.T) {
+	msg := createTestMessage()
+	msg.Params = dataStructures.NewDynamicMap(map[string]dataStructures.Column{"minStopovers": dataStructures.NewInt32Column(2)})
+
+	received, err := DeserializeMsg(SerializeMsg(msg))
+
+	assert.Nil(t, err)
+	assert.Len(t, received.DynMaps, 2)
+	minStopovers, err := received.Params.GetAsInt("minStopovers")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, minStopovers)
+	assert.Nil(t, received.DynMaps[0].GetCurrentMap()["minStopovers"].Value)
+
+	withoutParams, err := DeserializeMsg(SerializeMsg(createTestMessage()))
+	assert.Nil(t, err)
+	assert.Nil(t, withoutParams.Params)
+}
+
+func TestSerializedMessageStartsWithTheMagicNumberAndVersion(t *testing.T) {
+	bytesMsg := SerializeMsg(createTestMessage())
+
+	assert.Equal(t, frameMagic, binary.BigEndian.Uint16(bytesMsg[0:2]))
+	assert.Equal(t, byte(FrameVersion), bytesMsg[2])
+	assert.Equal(t, len(bytesMsg)-frameOverhead, int(binary.BigEndian.Uint32(bytesMsg[3:7])))
+}
+
+func TestDeserializeTruncatedMessageReturnsError(t *testing.T) {
+	bytesMsg := SerializeMsg(createTestMessage())
+	for _, length := range []int{0, 3, frameOverhead, len(bytesMsg) / 2, len(bytesMsg) - 1} {
+		assertMalformed(t, bytesMsg[:length])
+	}
+}
+
+func TestDeserializeMessageWithACorruptedByteReturnsError(t *testing.T) {
+	bytesMsg := SerializeMsg(createTestMessage())
+	bytesMsg[frameHeaderSize+10] ^= 0xFF
+	assertMalformed(t, bytesMsg)
+}
+
+func TestDeserializeMessageWithAnotherVersionReturnsError(t *testing.T) {
+	bytesMsg := SerializeMsg(createTestMessage())
+	bytesMsg[magicSize] = FrameVersion + 1
+	assertMalformed(t, bytesMsg)
+}
+
+func TestDeserializeMessageWithoutMagicNumberReturnsError(t *testing.T) {
+	bytesMsg := SerializeMsg(createTestMessage())
+	assertMalformed(t, bytesMsg[frameHeaderSize:])
+}
+
+func TestDeserializeMessageWithUnknownTypeReturnsError(t *testing.T) {
+	msg := createTestMessage()
+	msg.TypeMessage = 200
+	assertMalformed(t, SerializeMsg(msg))
+}
+
+func TestDeserializeMessageWithAColumnOutOfBoundsReturnsError(t *testing.T) {
+	var body []byte
+	body = append(body, SerializeUint(dataStructures.FlightRows)...)
+	body = append(body, SerializeUint(1)...)
+	body = append(body, SerializeUint(0)...)
+	body = append(body, SerializeUint(0)...)
+	body = append(body, SerializeUint(0)...)
+	body = append(body, byte(dataStructures.RowsEncoding))
+	body = append(body, SerializeUint(1)...)
+	body = append(body, SerializeUint(3)...)
+	body = append(body, []byte("key")...)
+	body = append(body, byte(dataStructures.StringType))
+	body = append(body, SerializeUint(1000)...)
+	assertMalformed(t, wrapInFrame(body))
+}
+
+func TestDeserializeMessageWithAValidChecksumButMoreRowsThanBytesReturnsError(t *testing.T) {
+	var body []byte
+	body = append(body, SerializeUint(dataStructures.FlightRows)...)
+	body = append(body, SerializeUint(1<<30)...)
+	body = append(body, SerializeUint(0)...)
+	body = append(body, SerializeUint(0)...)
+	body = append(body, SerializeUint(0)...)
+	body = append(body, byte(dataStructures.RowsEncoding))
+	frame := wrapInFrame(body)
+	assert.Equal(t, crc32.ChecksumIEEE(frame[:len(frame)-checksumSize]), binary.BigEndian.Uint32(frame[len(frame)-checksumSize:]))
+	assertMalformed(t, frame)
+}
@@ -4,6 +4,7 @@ const CommaSeparator = ","
 const DotCommaSeparator = ";"
 const DoublePipeSeparator = "||"
 const EqualsSeparator = "="
+const ColonSeparator = ":"
 const SimplePipeSeparator = "|"
 const AtSeparator = "@"
 
@@ -51,12 +52,45 @@ const TotalStopovers = "totalStopovers"
 const SegmentsArrivalAirportCode = "segmentsArrivalAirportCode"
 const SegmentsAirlineName = "segmentsAirlineName"
 const NodesVisited = "nodesVisited"
+const ExpectedRows = "expectedRows"
+const ReceivedRows = "receivedRows"
+const EmittedRows = "emittedRows"
+const NodeId = "nodeId"
+const NodeReceivedRows = "nodeReceivedRows"
 const Exercise = "exercise"
 const NumberOfRow = "numberOfRow"
+const SessionStage = "stage"
+const AirportBatches = "airportBatches"
+const FlightBatches = "flightBatches"
+const FlightRowsReceived = "flightRows"
+const SkippedLines = "skippedLines"
+const SkippedLinesErrors = "skippedLinesErrors"
+const SkippedAirportLines = "skippedAirportLines"
+const SkippedFlightLines = "skippedFlightLines"
+const UploadStream = "stream"
+const UploadStreams = "streams"
+const RetryAfter = "retryAfter"
+const MinStopovers = "minStopovers"
+const DistanceFactor = "distanceFactor"
+const FastestFlights = "fastestFlights"
+const PriceThreshold = "priceThreshold"
+const Queries = "queries"
 const ServiceName = "name"
+const MemberStage = "memberStage"
+const MemberNodes = "memberNodes"
+const MemberFirstKey = "memberFirstKey"
+const MembershipEpoch = "membershipEpoch"
+const MembershipSizePrefix = "membershipSize."
+const DeadLetterStage = "deadLetterStage"
+const DeadLetterNode = "deadLetterNode"
+const DeadLetterReason = "deadLetterReason"
+const DeadLetterType = "deadLetterType"
+const DeadLetterTime = "deadLetterTime"
+const DeadLetterRaw = "deadLetterRaw"
 
 const LocalPrice = "localPrice"
 const LocalQuantity = "localQuantity"
+const JourneySavers = "journeySavers"
 const Avg = "avg"
 const FinalAvg = "finalAvg"
 const Max = "max"
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const queriesCount = 4
+const getterPort = 8080
+const healthCheckerPort = 8080
+const electionPort = 8081
+
+// container A replica of a stage
+type container struct {
+	name          string
+	id            int
+	rkInput       int
+	getterAddress string
+}
+
+// stage A stage of the topology with its kind and containers resolved
+type stage struct {
+	StageTopology
+	kind       stageKind
+	settings   *viper.Viper
+	config     *viper.Viper
+	containers []container
+	inputs     []string
+	outputs    []string
+}
+
+// Dependency A service that has to be started before another one
+type Dependency struct {
+	Service   string
+	Condition string
+}
+
+// Service A service of the compose file
+type Service struct {
+	Name        string
+	Image       string
+	Environment []string
+	Volumes     []string
+	DependsOn   []Dependency
+}
+
+// StageConfig The configuration file shared by every replica of a stage
+type StageConfig struct {
+	Stage  string
+	Config *viper.Viper
+}
+
+// Deployment Everything that is generated from a topology
+type Deployment struct {
+	Services []Service
+	Configs  []StageConfig
+}
+
+// Build Validates the topology and generates the services of the compose and the configuration of each stage.
+// root is the folder of the repository, where the base configs of the services are, and configsMountDir
+// the folder, relative to the root, from which the compose mounts the generated configs
+func Build(t *Topology, root string, configsMountDir string) (*Deployment, error) {
+	stages, err := resolveStages(t)
+	if err != nil {
+		return nil, err
+	}
+	nextId := assignContainers(stages)
+
+	producers, consumers := buildGraph(stages)
+	if err := validateGraph(stages, producers, consumers); err != nil {
+		return nil, err
+	}
+	if err := validateQueries(stages, consumers); err != nil {
+		return nil, err
+	}
+	if err := validateCoordinators(stages, consumers); err != nil {
+		return nil, err
+	}
+
+	healthCheckers := healthCheckerNames(t.HealthCheckers)
+	for _, s := range stages {
+		if err := buildStageConfig(t, s, stages, healthCheckers, root); err != nil {
+			return nil, fmt.Errorf("error in stage %v: %v", s.Name, err)
+		}
+	}
+
+	d := &Deployment{}
+	for _, s := range stages {
+		d.Configs = append(d.Configs, StageConfig{Stage: s.Name, Config: s.config})
+		d.Services = append(d.Services, stageServices(s, stages, consumers, configsMountDir)...)
+	}
+	server := findServer(stages)
+	d.Services = append(d.Services, clientService(t, server, nextId))
+	d.Services = append(d.Services, healthCheckerServices(t, stages, healthCheckers, nextId+1)...)
+	return d, nil
+}
+
+func resolveStages(t *Topology) ([]*stage, error) {
+	var stages []*stage
+	names := make(map[string]bool)
+	servers := 0
+	for _, st := range t.Stages {
+		if st.Name == "" {
+			return nil, errors.New("missing name of stage")
+		}
+		if names[st.Name] {
+			return nil, fmt.Errorf("stage %v is defined twice", st.Name)
+		}
+		names[st.Name] = true
+
+		kind, exists := stageKinds[st.Kind]
+		if !exists {
+			return nil, fmt.Errorf("stage %v has an unknown kind '%v'", st.Name, st.Kind)
+		}
+		if st.Replicas <= 0 {
+			return nil, fmt.Errorf("stage %v needs at least one replica", st.Name)
+		}
+		if kind.goroutinesKey != "" && st.Goroutines <= 0 {
+			return nil, fmt.Errorf("stage %v needs at least one goroutine", st.Name)
+		}
+		if kind.goroutinesKey == "" && st.Goroutines != 0 {
+			return nil, fmt.Errorf("stage %v of kind %v does not support goroutines", st.Name, st.Kind)
+		}
+		if kind.partitioned {
+			if st.Partitions <= 0 {
+				st.Partitions = st.Replicas
+			}
+			if st.Replicas%st.Partitions != 0 {
+				return nil, fmt.Errorf("stage %v has %v replicas that can not be split in %v partitions", st.Name, st.Replicas, st.Partitions)
+			}
+		} else if st.Partitions != 0 {
+			return nil, fmt.Errorf("stage %v of kind %v can not be partitioned", st.Name, st.Kind)
+		}
+		if kind.isServer {
+			servers++
+			if st.Replicas != 1 {
+				return nil, fmt.Errorf("the server %v can only have one replica", st.Name)
+			}
+		}
+
+		settings := viper.New()
+		if err := settings.MergeConfigMap(st.Settings); err != nil {
+			return nil, fmt.Errorf("error parsing settings of stage %v: %v", st.Name, err)
+		}
+		s := &stage{StageTopology: st, kind: kind, settings: settings}
+		s.inputs = endpoints(settings, kind.inputKeys)
+		s.outputs = endpoints(settings, kind.outputKeys)
+		if len(s.inputs) != len(kind.inputKeys) {
+			return nil, fmt.Errorf("stage %v is missing its inputs %v", st.Name, kind.inputKeys)
+		}
+		stages = append(stages, s)
+	}
+	if servers != 1 {
+		return nil, fmt.Errorf("the topology needs exactly one server, found %v", servers)
+	}
+	return stages, nil
+}
+
+// endpoints Returns the queues and exchanges of the keys. A key can have many of them separated by commas
+func endpoints(settings *viper.Viper, keys []string) []string {
+	var names []string
+	for _, key := range keys {
+		for _, name := range strings.Split(settings.GetString(key), ",") {
+			name = strings.TrimSpace(name)
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// assignContainers Names the replicas of each stage and gives them an id. Returns the next free id
+func assignContainers(stages []*stage) int {
+	id := 1
+	for _, s := range stages {
+		if s.kind.isServer {
+			s.containers = []container{{name: s.Name, id: id}}
+			id++
+			continue
+		}
+		replicasPerPartition := s.Replicas
+		if s.kind.partitioned {
+			replicasPerPartition = s.Replicas / s.Partitions
+		}
+		for i := 0; i < s.Replicas; i++ {
+			c := container{name: fmt.Sprintf("%v-%v", s.Name, i+1), id: id}
+			if s.kind.partitioned {
+				partition := i / replicasPerPartition
+				c.name = fmt.Sprintf("%v-%v-%v", s.Name, partition+1, i%replicasPerPartition+1)
+				c.rkInput = partition * s.Goroutines
+			}
+			if s.kind.hasGetter {
+				c.getterAddress = fmt.Sprintf("%v:%v", c.name, getterPort)
+			}
+			s.containers = append(s.containers, c)
+			id++
+		}
+	}
+	return id
+}
+
+func buildGraph(stages []*stage) (map[string][]*stage, map[string][]*stage) {
+	producers := make(map[string][]*stage)
+	consumers := make(map[string][]*stage)
+	for _, s := range stages {
+		for _, output := range s.outputs {
+			producers[output] = append(producers[output], s)
+		}
+		for _, input := range s.inputs {
+			consumers[input] = append(consumers[input], s)
+		}
+	}
+	return producers, consumers
+}
+
+// validateGraph Checks that every queue or exchange has someone producing to it and someone consuming from it
+func validateGraph(stages []*stage, producers map[string][]*stage, consumers map[string][]*stage) error {
+	for _, s := range stages {
+		for _, input := range s.inputs {
+			if len(producers[input]) == 0 {
+				return fmt.Errorf("stage %v consumes from %v, but no stage produces to it", s.Name, input)
+			}
+		}
+		for _, output := range s.outputs {
+			if len(consumers[output]) == 0 {
+				return fmt.Errorf("stage %v produces to %v, but no stage consumes from it", s.Name, output)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCoordinators Checks that each input queue with a coordinator of the EOF is consumed by a single stage,
+// as the coordinator takes the reports of every node that consumes the queue
+func validateCoordinators(stages []*stage, consumers map[string][]*stage) error {
+	for _, s := range stages {
+		if s.kind.eof != inputQueueRing {
+			continue
+		}
+		for _, other := range consumers[s.inputs[0]] {
+			if other != s && other.kind.eof == inputQueueRing {
+				return fmt.Errorf("stages %v and %v consume from %v, but only one stage can coordinate the EOF of a queue", s.Name, other.Name, s.inputs[0])
+			}
+		}
+	}
+	return nil
+}
+
+// validateQueries Checks that the queries of each stage are the ones of the stages that consume its outputs,
+// and that every query has exactly one stage returning its results
+func validateQueries(stages []*stage, consumers map[string][]*stage) error {
+	saverOfQuery := make(map[int]string)
+	for _, s := range stages {
+		for _, query := range s.Queries {
+			if query < 1 || query > queriesCount {
+				return fmt.Errorf("stage %v feeds an unknown query %v", s.Name, query)
+			}
+		}
+		if s.kind.hasGetter {
+			if len(s.Queries) != 1 {
+				return fmt.Errorf("stage %v returns results, so it must feed exactly one query", s.Name)
+			}
+			if other, exists := saverOfQuery[s.Queries[0]]; exists {
+				return fmt.Errorf("query %v is returned by stages %v and %v", s.Queries[0], other, s.Name)
+			}
+			saverOfQuery[s.Queries[0]] = s.Name
+		}
+		if len(s.outputs) == 0 {
+			continue
+		}
+		downstream := make(map[int]bool)
+		for _, output := range s.outputs {
+			for _, consumer := range consumers[output] {
+				for _, query := range consumer.Queries {
+					downstream[query] = true
+				}
+			}
+		}
+		if !equalQueries(s.Queries, downstream) {
+			return fmt.Errorf("stage %v feeds queries %v, but the stages after it feed %v", s.Name, s.Queries, sortedQueries(downstream))
+		}
+	}
+	for query := 1; query <= queriesCount; query++ {
+		if _, exists := saverOfQuery[query]; !exists {
+			return fmt.Errorf("no stage returns the results of query %v", query)
+		}
+	}
+	return nil
+}
+
+func equalQueries(queries []int, expected map[int]bool) bool {
+	found := make(map[int]bool)
+	for _, query := range queries {
+		if !expected[query] {
+			return false
+		}
+		found[query] = true
+	}
+	return len(found) == len(expected)
+}
+
+func sortedQueries(queries map[int]bool) []int {
+	var sorted []int
+	for query := range queries {
+		sorted = append(sorted, query)
+	}
+	sort.Ints(sorted)
+	return sorted
+}
+
+// buildStageConfig Creates the config of the stage from the base config of the service, the settings of the
+// topology and the values that depend on the rest of the topology
+func buildStageConfig(t *Topology, s *stage, stages []*stage, healthCheckers []string, root string) error {
+	s.config = viper.New()
+	s.config.SetConfigFile(filepath.Join(root, s.kind.baseConfig))
+	if err := s.config.ReadInConfig(); err != nil {
+		return fmt.Errorf("base config could not be read: %v", err)
+	}
+	if err := s.config.MergeConfigMap(s.settings.AllSettings()); err != nil {
+		return err
+	}
+
+	s.config.Set("log.level", t.LogLevel)
+	var healthCheckerAddresses []string
+	for _, healthChecker := range healthCheckers {
+		healthCheckerAddresses = append(healthCheckerAddresses, fmt.Sprintf("%v:%v", healthChecker, healthCheckerPort))
+	}
+	s.config.Set("healthchecker.addresses", strings.Join(healthCheckerAddresses, ","))
+
+	if s.kind.goroutinesKey != "" {
+		if err := s.setDerived(s.kind.goroutinesKey, s.Goroutines); err != nil {
+			return err
+		}
+	}
+	if s.kind.eof != noEOFRing {
+		if err := s.setDerived("total.nodes.for.eof", eofNodes(s, stages)); err != nil {
+			return err
+		}
+	}
+	if s.kind.journeySaversKey != "" {
+		if err := s.setDerived(s.kind.journeySaversKey, journeySaversCount(stages)); err != nil {
+			return err
+		}
+	}
+	if s.kind.outputQueriesKey != "" {
+		if err := s.setDerived(s.kind.outputQueriesKey, outputQueries(s, stages)); err != nil {
+			return err
+		}
+	}
+	if s.kind.isServer {
+		for query := 1; query <= queriesCount; query++ {
+			if err := s.setDerived(fmt.Sprintf("getter.addresses.%v", query), getterAddresses(stages, query)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setDerived Sets a value that is calculated from the topology. If the settings of the stage
+// already have a different value, the topology is inconsistent and an error is returned
+func (s *stage) setDerived(key string, value interface{}) error {
+	if s.settings.IsSet(key) && fmt.Sprint(s.settings.Get(key)) != fmt.Sprint(value) {
+		return fmt.Errorf("%v is set to %v, but the topology requires %v", key, s.settings.Get(key), value)
+	}
+	s.config.Set(key, value)
+	return nil
+}
+
+// eofNodes Returns the amount of nodes that have to see the EOF before it is sent to the next stage
+func eofNodes(s *stage, stages []*stage) int {
+	if s.kind.eof == replicaRing {
+		return s.Goroutines
+	}
+	nodes := 0
+	for _, other := range stages {
+		if other.kind.eof == inputQueueRing && other.inputs[0] == s.inputs[0] {
+			nodes += other.Replicas * other.Goroutines
+		}
+	}
+	return nodes
+}
+
+// outputQueries Returns the queries fed by each output of the stage, with the form output:queries separated by commas
+func outputQueries(s *stage, stages []*stage) string {
+	var outputs []string
+	for _, output := range s.outputs {
+		queries := make(map[int]bool)
+		for _, other := range stages {
+			if slices.Contains(other.inputs, output) {
+				for _, query := range other.Queries {
+					queries[query] = true
+				}
+			}
+		}
+		var names []string
+		for _, query := range sortedQueries(queries) {
+			names = append(names, fmt.Sprint(query))
+		}
+		outputs = append(outputs, fmt.Sprintf("%v:%v", output, strings.Join(names, " ")))
+	}
+	return strings.Join(outputs, ",")
+}
+
+// journeySaversCount Returns the amount of journey savers, each one with its own routing key
+func journeySaversCount(stages []*stage) int {
+	count := 0
+	for _, s := range stages {
+		if s.kind.partitioned {
+			count += s.Partitions * s.Goroutines
+		}
+	}
+	return count
+}
+
+func getterAddresses(stages []*stage, query int) string {
+	var addresses []string
+	for _, s := range stages {
+		if s.kind.hasGetter && s.Queries[0] == query {
+			for _, c := range s.containers {
+				addresses = append(addresses, c.getterAddress)
+			}
+		}
+	}
+	return strings.Join(addresses, ",")
+}
+
+func findServer(stages []*stage) *stage {
+	for _, s := range stages {
+		if s.kind.isServer {
+			return s
+		}
+	}
+	return nil
+}
+
+func healthCheckerNames(count int) []string {
+	var names []string
+	for i := 1; i <= count; i++ {
+		names = append(names, fmt.Sprintf("healthchecker-%v", i))
+	}
+	return names
+}
@@ -0,0 +1,3 @@
+module compose_generator
+
+go 1.21
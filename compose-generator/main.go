@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	log "github.com/sirupsen/logrus"
+	"path/filepath"
+)
+
+func main() {
+	topologyPath := flag.String("topology", "./topology.yaml", "file with the topology of the system")
+	root := flag.String("root", "..", "folder of the repository, from where the compose is run")
+	outputDir := flag.String("output", "./generated", "folder where the compose and the configs are written")
+	flag.Parse()
+	_ = config.InitLogger("info")
+
+	topology, err := LoadTopology(*topologyPath)
+	if err != nil {
+		log.Fatalf("Main - Compose Generator | Error loading topology | %s", err)
+	}
+
+	configsMountDir, err := configsMountDir(*root, *outputDir)
+	if err != nil {
+		log.Fatalf("Main - Compose Generator | Error resolving the configs folder | %s", err)
+	}
+	deployment, err := Build(topology, *root, configsMountDir)
+	if err != nil {
+		log.Fatalf("Main - Compose Generator | Invalid topology | %s", err)
+	}
+
+	if err = WriteDeployment(deployment, *outputDir); err != nil {
+		log.Fatalf("Main - Compose Generator | Error writing deployment | %s", err)
+	}
+	log.Infof("Main - Compose Generator | Wrote %v services and %v configs to %v", len(deployment.Services), len(deployment.Configs), *outputDir)
+}
+
+// configsMountDir Returns the folder of the generated configs relative to the root of the repository
+func configsMountDir(root string, outputDir string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absConfigs, err := filepath.Abs(filepath.Join(outputDir, configsFolder))
+	if err != nil {
+		return "", err
+	}
+	relative, err := filepath.Rel(absRoot, absConfigs)
+	if err != nil {
+		return "", err
+	}
+	return "./" + filepath.ToSlash(relative), nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const rabbitService = "rabbitmq"
+const conditionHealthy = "service_healthy"
+const conditionStarted = "service_started"
+
+// stageServices Returns a service for each replica of the stage. The values that change between
+// replicas go in the environment, the rest is in the config file of the stage
+func stageServices(s *stage, stages []*stage, consumers map[string][]*stage, configsMountDir string) []Service {
+	dependsOn := []Dependency{{Service: rabbitService, Condition: conditionHealthy}}
+	if s.kind.waitsForConsumers {
+		dependsOn = append(dependsOn, startedDependencies(stagesAfter(s, stages, consumers))...)
+	}
+
+	var services []Service
+	for idx, c := range s.containers {
+		environment := []string{
+			fmt.Sprintf("CLI_ID=%v", c.id),
+			fmt.Sprintf("CLI_NAME=%v", c.name),
+		}
+		if s.kind.eof == inputQueueRing && idx == 0 {
+			// The first replica runs the coordinator of the EOF of the stage
+			environment = append(environment, "CLI_EOF_COORDINATOR=true")
+		}
+		if s.kind.partitioned {
+			environment = append(environment, fmt.Sprintf("CLI_RABBITMQ_RK_INPUT=%v", c.rkInput))
+		}
+		if s.kind.hasGetter {
+			environment = append(environment, fmt.Sprintf("CLI_GETTER_ADDRESS=%v", c.getterAddress))
+		}
+		services = append(services, Service{
+			Name:        c.name,
+			Image:       s.kind.image,
+			Environment: environment,
+			Volumes:     []string{fmt.Sprintf("%v/%v.yaml:/config.yaml", configsMountDir, s.Name)},
+			DependsOn:   dependsOn,
+		})
+	}
+	return services
+}
+
+// stagesAfter Returns the stages that consume the outputs of the stage. The server
+// also has to wait for the stages that return the results
+func stagesAfter(s *stage, stages []*stage, consumers map[string][]*stage) []*stage {
+	var after []*stage
+	added := make(map[string]bool)
+	add := func(other *stage) {
+		if !added[other.Name] {
+			added[other.Name] = true
+			after = append(after, other)
+		}
+	}
+	for _, output := range s.outputs {
+		for _, consumer := range consumers[output] {
+			add(consumer)
+		}
+	}
+	if s.kind.isServer {
+		for _, other := range stages {
+			if other.kind.hasGetter {
+				add(other)
+			}
+		}
+	}
+	return after
+}
+
+func startedDependencies(stages []*stage) []Dependency {
+	var dependencies []Dependency
+	for _, s := range stages {
+		for _, c := range s.containers {
+			dependencies = append(dependencies, Dependency{Service: c.name, Condition: conditionStarted})
+		}
+	}
+	return dependencies
+}
+
+func clientService(t *Topology, server *stage, id int) Service {
+	return Service{
+		Name:  "client",
+		Image: "client",
+		Environment: []string{
+			fmt.Sprintf("CLI_ID=%v", id),
+			fmt.Sprintf("CLI_LOG_LEVEL=%v", t.LogLevel),
+			fmt.Sprintf("CLI_INPUT_BATCH=%v", t.Client.Batch),
+			fmt.Sprintf("CLI_INPUT_ENCODING=%v", t.Client.Encoding),
+			fmt.Sprintf("CLI_SERVER_COMPRESSION=%v", t.Client.Compression),
+			fmt.Sprintf("CLI_SERVER_WINDOW=%v", t.Client.Window),
+			fmt.Sprintf("CLI_SERVER_CONNECTIONS=%v", t.Client.Connections),
+			fmt.Sprintf("CLI_INPUT_AIRPORTS=%v", t.Client.AirportsFile),
+			fmt.Sprintf("CLI_INPUT_FILE=%v", t.Client.FlightsFile),
+		},
+		Volumes:   []string{"./client/config.yaml:/config.yaml", "./data:/data", "./results:/results"},
+		DependsOn: []Dependency{{Service: server.Name, Condition: conditionStarted}},
+	}
+}
+
+// healthCheckerServices Returns the healthcheckers. Each one watches every container of the stages and the other healthcheckers
+func healthCheckerServices(t *Topology, stages []*stage, healthCheckers []string, firstId int) []Service {
+	var containers []string
+	for _, s := range stages {
+		for _, c := range s.containers {
+			containers = append(containers, c.name)
+		}
+	}
+
+	var services []Service
+	for i, name := range healthCheckers {
+		var others, otherAddresses, electionAddresses []string
+		for j, other := range healthCheckers {
+			if i == j {
+				continue
+			}
+			others = append(others, other)
+			otherAddresses = append(otherAddresses, fmt.Sprintf("%v:%v", other, healthCheckerPort))
+			electionAddresses = append(electionAddresses, fmt.Sprintf("%v:%v:%v", j+1, other, electionPort))
+		}
+		watched := append(append([]string{}, containers...), others...)
+		services = append(services, Service{
+			Name:  name,
+			Image: "healthchecker",
+			Environment: []string{
+				fmt.Sprintf("CLI_ID=%v", firstId+i),
+				fmt.Sprintf("CLI_LOG_LEVEL=%v", t.LogLevel),
+				fmt.Sprintf("CLI_NAME=%v", name),
+				fmt.Sprintf("CLI_HEALTHCHECKER_ADDRESS=%v:%v", name, healthCheckerPort),
+				fmt.Sprintf("CLI_HEALTHCHECKER_ELECTION_ID_ADDRESSES=%v", strings.Join(electionAddresses, ",")),
+				fmt.Sprintf("CLI_HEALTHCHECKER_ELECTION_UDP_ADDRESS=%v:%v", name, electionPort),
+				fmt.Sprintf("CLI_HEALTHCHECKER_ELECTION_ID=%v", i+1),
+				fmt.Sprintf("CLI_HEALTHCHECKER_ADDRESSES=%v", strings.Join(otherAddresses, ",")),
+				fmt.Sprintf("CLI_HEALTHCHECKER_CONTAINERS=%v", strings.Join(watched, ",")),
+			},
+			Volumes:   []string{"./healthchecker/config.yaml:/config.yaml", "/var/run/docker.sock:/var/run/docker.sock"},
+			DependsOn: []Dependency{{Service: rabbitService, Condition: conditionHealthy}},
+		})
+	}
+	return services
+}
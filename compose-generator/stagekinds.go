@@ -0,0 +1,142 @@
+package main
+
+// eofRing How the nodes of a stage forward the EOF between them until every one has seen it
+type eofRing int
+
+const (
+	// noEOFRing The stage does not forward the EOF through its input queue
+	noEOFRing eofRing = iota
+	// inputQueueRing Every goroutine of every replica that consumes the input queue is part of the ring
+	inputQueueRing
+	// replicaRing Each replica has its own input queue, so the ring is formed by the goroutines of the replica
+	replicaRing
+)
+
+// stageKind Describes a service of the system. The keys are the same that the service reads from its config
+type stageKind struct {
+	image      string
+	baseConfig string
+	inputKeys  []string
+	outputKeys []string
+	// goroutinesKey Key with the amount of goroutines consuming the input. Empty if the service has a single consumer
+	goroutinesKey string
+	eof           eofRing
+	// journeySaversKey Key with the total amount of journey savers of the query 4
+	journeySaversKey string
+	// partitioned The replicas are split in partitions by routing key, as the journey savers are
+	partitioned bool
+	// outputQueriesKey Key with the queries fed by each output, so the data of a client is only sent to the
+	// outputs of the queries it requested. Empty if the service sends the data to every output
+	outputQueriesKey string
+	// hasGetter The service returns the results of a query to the server
+	hasGetter bool
+	// waitsForConsumers The service has to start after the services that consume its outputs
+	waitsForConsumers bool
+	isServer          bool
+}
+
+var stageKinds = map[string]stageKind{
+	"server": {
+		image:             "server",
+		baseConfig:        "server/config.yaml",
+		outputKeys:        []string{"queues.flightrows", "queues.airports.exchange.name"},
+		waitsForConsumers: true,
+		isServer:          true,
+	},
+	"data_processor": {
+		image:            "data_processor",
+		baseConfig:       "data_processor/config.yaml",
+		inputKeys:        []string{"rabbitmq.queue.input"},
+		outputKeys:       []string{"rabbitmq.queue.output.ex123", "rabbitmq.queue.output.ex4"},
+		goroutinesKey:    "processor.goroutines",
+		eof:              inputQueueRing,
+		outputQueriesKey: "rabbitmq.queue.output.queries",
+	},
+	"dim_reducer": {
+		image:         "dim_reducer",
+		baseConfig:    "dim_reducer/config.yaml",
+		inputKeys:     []string{"rabbitmq.queue.input"},
+		outputKeys:    []string{"rabbitmq.queue.output"},
+		goroutinesKey: "reducer.goroutines",
+		eof:           inputQueueRing,
+	},
+	"filter_escalas": {
+		image:         "filter_escalas",
+		baseConfig:    "filters/filter_escalas/config.yaml",
+		inputKeys:     []string{"rabbitmq.queues.input"},
+		outputKeys:    []string{"rabbitmq.queues.output", "rabbitmq.exchange.outputs"},
+		goroutinesKey: "filter.goroutines",
+		eof:           inputQueueRing,
+	},
+	"filter_distancias": {
+		image:         "filter_distancias",
+		baseConfig:    "filters/filter_distancias/config.yaml",
+		inputKeys:     []string{"rabbitmq.queues.input"},
+		outputKeys:    []string{"rabbitmq.queues.output", "rabbitmq.exchange.outputs"},
+		goroutinesKey: "filter.goroutines",
+		eof:           inputQueueRing,
+	},
+	"filter_generic": {
+		image:         "filter_generic",
+		baseConfig:    "filters/filter_generic/config.yaml",
+		inputKeys:     []string{"rabbitmq.queues.input"},
+		outputKeys:    []string{"rabbitmq.queues.output", "rabbitmq.exchange.outputs"},
+		goroutinesKey: "filter.goroutines",
+		eof:           inputQueueRing,
+	},
+	"distance_completer": {
+		image:         "distance_completer",
+		baseConfig:    "distance_completer/config.yaml",
+		inputKeys:     []string{"rabbitmq.queue.input.flights", "rabbitmq.queue.input.airportexchange"},
+		outputKeys:    []string{"rabbitmq.queues.output"},
+		goroutinesKey: "completer.goroutines",
+		eof:           inputQueueRing,
+	},
+	"dispatcher_ex4": {
+		image:             "dispatcher_ex4",
+		baseConfig:        "dispatcher_ex4/config.yaml",
+		inputKeys:         []string{"rabbitmq.queue.input"},
+		outputKeys:        []string{"rabbitmq.queue.output"},
+		goroutinesKey:     "internal.dispatcher.count",
+		eof:               inputQueueRing,
+		journeySaversKey:  "savers.count",
+		waitsForConsumers: true,
+	},
+	"ex4_journey_saver": {
+		image:            "ex4_journey_saver",
+		baseConfig:       "ex4_journey_saver/config.yaml",
+		inputKeys:        []string{"rabbitmq.queue.input"},
+		outputKeys:       []string{"rabbitmq.queue.outputs.accum", "rabbitmq.queue.outputs.saver"},
+		goroutinesKey:    "internal.savers.count",
+		journeySaversKey: "total.savers.count",
+		partitioned:      true,
+	},
+	"avg_calculator_ex4": {
+		image:            "avg_calculator_ex4",
+		baseConfig:       "avg_calculator_ex4/config.yaml",
+		inputKeys:        []string{"rabbitmq.queue.input"},
+		outputKeys:       []string{"rabbitmq.queue.output"},
+		journeySaversKey: "savers.count",
+	},
+	"ex4_sink": {
+		image:            "ex4_sink",
+		baseConfig:       "ex4_sink/config.yaml",
+		inputKeys:        []string{"rabbitmq.queue.input"},
+		outputKeys:       []string{"rabbitmq.queue.output"},
+		journeySaversKey: "savers.count",
+	},
+	"simple_saver": {
+		image:      "simple_saver",
+		baseConfig: "simple_saver/config.yaml",
+		inputKeys:  []string{"rabbitmq.queue.input"},
+		hasGetter:  true,
+	},
+	"saver_ex_3": {
+		image:         "saver_ex_3",
+		baseConfig:    "saver_ex_3/config.yaml",
+		inputKeys:     []string{"rabbitmq.queue.input"},
+		goroutinesKey: "dispatchers.count",
+		eof:           replicaRing,
+		hasGetter:     true,
+	},
+}
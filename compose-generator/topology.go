@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+)
+
+// StageTopology A stage of the pipeline, deployed as one or more replicas of the same service
+type StageTopology struct {
+	Name string
+	Kind string
+	// Replicas Amount of containers of the stage
+	Replicas int
+	// Partitions Only for partitioned stages. Amount of routing keys groups, the replicas are split between them
+	Partitions int
+	// Goroutines Amount of goroutines consuming the input in each replica
+	Goroutines int
+	// Queries Queries fed by the stage
+	Queries []int
+	// Settings Configuration of the service, with the same keys that the service reads from its config file
+	Settings map[string]interface{}
+}
+
+// ClientTopology Configuration of the client of the compose
+type ClientTopology struct {
+	Batch        uint
+	Encoding     string
+	Compression  string
+	Window       uint
+	Connections  uint
+	AirportsFile string
+	FlightsFile  string
+}
+
+// Topology Declarative description of the deployment of the system
+type Topology struct {
+	LogLevel       string
+	HealthCheckers int
+	Client         ClientTopology
+	Stages         []StageTopology
+}
+
+// LoadTopology Reads the topology from a yaml file
+func LoadTopology(path string) (*Topology, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetDefault("log.level", "INFO")
+	v.SetDefault("healthcheckers", 1)
+	v.SetDefault("client.batch", 100)
+	v.SetDefault("client.encoding", "rows")
+	v.SetDefault("client.compression", "none")
+	v.SetDefault("client.window", 16)
+	v.SetDefault("client.connections", 1)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("topology could not be read from %v: %v", path, err)
+	}
+
+	var stages []StageTopology
+	if err := v.UnmarshalKey("stages", &stages); err != nil {
+		return nil, fmt.Errorf("error parsing stages: %v", err)
+	}
+	if len(stages) == 0 {
+		return nil, errors.New("missing stages")
+	}
+
+	healthCheckers := v.GetInt("healthcheckers")
+	if healthCheckers <= 0 {
+		return nil, errors.New("at least one healthchecker is needed")
+	}
+
+	return &Topology{
+		LogLevel:       v.GetString("log.level"),
+		HealthCheckers: healthCheckers,
+		Client: ClientTopology{
+			Batch:        v.GetUint("client.batch"),
+			Encoding:     v.GetString("client.encoding"),
+			Compression:  v.GetString("client.compression"),
+			Window:       v.GetUint("client.window"),
+			Connections:  v.GetUint("client.connections"),
+			AirportsFile: v.GetString("client.airports"),
+			FlightsFile:  v.GetString("client.flights"),
+		},
+		Stages: stages,
+	}, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+//go:embed templates/docker-compose.yaml.tmpl
+var composeTemplate string
+
+const composeFileName = "docker-compose.yaml"
+const configsFolder = "configs"
+
+// WriteDeployment Writes the compose file and the config of each stage in the output folder
+func WriteDeployment(d *Deployment, outputDir string) error {
+	configsDir := filepath.Join(outputDir, configsFolder)
+	if err := os.MkdirAll(configsDir, os.ModePerm); err != nil {
+		return err
+	}
+	for _, stageConfig := range d.Configs {
+		path := filepath.Join(configsDir, stageConfig.Stage+".yaml")
+		if err := stageConfig.Config.WriteConfigAs(path); err != nil {
+			return fmt.Errorf("error writing config of stage %v: %v", stageConfig.Stage, err)
+		}
+	}
+
+	tmpl, err := template.New(composeFileName).Parse(composeTemplate)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(filepath.Join(outputDir, composeFileName))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return tmpl.Execute(file, d)
+}
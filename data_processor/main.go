@@ -4,6 +4,7 @@ import (
 	"data_processor/processor"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	"github.com/brunograssano/Distribuidos-TP1/common/heartbeat"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
@@ -24,7 +25,7 @@ func main() {
 	}
 
 	qMiddleware := middleware.NewQueueMiddleware(config.RabbitAddress)
-	qFactory := queuefactory.NewSimpleQueueFactory(qMiddleware)
+	qFactory := queuefactory.NewSimpleQueueFactory(qMiddleware, config.Compressions)
 
 	var dataProcs []*processor.DataProcessor
 	for i := 0; i < config.GoroutinesCount; i++ {
@@ -38,8 +39,15 @@ func main() {
 		log.Infof("Main Data Processor | Spawning GoRoutine - Processor #%v", i)
 		go dataProcs[i].ProcessData()
 	}
-	endSigHB := heartbeat.StartHeartbeat(config.AddressesHealthCheckers, config.ServiceName)
+	if config.EOFCoordinator {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		coordinator := processor.NewEOFCoordinator(qFactory, config, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		log.Infof("Main Data Processor | Spawning GoRoutine - EOF Coordinator")
+		go coordinator.CoordinateLoop()
+	}
+	endSigHB := heartbeat.StartMemberHeartbeat(config.AddressesHealthCheckers, config.ServiceName, membership.Member{Stage: config.InputQueueName, Nodes: uint(config.GoroutinesCount)})
 	<-sigs
-	endSigHB <- true
+	heartbeat.StopMemberHeartbeat(endSigHB)
 	qMiddleware.Close()
 }
@@ -5,9 +5,10 @@ import (
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
-	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"strings"
@@ -22,55 +23,78 @@ type DataProcessor struct {
 	consumer       queueProtocol.ConsumerProtocolInterface
 	producersEx123 []queueProtocol.ProducerProtocolInterface
 	producersEx4   queueProtocol.ProducerProtocolInterface
+	// queriesEx123 Queries fed by each producer of ex 1,2,3, nil if the producer feeds every query
+	queriesEx123   [][]int
+	queriesEx4     []int
+	params         *queryparams.Registry
 	ex123Columns   []string
 	ex4Columns     []string
 	inputQueueProd queueProtocol.ProducerProtocolInterface
 	checkpointer   *checkpointer.CheckpointerHandler
-	toAllProducers []queueProtocol.ProducerProtocolInterface
+	eof            *queueProtocol.EOFReporter
+}
+
+// outputProducers Creates the producers of the outputs of exercises 1,2,3 followed by the one of exercise 4
+func outputProducers(qFactory queuefactory.QueueProtocolFactory, c *Config) []queueProtocol.ProducerProtocolInterface {
+	var producers []queueProtocol.ProducerProtocolInterface
+	for _, queueName := range c.OutputQueueNameEx123 {
+		producers = append(producers, qFactory.CreateProducer(queueName))
+	}
+	return append(producers, qFactory.CreateProducer(c.OutputQueueNameEx4))
 }
 
 // NewDataProcessor Creates a new DataProcessor structure
 func NewDataProcessor(id int, qFactory queuefactory.QueueProtocolFactory, c *Config, chkHandler *checkpointer.CheckpointerHandler) *DataProcessor {
 	consumer := qFactory.CreateConsumer(c.InputQueueName)
-	var producersEx123 []queueProtocol.ProducerProtocolInterface
-	var toAllProducers []queueProtocol.ProducerProtocolInterface
+	toCoordinator := qFactory.CreateProducer(queueProtocol.CoordinatorQueueName(c.InputQueueName))
+	eofReporter := queueProtocol.NewEOFReporter(fmt.Sprintf("%v-%v", c.ID, id), consumer, toCoordinator, outputProducers(qFactory, c))
+	producers := eofReporter.Outputs()
+	var queriesEx123 [][]int
 	for _, queueName := range c.OutputQueueNameEx123 {
-		prod := qFactory.CreateProducer(queueName)
-		producersEx123 = append(producersEx123, prod)
-		toAllProducers = append(toAllProducers, prod)
+		queriesEx123 = append(queriesEx123, c.OutputQueries[queueName])
 	}
-	producersEx4 := qFactory.CreateProducer(c.OutputQueueNameEx4)
-	toAllProducers = append(toAllProducers, producersEx4)
 	inputQProd := qFactory.CreateProducer(c.InputQueueName)
 	chkHandler.AddCheckpointable(consumer, id)
 	return &DataProcessor{
 		processorId:    id,
 		c:              c,
 		consumer:       consumer,
-		producersEx123: producersEx123,
-		producersEx4:   producersEx4,
+		producersEx123: producers[:len(c.OutputQueueNameEx123)],
+		producersEx4:   producers[len(c.OutputQueueNameEx123)],
+		queriesEx123:   queriesEx123,
+		queriesEx4:     c.OutputQueries[c.OutputQueueNameEx4],
+		params:         queryparams.NewRegistry(),
 		ex123Columns:   []string{utils.LegId, utils.StartingAirport, utils.DestinationAirport, utils.TravelDuration, utils.TotalFare, utils.TotalTravelDistance, utils.SegmentsAirlineName, utils.TotalStopovers, utils.Route},
 		ex4Columns:     []string{utils.StartingAirport, utils.DestinationAirport, utils.TotalFare},
 		inputQueueProd: inputQProd,
 		checkpointer:   chkHandler,
-		toAllProducers: toAllProducers,
+		eof:            eofReporter,
 	}
 }
 
+// NewEOFCoordinator Creates the coordinator of the EOF of the data processors, with the same outputs as them
+func NewEOFCoordinator(qFactory queuefactory.QueueProtocolFactory, c *Config, chkHandler *checkpointer.CheckpointerHandler) *queueProtocol.EOFCoordinator {
+	name := queueProtocol.CoordinatorQueueName(c.InputQueueName)
+	toNodes := qFactory.CreateProducer(c.InputQueueName)
+	return queueProtocol.NewEOFCoordinator(name, qFactory.CreateConsumer(name), toNodes, outputProducers(qFactory, c), chkHandler)
+}
+
 func (d *DataProcessor) processRows(rows []*dataStructures.DynamicMap) ([]*dataStructures.DynamicMap, []*dataStructures.DynamicMap) {
 
 	var ex123Rows []*dataStructures.DynamicMap
 	var ex4Rows []*dataStructures.DynamicMap
-	for _, cols := range rows {
-		cols, err := d.processEx123Row(cols)
+	for _, row := range rows {
+		cols, err := d.processEx123Row(row)
 		if err != nil {
 			log.Errorf("DataProcesssor %v | action: reduce_columns_ex123 | result: fail | skipping row | error: %v", d.processorId, err)
+			d.consumer.DeadLetter([]*dataStructures.DynamicMap{row}, fmt.Errorf("reduce_columns_ex123: %v", err))
 			continue
 		}
 		ex123Rows = append(ex123Rows, cols)
 		cols, err = d.processEx4Row(cols)
 		if err != nil {
 			log.Errorf("DataProcessor %v | action: reduce_columns_ex4 | result: fail | skipping row | error: %v", d.processorId, err)
+			d.consumer.DeadLetter([]*dataStructures.DynamicMap{row}, fmt.Errorf("reduce_columns_ex4: %v", err))
 			continue
 		}
 		ex4Rows = append(ex4Rows, cols)
@@ -82,36 +106,57 @@ func (d *DataProcessor) processRows(rows []*dataStructures.DynamicMap) ([]*dataS
 func (d *DataProcessor) ProcessData() {
 	defer log.Infof("DataProcessor %v | Closing goroutine...", d.processorId)
 	for {
-		msg, ok := d.consumer.Pop()
-		if !ok {
+		msg, err := d.consumer.Pop()
+		if errors.Is(err, queueProtocol.ErrConsumerClosed) {
 			return
 		}
+		if err != nil {
+			log.Errorf("DataProcessor %v | Skipping message | %v", d.processorId, err)
+			continue
+		}
 		if msg.TypeMessage == dataStructures.EOFFlightRows {
 			log.Infof("DataProcessor %v | Received EOF from server. Now finishing...", d.processorId)
-			_ = queueProtocol.HandleEOF(
-				msg,
-				d.inputQueueProd,
-				d.toAllProducers,
-				fmt.Sprintf("%v-%v", d.c.ID, d.processorId),
-				d.c.TotalEofNodes,
-			)
+			_ = d.eof.HandleEOF(msg)
+		} else if msg.TypeMessage == dataStructures.Abort {
+			log.Infof("DataProcessor %v | Received Abort of client %v. Clearing its data...", d.processorId, msg.ClientId)
+			d.consumer.ClearData(msg.ClientId)
+			d.params.Remove(msg.ClientId)
+			_ = d.eof.HandleAbort(msg, d.inputQueueProd, membership.SizeOf(msg, d.c.InputQueueName, d.c.TotalEofNodes))
+		} else if msg.TypeMessage == dataStructures.ClearClient {
+			log.Infof("DataProcessor %v | Client %v finished. Clearing its data...", d.processorId, msg.ClientId)
+			d.consumer.ClearData(msg.ClientId)
+			d.params.Remove(msg.ClientId)
+			_ = d.eof.HandleClear(msg, d.inputQueueProd, membership.SizeOf(msg, d.c.InputQueueName, d.c.TotalEofNodes))
 		} else if msg.TypeMessage == dataStructures.FlightRows {
 			log.Debugf("DataProcessor %v | Received Batch of Rows. Now processing...", d.processorId)
 			ex123Rows, ex4Rows := d.processRows(msg.DynMaps)
 			log.Debugf("DataProcessor %v | Sending processed rows to next nodes...", d.processorId)
-			d.sendToEx123(ex123Rows, msg)
-			d.sendToEx4(ex4Rows, msg)
+			params := d.params.Of(msg)
+			d.sendToEx123(ex123Rows, msg, params)
+			d.sendToEx4(ex4Rows, msg, params)
+			_ = d.eof.ReportBatch(msg)
 		} else {
 			log.Warnf("DataProcessor %v | Warning Messsage | Received unknown type of message. Skipping it...", d.processorId)
+			d.consumer.DeadLetter(msg.DynMaps, fmt.Errorf("unknown type of message %v", msg.TypeMessage))
 		}
-		err := d.checkpointer.DoCheckpoint(d.processorId)
+		err = d.checkpointer.DoCheckpoint(d.processorId)
 		if err != nil {
 			log.Errorf("DataProcessor #%v | Error on checkpointing | %v", d.processorId, err)
 		}
 	}
 }
 
-func (d *DataProcessor) sendToEx4(ex4Rows []*dataStructures.DynamicMap, oldMsg *dataStructures.Message) {
+// feedsRequestedQuery Returns true if the output feeds one of the queries requested by the client.
+// The outputs without queries configured feed every query
+func feedsRequestedQuery(queries []int, params queryparams.QueryParams) bool {
+	return queries == nil || params.RequestsAny(queries)
+}
+
+// sendToEx4 Sends the rows to the exercise 4 if the client requested it. Otherwise, the stages only receive the EOF
+func (d *DataProcessor) sendToEx4(ex4Rows []*dataStructures.DynamicMap, oldMsg *dataStructures.Message, params queryparams.QueryParams) {
+	if !feedsRequestedQuery(d.queriesEx4, params) {
+		return
+	}
 	msg := dataStructures.NewMessageWithData(oldMsg, ex4Rows)
 	err := d.producersEx4.Send(msg)
 	if err != nil {
@@ -120,9 +165,13 @@ func (d *DataProcessor) sendToEx4(ex4Rows []*dataStructures.DynamicMap, oldMsg *
 	log.Debugf("DataProcessor %v | Ending send of batch for Ex4...", d.processorId)
 }
 
-func (d *DataProcessor) sendToEx123(ex123Rows []*dataStructures.DynamicMap, oldMsg *dataStructures.Message) {
+// sendToEx123 Sends the rows to the outputs of the exercises 1,2,3 that feed a query requested by the client
+func (d *DataProcessor) sendToEx123(ex123Rows []*dataStructures.DynamicMap, oldMsg *dataStructures.Message, params queryparams.QueryParams) {
 	msg := dataStructures.NewMessageWithData(oldMsg, ex123Rows)
-	for _, producer := range d.producersEx123 {
+	for i, producer := range d.producersEx123 {
+		if i < len(d.queriesEx123) && !feedsRequestedQuery(d.queriesEx123[i], params) {
+			continue
+		}
 		err := producer.Send(msg)
 		if err != nil {
 			log.Errorf("DataProcessor %v | Error trying to send to exercises 1,2,3 the serialized row | %v", d.processorId, err)
@@ -149,10 +198,10 @@ func (d *DataProcessor) processEx123Row(cols *dataStructures.DynamicMap) (*dataS
 	}
 
 	totalStopovers := uint32(splittedSegmentsLen) - destinationAirport
-	cols.AddColumn(utils.TotalStopovers, serializer.SerializeUint(totalStopovers))
+	cols.AddColumn(utils.TotalStopovers, dataStructures.NewInt32Column(int32(totalStopovers)))
 
 	route := startingAirport + utils.DoublePipeSeparator + segments
-	cols.AddColumn(utils.Route, serializer.SerializeString(route))
+	cols.AddColumn(utils.Route, dataStructures.NewStringColumn(route))
 	return cols.ReduceToColumns(d.ex123Columns)
 }
 
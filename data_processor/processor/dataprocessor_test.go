@@ -4,6 +4,7 @@ import (
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -47,18 +48,25 @@ func (m *mockConsumer) ClearData(s string) {
 	panic("implement me")
 }
 
-func (m *mockConsumer) SetStatusOfLastMessage(b bool) {}
+func (m *mockConsumer) RejectLastMessage(_ error) bool {
+	return true
+}
+
+func (m *mockConsumer) DeadLetter(_ []*dataStructures.DynamicMap, _ error) {}
 
 func (m *mockConsumer) GetReceivedMessages(s string) int {
 	return 0
 }
 
-func (m *mockConsumer) Pop() (*dataStructures.Message, bool) {
+func (m *mockConsumer) Pop() (*dataStructures.Message, error) {
 	if !m.ok {
-		return nil, m.ok
+		return nil, queueProtocol.ErrConsumerClosed
 	}
 	msg, ok := <-m.inputChannel
-	return msg, ok
+	if !ok {
+		return nil, queueProtocol.ErrConsumerClosed
+	}
+	return msg, nil
 }
 
 type (
@@ -107,13 +115,15 @@ func TestShouldGetAMessageProcessItAndSendItToAllChannels(t *testing.T) {
 		producersEx4:   mProducer4,
 		ex123Columns:   []string{utils.StartingAirport, utils.SegmentsArrivalAirportCode, utils.TotalStopovers, utils.Route},
 		ex4Columns:     []string{utils.Route},
+		params:         queryparams.NewRegistry(),
 		checkpointer:   checkpointer.NewCheckpointerHandler(),
+		eof:            queueProtocol.NewEOFReporter("0", mConsumer, &mockProducer{outputChannel: make(chan *dataStructures.Message, 10)}, nil),
 	}
 
-	dynMap := make(map[string][]byte)
-	dynMap[utils.StartingAirport] = []byte("FRA")
-	dynMap[utils.SegmentsArrivalAirportCode] = []byte("EZE")
-	dynMap["col"] = []byte("Even more data")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap[utils.StartingAirport] = dataStructures.NewStringColumn("FRA")
+	dynMap[utils.SegmentsArrivalAirportCode] = dataStructures.NewStringColumn("EZE")
+	dynMap["col"] = dataStructures.NewStringColumn("Even more data")
 
 	row := dataStructures.NewDynamicMap(dynMap)
 
@@ -147,15 +157,62 @@ func TestShouldGetAMessageProcessItAndSendItToAllChannels(t *testing.T) {
 	}
 }
 
+func TestShouldOnlySendTheRowsToTheOutputsOfTheRequestedQueries(t *testing.T) {
+	input := make(chan *dataStructures.Message, 10)
+	outputEx13 := make(chan *dataStructures.Message, 10)
+	outputEx2 := make(chan *dataStructures.Message, 10)
+	outputEx4 := make(chan *dataStructures.Message, 10)
+
+	consumer := &mockConsumer{inputChannel: input, ok: true}
+	processor := &DataProcessor{
+		processorId:    0,
+		c:              &Config{},
+		consumer:       consumer,
+		producersEx123: []queueProtocol.ProducerProtocolInterface{&mockProducer{outputChannel: outputEx2}, &mockProducer{outputChannel: outputEx13}},
+		producersEx4:   &mockProducer{outputChannel: outputEx4},
+		queriesEx123:   [][]int{{2}, {1, 3}},
+		queriesEx4:     []int{4},
+		params:         queryparams.NewRegistry(),
+		ex123Columns:   []string{utils.StartingAirport, utils.SegmentsArrivalAirportCode, utils.TotalStopovers, utils.Route},
+		ex4Columns:     []string{utils.Route},
+		checkpointer:   checkpointer.NewCheckpointerHandler(),
+		eof:            queueProtocol.NewEOFReporter("0", consumer, &mockProducer{outputChannel: make(chan *dataStructures.Message, 10)}, nil),
+	}
+
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap[utils.StartingAirport] = dataStructures.NewStringColumn("FRA")
+	dynMap[utils.SegmentsArrivalAirportCode] = dataStructures.NewStringColumn("EZE")
+	params := queryparams.Default()
+	params.Queries = []int{3}
+
+	go processor.ProcessData()
+	input <- &dataStructures.Message{TypeMessage: dataStructures.FlightRows, ClientId: "client", DynMaps: []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMap)}, Params: params.ToDynMap()}
+	close(input)
+
+	select {
+	case result := <-outputEx13:
+		assert.Len(t, result.DynMaps, 1)
+	case <-time.After(1 * time.Second):
+		t.Errorf("Timeout! Should have sent the rows to the output of the query 3...")
+	}
+	select {
+	case <-outputEx2:
+		t.Errorf("Should not have sent the rows to the output of the query 2")
+	case <-outputEx4:
+		t.Errorf("Should not have sent the rows to the output of the query 4")
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
 func TestShouldProcessTheDataOfEx123(t *testing.T) {
 	processor := &DataProcessor{
 		processorId:  0,
 		ex123Columns: []string{utils.TotalStopovers, utils.Route},
 	}
-	dynMap := make(map[string][]byte)
-	dynMap[utils.StartingAirport] = []byte("FRA")
-	dynMap[utils.SegmentsArrivalAirportCode] = []byte("CDG||EZE")
-	dynMap["col"] = []byte("Even more data")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap[utils.StartingAirport] = dataStructures.NewStringColumn("FRA")
+	dynMap[utils.SegmentsArrivalAirportCode] = dataStructures.NewStringColumn("CDG||EZE")
+	dynMap["col"] = dataStructures.NewStringColumn("Even more data")
 
 	row := dataStructures.NewDynamicMap(dynMap)
 	row, err := processor.processEx123Row(row)
@@ -176,8 +233,8 @@ func TestShouldReturnAnErrorIfTheSegmentsColDoesNotExist(t *testing.T) {
 		processorId:  0,
 		ex123Columns: []string{utils.TotalStopovers, utils.Route},
 	}
-	dynMap := make(map[string][]byte)
-	dynMap[utils.StartingAirport] = []byte("FRA")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap[utils.StartingAirport] = dataStructures.NewStringColumn("FRA")
 
 	row := dataStructures.NewDynamicMap(dynMap)
 	row, err := processor.processEx123Row(row)
@@ -190,8 +247,8 @@ func TestShouldReturnAnErrorIfTheStartingAirportColDoesNotExist(t *testing.T) {
 		processorId:  0,
 		ex123Columns: []string{utils.TotalStopovers, utils.Route},
 	}
-	dynMap := make(map[string][]byte)
-	dynMap[utils.SegmentsArrivalAirportCode] = []byte("CDG||EZE")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap[utils.SegmentsArrivalAirportCode] = dataStructures.NewStringColumn("CDG||EZE")
 
 	row := dataStructures.NewDynamicMap(dynMap)
 	row, err := processor.processEx123Row(row)
@@ -3,6 +3,8 @@ package processor
 import (
 	"errors"
 	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -20,6 +22,12 @@ type Config struct {
 	ServiceName             string
 	AddressesHealthCheckers []string
 	TotalEofNodes           uint
+	// EOFCoordinator The replica runs the coordinator of the EOF of the stage. Only one replica of the stage runs it
+	EOFCoordinator bool
+	// OutputQueries Queries fed by each output queue. The outputs that are not in it receive the data of every client
+	OutputQueries map[string][]int
+	// Compressions Compression of the messages sent to each queue or exchange
+	Compressions queues.Compressions
 }
 
 // InitEnv Initializes the configuration properties from a config file and environment
@@ -37,14 +45,17 @@ func InitEnv() (*viper.Viper, error) {
 	// Add env variables supported
 	_ = v.BindEnv("id")
 	_ = v.BindEnv("log", "level")
+	_ = v.BindEnv("compression")
 	_ = v.BindEnv("rabbitmq", "address")
 	_ = v.BindEnv("rabbitmq", "queue", "input")
 	_ = v.BindEnv("rabbitmq", "queue", "output", "ex123")
 	_ = v.BindEnv("rabbitmq", "queue", "output", "ex4")
+	_ = v.BindEnv("rabbitmq", "queue", "output", "queries")
 	_ = v.BindEnv("processor", "goroutines")
 	_ = v.BindEnv("name")
 	_ = v.BindEnv("healthchecker", "addresses")
 	_ = v.BindEnv("total", "nodes", "for", "eof")
+	_ = v.BindEnv("eof", "coordinator")
 	// Try to read configuration from config file. If config file
 	// does not exist then ReadInConfig will fail but configuration
 	// can be loaded from the environment variables, so we shouldn't
@@ -63,6 +74,11 @@ func GetConfig(env *viper.Viper) (*Config, error) {
 		return nil, err
 	}
 
+	compressions, err := config.GetCompressions(env.GetStringMapString("compression"))
+	if err != nil {
+		return nil, err
+	}
+
 	id := env.GetString("id")
 	if id == "" {
 		return nil, errors.New("missing id")
@@ -84,6 +100,11 @@ func GetConfig(env *viper.Viper) (*Config, error) {
 		return nil, errors.New("missing output queue for ex 4")
 	}
 
+	outputQueries, err := queryparams.ParseOutputQueries(env.GetString("rabbitmq.queue.output.queries"))
+	if err != nil {
+		return nil, err
+	}
+
 	rabbitAddress := env.GetString("rabbitmq.address")
 	if rabbitAddress == "" {
 		return nil, errors.New("missing rabbitmq address")
@@ -111,21 +132,24 @@ func GetConfig(env *viper.Viper) (*Config, error) {
 		goroutinesCount = utils.DefaultGoroutines
 	}
 
-	log.Infof("DataProcessorConfig | action: config | result: success | id: %s | log_level: %s | rabbitAddress: %v | inputQueueName: %v | outputQueueNameEx123: %v | outputQueueNameEx4: %v | goroutinesCount: %v",
+	log.Infof("DataProcessorConfig | action: config | result: success | id: %s | log_level: %s | rabbitAddress: %v | inputQueueName: %v | outputQueueNameEx123: %v | outputQueueNameEx4: %v | outputQueries: %v | goroutinesCount: %v",
 		id,
 		env.GetString("log.level"),
 		rabbitAddress,
-		inputQueueName, outputQueueNameEx123, outputQueueNameEx4, goroutinesCount)
+		inputQueueName, outputQueueNameEx123, outputQueueNameEx4, outputQueries, goroutinesCount)
 
 	return &Config{
 		ID:                      id,
 		InputQueueName:          inputQueueName,
 		OutputQueueNameEx123:    outputQueueNameEx123Array,
 		OutputQueueNameEx4:      outputQueueNameEx4,
+		OutputQueries:           outputQueries,
 		GoroutinesCount:         goroutinesCount,
 		RabbitAddress:           rabbitAddress,
 		ServiceName:             serviceName,
 		AddressesHealthCheckers: healthCheckerAddresses,
 		TotalEofNodes:           TotalEofNodes,
+		EOFCoordinator:          env.GetBool("eof.coordinator"),
+		Compressions:            compressions,
 	}, nil
 }
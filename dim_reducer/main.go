@@ -4,7 +4,9 @@ import (
 	"dim_reducer/reducer"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	"github.com/brunograssano/Distribuidos-TP1/common/heartbeat"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
@@ -24,15 +26,16 @@ func main() {
 	}
 
 	qMiddleware := middleware.NewQueueMiddleware(config.RabbitAddress)
-	simpleFactory := queuefactory.NewSimpleQueueFactory(qMiddleware)
-	fanoutFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.OutputQueueName, "")
+	simpleFactory := queuefactory.NewSimpleQueueFactory(qMiddleware, config.Compressions)
+	fanoutFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.OutputQueueName, "", config.Compressions)
 	var services []*reducer.Reducer
 	for i := 0; i < config.GoroutinesCount; i++ {
 		checkpointerHandler := checkpointer.NewCheckpointerHandler()
 		consumer := simpleFactory.CreateConsumer(config.InputQueueName)
 		producer := fanoutFactory.CreateProducer(config.OutputQueueName)
 		prodToCons := simpleFactory.CreateProducer(config.InputQueueName)
-		r := reducer.NewReducer(i, consumer, producer, prodToCons, config, checkpointerHandler)
+		toCoordinator := simpleFactory.CreateProducer(queueProtocol.CoordinatorQueueName(config.InputQueueName))
+		r := reducer.NewReducer(i, consumer, producer, prodToCons, toCoordinator, config, checkpointerHandler)
 		services = append(services, r)
 		checkpointerHandler.RestoreCheckpoint()
 
@@ -41,8 +44,15 @@ func main() {
 		log.Infof("Main Reducer | Spawning GoRoutine - Reducer #%v", i)
 		go services[i].ReduceDims()
 	}
-	endSigHB := heartbeat.StartHeartbeat(config.AddressesHealthCheckers, config.ServiceName)
+	if config.EOFCoordinator {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		coordinator := reducer.NewEOFCoordinator(simpleFactory, fanoutFactory, config, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		log.Infof("Main Reducer | Spawning GoRoutine - EOF Coordinator")
+		go coordinator.CoordinateLoop()
+	}
+	endSigHB := heartbeat.StartMemberHeartbeat(config.AddressesHealthCheckers, config.ServiceName, membership.Member{Stage: config.InputQueueName, Nodes: uint(config.GoroutinesCount)})
 	<-sigs
-	endSigHB <- true
+	heartbeat.StopMemberHeartbeat(endSigHB)
 	qMiddleware.Close()
 }
@@ -1,10 +1,13 @@
 package reducer
 
 import (
+	"errors"
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -16,6 +19,7 @@ type Reducer struct {
 	producer     queueProtocol.ProducerProtocolInterface
 	prodToCons   queueProtocol.ProducerProtocolInterface
 	checkpointer *checkpointer.CheckpointerHandler
+	eof          *queueProtocol.EOFReporter
 }
 
 // NewReducer Creates a new reducer
@@ -24,49 +28,79 @@ func NewReducer(
 	consumer queueProtocol.ConsumerProtocolInterface,
 	producer queueProtocol.ProducerProtocolInterface,
 	prodToCons queueProtocol.ProducerProtocolInterface,
+	toCoordinator queueProtocol.ProducerProtocolInterface,
 	c *Config,
 	chkHandler *checkpointer.CheckpointerHandler,
 ) *Reducer {
 	chkHandler.AddCheckpointable(consumer, reducerId)
+	eofReporter := queueProtocol.NewEOFReporter(fmt.Sprintf("%v-%v", c.ID, reducerId), consumer, toCoordinator, []queueProtocol.ProducerProtocolInterface{producer})
 	return &Reducer{
 		reducerId:    reducerId,
 		c:            c,
 		consumer:     consumer,
-		producer:     producer,
+		producer:     eofReporter.Outputs()[0],
 		prodToCons:   prodToCons,
 		checkpointer: chkHandler,
+		eof:          eofReporter,
 	}
 }
 
+// NewEOFCoordinator Creates the coordinator of the EOF of the reducers, that sends the EOF to the output of the factory
+func NewEOFCoordinator(
+	qFactory queuefactory.QueueProtocolFactory,
+	outputFactory queuefactory.QueueProtocolFactory,
+	c *Config,
+	chkHandler *checkpointer.CheckpointerHandler,
+) *queueProtocol.EOFCoordinator {
+	name := queueProtocol.CoordinatorQueueName(c.InputQueueName)
+	output := outputFactory.CreateProducer(c.OutputQueueName)
+	toNodes := qFactory.CreateProducer(c.InputQueueName)
+	return queueProtocol.NewEOFCoordinator(name, qFactory.CreateConsumer(name), toNodes, []queueProtocol.ProducerProtocolInterface{output}, chkHandler)
+}
+
 // ReduceDims Loop that waits for input from the queue, reduces the rows by removing columns
 // and sends the result to the next step
 func (r *Reducer) ReduceDims() {
 	log.Infof("DimReducer %v | Started goroutine", r.reducerId)
 	for {
-		msg, ok := r.consumer.Pop()
-		if !ok {
+		msg, err := r.consumer.Pop()
+		if errors.Is(err, queueProtocol.ErrConsumerClosed) {
 			log.Infof("DimReducer %v | Closing goroutine...", r.reducerId)
 			return
 		}
+		if err != nil {
+			log.Errorf("DimReducer %v | Skipping message | %v", r.reducerId, err)
+			continue
+		}
 		if msg.TypeMessage == dataStructures.EOFFlightRows {
 			log.Infof("DimReducer %v | Received EOF. Now handling...", r.reducerId)
-			err := queueProtocol.HandleEOF(
-				msg,
-				r.prodToCons,
-				[]queueProtocol.ProducerProtocolInterface{r.producer},
-				fmt.Sprintf("%v-%v", r.c.ID, r.reducerId),
-				r.c.TotalEofNodes,
-			)
+			err := r.eof.HandleEOF(msg)
 			if err != nil {
 				log.Errorf("DimReducer %v | Error handling EOF: %v", r.reducerId, err)
 			}
+		} else if msg.TypeMessage == dataStructures.Abort {
+			log.Infof("DimReducer %v | Received Abort of client %v. Clearing its data...", r.reducerId, msg.ClientId)
+			r.consumer.ClearData(msg.ClientId)
+			err := r.eof.HandleAbort(msg, r.prodToCons, membership.SizeOf(msg, r.c.InputQueueName, r.c.TotalEofNodes))
+			if err != nil {
+				log.Errorf("DimReducer %v | Error handling Abort: %v", r.reducerId, err)
+			}
+		} else if msg.TypeMessage == dataStructures.ClearClient {
+			log.Infof("DimReducer %v | Client %v finished. Clearing its data...", r.reducerId, msg.ClientId)
+			r.consumer.ClearData(msg.ClientId)
+			err := r.eof.HandleClear(msg, r.prodToCons, membership.SizeOf(msg, r.c.InputQueueName, r.c.TotalEofNodes))
+			if err != nil {
+				log.Errorf("DimReducer %v | Error handling Clear: %v", r.reducerId, err)
+			}
 		} else if msg.TypeMessage == dataStructures.FlightRows {
 			log.Debugf("DimReducer %v | Received flight rows. Now handling...", r.reducerId)
 			r.handleFlightRows(msg)
+			_ = r.eof.ReportBatch(msg)
 		} else {
 			log.Warnf("DimReducer %v | Received unknown type message. Skipping it...", r.reducerId)
+			r.consumer.DeadLetter(msg.DynMaps, fmt.Errorf("unknown type of message %v", msg.TypeMessage))
 		}
-		err := r.checkpointer.DoCheckpoint(r.reducerId)
+		err = r.checkpointer.DoCheckpoint(r.reducerId)
 		if err != nil {
 			log.Errorf("DimReducer #%v | Error on checkpointing | %v", r.reducerId, err)
 		}
@@ -79,6 +113,7 @@ func (r *Reducer) handleFlightRows(msg *dataStructures.Message) {
 		reducedData, err := row.ReduceToColumns(r.c.ColumnsToKeep)
 		if err != nil {
 			log.Errorf("DimReducer %v | Error reducing column, skipping row | error: %v", r.reducerId, err)
+			r.consumer.DeadLetter([]*dataStructures.DynamicMap{row}, fmt.Errorf("reduce_columns: %v", err))
 			continue
 		}
 		rows = append(rows, reducedData)
@@ -3,6 +3,7 @@ package reducer
 import (
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
@@ -40,7 +41,11 @@ func (m *mockConsumerQueueProtocolHandler) Abort(i int, errors chan error) {
 	panic("implement me")
 }
 
-func (m *mockConsumerQueueProtocolHandler) SetStatusOfLastMessage(b bool) {}
+func (m *mockConsumerQueueProtocolHandler) RejectLastMessage(_ error) bool {
+	return true
+}
+
+func (m *mockConsumerQueueProtocolHandler) DeadLetter(_ []*dataStructures.DynamicMap, _ error) {}
 
 func (m *mockConsumerQueueProtocolHandler) ClearData(s string) {}
 
@@ -48,12 +53,15 @@ func (m *mockConsumerQueueProtocolHandler) GetReceivedMessages(s string) int {
 	return 0
 }
 
-func (m *mockConsumerQueueProtocolHandler) Pop() (*dataStructures.Message, bool) {
+func (m *mockConsumerQueueProtocolHandler) Pop() (*dataStructures.Message, error) {
 	if !m.ok {
-		return nil, m.ok
+		return nil, queueProtocol.ErrConsumerClosed
 	}
 	msg, ok := <-m.inputChannel
-	return msg, ok
+	if !ok {
+		return nil, queueProtocol.ErrConsumerClosed
+	}
+	return msg, nil
 }
 
 func (m *mockConsumerQueueProtocolHandler) BindTo(_ string, _ string, _ string) error {
@@ -96,11 +104,12 @@ func TestShouldGetAMessageReduceItAndSendIt(t *testing.T) {
 		consumer:     mConsumer,
 		producer:     mProducer,
 		checkpointer: checkpointer.NewCheckpointerHandler(),
+		eof:          queueProtocol.NewEOFReporter("0", mConsumer, &mockProducerQueueProtocolHandler{outputChannel: make(chan *dataStructures.Message, 10)}, nil),
 	}
 
-	dynMap := make(map[string][]byte)
-	dynMap["col1"] = []byte("Some data")
-	dynMap["col2"] = []byte("More data")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["col1"] = dataStructures.NewStringColumn("Some data")
+	dynMap["col2"] = dataStructures.NewStringColumn("More data")
 
 	row := dataStructures.NewDynamicMap(dynMap)
 
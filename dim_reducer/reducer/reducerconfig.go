@@ -3,6 +3,7 @@ package reducer
 import (
 	"errors"
 	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -20,6 +21,10 @@ type Config struct {
 	AddressesHealthCheckers []string
 	ServiceName             string
 	TotalEofNodes           uint
+	// EOFCoordinator The replica runs the coordinator of the EOF of the stage. Only one replica of the stage runs it
+	EOFCoordinator bool
+	// Compressions Compression of the messages sent to each queue or exchange
+	Compressions queues.Compressions
 }
 
 // InitEnv Initializes the configuration properties from a config file and environment
@@ -37,6 +42,7 @@ func InitEnv() (*viper.Viper, error) {
 	// Add env variables supported
 	_ = v.BindEnv("id")
 	_ = v.BindEnv("log", "level")
+	_ = v.BindEnv("compression")
 	_ = v.BindEnv("rabbitmq", "address")
 	_ = v.BindEnv("rabbitmq", "queue", "input")
 	_ = v.BindEnv("rabbitmq", "queue", "output")
@@ -45,6 +51,7 @@ func InitEnv() (*viper.Viper, error) {
 	_ = v.BindEnv("name")
 	_ = v.BindEnv("healthchecker", "addresses")
 	_ = v.BindEnv("total", "nodes", "for", "eof")
+	_ = v.BindEnv("eof", "coordinator")
 	// Try to read configuration from config file. If config file
 	// does not exist then ReadInConfig will fail but configuration
 	// can be loaded from the environment variables, so we shouldn't
@@ -63,6 +70,11 @@ func GetConfig(env *viper.Viper) (*Config, error) {
 		return nil, err
 	}
 
+	compressions, err := config.GetCompressions(env.GetStringMapString("compression"))
+	if err != nil {
+		return nil, err
+	}
+
 	id := env.GetString("id")
 	if id == "" {
 		return nil, errors.New("missing id")
@@ -128,5 +140,7 @@ func GetConfig(env *viper.Viper) (*Config, error) {
 		AddressesHealthCheckers: healthCheckerAddresses,
 		ServiceName:             serviceName,
 		TotalEofNodes:           TotalEofNodes,
+		EOFCoordinator:          env.GetBool("eof.coordinator"),
+		Compressions:            compressions,
 	}, nil
 }
@@ -0,0 +1,82 @@
+package ex4
+
+import (
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
+	"github.com/brunograssano/Distribuidos-TP1/common/dispatcher"
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+)
+
+type DispatcherEx4 struct {
+	dispatchers []*dispatcher.JourneyDispatcher
+	// coordinator Coordinator of the EOF of the stage. Nil if another replica runs it
+	coordinator *queueProtocol.EOFCoordinator
+	c           *DispatcherEx4Config
+	qMiddleware middleware.QueueMiddlewareI
+}
+
+// toJourneySavers Creates the producers to the journey savers in the view of each client, with their index as routing key
+func toJourneySavers(qMiddleware middleware.QueueMiddlewareI, c *DispatcherEx4Config) *queueProtocol.KeyedOutputs {
+	exchangeFactory := queuefactory.NewTopicFactory(qMiddleware, []string{""}, c.OutputExchangeName, c.Compressions)
+	return queueProtocol.NewKeyedOutputs(c.OutputExchangeName, c.SaversCount, func(idx int) queueProtocol.ProducerProtocolInterface {
+		return exchangeFactory.CreateProducer(strconv.Itoa(idx))
+	})
+}
+
+func NewDispatcherEx4(dispatcherConfig *DispatcherEx4Config, qMiddleware middleware.QueueMiddlewareI) *DispatcherEx4 {
+	simpleFactory := queuefactory.NewSimpleQueueFactory(qMiddleware, dispatcherConfig.Compressions)
+	var dispatchers []*dispatcher.JourneyDispatcher
+	var coordinator *queueProtocol.EOFCoordinator
+	log.Infof("DispatcherEx4 | Creating %v dispatchers...", dispatcherConfig.DispatchersCount)
+	for idx := uint(0); idx < dispatcherConfig.DispatchersCount; idx++ {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		inputQueue := simpleFactory.CreateConsumer(dispatcherConfig.InputQueueName)
+		prodToInput := simpleFactory.CreateProducer(dispatcherConfig.InputQueueName)
+		toCoordinator := simpleFactory.CreateProducer(queueProtocol.CoordinatorQueueName(dispatcherConfig.InputQueueName))
+		tmpDispatcher := dispatcher.NewJourneyDispatcher(
+			idx,
+			inputQueue,
+			prodToInput,
+			toCoordinator,
+			toJourneySavers(qMiddleware, dispatcherConfig),
+			checkpointerHandler,
+			dispatcherConfig.TotalEofNodes,
+			fmt.Sprintf("%v-%v", dispatcherConfig.ID, idx),
+			dispatcherConfig.InputQueueName,
+			dispatcherConfig.Partitioning,
+		)
+		dispatchers = append(dispatchers, tmpDispatcher)
+		checkpointerHandler.RestoreCheckpoint()
+	}
+	if dispatcherConfig.EOFCoordinator {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		name := queueProtocol.CoordinatorQueueName(dispatcherConfig.InputQueueName)
+		toNodes := simpleFactory.CreateProducer(dispatcherConfig.InputQueueName)
+		coordinator = queueProtocol.NewKeyedEOFCoordinator(name, simpleFactory.CreateConsumer(name), toNodes, toJourneySavers(qMiddleware, dispatcherConfig), checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+	}
+	return &DispatcherEx4{
+		dispatchers: dispatchers,
+		coordinator: coordinator,
+		c:           dispatcherConfig,
+		qMiddleware: qMiddleware,
+	}
+}
+
+func (de4 *DispatcherEx4) StartDispatch() {
+	log.Infof("DispatcherEx4 | Spawning %v dispatchers...", de4.c.DispatchersCount)
+	for idx := uint(0); idx < de4.c.DispatchersCount; idx++ {
+		go de4.dispatchers[idx].DispatchLoop()
+	}
+	if de4.coordinator != nil {
+		go de4.coordinator.CoordinateLoop()
+	}
+}
+
+func (de4 *DispatcherEx4) Close() {
+	de4.qMiddleware.Close()
+}
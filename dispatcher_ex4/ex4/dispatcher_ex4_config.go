@@ -1,4 +1,4 @@
-package main
+package ex4
 
 import (
 	"errors"
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	"github.com/brunograssano/Distribuidos-TP1/common/dispatcher"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
@@ -21,6 +23,12 @@ type DispatcherEx4Config struct {
 	ServiceName             string
 	AddressesHealthCheckers []string
 	TotalEofNodes           uint
+	// EOFCoordinator The replica runs the coordinator of the EOF of the stage. Only one replica of the stage runs it
+	EOFCoordinator bool
+	// Partitioning How the journeys are split between the savers
+	Partitioning *dispatcher.PartitioningConfig
+	// Compressions Compression of the messages sent to each queue or exchange
+	Compressions queues.Compressions
 }
 
 // InitEnv Initializes the configuration properties from a config file and environment
@@ -34,6 +42,7 @@ func InitEnv() (*viper.Viper, error) {
 
 	_ = v.BindEnv("id")
 	_ = v.BindEnv("log", "level")
+	_ = v.BindEnv("compression")
 	_ = v.BindEnv("rabbitmq", "address")
 	_ = v.BindEnv("rabbitmq", "queue", "input")
 	_ = v.BindEnv("rabbitmq", "queue", "output")
@@ -42,6 +51,10 @@ func InitEnv() (*viper.Viper, error) {
 	_ = v.BindEnv("name")
 	_ = v.BindEnv("healthchecker", "addresses")
 	_ = v.BindEnv("total", "nodes", "for", "eof")
+	_ = v.BindEnv("dispatcher", "partitioner")
+	_ = v.BindEnv("dispatcher", "virtualnodes")
+	_ = v.BindEnv("dispatcher", "key", "columns")
+	_ = v.BindEnv("eof", "coordinator")
 
 	v.SetConfigFile("./config.yaml")
 	if err := v.ReadInConfig(); err != nil {
@@ -57,6 +70,11 @@ func GetConfig(env *viper.Viper) (*DispatcherEx4Config, error) {
 		return nil, err
 	}
 
+	compressions, err := config.GetCompressions(env.GetStringMapString("compression"))
+	if err != nil {
+		return nil, err
+	}
+
 	id := env.GetString("id")
 	if id == "" {
 		return nil, errors.New("missing id")
@@ -108,12 +126,18 @@ func GetConfig(env *viper.Viper) (*DispatcherEx4Config, error) {
 		return nil, err
 	}
 
-	log.Infof("DispatcherEx4Config | action: config | result: success | id: %s | log_level: %s | rabbitAddress: %v | inputQueueName: %v | saversCount: %v",
+	partitioning, err := dispatcher.NewPartitioningConfig(env.GetString("dispatcher.partitioner"), env.GetUint("dispatcher.virtualnodes"), env.GetString("dispatcher.key.columns"))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("DispatcherEx4Config | action: config | result: success | id: %s | log_level: %s | rabbitAddress: %v | inputQueueName: %v | saversCount: %v | partitioning: %v",
 		id,
 		env.GetString("log.level"),
 		rabbitAddress,
 		inputQueueName,
-		saversCount)
+		saversCount,
+		partitioning)
 
 	return &DispatcherEx4Config{
 		ID:                      id,
@@ -125,5 +149,8 @@ func GetConfig(env *viper.Viper) (*DispatcherEx4Config, error) {
 		AddressesHealthCheckers: healthCheckerAddresses,
 		ServiceName:             serviceName,
 		TotalEofNodes:           TotalEofNodes,
+		EOFCoordinator:          env.GetBool("eof.coordinator"),
+		Partitioning:            partitioning,
+		Compressions:            compressions,
 	}, nil
 }
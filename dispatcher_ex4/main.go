@@ -1,27 +1,31 @@
 package main
 
 import (
+	"dispatcher_ex4/ex4"
 	"github.com/brunograssano/Distribuidos-TP1/common/heartbeat"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 )
 
 func main() {
 	sigs := utils.CreateSignalListener()
-	env, err := InitEnv()
+	env, err := ex4.InitEnv()
 	if err != nil {
 		log.Fatalf("Main - DispatcherEx4 | Error initializing env | %s", err)
 	}
-	config, err := GetConfig(env)
+	config, err := ex4.GetConfig(env)
 	if err != nil {
 		log.Fatalf("Main - DispatcherEx4 | Error initializing Config | %s", err)
 	}
-	dispatcherEx4 := NewDispatcherEx4(config)
+	qMiddleware := middleware.NewQueueMiddleware(config.RabbitAddress)
+	dispatcherEx4 := ex4.NewDispatcherEx4(config, qMiddleware)
 	log.Infof("Main - DispatcherEx4 | Spawned DispatcherEx4")
 	go dispatcherEx4.StartDispatch()
-	endSigHB := heartbeat.StartHeartbeat(config.AddressesHealthCheckers, config.ServiceName)
+	endSigHB := heartbeat.StartMemberHeartbeat(config.AddressesHealthCheckers, config.ServiceName, membership.Member{Stage: config.InputQueueName, Nodes: config.DispatchersCount})
 	<-sigs
-	endSigHB <- true
+	heartbeat.StopMemberHeartbeat(endSigHB)
 	log.Infof("Main - DispatcherEx4 | Ending DispatcherEx4")
 	dispatcherEx4.Close()
 
@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/retention"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -24,6 +26,11 @@ type CompleterConfig struct {
 	ServiceName                string
 	AddressesHealthCheckers    []string
 	TotalEofNodes              uint
+	// EOFCoordinator The replica runs the coordinator of the EOF of the stage. Only one replica of the stage runs it
+	EOFCoordinator bool
+	Retention      *retention.Config
+	// Compressions Compression of the messages sent to each queue or exchange
+	Compressions queues.Compressions
 }
 
 func InitEnv() (*viper.Viper, error) {
@@ -36,6 +43,7 @@ func InitEnv() (*viper.Viper, error) {
 
 	_ = v.BindEnv("id")
 	_ = v.BindEnv("log", "level")
+	_ = v.BindEnv("compression")
 	_ = v.BindEnv("rabbitmq", "queue", "input", "airport")
 	_ = v.BindEnv("rabbitmq", "queue", "input", "flights")
 	_ = v.BindEnv("rabbitmq", "queue", "output")
@@ -48,6 +56,13 @@ func InitEnv() (*viper.Viper, error) {
 	_ = v.BindEnv("name")
 	_ = v.BindEnv("healthchecker", "addresses")
 	_ = v.BindEnv("total", "nodes", "for", "eof")
+	_ = v.BindEnv("eof", "coordinator")
+	_ = v.BindEnv("retention", "ttl")
+	_ = v.BindEnv("retention", "fetched")
+	_ = v.BindEnv("retention", "interval")
+	_ = v.BindEnv("retry", "delay")
+	_ = v.BindEnv("retry", "maxdelay")
+	_ = v.BindEnv("retry", "attempts")
 
 	v.SetConfigFile("./config.yaml")
 	if err := v.ReadInConfig(); err != nil {
@@ -62,6 +77,17 @@ func GetConfig(env *viper.Viper) (*CompleterConfig, error) {
 		return nil, err
 	}
 
+	compressions, err := config.GetCompressions(env.GetStringMapString("compression"))
+	if err != nil {
+		return nil, err
+	}
+
+	// The batches of flights that arrive before the airports of their client are retried later
+	err = config.InitRetryPolicy(env.GetDuration("retry.delay"), env.GetDuration("retry.maxdelay"), env.GetInt("retry.attempts"))
+	if err != nil {
+		return nil, err
+	}
+
 	id := env.GetString("id")
 	if id == "" {
 		return nil, errors.New("missing id")
@@ -129,6 +155,11 @@ func GetConfig(env *viper.Viper) (*CompleterConfig, error) {
 		return nil, errors.New("missing total nodes for eof")
 	}
 
+	retentionConfig, err := retention.NewConfig(id, env.GetString("retention.ttl"), env.GetString("retention.fetched"), env.GetString("retention.interval"))
+	if err != nil {
+		return nil, err
+	}
+
 	log.Infof("DistCompleterConfig | action: config | result: success | id: %s | log_level: %s | inputQueueAirportName: %v | inputQueueFlightName: %v | outputQueueNames: %v | goroutinesCount: %v | airportsFilename: %v | exchangeName: %v | routingKey: %v",
 		id,
 		env.GetString("log.level"),
@@ -155,5 +186,8 @@ func GetConfig(env *viper.Viper) (*CompleterConfig, error) {
 		AddressesHealthCheckers:    healthCheckerAddresses,
 		ServiceName:                serviceName,
 		TotalEofNodes:              TotalEofNodes,
+		EOFCoordinator:             env.GetBool("eof.coordinator"),
+		Retention:                  retentionConfig,
+		Compressions:               compressions,
 	}, nil
 }
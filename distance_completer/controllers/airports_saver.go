@@ -2,12 +2,14 @@ package controllers
 
 import (
 	"distance_completer/config"
+	"errors"
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
 	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
+	"github.com/brunograssano/Distribuidos-TP1/common/retention"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"strings"
@@ -20,12 +22,14 @@ type AirportSaver struct {
 	consumer     queues.ConsumerProtocolInterface
 	fileSavers   map[string]*filemanager.FileWriter
 	checkpointer *checkpointer.CheckpointerHandler
+	collector    *retention.Collector
 }
 
 func NewAirportSaver(
 	conf *config.CompleterConfig,
 	qFactory queuefactory.QueueProtocolFactory,
 	chkHandler *checkpointer.CheckpointerHandler,
+	collector *retention.Collector,
 ) *AirportSaver {
 	consumer := qFactory.CreateConsumer(fmt.Sprintf("%v-%v", conf.InputQueueAirportsName, conf.ID))
 	// We do a checkpoint to remember duplicates
@@ -35,9 +39,20 @@ func NewAirportSaver(
 		consumer:     consumer,
 		fileSavers:   make(map[string]*filemanager.FileWriter),
 		checkpointer: chkHandler,
+		collector:    collector,
 	}
 }
 
+// NewCollector Creates the collector of the airports saved for the clients
+func NewCollector(conf *config.CompleterConfig) *retention.Collector {
+	return retention.NewCollector(conf.Retention, func(clientId string) []string {
+		return []string{
+			conf.AirportsFilename + "_" + clientId + utils.CsvSuffix,
+			conf.AirportsFilename + "_" + clientId + utils.TempSuffix + utils.CsvSuffix,
+		}
+	})
+}
+
 func (as *AirportSaver) createFileWriter(clientId string) *filemanager.FileWriter {
 	_, exists := as.fileSavers[clientId]
 	if exists {
@@ -68,26 +83,33 @@ func (as *AirportSaver) markFileAsDone(clientId string) error {
 		as.c.AirportsFilename+"_"+clientId+utils.TempSuffix+utils.CsvSuffix,
 		as.c.AirportsFilename+"_"+clientId+utils.CsvSuffix,
 	)
+	as.collector.Track(clientId)
 	log.Infof("AirportsSaver | Marked file for client id %v as finished", clientId)
 	return nil
 }
 
 func (as *AirportSaver) SaveAirports() {
 	for {
-		msg, ok := as.consumer.Pop()
-		if !ok {
+		msg, err := as.consumer.Pop()
+		if errors.Is(err, queues.ErrConsumerClosed) {
 			log.Infof("AirportsSaver | Closing goroutine...")
 			return
 		}
+		if err != nil {
+			log.Errorf("AirportsSaver | Skipping message | %v", err)
+			continue
+		}
 		log.Debugf("AirportsSaver | Received message | {type: %v, rowCount: %v}", msg.TypeMessage, len(msg.DynMaps))
 		if msg.TypeMessage == dataStructures.EOFAirports {
 			as.handleAirportsEOF(msg)
 		} else if msg.TypeMessage == dataStructures.Airports {
 			as.handleAirports(msg)
+		} else if msg.TypeMessage == dataStructures.Abort {
+			as.handleAbort(msg)
 		} else {
 			log.Warnf("AirportsSaver | Received Unknown Type of Message | Type was: %v", msg.TypeMessage)
 		}
-		err := as.checkpointer.DoCheckpoint(airportsSaverId)
+		err = as.checkpointer.DoCheckpoint(airportsSaverId)
 		if err != nil {
 			log.Errorf("AirportsSaver | Error on checkpointing | %v", err)
 		}
@@ -115,22 +137,46 @@ func (as *AirportSaver) handleAirportsEOF(msgStruct *dataStructures.Message) {
 	}
 }
 
+// handleAbort Closes the file of the airports of the client and deletes it, along with the one already finished
+func (as *AirportSaver) handleAbort(msgStruct *dataStructures.Message) {
+	log.Infof("AirportsSaver | Received Abort of client %v. Deleting its airports...", msgStruct.ClientId)
+	as.consumer.ClearData(msgStruct.ClientId)
+	fileWriter, exists := as.fileSavers[msgStruct.ClientId]
+	if exists {
+		err := fileWriter.FileManager.Close()
+		if err != nil {
+			log.Errorf("AirportsSaver | Error trying to close file | %v", err)
+		}
+		delete(as.fileSavers, msgStruct.ClientId)
+	}
+	err := filemanager.DeleteIfExists(
+		as.c.AirportsFilename+"_"+msgStruct.ClientId+utils.TempSuffix+utils.CsvSuffix,
+		as.c.AirportsFilename+"_"+msgStruct.ClientId+utils.CsvSuffix,
+	)
+	if err != nil {
+		log.Errorf("AirportsSaver | Error deleting the airports of client %v | %v", msgStruct.ClientId, err)
+	}
+}
+
 func (as *AirportSaver) getLineToSave(rows []*dataStructures.DynamicMap) string {
 	var stringToSave strings.Builder
 	for _, row := range rows {
 		airportCode, err := row.GetAsString(utils.AirportCode)
 		if err != nil {
 			log.Errorf("AirportsSaver | Error trying to get airport code | %v | Skipping row...", err)
+			as.consumer.DeadLetter([]*dataStructures.DynamicMap{row}, err)
 			continue
 		}
 		lat, err := row.GetAsFloat(utils.Latitude)
 		if err != nil {
 			log.Errorf("AirportsSaver | Error trying to get latitude | %v | Skipping row...", err)
+			as.consumer.DeadLetter([]*dataStructures.DynamicMap{row}, err)
 			continue
 		}
 		long, err := row.GetAsFloat(utils.Longitude)
 		if err != nil {
 			log.Errorf("AirportsSaver | Error trying to get longitude | %v | Skipping row...", err)
+			as.consumer.DeadLetter([]*dataStructures.DynamicMap{row}, err)
 			continue
 		}
 		stringToSave.WriteString(fmt.Sprintf("%v,%v,%v\n", airportCode, lat, long))
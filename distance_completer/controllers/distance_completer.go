@@ -2,13 +2,14 @@ package controllers
 
 import (
 	"distance_completer/config"
+	"errors"
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
-	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"strconv"
@@ -18,10 +19,13 @@ import (
 type DistanceCompleter struct {
 	completerId  int
 	airportsMaps map[string]map[string][2]float32
+	// aborted Clients that cancelled their session, until every node cleared them. Their rows are discarded instead of waiting for the airports
+	aborted      map[string]bool
 	c            *config.CompleterConfig
 	consumer     queueProtocol.ConsumerProtocolInterface
 	producer     queueProtocol.ProducerProtocolInterface
 	prodForCons  queueProtocol.ProducerProtocolInterface
+	eof          *queueProtocol.EOFReporter
 	checkpointer *checkpointer.CheckpointerHandler
 }
 
@@ -34,18 +38,34 @@ func NewDistanceCompleter(
 	consumer := qFactory.CreateConsumer(c.InputQueueFlightsName)
 	producer := qFactory.CreateProducer(c.OutputQueueName)
 	producerForCons := qFactory.CreateProducer(c.InputQueueFlightsName)
+	toCoordinator := qFactory.CreateProducer(queueProtocol.CoordinatorQueueName(c.InputQueueFlightsName))
 	chkHandler.AddCheckpointable(consumer, id)
+	eofReporter := queueProtocol.NewEOFReporter(fmt.Sprintf("%v-%v", c.ID, id), consumer, toCoordinator, []queueProtocol.ProducerProtocolInterface{producer})
 	return &DistanceCompleter{
 		completerId:  id,
 		airportsMaps: make(map[string]map[string][2]float32),
+		aborted:      make(map[string]bool),
 		c:            c,
 		consumer:     consumer,
-		producer:     producer,
+		producer:     eofReporter.Outputs()[0],
 		prodForCons:  producerForCons,
+		eof:          eofReporter,
 		checkpointer: chkHandler,
 	}
 }
 
+// NewEOFCoordinator Creates the coordinator of the EOF of the completers, that sends the EOF to their output queue
+func NewEOFCoordinator(
+	qFactory queuefactory.QueueProtocolFactory,
+	c *config.CompleterConfig,
+	chkHandler *checkpointer.CheckpointerHandler,
+) *queueProtocol.EOFCoordinator {
+	name := queueProtocol.CoordinatorQueueName(c.InputQueueFlightsName)
+	output := qFactory.CreateProducer(c.OutputQueueName)
+	toNodes := qFactory.CreateProducer(c.InputQueueFlightsName)
+	return queueProtocol.NewEOFCoordinator(name, qFactory.CreateConsumer(name), toNodes, []queueProtocol.ProducerProtocolInterface{output}, chkHandler)
+}
+
 func (dc *DistanceCompleter) calculateDirectDistance(flightRow *dataStructures.DynamicMap, clientId string) (float32, error) {
 
 	originId, errOri := flightRow.GetAsString(utils.StartingAirport)
@@ -69,8 +89,7 @@ func (dc *DistanceCompleter) calculateDirectDistance(flightRow *dataStructures.D
 }
 
 func (dc *DistanceCompleter) addColumnToRow(key string, value float32, row *dataStructures.DynamicMap) {
-	bytes := serializer.SerializeFloat(value)
-	row.AddColumn(key, bytes)
+	row.AddColumn(key, dataStructures.NewFloat32Column(value))
 }
 
 func (dc *DistanceCompleter) calculateTotalTravelDistance(flightRow *dataStructures.DynamicMap, clientId string) (float32, error) {
@@ -143,26 +162,52 @@ func (dc *DistanceCompleter) loadAirports(clientId string) {
 
 func (dc *DistanceCompleter) CompleteDistances() {
 	for {
-		msg, ok := dc.consumer.Pop()
-		if !ok {
+		msg, err := dc.consumer.Pop()
+		if errors.Is(err, queueProtocol.ErrConsumerClosed) {
 			log.Infof("DistanceCompleter %v | Closing goroutine...", dc.completerId)
 			return
 		}
+		if err != nil {
+			log.Errorf("DistanceCompleter %v | Skipping message | %v", dc.completerId, err)
+			continue
+		}
 		log.Debugf("DistanceCompleter %v | Received Message | {type: %v, rowCount:%v}", dc.completerId, msg.TypeMessage, len(msg.DynMaps))
 		if msg.TypeMessage == dataStructures.EOFFlightRows {
 			log.Infof("DistanceCompleter %v | Received EOF. Handling...", dc.completerId)
-			err := queueProtocol.HandleEOF(msg, dc.prodForCons, []queueProtocol.ProducerProtocolInterface{dc.producer}, fmt.Sprintf("%v-%v", dc.c.ID, dc.completerId), dc.c.TotalEofNodes)
+			err := dc.eof.HandleEOF(msg)
 			if err != nil {
 				log.Errorf("DistanceCompleter %v | Error handling EOF | %v", dc.completerId, err)
 			}
 			dc.clearInternalState(msg.ClientId)
+		} else if msg.TypeMessage == dataStructures.Abort {
+			log.Infof("DistanceCompleter %v | Received Abort of client %v. Clearing its data...", dc.completerId, msg.ClientId)
+			dc.consumer.ClearData(msg.ClientId)
+			dc.clearInternalState(msg.ClientId)
+			dc.aborted[msg.ClientId] = true
+			err := dc.eof.HandleAbort(msg, dc.prodForCons, membership.SizeOf(msg, dc.c.InputQueueFlightsName, dc.c.TotalEofNodes))
+			if err != nil {
+				log.Errorf("DistanceCompleter %v | Error handling Abort | %v", dc.completerId, err)
+			}
+		} else if msg.TypeMessage == dataStructures.ClearClient {
+			log.Infof("DistanceCompleter %v | Client %v finished. Clearing its data...", dc.completerId, msg.ClientId)
+			dc.consumer.ClearData(msg.ClientId)
+			dc.clearInternalState(msg.ClientId)
+			// The clear goes after the abort went through every node, so the rows of the client were already discarded
+			delete(dc.aborted, msg.ClientId)
+			err := dc.eof.HandleClear(msg, dc.prodForCons, membership.SizeOf(msg, dc.c.InputQueueFlightsName, dc.c.TotalEofNodes))
+			if err != nil {
+				log.Errorf("DistanceCompleter %v | Error handling Clear | %v", dc.completerId, err)
+			}
 		} else if msg.TypeMessage == dataStructures.FlightRows {
 			log.Debugf("DistanceCompleter %v | Received Batch. Handling rows to be completed...", dc.completerId)
-			dc.handleFlightRows(msg)
+			if dc.handleFlightRows(msg) {
+				_ = dc.eof.ReportBatch(msg)
+			}
 		} else {
 			log.Warnf("DistanceCompleter %v | Warning Message | Unknown type of message: %v. Skipping it...", dc.completerId, msg.TypeMessage)
+			dc.consumer.DeadLetter(msg.DynMaps, fmt.Errorf("unknown type of message %v", msg.TypeMessage))
 		}
-		err := dc.checkpointer.DoCheckpoint(dc.completerId)
+		err = dc.checkpointer.DoCheckpoint(dc.completerId)
 		if err != nil {
 			log.Errorf("DistanceCompleter #%v | Error on checkpointing | %v", dc.completerId, err)
 		}
@@ -185,12 +230,18 @@ func (dc *DistanceCompleter) checkForAirports(clientId string) bool {
 	return exists
 }
 
-func (dc *DistanceCompleter) handleFlightRows(msg *dataStructures.Message) {
+// handleFlightRows Completes the distances of the rows and sends them. Returns false if the airports of the client
+// are not saved yet, so the batch is retried later instead of processed. After the max attempts it goes to the
+// dead-letter queue, and it is processed as a batch without rows
+func (dc *DistanceCompleter) handleFlightRows(msg *dataStructures.Message) bool {
+	if dc.aborted[msg.ClientId] {
+		log.Debugf("DistanceCompleter %v | Client %v aborted its session | Discarding rows...", dc.completerId, msg.ClientId)
+		return true
+	}
 	rows := msg.DynMaps
 	existAirports := dc.checkForAirports(msg.ClientId)
 	if !existAirports {
-		dc.consumer.SetStatusOfLastMessage(false)
-		return
+		return !dc.consumer.RejectLastMessage(fmt.Errorf("the airports of client %v are not saved", msg.ClientId))
 	}
 	var nextBatch []*dataStructures.DynamicMap
 	for _, row := range rows {
@@ -200,6 +251,7 @@ func (dc *DistanceCompleter) handleFlightRows(msg *dataStructures.Message) {
 			totalTravelDistance, err = dc.calculateTotalTravelDistance(row, msg.ClientId)
 			if err != nil {
 				log.Errorf("DistanceCompleter %v | Error adding totalTravelDistance | %v | Skipping row...", dc.completerId, err)
+				dc.consumer.DeadLetter([]*dataStructures.DynamicMap{row}, err)
 				continue
 			}
 			dc.addColumnToRow(utils.TotalTravelDistance, totalTravelDistance, row)
@@ -209,6 +261,7 @@ func (dc *DistanceCompleter) handleFlightRows(msg *dataStructures.Message) {
 		directDistance, err := dc.calculateDirectDistance(row, msg.ClientId)
 		if err != nil {
 			log.Errorf("DistanceCompleter %v | Error adding directDistance | %v | Skipping row...", dc.completerId, err)
+			dc.consumer.DeadLetter([]*dataStructures.DynamicMap{row}, err)
 			continue
 		}
 		dc.addColumnToRow(utils.DirectDistance, directDistance, row)
@@ -218,4 +271,5 @@ func (dc *DistanceCompleter) handleFlightRows(msg *dataStructures.Message) {
 	log.Debugf("DistanceCompleter %v | Finished processing batch. Sending to next queue...", dc.completerId)
 	msgToSend := dataStructures.NewMessageWithData(msg, nextBatch)
 	dc.sendNext(msgToSend)
+	return true
 }
@@ -4,6 +4,7 @@ import (
 	"distance_completer/config"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	"github.com/stretchr/testify/assert"
 	"math"
@@ -48,16 +49,21 @@ func (m *mockConsumer) GetReceivedMessages(s string) int {
 	return 0
 }
 
-func (m *mockConsumer) SetStatusOfLastMessage(_ bool) {
-
+func (m *mockConsumer) RejectLastMessage(_ error) bool {
+	return true
 }
 
-func (m *mockConsumer) Pop() (*dataStructures.Message, bool) {
+func (m *mockConsumer) DeadLetter(_ []*dataStructures.DynamicMap, _ error) {}
+
+func (m *mockConsumer) Pop() (*dataStructures.Message, error) {
 	if !m.ok {
-		return &dataStructures.Message{}, m.ok
+		return nil, queueProtocol.ErrConsumerClosed
 	}
 	msg, ok := <-m.inputChannel
-	return msg, ok
+	if !ok {
+		return nil, queueProtocol.ErrConsumerClosed
+	}
+	return msg, nil
 }
 
 type (
@@ -101,13 +107,14 @@ func TestCompleteDistancesForAFlightThatHasTwoStopoversSatisfiesGeneralCondition
 		c:            &config.CompleterConfig{},
 		consumer:     mockCons,
 		producer:     mockProd,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *dataStructures.Message, 10)}, nil),
 		checkpointer: checkpointer.NewCheckpointerHandler(),
 	}
 	go distCompleter.CompleteDistances()
-	dynMapWithRoute := make(map[string][]byte)
-	dynMapWithRoute[utils.StartingAirport] = []byte("A")
-	dynMapWithRoute[utils.DestinationAirport] = []byte("D")
-	dynMapWithRoute[utils.Route] = []byte("A||B||C||D")
+	dynMapWithRoute := make(map[string]dataStructures.Column)
+	dynMapWithRoute[utils.StartingAirport] = dataStructures.NewStringColumn("A")
+	dynMapWithRoute[utils.DestinationAirport] = dataStructures.NewStringColumn("D")
+	dynMapWithRoute[utils.Route] = dataStructures.NewStringColumn("A||B||C||D")
 	dynMapStructure := dataStructures.NewDynamicMap(dynMapWithRoute)
 	input <- &dataStructures.Message{TypeMessage: dataStructures.FlightRows, DynMaps: []*dataStructures.DynamicMap{dynMapStructure}, ClientId: "1"}
 	dynMapResult := (<-output).DynMaps[0]
@@ -145,13 +152,14 @@ func TestDirectDistanceShouldBeSameAsTotalTravelDistance(t *testing.T) {
 		c:            &config.CompleterConfig{},
 		consumer:     mockCons,
 		producer:     mockProd,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *dataStructures.Message, 10)}, nil),
 		checkpointer: checkpointer.NewCheckpointerHandler(),
 	}
 	go distCompleter.CompleteDistances()
-	dynMapWithRoute := make(map[string][]byte)
-	dynMapWithRoute[utils.StartingAirport] = []byte("A")
-	dynMapWithRoute[utils.DestinationAirport] = []byte("D")
-	dynMapWithRoute[utils.Route] = []byte("A||B||C||D")
+	dynMapWithRoute := make(map[string]dataStructures.Column)
+	dynMapWithRoute[utils.StartingAirport] = dataStructures.NewStringColumn("A")
+	dynMapWithRoute[utils.DestinationAirport] = dataStructures.NewStringColumn("D")
+	dynMapWithRoute[utils.Route] = dataStructures.NewStringColumn("A||B||C||D")
 	dynMapStructure := dataStructures.NewDynamicMap(dynMapWithRoute)
 	input <- &dataStructures.Message{TypeMessage: dataStructures.FlightRows, DynMaps: []*dataStructures.DynamicMap{dynMapStructure}, ClientId: "1"}
 	dynMapResult := (<-output).DynMaps[0]
@@ -191,13 +199,14 @@ func TestTotalTravelDistanceShouldBeThreeTimesTheDirectDistance(t *testing.T) {
 		c:            &config.CompleterConfig{},
 		consumer:     mockCons,
 		producer:     mockProd,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *dataStructures.Message, 10)}, nil),
 		checkpointer: checkpointer.NewCheckpointerHandler(),
 	}
 	go distCompleter.CompleteDistances()
-	dynMapWithRoute := make(map[string][]byte)
-	dynMapWithRoute[utils.StartingAirport] = []byte("A")
-	dynMapWithRoute[utils.DestinationAirport] = []byte("D")
-	dynMapWithRoute[utils.Route] = []byte("A||B||C||D")
+	dynMapWithRoute := make(map[string]dataStructures.Column)
+	dynMapWithRoute[utils.StartingAirport] = dataStructures.NewStringColumn("A")
+	dynMapWithRoute[utils.DestinationAirport] = dataStructures.NewStringColumn("D")
+	dynMapWithRoute[utils.Route] = dataStructures.NewStringColumn("A||B||C||D")
 	dynMapStructure := dataStructures.NewDynamicMap(dynMapWithRoute)
 	input <- &dataStructures.Message{TypeMessage: dataStructures.FlightRows, DynMaps: []*dataStructures.DynamicMap{dynMapStructure}, ClientId: "1"}
 	dynMapResult := (<-output).DynMaps[0]
@@ -214,3 +223,80 @@ func TestTotalTravelDistanceShouldBeThreeTimesTheDirectDistance(t *testing.T) {
 		"totalTravelDistance should be equal to three times the directDistance. 3*DDistance = %v, DDistance = %v, TTDistance = %v", dDistance*3, dDistance, ttDistance)
 
 }
+
+func TestAbortShouldClearTheAirportsOfTheClientAndDiscardItsRows(t *testing.T) {
+	input := make(chan *dataStructures.Message, 10)
+	output := make(chan *dataStructures.Message, 10)
+	mapAirports := make(map[string]map[string][2]float32)
+	for _, clientId := range []string{"1", "2"} {
+		mapAirports[clientId] = make(map[string][2]float32)
+		mapAirports[clientId]["A"] = [2]float32{0.0, 0.0}
+		mapAirports[clientId]["B"] = [2]float32{1.0, 0.0}
+	}
+	mockCons := &mockConsumer{inputChannel: input, ok: true}
+	eofReporter := queueProtocol.NewEOFReporter(
+		"0",
+		mockCons,
+		&mockProducer{outputChannel: make(chan *dataStructures.Message, 10)},
+		[]queueProtocol.ProducerProtocolInterface{&mockProducer{outputChannel: output}},
+	)
+	distCompleter := &DistanceCompleter{
+		completerId:  0,
+		airportsMaps: mapAirports,
+		aborted:      make(map[string]bool),
+		c:            &config.CompleterConfig{TotalEofNodes: 1},
+		consumer:     mockCons,
+		producer:     eofReporter.Outputs()[0],
+		eof:          eofReporter,
+		checkpointer: checkpointer.NewCheckpointerHandler(),
+	}
+	go distCompleter.CompleteDistances()
+	dynMapNodes := make(map[string]dataStructures.Column)
+	dynMapNodes[utils.NodesVisited] = dataStructures.NewStringColumn("")
+	input <- &dataStructures.Message{TypeMessage: dataStructures.Abort, DynMaps: []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMapNodes)}, ClientId: "1"}
+	abort := <-output
+	assert.Equalf(t, dataStructures.Abort, abort.TypeMessage, "Expected the Abort to be sent to the next step, got: %v", abort.TypeMessage)
+
+	for _, clientId := range []string{"1", "2"} {
+		dynMapWithRoute := make(map[string]dataStructures.Column)
+		dynMapWithRoute[utils.StartingAirport] = dataStructures.NewStringColumn("A")
+		dynMapWithRoute[utils.DestinationAirport] = dataStructures.NewStringColumn("B")
+		dynMapWithRoute[utils.Route] = dataStructures.NewStringColumn("A||B")
+		input <- &dataStructures.Message{TypeMessage: dataStructures.FlightRows, DynMaps: []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMapWithRoute)}, ClientId: clientId}
+	}
+	completed := <-output
+	assert.Equalf(t, "2", completed.ClientId, "Only the rows of the client that did not abort should be completed, got client: %v", completed.ClientId)
+	close(input)
+}
+
+func TestTheAbortedClientIsForgottenOnceItIsCleared(t *testing.T) {
+	input := make(chan *dataStructures.Message, 10)
+	toCoordinator := make(chan *dataStructures.Message, 10)
+	mockCons := &mockConsumer{inputChannel: input, ok: true}
+	eofReporter := queueProtocol.NewEOFReporter(
+		"0",
+		mockCons,
+		&mockProducer{outputChannel: toCoordinator},
+		[]queueProtocol.ProducerProtocolInterface{&mockProducer{outputChannel: make(chan *dataStructures.Message, 10)}},
+	)
+	distCompleter := &DistanceCompleter{
+		completerId:  0,
+		airportsMaps: make(map[string]map[string][2]float32),
+		aborted:      make(map[string]bool),
+		c:            &config.CompleterConfig{TotalEofNodes: 1},
+		consumer:     mockCons,
+		producer:     eofReporter.Outputs()[0],
+		eof:          eofReporter,
+		checkpointer: checkpointer.NewCheckpointerHandler(),
+	}
+	go distCompleter.CompleteDistances()
+	for _, typeMessage := range []int{dataStructures.Abort, dataStructures.ClearClient} {
+		dynMapNodes := make(map[string]dataStructures.Column)
+		dynMapNodes[utils.NodesVisited] = dataStructures.NewStringColumn("")
+		input <- &dataStructures.Message{TypeMessage: typeMessage, DynMaps: []*dataStructures.DynamicMap{dataStructures.NewDynamicMap(dynMapNodes)}, ClientId: "1"}
+		msg := <-toCoordinator
+		assert.Equal(t, typeMessage, msg.TypeMessage)
+	}
+	assert.Empty(t, distCompleter.aborted, "The aborted client should be forgotten once every node cleared it")
+	close(input)
+}
@@ -5,10 +5,13 @@ import (
 	"distance_completer/controllers"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	"github.com/brunograssano/Distribuidos-TP1/common/heartbeat"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
+	"github.com/brunograssano/Distribuidos-TP1/common/retention"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
+	"os"
 )
 
 func main() {
@@ -24,9 +27,14 @@ func main() {
 		log.Fatalf("Main - Distance Completer | Error initializing config | %s", err)
 	}
 
+	collector := controllers.NewCollector(config)
+	if retention.HandleCommand(os.Args[1:], collector) {
+		return
+	}
+
 	qMiddleware := middleware.NewQueueMiddleware(config.RabbitAddress)
-	simpleFactory := queuefactory.NewSimpleQueueFactory(qMiddleware)
-	exchangeFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.ExchangeNameAirports, config.RoutingKeyExchangeAirports)
+	simpleFactory := queuefactory.NewSimpleQueueFactory(qMiddleware, config.Compressions)
+	exchangeFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.ExchangeNameAirports, config.RoutingKeyExchangeAirports, config.Compressions)
 	var services []*controllers.DistanceCompleter
 	for i := 0; i < config.GoroutinesCount; i++ {
 		checkpointerHandler := checkpointer.NewCheckpointerHandler()
@@ -45,17 +53,27 @@ func main() {
 		log.Infof("Main Completer | Spawning GoRoutine - Completer #%v", i)
 		go service.CompleteDistances()
 	}
+	if config.EOFCoordinator {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		coordinator := controllers.NewEOFCoordinator(simpleFactory, config, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		log.Infof("Main Completer | Spawning GoRoutine - EOF Coordinator")
+		go coordinator.CoordinateLoop()
+	}
 	checkpointerHandler := checkpointer.NewCheckpointerHandler()
 	airportsSaver := controllers.NewAirportSaver(
 		config,
 		exchangeFactory,
 		checkpointerHandler,
+		collector,
 	)
 	checkpointerHandler.RestoreCheckpoint()
 	go airportsSaver.SaveAirports()
+	go collector.CollectLoop()
 
-	endSigHB := heartbeat.StartHeartbeat(config.AddressesHealthCheckers, config.ServiceName)
+	endSigHB := heartbeat.StartMemberHeartbeat(config.AddressesHealthCheckers, config.ServiceName, membership.Member{Stage: config.InputQueueFlightsName, Nodes: uint(config.GoroutinesCount)})
 	<-sigs
-	endSigHB <- true
+	heartbeat.StopMemberHeartbeat(endSigHB)
 	qMiddleware.Close()
+	collector.Close()
 }
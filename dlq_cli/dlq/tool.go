@@ -0,0 +1,167 @@
+package dlq
+
+import (
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/deadletter"
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"time"
+)
+
+// QueueBrowser Reads the messages of a queue without removing them until they are acked
+type QueueBrowser interface {
+	Next() ([]byte, uint64, bool, error)
+	Ack(deliveryTag uint64) error
+}
+
+// Filter Dead letters to select. The zero value selects all of them
+type Filter struct {
+	ClientId string
+	Limit    int
+}
+
+func (f Filter) matches(info *deadletter.Info) bool {
+	return f.ClientId == "" || f.ClientId == info.ClientId
+}
+
+// deadLetterRead Dead letter read from the queue, with its position in the queue
+type deadLetterRead struct {
+	position    int
+	deliveryTag uint64
+	msg         *dataStructures.Message
+	info        *deadletter.Info
+}
+
+// Tool Lists, inspects and replays the dead letters of a queue
+type Tool struct {
+	browser QueueBrowser
+	out     io.Writer
+}
+
+func NewTool(browser QueueBrowser, out io.Writer) *Tool {
+	return &Tool{browser: browser, out: out}
+}
+
+// forEach Reads the dead letters of the queue that match the filter, until the handler returns false.
+// The messages that are not dead letters are logged and skipped
+func (t *Tool) forEach(filter Filter, handler func(read *deadLetterRead) (bool, error)) error {
+	selected := 0
+	for position := 0; filter.Limit <= 0 || selected < filter.Limit; position++ {
+		bytes, deliveryTag, ok, err := t.browser.Next()
+		if err != nil || !ok {
+			return err
+		}
+		msg, err := serializer.DeserializeMsg(bytes)
+		if err != nil {
+			log.Errorf("DeadLetterTool | Skipping message %v, it could not be deserialized | %v", position, err)
+			continue
+		}
+		info, err := deadletter.InfoOf(msg)
+		if err != nil {
+			log.Errorf("DeadLetterTool | Skipping message %v | %v", position, err)
+			continue
+		}
+		if !filter.matches(info) {
+			continue
+		}
+		selected++
+		keepReading, err := handler(&deadLetterRead{position: position, deliveryTag: deliveryTag, msg: msg, info: info})
+		if err != nil || !keepReading {
+			return err
+		}
+	}
+	return nil
+}
+
+// List Prints a line with the information of each dead letter
+func (t *Tool) List(filter Filter) error {
+	_, _ = fmt.Fprintf(t.out, "position\ttime\tstage\tnode\tclient\tmessage\ttype\trows\treason\n")
+	return t.forEach(filter, func(read *deadLetterRead) (bool, error) {
+		info := read.info
+		_, err := fmt.Fprintf(t.out, "%v\t%v\t%v\t%v\t%v\t%v-%v\t%v\t%v\t%v\n",
+			read.position, info.Time.Format(time.RFC3339), info.Stage, info.Node, info.ClientId,
+			info.MessageId, info.RowId, typeName(info), info.Rows, info.Reason)
+		return true, err
+	})
+}
+
+// Inspect Prints the information and the rows of the dead letter in the position of the queue
+func (t *Tool) Inspect(position int) error {
+	found := false
+	err := t.forEach(Filter{}, func(read *deadLetterRead) (bool, error) {
+		if read.position < position {
+			return true, nil
+		}
+		found = read.position == position
+		if found {
+			t.printDeadLetter(read)
+		}
+		return false, nil
+	})
+	if err == nil && !found {
+		return fmt.Errorf("there is no dead letter in position %v", position)
+	}
+	return err
+}
+
+func (t *Tool) printDeadLetter(read *deadLetterRead) {
+	info := read.info
+	_, _ = fmt.Fprintf(t.out, "position: %v\nstage: %v\nnode: %v\ntime: %v\nreason: %v\nclient: %v\nmessage: %v\nrow: %v\ntype: %v\nrows: %v\n",
+		read.position, info.Stage, info.Node, info.Time.Format(time.RFC3339), info.Reason, info.ClientId,
+		info.MessageId, info.RowId, typeName(info), info.Rows)
+	original, raw, err := deadletter.Original(read.msg)
+	if err != nil {
+		log.Errorf("DeadLetterTool | Error getting the original message | %v", err)
+		return
+	}
+	if info.Raw {
+		_, _ = fmt.Fprintf(t.out, "bytes: %v\n", len(raw))
+		return
+	}
+	for _, row := range original.DynMaps {
+		// The dynmap serializer adds \n
+		_, _ = fmt.Fprint(t.out, serializer.SerializeToString(row))
+	}
+}
+
+func typeName(info *deadletter.Info) string {
+	if info.Raw {
+		return "raw"
+	}
+	return fmt.Sprint(info.Type)
+}
+
+// Replay Sends the original message of each dead letter to the producer and removes it from the dead-letter queue.
+// The messages get new ids from firstMessageId, as the duplicates detector of the stage could have seen the
+// original ones. Returns the dead letters replayed
+func (t *Tool) Replay(filter Filter, producer middleware.ProducerInterface, firstMessageId uint) (int, error) {
+	protocolProducer := queues.NewProducerQueueProtocolHandler(producer, serializer.NoCompression)
+	replayed := 0
+	err := t.forEach(filter, func(read *deadLetterRead) (bool, error) {
+		original, raw, err := deadletter.Original(read.msg)
+		if err != nil {
+			return false, err
+		}
+		if read.info.Raw {
+			err = producer.Send(raw)
+		} else {
+			original.MessageId = firstMessageId + uint(replayed)
+			original.RowId = 0
+			err = protocolProducer.Send(original)
+		}
+		if err != nil {
+			return false, fmt.Errorf("error replaying dead letter %v: %v", read.position, err)
+		}
+		if err = t.browser.Ack(read.deliveryTag); err != nil {
+			return false, fmt.Errorf("error removing dead letter %v, it was replayed: %v", read.position, err)
+		}
+		log.Infof("DeadLetterTool | Replayed dead letter %v of client %v to %v", read.position, read.info.ClientId, producer.GetName())
+		replayed++
+		return true, nil
+	})
+	return replayed, err
+}
@@ -0,0 +1,79 @@
+package dlq
+
+import (
+	"bytes"
+	"errors"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/deadletter"
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+type browserMock struct {
+	messages [][]byte
+	next     int
+	acked    []uint64
+}
+
+func (b *browserMock) Next() ([]byte, uint64, bool, error) {
+	if b.next >= len(b.messages) {
+		return nil, 0, false, nil
+	}
+	b.next++
+	return b.messages[b.next-1], uint64(b.next), true, nil
+}
+
+func (b *browserMock) Ack(deliveryTag uint64) error {
+	b.acked = append(b.acked, deliveryTag)
+	return nil
+}
+
+func deadLetterOf(clientId string, messageId uint) []byte {
+	row := dataStructures.NewDynamicMap(map[string]dataStructures.Column{
+		utils.StartingAirport: dataStructures.NewStringColumn("EZE"),
+	})
+	msg := &dataStructures.Message{TypeMessage: dataStructures.FlightRows, ClientId: clientId, MessageId: messageId, RowId: 2}
+	return serializer.SerializeMsg(deadletter.New(msg, []*dataStructures.DynamicMap{row}, "filter", errors.New("missing column")))
+}
+
+func TestListPrintsTheDeadLettersOfTheClient(t *testing.T) {
+	browser := &browserMock{messages: [][]byte{deadLetterOf("A", 1), []byte{1, 2}, deadLetterOf("B", 2), deadLetterOf("A", 3)}}
+	out := &bytes.Buffer{}
+
+	assert.Nil(t, NewTool(browser, out).List(Filter{ClientId: "A"}))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[1], "0\t"))
+	assert.True(t, strings.HasPrefix(lines[2], "3\t"))
+	assert.Contains(t, lines[2], "missing column")
+	assert.Empty(t, browser.acked, "Listing does not remove the dead letters")
+}
+
+func TestReplaySendsTheOriginalMessagesWithNewIdsAndRemovesThem(t *testing.T) {
+	browser := &browserMock{messages: [][]byte{deadLetterOf("A", 1), deadLetterOf("B", 2), deadLetterOf("A", 3)}}
+	broker := middleware.NewInMemoryBroker()
+	consumer := middleware.NewInMemoryConsumer(broker, "filter", true)
+	producer := middleware.NewInMemoryProducer(broker, "filter", true)
+
+	replayed, err := NewTool(browser, &bytes.Buffer{}).Replay(Filter{ClientId: "A"}, producer, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, replayed)
+	assert.Equal(t, []uint64{1, 3}, browser.acked)
+
+	for _, expectedId := range []uint{100, 101} {
+		msgBytes, ok := consumer.Pop()
+		assert.True(t, ok)
+		msg, err := serializer.DeserializeMsg(msgBytes)
+		assert.Nil(t, err)
+		assert.Equal(t, dataStructures.FlightRows, msg.TypeMessage)
+		assert.Equal(t, "A", msg.ClientId)
+		assert.Equal(t, expectedId, msg.MessageId)
+		assert.Equal(t, uint16(0), msg.RowId)
+		assert.Len(t, msg.DynMaps, 1)
+		assert.Nil(t, consumer.SignalFinishedMessage(true))
+	}
+}
@@ -0,0 +1,3 @@
+module dlq_cli
+
+go 1.21
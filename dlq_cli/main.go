@@ -0,0 +1,115 @@
+package main
+
+import (
+	"dlq_cli/dlq"
+	"flag"
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	"github.com/brunograssano/Distribuidos-TP1/common/deadletter"
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"strings"
+	"time"
+)
+
+const usage = `Usage: dlq_cli [command] --queue [queue] [flags]
+
+Commands:
+  list      Prints the dead letters of the queue (default)
+  inspect   Prints the information and the rows of a dead letter
+  replay    Sends the original messages to a queue and removes them from the dead-letter queue
+
+Flags:
+  --queue      Dead-letter queue, or the input queue of the stage
+  --client     Only the dead letters of the client (list, replay)
+  --limit      Maximum dead letters, 0 for all of them (list, replay)
+  --position   Position of the dead letter in the queue (inspect)
+  --to         Queue that receives the messages, by default the one of the stage (replay)
+`
+
+// command Subcommand selected and its flags
+type command struct {
+	name     string
+	queue    string
+	filter   dlq.Filter
+	position int
+	to       string
+}
+
+func parseCommand(args []string) *command {
+	cmd := &command{name: "list"}
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		cmd.name = args[0]
+		args = args[1:]
+	}
+	flags := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flags.StringVar(&cmd.queue, "queue", "", "dead-letter queue")
+	switch cmd.name {
+	case "list", "replay":
+		flags.StringVar(&cmd.filter.ClientId, "client", "", "only the dead letters of the client")
+		flags.IntVar(&cmd.filter.Limit, "limit", 0, "maximum dead letters, 0 for all")
+		if cmd.name == "replay" {
+			flags.StringVar(&cmd.to, "to", "", "queue that receives the messages")
+		}
+	case "inspect":
+		flags.IntVar(&cmd.position, "position", 0, "position of the dead letter in the queue")
+	default:
+		log.Fatalf("Main - DeadLetterTool | Unknown command %v\n%v", cmd.name, usage)
+	}
+	_ = flags.Parse(args)
+	if cmd.queue == "" {
+		log.Fatalf("Main - DeadLetterTool | Missing queue\n%v", usage)
+	}
+	stageQueue := strings.TrimSuffix(cmd.queue, deadletter.QueueName(""))
+	cmd.queue = deadletter.QueueName(stageQueue)
+	if cmd.to == "" {
+		cmd.to = stageQueue
+	}
+	return cmd
+}
+
+func main() {
+	cmd := parseCommand(os.Args[1:])
+	env, err := InitEnv()
+	if err != nil {
+		log.Fatalf("Main - DeadLetterTool | Error initializing env | %s", err)
+	}
+	if err = config.InitLogger(env.GetString("log.level")); err != nil {
+		log.Fatalf("Main - DeadLetterTool | Error initializing logger | %s", err)
+	}
+	address := env.GetString("rabbitmq.address")
+	if address == "" {
+		log.Fatalf("Main - DeadLetterTool | Missing rabbitmq address")
+	}
+
+	qMiddleware := middleware.NewQueueMiddleware(address)
+	defer qMiddleware.Close()
+	browser, err := qMiddleware.CreateBrowser(cmd.queue, true)
+	if err != nil {
+		log.Fatalf("Main - DeadLetterTool | %v", err)
+	}
+	// The dead letters that were not replayed return to the queue
+	defer func() {
+		if err := browser.Close(); err != nil {
+			log.Errorf("Main - DeadLetterTool | Error closing browser | %v", err)
+		}
+	}()
+	tool := dlq.NewTool(browser, os.Stdout)
+
+	switch cmd.name {
+	case "list":
+		err = tool.List(cmd.filter)
+	case "inspect":
+		err = tool.Inspect(cmd.position)
+	case "replay":
+		var replayed int
+		// The ids of the messages are 32 bits, so the seconds are used to get new ones on each replay
+		replayed, err = tool.Replay(cmd.filter, qMiddleware.CreateProducer(cmd.to, true), uint(time.Now().Unix()))
+		fmt.Printf("replayed: %v\n", replayed)
+	}
+	if err != nil {
+		log.Errorf("Main - DeadLetterTool | Error on %v of %v | %v", cmd.name, cmd.queue, err)
+	}
+}
@@ -1,8 +1,10 @@
-package main
+package journeysaver
 
 import (
 	"errors"
 	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/retention"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -21,6 +23,9 @@ type Ex4JourneySaverConfig struct {
 	ServiceName             string
 	AddressesHealthCheckers []string
 	TotalSaversCount        uint
+	Retention               *retention.Config
+	// Compressions Compression of the messages sent to each queue or exchange
+	Compressions queues.Compressions
 }
 
 // InitEnv Initializes the configuration properties from a config file and environment
@@ -34,6 +39,7 @@ func InitEnv() (*viper.Viper, error) {
 
 	_ = v.BindEnv("id")
 	_ = v.BindEnv("log", "level")
+	_ = v.BindEnv("compression")
 	_ = v.BindEnv("rabbitmq", "address")
 	_ = v.BindEnv("rabbitmq", "queue", "input")
 	_ = v.BindEnv("rabbitmq", "queue", "outputs", "accum")
@@ -43,6 +49,9 @@ func InitEnv() (*viper.Viper, error) {
 	_ = v.BindEnv("rabbitmq", "rk", "input")
 	_ = v.BindEnv("name")
 	_ = v.BindEnv("healthchecker", "addresses")
+	_ = v.BindEnv("retention", "ttl")
+	_ = v.BindEnv("retention", "fetched")
+	_ = v.BindEnv("retention", "interval")
 
 	v.SetConfigFile("./config.yaml")
 	if err := v.ReadInConfig(); err != nil {
@@ -58,6 +67,11 @@ func GetConfig(env *viper.Viper) (*Ex4JourneySaverConfig, error) {
 		return nil, err
 	}
 
+	compressions, err := config.GetCompressions(env.GetStringMapString("compression"))
+	if err != nil {
+		return nil, err
+	}
+
 	id := env.GetString("id")
 	if id == "" {
 		return nil, errors.New("missing id")
@@ -111,6 +125,11 @@ func GetConfig(env *viper.Viper) (*Ex4JourneySaverConfig, error) {
 		return nil, err
 	}
 
+	retentionConfig, err := retention.NewConfig(id, env.GetString("retention.ttl"), env.GetString("retention.fetched"), env.GetString("retention.interval"))
+	if err != nil {
+		return nil, err
+	}
+
 	log.Infof("Ex4JourneySaverConfig | action: config | result: success | id: %s | log_level: %s | rabbitAddress: %v | inputQueueName: %v | internalSaversCount: %v | routingKey: %v",
 		id,
 		env.GetString("log.level"),
@@ -130,5 +149,7 @@ func GetConfig(env *viper.Viper) (*Ex4JourneySaverConfig, error) {
 		AddressesHealthCheckers: healthCheckerAddresses,
 		ServiceName:             serviceName,
 		TotalSaversCount:        totalSaversCount,
+		Retention:               retentionConfig,
+		Compressions:            compressions,
 	}, nil
 }
@@ -1,14 +1,18 @@
-package main
+package journeysaver
 
 import (
+	"errors"
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructure "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
-	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
+	"github.com/brunograssano/Distribuidos-TP1/common/retention"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
@@ -22,8 +26,14 @@ type JourneySaver struct {
 	partialResultsByClient map[string]*PartialResult
 	processedClients       map[string]bool
 	totalSaversCount       uint
+	params                 *queryparams.Registry
 	checkpointer           *checkpointer.CheckpointerHandler
+	collector              *retention.Collector
 	id                     int
+	// stage Name of the stage of the journey savers in the view of each client
+	stage string
+	// routingKey Key of the journeys sent to the saver
+	routingKey uint
 }
 
 // NewJourneySaver Creates a new JourneySaver
@@ -33,7 +43,10 @@ func NewJourneySaver(
 	avgAndMaxProducer queueProtocol.ProducerProtocolInterface,
 	totalSaversCount uint,
 	chkHandler *checkpointer.CheckpointerHandler,
+	collector *retention.Collector,
 	id uint,
+	stage string,
+	routingKey uint,
 ) *JourneySaver {
 	js := &JourneySaver{
 		consumer:               consumer,
@@ -42,14 +55,35 @@ func NewJourneySaver(
 		partialResultsByClient: make(map[string]*PartialResult),
 		processedClients:       make(map[string]bool),
 		totalSaversCount:       totalSaversCount,
+		params:                 queryparams.NewRegistry(),
 		checkpointer:           chkHandler,
+		collector:              collector,
 		id:                     int(id),
+		stage:                  stage,
+		routingKey:             routingKey,
 	}
 	chkHandler.AddCheckpointable(consumer, js.id)
 	chkHandler.AddCheckpointable(js, js.id)
 	return js
 }
 
+// NewCollector Creates the collector of the prices of the journeys of the clients, the ones being saved
+// and the ones moved to the folder of the client
+func NewCollector(c *Ex4JourneySaverConfig) *retention.Collector {
+	return retention.NewCollector(c.Retention, func(clientId string) []string {
+		var artifacts []string
+		for _, pattern := range []string{fmt.Sprintf("*-*_%v", clientId), fmt.Sprintf("%v/*-*_%v", clientId, clientId)} {
+			journeys, err := filepath.Glob(pattern)
+			if err != nil {
+				log.Errorf("JourneySaver | Error listing the journeys of client %v | %v", clientId, err)
+				continue
+			}
+			artifacts = append(artifacts, journeys...)
+		}
+		return artifacts
+	})
+}
+
 func (js *JourneySaver) saveRowsInFiles(dynMaps []*dataStructure.DynamicMap, clientId string) {
 	// May need optimization. Mix of memory and disk or only memory...
 	log.Debugf("JourneySaver %v | Writing records to files", js.id)
@@ -57,20 +91,24 @@ func (js *JourneySaver) saveRowsInFiles(dynMaps []*dataStructure.DynamicMap, cli
 		stAirport, err := dynMap.GetAsString(utils.StartingAirport)
 		if err != nil {
 			log.Errorf("JourneySaver %v | Error getting starting airport | %v | Skipping row...", js.id, err)
+			js.consumer.DeadLetter([]*dataStructure.DynamicMap{dynMap}, err)
 			continue
 		}
 		destAirport, err := dynMap.GetAsString(utils.DestinationAirport)
 		if err != nil {
 			log.Errorf("JourneySaver %v | Error getting destination airport | %v | Skipping row...", js.id, err)
+			js.consumer.DeadLetter([]*dataStructure.DynamicMap{dynMap}, err)
 			continue
 		}
 		totalFare, err := dynMap.GetAsFloat(utils.TotalFare)
 		if err != nil {
 			log.Errorf("JourneySaver %v | Error getting total fare | %v | Skipping row...", js.id, err)
+			js.consumer.DeadLetter([]*dataStructure.DynamicMap{dynMap}, err)
 			continue
 		}
 		if totalFare <= 0 {
 			log.Errorf("JourneySaver %v | Total fare <= 0 | Skipping row...", js.id)
+			js.consumer.DeadLetter([]*dataStructure.DynamicMap{dynMap}, fmt.Errorf("total fare %v <= 0", totalFare))
 			continue
 		}
 		js.writeResults(stAirport, destAirport, clientId, err, totalFare)
@@ -132,15 +170,21 @@ func (js *JourneySaver) readJourneyAsArrays(journeyStr string) ([]float32, error
 	return prices, nil
 }
 
+// saversOf Returns the journey savers of the view of the client of the message. The routing keys from
+// that amount on do not get journeys of the client
+func (js *JourneySaver) saversOf(msg *dataStructure.Message) uint {
+	return membership.SizeOf(msg, js.stage, js.totalSaversCount)
+}
+
 // sendToGeneralAccumulator Sends to the accumulator the values that the JourneySaver managed
 func (js *JourneySaver) sendToGeneralAccumulator(oldMsg *dataStructure.Message) error {
-	dynMapData := make(map[string][]byte)
+	dynMapData := make(map[string]dataStructure.Column)
 	partialResult, exists := js.partialResultsByClient[oldMsg.ClientId]
 	if !exists {
 		partialResult = NewPartialResult()
 	}
-	dynMapData[utils.LocalPrice] = serializer.SerializeFloat(partialResult.totalPrice)
-	dynMapData[utils.LocalQuantity] = serializer.SerializeUint(uint32(partialResult.quantities))
+	dynMapData[utils.LocalPrice] = dataStructure.NewFloat32Column(partialResult.totalPrice)
+	dynMapData[utils.LocalQuantity] = dataStructure.NewInt32Column(int32(partialResult.quantities))
 	msgToSend := dataStructure.NewTypeMessageWithDataAndMsgId(dataStructure.EOFFlightRows, oldMsg, []*dataStructure.DynamicMap{dataStructure.NewDynamicMap(dynMapData)}, oldMsg.MessageId+uint(oldMsg.RowId))
 	log.Infof("JourneySaver %v | Received EOF. Sending to Gral Accum. TotalPrice: %v, Quantities: %v | ID: %v-%v-%v", js.id, partialResult.totalPrice, partialResult.quantities, msgToSend.ClientId, msgToSend.MessageId, msgToSend.RowId)
 	err := js.accumProducer.Send(msgToSend)
@@ -162,6 +206,14 @@ func (js *JourneySaver) filterGreaterThanAverage(prices []float32, avg float32)
 	return returnArray
 }
 
+// filterPrices Returns the prices that are considered with the price threshold of the client
+func (js *JourneySaver) filterPrices(prices []float32, avg float32, threshold queryparams.PriceThreshold) []float32 {
+	if threshold == queryparams.AllPrices {
+		return prices
+	}
+	return js.filterGreaterThanAverage(prices, avg)
+}
+
 func (js *JourneySaver) getMaxAndAverage(prices []float32) (float32, float32) {
 	maxVal := float32(0)
 	accumPrices := float32(0)
@@ -185,6 +237,7 @@ func (js *JourneySaver) sendAverageForJourneys(finalAvg float32, msg *dataStruct
 	if !exist {
 		partialResults = NewPartialResult()
 	}
+	threshold := js.params.Of(msg).PriceThreshold
 	var data []*dataStructure.DynamicMap
 	for _, fileStr := range partialResults.filesToRead {
 		log.Debugf("JourneySaver %v | Reading file: %v", js.id, fileStr)
@@ -193,7 +246,7 @@ func (js *JourneySaver) sendAverageForJourneys(finalAvg float32, msg *dataStruct
 			log.Errorf("JourneySaver %v | Error reading file | %v | Skipping file...", js.id, err)
 			continue
 		}
-		filteredPrices := js.filterGreaterThanAverage(pricesForJourney, finalAvg)
+		filteredPrices := js.filterPrices(pricesForJourney, finalAvg, threshold)
 		log.Debugf("JourneySaver %v | Filtered prices. Original len: %v ; Filtered len: %v", js.id, len(pricesForJourney), len(filteredPrices))
 		if len(filteredPrices) == 0 {
 			log.Warnf("JourneySaver %v | Filtered Prices Length is 0 | Skipping...", js.id)
@@ -202,10 +255,10 @@ func (js *JourneySaver) sendAverageForJourneys(finalAvg float32, msg *dataStruct
 		journeyAverage, journeyMax := js.getMaxAndAverage(filteredPrices)
 		log.Debugf("JourneySaver | Average: %v, Maximum: %v", journeyAverage, journeyMax)
 
-		dynMap := make(map[string][]byte)
-		dynMap[utils.Avg] = serializer.SerializeFloat(journeyAverage)
-		dynMap[utils.Max] = serializer.SerializeFloat(journeyMax)
-		dynMap[utils.Journey] = serializer.SerializeString(strings.Split(fileStr, "_")[0])
+		dynMap := make(map[string]dataStructure.Column)
+		dynMap[utils.Avg] = dataStructure.NewFloat32Column(journeyAverage)
+		dynMap[utils.Max] = dataStructure.NewFloat32Column(journeyMax)
+		dynMap[utils.Journey] = dataStructure.NewStringColumn(strings.Split(fileStr, "_")[0])
 		data = append(data, dataStructure.NewDynamicMap(dynMap))
 	}
 	log.Debugf("JourneySaver %v | Sending max and avg to next step...", js.id)
@@ -213,10 +266,16 @@ func (js *JourneySaver) sendAverageForJourneys(finalAvg float32, msg *dataStruct
 	if err != nil {
 		log.Errorf("JourneySaver %v | Error sending to saver the journeys | %v | Skipping...", js.id, err)
 	}
-	err = js.avgAndMaxProducer.Send(dataStructure.NewTypeMessageWithoutDataAndMsgId(dataStructure.EOFFlightRows, msg, msg.MessageId+js.totalSaversCount))
+	// The sink waits for the EOF of every saver of the client, so it is told how many there are
+	saversCount := js.saversOf(msg)
+	eofData := make(map[string]dataStructure.Column)
+	eofData[utils.JourneySavers] = dataStructure.NewInt64Column(int64(saversCount))
+	eof := dataStructure.NewTypeMessageWithDataAndMsgId(dataStructure.EOFFlightRows, msg, []*dataStructure.DynamicMap{dataStructure.NewDynamicMap(eofData)}, msg.MessageId+saversCount)
+	err = js.avgAndMaxProducer.Send(eof)
 	if err != nil {
 		log.Errorf("JourneySaver %v | Error sending EOF to saver | %v", js.id, err)
 	}
+	js.collector.Track(msg.ClientId)
 	err = filemanager.MoveFiles(partialResults.filesToRead, msg.ClientId)
 	if err != nil {
 		log.Errorf("JourneySaver %v | Error trying to move files | %v", js.id, err)
@@ -227,19 +286,27 @@ func (js *JourneySaver) sendAverageForJourneys(finalAvg float32, msg *dataStruct
 // SavePricesForJourneys JourneySaver loop that reads from the input channel, saves the journey and performs calculations
 func (js *JourneySaver) SavePricesForJourneys() {
 	for {
-		msg, ok := js.consumer.Pop()
-		if !ok {
+		msg, err := js.consumer.Pop()
+		if errors.Is(err, queueProtocol.ErrConsumerClosed) {
 			log.Errorf("JourneySaver %v | Input of messages closed. Ending execution...", js.id)
 			return
 		}
+		if err != nil {
+			log.Errorf("JourneySaver %v | Skipping message | %v", js.id, err)
+			continue
+		}
 		log.Debugf("JourneySaver %v | Received message of type: %v. Row Count: %v", js.id, msg.TypeMessage, len(msg.DynMaps))
-		if msg.TypeMessage == dataStructure.EOFFlightRows {
+		if msg.TypeMessage == dataStructure.EOFFlightRows && js.routingKey >= js.saversOf(msg) {
+			log.Infof("JourneySaver %v | Client %v started with %v savers, without the routing key %v | Skipping its EOF...", js.id, msg.ClientId, js.saversOf(msg), js.routingKey)
+		} else if msg.TypeMessage == dataStructure.EOFFlightRows {
 			err := js.sendToGeneralAccumulator(msg)
 			if err != nil {
 				log.Errorf("JourneySaver %v | Could not send to General Accumulator. Ending execution...", js.id)
 				return
 			}
 			log.Debugf("JourneySaver %v | Sent correctly!", js.id)
+		} else if msg.TypeMessage == dataStructure.Abort {
+			js.handleAbort(msg)
 		} else if msg.TypeMessage == dataStructure.FlightRows {
 			log.Debugf("JourneySaver %v | Received flight row. Now saving...", js.id)
 			js.saveRowsInFiles(msg.DynMaps, msg.ClientId)
@@ -258,7 +325,7 @@ func (js *JourneySaver) SavePricesForJourneys() {
 			}
 
 		}
-		err := js.checkpointer.DoCheckpoint(js.id)
+		err = js.checkpointer.DoCheckpoint(js.id)
 		if err != nil {
 			log.Errorf("JourneySaver %v | Error doing checkpointing | %v", js.id, err)
 		}
@@ -267,5 +334,34 @@ func (js *JourneySaver) SavePricesForJourneys() {
 
 func (js *JourneySaver) clearInternalState(clientId string) {
 	delete(js.partialResultsByClient, clientId)
+	js.params.Remove(clientId)
 	js.processedClients[clientId] = true
 }
+
+// handleAbort Deletes the prices saved of the client and the results of a previous run, and passes the abort
+// to the accumulator and the sink so they clear the data of the client too
+func (js *JourneySaver) handleAbort(msg *dataStructure.Message) {
+	log.Infof("JourneySaver %v | Received Abort of client %v. Clearing its data...", js.id, msg.ClientId)
+	js.consumer.ClearData(msg.ClientId)
+	partialResult, exists := js.partialResultsByClient[msg.ClientId]
+	if exists {
+		err := filemanager.DeleteIfExists(partialResult.filesToRead...)
+		if err != nil {
+			log.Errorf("JourneySaver %v | Error deleting the prices of client %v | %v", js.id, msg.ClientId, err)
+		}
+	}
+	err := filemanager.DeleteIfExists(msg.ClientId)
+	if err != nil {
+		log.Errorf("JourneySaver %v | Error deleting the folder of client %v | %v", js.id, msg.ClientId, err)
+	}
+	js.clearInternalState(msg.ClientId)
+	abort := dataStructure.NewTypeMessageWithoutDataAndMsgId(dataStructure.Abort, msg, msg.MessageId+uint(msg.RowId))
+	err = js.accumProducer.Send(abort)
+	if err != nil {
+		log.Errorf("JourneySaver %v | Error sending Abort to general accumulator | %v", js.id, err)
+	}
+	err = js.avgAndMaxProducer.Send(abort)
+	if err != nil {
+		log.Errorf("JourneySaver %v | Error sending Abort to saver | %v", js.id, err)
+	}
+}
@@ -1,37 +1,45 @@
 package main
 
 import (
+	"ex4_journey_saver/journeysaver"
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	"github.com/brunograssano/Distribuidos-TP1/common/heartbeat"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
+	"github.com/brunograssano/Distribuidos-TP1/common/retention"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
+	"os"
 	"strconv"
 )
 
 func main() {
 	sigs := utils.CreateSignalListener()
-	env, err := InitEnv()
+	env, err := journeysaver.InitEnv()
 	if err != nil {
 		log.Fatalf("Main - Ex4 Journey Saver | Error initializing env | %s", err)
 	}
-	config, err := GetConfig(env)
+	config, err := journeysaver.GetConfig(env)
 	if err != nil {
 		log.Fatalf("Main - Ex4 Journey Saver | Error initializing Config | %s", err)
 	}
+	collector := journeysaver.NewCollector(config)
+	if retention.HandleCommand(os.Args[1:], collector) {
+		return
+	}
 	qMiddleware := middleware.NewQueueMiddleware(config.RabbitAddress)
-	qFanoutFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.OutputQueueNameAccum, "")
-	qFanoutFactorySink := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.OutputQueueNameSaver, "")
-	var services []*JourneySaver
+	qFanoutFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.OutputQueueNameAccum, "", config.Compressions)
+	qFanoutFactorySink := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.OutputQueueNameSaver, "", config.Compressions)
+	var services []*journeysaver.JourneySaver
 	for i := uint(0); i < config.InternalSaversCount; i++ {
-		qFactory := queuefactory.NewTopicFactory(qMiddleware, []string{"", strconv.Itoa(int(i + config.RoutingKeyInput))}, config.InputQueueName)
+		qFactory := queuefactory.NewTopicFactory(qMiddleware, []string{"", strconv.Itoa(int(i + config.RoutingKeyInput))}, config.InputQueueName, config.Compressions)
 		inputQ := qFactory.CreateConsumer(fmt.Sprintf("%v-%v-%v", config.InputQueueName, config.ID, i+config.RoutingKeyInput))
 		chkHandler := checkpointer.NewCheckpointerHandler()
 		prodToAccum := qFanoutFactory.CreateProducer(config.OutputQueueNameAccum)
 		prodToSink := qFanoutFactorySink.CreateProducer(config.OutputQueueNameSaver)
-		js := NewJourneySaver(inputQ, prodToAccum, prodToSink, config.TotalSaversCount, chkHandler, i)
+		js := journeysaver.NewJourneySaver(inputQ, prodToAccum, prodToSink, config.TotalSaversCount, chkHandler, collector, i, config.InputQueueName, i+config.RoutingKeyInput)
 		chkHandler.RestoreCheckpoint()
 		services = append(services, js)
 	}
@@ -39,10 +47,11 @@ func main() {
 	for _, service := range services {
 		go service.SavePricesForJourneys()
 	}
+	go collector.CollectLoop()
 
-	endSigHB := heartbeat.StartHeartbeat(config.AddressesHealthCheckers, config.ServiceName)
+	endSigHB := heartbeat.StartMemberHeartbeat(config.AddressesHealthCheckers, config.ServiceName, membership.Member{Stage: config.InputQueueName, Nodes: config.InternalSaversCount, Keyed: true, FirstKey: config.RoutingKeyInput})
 	<-sigs
-	endSigHB <- true
+	heartbeat.StopMemberHeartbeat(endSigHB)
 	qMiddleware.Close()
-
+	collector.Close()
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"ex4_sink/sink"
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	"github.com/brunograssano/Distribuidos-TP1/common/heartbeat"
@@ -12,21 +13,21 @@ import (
 
 func main() {
 	sigs := utils.CreateSignalListener()
-	env, err := InitEnv()
+	env, err := sink.InitEnv()
 	if err != nil {
 		log.Fatalf("Main - Ex4 Sink | Error initializing env | %s", err)
 	}
-	config, err := GetConfig(env)
+	config, err := sink.GetConfig(env)
 	if err != nil {
 		log.Fatalf("Main - Ex4 Sink | Error initializing Config | %s", err)
 	}
 	qMiddleware := middleware.NewQueueMiddleware(config.RabbitAddress)
-	qFanoutInputFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.InputQueueName, "")
-	qFanoutOutputFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.OutputQueueName, "")
+	qFanoutInputFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.InputQueueName, "", config.Compressions)
+	qFanoutOutputFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.OutputQueueName, "", config.Compressions)
 	inputQueue := qFanoutInputFactory.CreateConsumer(fmt.Sprintf("%v-%v", config.InputQueueName, config.ID))
 	toSaver4 := qFanoutOutputFactory.CreateProducer(config.OutputQueueName)
 	chkHandler := checkpointer.NewCheckpointerHandler()
-	sink := NewJourneySink(inputQueue, toSaver4, config.SaversCount, chkHandler)
+	sink := sink.NewJourneySink(inputQueue, toSaver4, config.SaversCount, chkHandler)
 	chkHandler.RestoreCheckpoint()
 	go sink.HandleJourneys()
 	endSigHB := heartbeat.StartHeartbeat(config.AddressesHealthCheckers, config.ServiceName)
@@ -1,9 +1,12 @@
-package main
+package sink
 
 import (
+	"errors"
+	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -37,19 +40,26 @@ func NewJourneySink(
 
 func (j *JourneySink) HandleJourneys() {
 	for {
-		msg, ok := j.inputQueue.Pop()
-		if !ok {
+		msg, err := j.inputQueue.Pop()
+		if errors.Is(err, queueProtocol.ErrConsumerClosed) {
 			log.Infof("JourneySink | Consumer closed, exiting goroutine")
 			return
 		}
+		if err != nil {
+			log.Errorf("JourneySink | Skipping message | %v", err)
+			continue
+		}
 		if msg.TypeMessage == dataStructures.FlightRows {
 			j.handleFlightRows(msg)
 		} else if msg.TypeMessage == dataStructures.EOFFlightRows {
 			j.handleEofMsg(msg)
+		} else if msg.TypeMessage == dataStructures.Abort {
+			j.handleAbortMsg(msg)
 		} else {
 			log.Warnf("JourneySink | Received unexpected message type %v", msg.TypeMessage)
+			j.inputQueue.DeadLetter(msg.DynMaps, fmt.Errorf("unexpected message type %v", msg.TypeMessage))
 		}
-		err := j.checkpointer.DoCheckpoint(sinkId)
+		err = j.checkpointer.DoCheckpoint(sinkId)
 		if err != nil {
 			log.Errorf("JourneySink | Error on checkpointing | %v", err)
 		}
@@ -62,12 +72,38 @@ func (j *JourneySink) handleEofMsg(msg *dataStructures.Message) {
 		j.journeySaversReceivedByClient[msg.ClientId] = 0
 	}
 	j.journeySaversReceivedByClient[msg.ClientId]++
-	log.Infof("JourneySink | Received EOF of one journey saver | Accumulated %v | Total: %v ", j.journeySaversReceivedByClient[msg.ClientId], j.totalJourneySavers)
-	if j.journeySaversReceivedByClient[msg.ClientId] >= j.totalJourneySavers {
+	totalJourneySavers := j.saversOf(msg)
+	log.Infof("JourneySink | Received EOF of one journey saver | Accumulated %v | Total: %v ", j.journeySaversReceivedByClient[msg.ClientId], totalJourneySavers)
+	if j.journeySaversReceivedByClient[msg.ClientId] >= totalJourneySavers {
 		j.sendEofToNext(msg)
 	}
 }
 
+// saversOf Returns the journey savers of the client. Each one sends it with its EOF, as the client could have
+// started with less savers than the configured ones
+func (j *JourneySink) saversOf(msg *dataStructures.Message) uint {
+	if len(msg.DynMaps) == 0 {
+		return j.totalJourneySavers
+	}
+	savers, err := msg.DynMaps[0].GetAsInt64(utils.JourneySavers)
+	if err != nil || savers <= 0 {
+		return j.totalJourneySavers
+	}
+	return uint(savers)
+}
+
+// handleAbortMsg Forgets the EOFs received for the client and passes the abort to the saver.
+// Every journey saver sends it, and the saver ignores the ones after the first
+func (j *JourneySink) handleAbortMsg(msg *dataStructures.Message) {
+	log.Infof("JourneySink | Received Abort of client %v. Clearing its data...", msg.ClientId)
+	j.inputQueue.ClearData(msg.ClientId)
+	delete(j.journeySaversReceivedByClient, msg.ClientId)
+	err := j.toSaver4Producer.Send(msg)
+	if err != nil {
+		log.Errorf("JourneySink | Error sending Abort to saver | %v", err)
+	}
+}
+
 func (j *JourneySink) handleFlightRows(msg *dataStructures.Message) {
 	err := j.toSaver4Producer.Send(msg)
 	if err != nil {
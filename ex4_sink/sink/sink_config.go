@@ -1,8 +1,9 @@
-package main
+package sink
 
 import (
 	"errors"
 	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -18,6 +19,8 @@ type SinkConfig struct {
 	SaversCount             uint
 	AddressesHealthCheckers []string
 	ServiceName             string
+	// Compressions Compression of the messages sent to each queue or exchange
+	Compressions queues.Compressions
 }
 
 // InitEnv Initializes the configuration properties from a config file and environment
@@ -31,6 +34,7 @@ func InitEnv() (*viper.Viper, error) {
 
 	_ = v.BindEnv("id")
 	_ = v.BindEnv("log", "level")
+	_ = v.BindEnv("compression")
 	_ = v.BindEnv("rabbitmq", "address")
 	_ = v.BindEnv("rabbitmq", "queue", "input")
 	_ = v.BindEnv("rabbitmq", "queue", "output")
@@ -52,6 +56,11 @@ func GetConfig(env *viper.Viper) (*SinkConfig, error) {
 		return nil, err
 	}
 
+	compressions, err := config.GetCompressions(env.GetStringMapString("compression"))
+	if err != nil {
+		return nil, err
+	}
+
 	id := env.GetString("id")
 	if id == "" {
 		return nil, errors.New("missing id")
@@ -106,5 +115,6 @@ func GetConfig(env *viper.Viper) (*SinkConfig, error) {
 		SaversCount:             saversCount,
 		AddressesHealthCheckers: healthCheckerAddresses,
 		ServiceName:             serviceName,
+		Compressions:            compressions,
 	}, nil
 }
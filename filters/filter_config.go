@@ -4,7 +4,10 @@ import (
 	"errors"
 	"strings"
 
+	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/query"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -20,6 +23,12 @@ type FilterConfig struct {
 	AddressesHealthCheckers []string
 	ServiceName             string
 	TotalEofNodes           uint
+	// EOFCoordinator The replica runs the coordinator of the EOF of the stage. Only one replica of the stage runs it
+	EOFCoordinator bool
+	// Predicate Condition of the filter read from the config. Nil if the filter does not have one
+	Predicate *query.Predicate
+	// Compressions Compression of the messages sent to each queue or exchange
+	Compressions queues.Compressions
 }
 
 func InitEnv() (*viper.Viper, error) {
@@ -31,13 +40,17 @@ func InitEnv() (*viper.Viper, error) {
 
 	_ = v.BindEnv("id")
 	_ = v.BindEnv("log", "level")
+	_ = v.BindEnv("compression")
 	_ = v.BindEnv("rabbitmq", "queues", "input")
 	_ = v.BindEnv("rabbitmq", "queues", "output")
 	_ = v.BindEnv("rabbitmq", "exchange", "outputs")
 	_ = v.BindEnv("filter", "goroutines")
+	_ = v.BindEnv("filter", "expression")
+	_ = v.BindEnv("filter", "schema")
 	_ = v.BindEnv("name")
 	_ = v.BindEnv("healthchecker", "addresses")
 	_ = v.BindEnv("total", "nodes", "for", "eof")
+	_ = v.BindEnv("eof", "coordinator")
 
 	v.SetConfigFile("./config.yaml")
 	if err := v.ReadInConfig(); err != nil {
@@ -52,6 +65,11 @@ func GetConfigFilters(env *viper.Viper) (*FilterConfig, error) {
 		return nil, err
 	}
 
+	compressions, err := config.GetCompressions(env.GetStringMapString("compression"))
+	if err != nil {
+		return nil, err
+	}
+
 	id := env.GetString("id")
 	if id == "" {
 		return nil, errors.New("missing id")
@@ -70,7 +88,7 @@ func GetConfigFilters(env *viper.Viper) (*FilterConfig, error) {
 
 	outputExchangesNames := env.GetString("rabbitmq.exchange.outputs")
 	var outputExchangesNamesArray []string
-	if outputQueueNames == "" {
+	if outputExchangesNames == "" {
 		log.Warnf("Missing output exchanges. Setting it as empty array")
 		outputExchangesNamesArray = []string{}
 	} else {
@@ -93,6 +111,18 @@ func GetConfigFilters(env *viper.Viper) (*FilterConfig, error) {
 		goroutinesCount = utils.DefaultGoroutines
 	}
 
+	var predicate *query.Predicate
+	if expression := env.GetString("filter.expression"); expression != "" {
+		schema, err := query.ParseSchema(env.GetString("filter.schema"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter schema: %v", err)
+		}
+		predicate, err = query.Compile(expression, schema)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	serviceName := env.GetString("name")
 	if serviceName == "" {
 		return nil, errors.New("missing name")
@@ -104,13 +134,14 @@ func GetConfigFilters(env *viper.Viper) (*FilterConfig, error) {
 	}
 	healthCheckerAddresses := strings.Split(healthCheckerAddressesString, utils.CommaSeparator)
 
-	log.Infof("FilterConfig | action: config | result: success | id: %s | log_level: %s | inputQueueNames: %v | outputQueueNames: %v | outputExchangesNames: %v | goroutinesCount: %v",
+	log.Infof("FilterConfig | action: config | result: success | id: %s | log_level: %s | inputQueueNames: %v | outputQueueNames: %v | outputExchangesNames: %v | goroutinesCount: %v | expression: %v",
 		id,
 		env.GetString("log.level"),
 		inputQueueName,
 		outputQueueNamesArray,
 		outputExchangesNamesArray,
 		goroutinesCount,
+		env.GetString("filter.expression"),
 	)
 
 	return &FilterConfig{
@@ -123,5 +154,8 @@ func GetConfigFilters(env *viper.Viper) (*FilterConfig, error) {
 		AddressesHealthCheckers: healthCheckerAddresses,
 		ServiceName:             serviceName,
 		TotalEofNodes:           TotalEofNodes,
+		EOFCoordinator:          env.GetBool("eof.coordinator"),
+		Predicate:               predicate,
+		Compressions:            compressions,
 	}, nil
 }
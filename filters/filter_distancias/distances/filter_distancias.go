@@ -1,12 +1,15 @@
-package main
+package distances
 
 import (
+	"errors"
 	"filters_config"
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/brunograssano/Distribuidos-TP1/common/filters"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
@@ -18,10 +21,17 @@ type FilterDistances struct {
 	consumer     queueProtocol.ConsumerProtocolInterface
 	producers    []queueProtocol.ProducerProtocolInterface
 	prodToCons   queueProtocol.ProducerProtocolInterface
-	filter       *filters.Filter
+	eof          *queueProtocol.EOFReporter
+	filter       filters.FilterInterface
+	params       *queryparams.Registry
 	checkpointer *checkpointer.CheckpointerHandler
 }
 
+// distancesPredicate Flights with a total travel distance greater than the factor times the direct distance
+func distancesPredicate(filter filters.FilterInterface, distanceFactor float64) filters.Predicate {
+	return filter.CompareColumnsScaled(utils.TotalTravelDistance, filters.GreaterOperator, utils.DirectDistance, distanceFactor)
+}
+
 func NewFilterDistances(
 	filterId int,
 	qFactory queuefactory.QueueProtocolFactory,
@@ -35,56 +45,79 @@ func NewFilterDistances(
 		outputQueues[i] = qFactory.CreateProducer(conf.OutputQueueNames[i])
 	}
 	chkHandler.AddCheckpointable(inputQueue, filterId)
+	toCoordinator := qFactory.CreateProducer(queueProtocol.CoordinatorQueueName(conf.InputQueueName))
+	eofReporter := queueProtocol.NewEOFReporter(fmt.Sprintf("%v-%v", conf.ID, filterId), inputQueue, toCoordinator, outputQueues)
 
-	filter := filters.NewFilter()
 	return &FilterDistances{
 		filterId:     filterId,
 		config:       conf,
 		consumer:     inputQueue,
 		prodToCons:   prodToCons,
-		producers:    outputQueues,
-		filter:       filter,
+		producers:    eofReporter.Outputs(),
+		eof:          eofReporter,
+		filter:       filters.NewFilter(),
+		params:       queryparams.NewRegistry(),
 		checkpointer: chkHandler,
 	}
 }
 
 func (fd *FilterDistances) FilterDistances() {
 	for {
-		msgStruct, ok := fd.consumer.Pop()
-		if !ok {
+		msgStruct, err := fd.consumer.Pop()
+		if errors.Is(err, queueProtocol.ErrConsumerClosed) {
 			log.Infof("FilterDistances %v | Closing Goroutine...", fd.filterId)
 			break
 		}
+		if err != nil {
+			log.Errorf("FilterDistances %v | Skipping message | %v", fd.filterId, err)
+			continue
+		}
 		if msgStruct.TypeMessage == dataStructures.EOFFlightRows {
 			log.Infof("FilterDistances %v | Received EOF. Handling...", fd.filterId)
-			err := queueProtocol.HandleEOF(msgStruct, fd.prodToCons, fd.producers, fmt.Sprintf("%v-%v", fd.config.ID, fd.filterId), fd.config.TotalEofNodes)
+			err := fd.eof.HandleEOF(msgStruct)
 			if err != nil {
 				log.Errorf("FilterDistances %v | Error handling EOF | %v", fd.filterId, err)
 			}
+		} else if msgStruct.TypeMessage == dataStructures.Abort {
+			log.Infof("FilterDistances %v | Received Abort of client %v. Clearing its data...", fd.filterId, msgStruct.ClientId)
+			fd.consumer.ClearData(msgStruct.ClientId)
+			fd.params.Remove(msgStruct.ClientId)
+			err := fd.eof.HandleAbort(msgStruct, fd.prodToCons, membership.SizeOf(msgStruct, fd.config.InputQueueName, fd.config.TotalEofNodes))
+			if err != nil {
+				log.Errorf("FilterDistances %v | Error handling Abort | %v", fd.filterId, err)
+			}
+		} else if msgStruct.TypeMessage == dataStructures.ClearClient {
+			log.Infof("FilterDistances %v | Client %v finished. Clearing its data...", fd.filterId, msgStruct.ClientId)
+			fd.consumer.ClearData(msgStruct.ClientId)
+			fd.params.Remove(msgStruct.ClientId)
+			err := fd.eof.HandleClear(msgStruct, fd.prodToCons, membership.SizeOf(msgStruct, fd.config.InputQueueName, fd.config.TotalEofNodes))
+			if err != nil {
+				log.Errorf("FilterDistances %v | Error handling Clear | %v", fd.filterId, err)
+			}
 		} else if msgStruct.TypeMessage == dataStructures.FlightRows {
 			log.Debugf("FilterDistances %v | Received FlightRows. Filtering...", fd.filterId)
 			fd.handleFlightRows(msgStruct)
+			_ = fd.eof.ReportBatch(msgStruct)
 		} else {
 			log.Warnf("FilterDistances %v | Received unknown message type | Skipping...", fd.filterId)
+			fd.consumer.DeadLetter(msgStruct.DynMaps, fmt.Errorf("unknown type of message %v", msgStruct.TypeMessage))
 		}
-		err := fd.checkpointer.DoCheckpoint(fd.filterId)
+		err = fd.checkpointer.DoCheckpoint(fd.filterId)
 		if err != nil {
 			log.Errorf("FilterDistances #%v | Error on checkpointing | %v", fd.filterId, err)
 		}
 	}
 }
 
+// handleFlightRows Sends the rows that exceed the distance factor of the client of the message
 func (fd *FilterDistances) handleFlightRows(msg *dataStructures.Message) {
 	var filteredRows []*dataStructures.DynamicMap
+	predicate := distancesPredicate(fd.filter, float64(fd.params.Of(msg).DistanceFactor))
 	for _, row := range msg.DynMaps {
-		directDistance, errCast := row.GetAsFloat(utils.DirectDistance)
-		if errCast != nil {
-			log.Errorf("FilterDistances %v | action: filter_distances | result: fail | skipping row | error: %v", fd.filterId, errCast)
-			continue
-		}
-		passesFilter, err := fd.filter.Greater(row, 4*directDistance, utils.TotalTravelDistance)
+		passesFilter, err := predicate(row)
 		if err != nil {
 			log.Errorf("FilterDistances %v | action: filter_distances | result: fail | skipping row | error: %v", fd.filterId, err)
+			fd.consumer.DeadLetter([]*dataStructures.DynamicMap{row}, fmt.Errorf("filter_distances: %v", err))
 			continue
 		}
 		if passesFilter {
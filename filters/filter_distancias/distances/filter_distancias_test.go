@@ -1,13 +1,12 @@
-package main
+package distances
 
 import (
-	"encoding/binary"
 	"filters_config"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/brunograssano/Distribuidos-TP1/common/filters"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
-	"math"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
 	"testing"
 	"time"
 )
@@ -44,11 +43,12 @@ func (m *mockConsumer) Abort(i int, errors chan error) {
 	panic("implement me")
 }
 
-func (m *mockConsumer) SetStatusOfLastMessage(b bool) {
-	//TODO implement me
-	panic("implement me")
+func (m *mockConsumer) RejectLastMessage(_ error) bool {
+	return true
 }
 
+func (m *mockConsumer) DeadLetter(_ []*dataStructures.DynamicMap, _ error) {}
+
 func (m *mockConsumer) ClearData(s string) {
 	return
 }
@@ -57,12 +57,15 @@ func (m *mockConsumer) GetReceivedMessages(s string) int {
 	return 0
 }
 
-func (m *mockConsumer) Pop() (*dataStructures.Message, bool) {
+func (m *mockConsumer) Pop() (*dataStructures.Message, error) {
 	if !m.ok {
-		return &dataStructures.Message{}, m.ok
+		return nil, queueProtocol.ErrConsumerClosed
 	}
 	msg, ok := <-m.inputChannel
-	return msg, ok
+	if !ok {
+		return nil, queueProtocol.ErrConsumerClosed
+	}
+	return msg, nil
 }
 
 func (m *mockConsumer) BindTo(_ string, _ string, _ string) error {
@@ -105,16 +108,16 @@ func TestGettingARowWithTotalDistanceGreaterThanFourTimesOfDirectDistancePassesF
 		config:       &filters_config.FilterConfig{},
 		consumer:     mockCons,
 		producers:    arrayProducers,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *dataStructures.Message, 10)}, nil),
 		filter:       filters.NewFilter(),
+		params:       queryparams.NewRegistry(),
 		checkpointer: checkpointer.NewCheckpointerHandler(),
 	}
 	go filterDistancias.FilterDistances()
 
-	dynMap := make(map[string][]byte)
-	dynMap["directDistance"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["directDistance"], math.Float32bits(1.9))
-	dynMap["totalTravelDistance"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["totalTravelDistance"], math.Float32bits(8.5))
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["directDistance"] = dataStructures.NewFloat32Column(1.9)
+	dynMap["totalTravelDistance"] = dataStructures.NewFloat32Column(8.5)
 	row := dataStructures.NewDynamicMap(dynMap)
 	input <- &dataStructures.Message{TypeMessage: dataStructures.FlightRows, DynMaps: []*dataStructures.DynamicMap{row}}
 	close(input)
@@ -149,16 +152,16 @@ func TestGettingARowWithTotalDistanceEqualToFourTimesDirectDistanceShallNotPass(
 		config:       &filters_config.FilterConfig{},
 		consumer:     mockCons,
 		producers:    arrayProducers,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *dataStructures.Message, 10)}, nil),
 		filter:       filters.NewFilter(),
+		params:       queryparams.NewRegistry(),
 		checkpointer: checkpointer.NewCheckpointerHandler(),
 	}
 	go filterDistancias.FilterDistances()
 
-	dynMap := make(map[string][]byte)
-	dynMap["directDistance"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["directDistance"], math.Float32bits(1.9))
-	dynMap["totalTravelDistance"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["totalTravelDistance"], math.Float32bits(7.6))
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["directDistance"] = dataStructures.NewFloat32Column(1.9)
+	dynMap["totalTravelDistance"] = dataStructures.NewFloat32Column(7.6)
 	row := dataStructures.NewDynamicMap(dynMap)
 	msgToSend := &dataStructures.Message{TypeMessage: dataStructures.FlightRows, DynMaps: []*dataStructures.DynamicMap{row}}
 	input <- msgToSend
@@ -187,16 +190,16 @@ func TestGettingARowWithTotalDistanceLessThanFourTimesDirectDistanceShallNotPass
 		config:       &filters_config.FilterConfig{},
 		consumer:     mockCons,
 		producers:    arrayProducers,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *dataStructures.Message, 10)}, nil),
 		filter:       filters.NewFilter(),
+		params:       queryparams.NewRegistry(),
 		checkpointer: checkpointer.NewCheckpointerHandler(),
 	}
 	go filterDistancias.FilterDistances()
 
-	dynMap := make(map[string][]byte)
-	dynMap["directDistance"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["directDistance"], math.Float32bits(1.9))
-	dynMap["totalTravelDistance"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["totalTravelDistance"], math.Float32bits(5.0))
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["directDistance"] = dataStructures.NewFloat32Column(1.9)
+	dynMap["totalTravelDistance"] = dataStructures.NewFloat32Column(5.0)
 	row := dataStructures.NewDynamicMap(dynMap)
 	msgToSend := &dataStructures.Message{TypeMessage: dataStructures.FlightRows, DynMaps: []*dataStructures.DynamicMap{row}}
 	input <- msgToSend
@@ -225,17 +228,17 @@ func TestWithLessEqualAndGreaterForDistances(t *testing.T) {
 		config:       &filters_config.FilterConfig{},
 		consumer:     mockCons,
 		producers:    arrayProducers,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *dataStructures.Message, 10)}, nil),
 		filter:       filters.NewFilter(),
+		params:       queryparams.NewRegistry(),
 		checkpointer: checkpointer.NewCheckpointerHandler(),
 	}
 	go filterDistancias.FilterDistances()
 
 	for i := 0; i < 3; i++ {
-		dynMap := make(map[string][]byte)
-		dynMap["directDistance"] = make([]byte, 4)
-		binary.BigEndian.PutUint32(dynMap["directDistance"], math.Float32bits(1.9))
-		dynMap["totalTravelDistance"] = make([]byte, 4)
-		binary.BigEndian.PutUint32(dynMap["totalTravelDistance"], math.Float32bits(5.7+1.9*float32(i)))
+		dynMap := make(map[string]dataStructures.Column)
+		dynMap["directDistance"] = dataStructures.NewFloat32Column(1.9)
+		dynMap["totalTravelDistance"] = dataStructures.NewFloat32Column(5.7 + 1.9*float32(i))
 		row := dataStructures.NewDynamicMap(dynMap)
 		msgToSend := &dataStructures.Message{TypeMessage: dataStructures.FlightRows, DynMaps: []*dataStructures.DynamicMap{row}}
 		input <- msgToSend
@@ -263,3 +266,46 @@ func TestWithLessEqualAndGreaterForDistances(t *testing.T) {
 		t.Errorf("Expected to receive only 1 row, but %v were received", rowCountRecvd)
 	}
 }
+
+func TestGettingARowWithTotalDistanceGreaterThanTheClientFactorPassesFilter(t *testing.T) {
+	input := make(chan *dataStructures.Message)
+	output := make(chan *dataStructures.Message)
+
+	mockCons := &mockConsumer{
+		inputChannel: input,
+		ok:           true,
+	}
+	arrayProducers := make([]queueProtocol.ProducerProtocolInterface, 1)
+	arrayProducers[0] = &mockProducer{
+		outputChannel: output,
+	}
+	filterDistancias := &FilterDistances{
+		filterId:     0,
+		config:       &filters_config.FilterConfig{},
+		consumer:     mockCons,
+		producers:    arrayProducers,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *dataStructures.Message, 10)}, nil),
+		filter:       filters.NewFilter(),
+		params:       queryparams.NewRegistry(),
+		checkpointer: checkpointer.NewCheckpointerHandler(),
+	}
+	go filterDistancias.FilterDistances()
+
+	params := queryparams.Default()
+	params.DistanceFactor = 2
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["directDistance"] = dataStructures.NewFloat32Column(1.9)
+	dynMap["totalTravelDistance"] = dataStructures.NewFloat32Column(5.0)
+	row := dataStructures.NewDynamicMap(dynMap)
+	msgToSend := &dataStructures.Message{TypeMessage: dataStructures.FlightRows, ClientId: "client", DynMaps: []*dataStructures.DynamicMap{row}, Params: params.ToDynMap()}
+	input <- msgToSend
+	close(input)
+	select {
+	case result := <-output:
+		if len(result.DynMaps) != 1 {
+			t.Errorf("Expected one row, got %v", len(result.DynMaps))
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("Timeout! Should have finished by now...")
+	}
+}
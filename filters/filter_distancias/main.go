@@ -1,9 +1,11 @@
 package main
 
 import (
+	"filter_distancias/distances"
 	"filters_config"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	"github.com/brunograssano/Distribuidos-TP1/common/heartbeat"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	middleware "github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
@@ -22,11 +24,11 @@ func main() {
 	}
 
 	qMiddleware := middleware.NewQueueMiddleware(config.RabbitAddress)
-	qFactory := queuefactory.NewSimpleQueueFactory(qMiddleware)
-	var services []*FilterDistances
+	qFactory := queuefactory.NewSimpleQueueFactory(qMiddleware, config.Compressions)
+	var services []*distances.FilterDistances
 	for i := 0; i < config.GoroutinesCount; i++ {
 		checkpointerHandler := checkpointer.NewCheckpointerHandler()
-		fd := NewFilterDistances(i, qFactory, config, checkpointerHandler)
+		fd := distances.NewFilterDistances(i, qFactory, config, checkpointerHandler)
 		services = append(services, fd)
 		checkpointerHandler.RestoreCheckpoint()
 	}
@@ -34,8 +36,15 @@ func main() {
 		log.Infof("Main - Filter Distances | Spawning GoRoutine - Filter #%v", i)
 		go services[i].FilterDistances()
 	}
-	endSigHB := heartbeat.StartHeartbeat(config.AddressesHealthCheckers, config.ServiceName)
+	if config.EOFCoordinator {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		coordinator := filters_config.NewEOFCoordinator(qMiddleware, config, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		log.Infof("Main - Filter Distances | Spawning GoRoutine - EOF Coordinator")
+		go coordinator.CoordinateLoop()
+	}
+	endSigHB := heartbeat.StartMemberHeartbeat(config.AddressesHealthCheckers, config.ServiceName, membership.Member{Stage: config.InputQueueName, Nodes: uint(config.GoroutinesCount)})
 	<-sigs
-	endSigHB <- true
+	heartbeat.StopMemberHeartbeat(endSigHB)
 	qMiddleware.Close()
 }
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"filter_escalas/stopovers"
 	"filters_config"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	"github.com/brunograssano/Distribuidos-TP1/common/heartbeat"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	middleware "github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
@@ -23,21 +25,15 @@ func main() {
 	}
 
 	qMiddleware := middleware.NewQueueMiddleware(config.RabbitAddress)
-	qFactory := queuefactory.NewSimpleQueueFactory(qMiddleware)
-	var services []*FilterStopovers
+	qFactory := queuefactory.NewSimpleQueueFactory(qMiddleware, config.Compressions)
+	var services []*stopovers.FilterStopovers
 	for i := 0; i < config.GoroutinesCount; i++ {
 		checkpointerHandler := checkpointer.NewCheckpointerHandler()
 		inputQueue := qFactory.CreateConsumer(config.InputQueueName)
 		prodToCons := qFactory.CreateProducer(config.InputQueueName)
-		outputQueues := make([]queueProtocol.ProducerProtocolInterface, len(config.OutputQueueNames)+len(config.OutputExchangeNames))
-		for i := 0; i < len(config.OutputQueueNames); i++ {
-			outputQueues[i] = qFactory.CreateProducer(config.OutputQueueNames[i])
-		}
-		for i := 0; i < len(config.OutputExchangeNames); i++ {
-			qTopicFactory := queuefactory.NewTopicFactory(qMiddleware, []string{""}, config.OutputExchangeNames[i])
-			outputQueues[len(config.OutputQueueNames)+i] = qTopicFactory.CreateProducer("")
-		}
-		fe := NewFilterStopovers(i, inputQueue, outputQueues, prodToCons, config, checkpointerHandler)
+		outputQueues := filters_config.NewOutputProducers(qMiddleware, config)
+		toCoordinator := qFactory.CreateProducer(queueProtocol.CoordinatorQueueName(config.InputQueueName))
+		fe := stopovers.NewFilterStopovers(i, inputQueue, outputQueues, prodToCons, toCoordinator, config, checkpointerHandler)
 		services = append(services, fe)
 		checkpointerHandler.RestoreCheckpoint()
 	}
@@ -45,8 +41,15 @@ func main() {
 		log.Infof("Main - Filter Stopovers | Spawning GoRoutine - Filter #%v", i)
 		go services[i].FilterStopovers()
 	}
-	endSigHB := heartbeat.StartHeartbeat(config.AddressesHealthCheckers, config.ServiceName)
+	if config.EOFCoordinator {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		coordinator := filters_config.NewEOFCoordinator(qMiddleware, config, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		log.Infof("Main - Filter Stopovers | Spawning GoRoutine - EOF Coordinator")
+		go coordinator.CoordinateLoop()
+	}
+	endSigHB := heartbeat.StartMemberHeartbeat(config.AddressesHealthCheckers, config.ServiceName, membership.Member{Stage: config.InputQueueName, Nodes: uint(config.GoroutinesCount)})
 	<-sigs
-	endSigHB <- true
+	heartbeat.StopMemberHeartbeat(endSigHB)
 	qMiddleware.Close()
 }
@@ -1,12 +1,15 @@
-package main
+package stopovers
 
 import (
+	"errors"
 	"filters_config"
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/brunograssano/Distribuidos-TP1/common/filters"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 )
@@ -17,65 +20,99 @@ type FilterStopovers struct {
 	consumer     queueProtocol.ConsumerProtocolInterface
 	producers    []queueProtocol.ProducerProtocolInterface
 	prodToCons   queueProtocol.ProducerProtocolInterface
-	filter       *filters.Filter
+	eof          *queueProtocol.EOFReporter
+	filter       filters.FilterInterface
+	params       *queryparams.Registry
 	checkpointer *checkpointer.CheckpointerHandler
 }
 
-const MinStopovers = 3
+// stopoversPredicate Flights with at least the minimum stopovers
+func stopoversPredicate(filter filters.FilterInterface, minStopovers int) filters.Predicate {
+	return filter.Compare(utils.TotalStopovers, filters.GreaterOrEqualsOperator, minStopovers)
+}
 
 func NewFilterStopovers(
 	filterId int,
 	consumer queueProtocol.ConsumerProtocolInterface,
 	producers []queueProtocol.ProducerProtocolInterface,
 	prodToCons queueProtocol.ProducerProtocolInterface,
+	toCoordinator queueProtocol.ProducerProtocolInterface,
 	conf *filters_config.FilterConfig,
 	chkHandler *checkpointer.CheckpointerHandler,
 ) *FilterStopovers {
-	filter := filters.NewFilter()
 	chkHandler.AddCheckpointable(consumer, filterId)
+	eofReporter := queueProtocol.NewEOFReporter(fmt.Sprintf("%v-%v", conf.ID, filterId), consumer, toCoordinator, producers)
 	return &FilterStopovers{
 		filterId:     filterId,
 		config:       conf,
 		consumer:     consumer,
-		producers:    producers,
+		producers:    eofReporter.Outputs(),
 		prodToCons:   prodToCons,
-		filter:       filter,
+		eof:          eofReporter,
+		filter:       filters.NewFilter(),
+		params:       queryparams.NewRegistry(),
 		checkpointer: chkHandler,
 	}
 }
 
 func (fe *FilterStopovers) FilterStopovers() {
 	for {
-		msg, ok := fe.consumer.Pop()
-		if !ok {
+		msg, err := fe.consumer.Pop()
+		if errors.Is(err, queueProtocol.ErrConsumerClosed) {
 			log.Infof("FilterStopovers %v | Closing FilterStopovers Goroutine...", fe.filterId)
 			break
 		}
+		if err != nil {
+			log.Errorf("FilterStopovers %v | Skipping message | %v", fe.filterId, err)
+			continue
+		}
 		if msg.TypeMessage == dataStructures.EOFFlightRows {
 			log.Infof("FilterStopovers %v | Received EOF. Now handling...", fe.filterId)
-			err := queueProtocol.HandleEOF(msg, fe.prodToCons, fe.producers, fmt.Sprintf("%v-%v", fe.config.ID, fe.filterId), fe.config.TotalEofNodes)
+			err := fe.eof.HandleEOF(msg)
 			if err != nil {
 				log.Errorf("FilterStopovers %v | Error handling EOF | %v", fe.filterId, err)
 			}
+		} else if msg.TypeMessage == dataStructures.Abort {
+			log.Infof("FilterStopovers %v | Received Abort of client %v. Clearing its data...", fe.filterId, msg.ClientId)
+			fe.consumer.ClearData(msg.ClientId)
+			fe.params.Remove(msg.ClientId)
+			err := fe.eof.HandleAbort(msg, fe.prodToCons, membership.SizeOf(msg, fe.config.InputQueueName, fe.config.TotalEofNodes))
+			if err != nil {
+				log.Errorf("FilterStopovers %v | Error handling Abort | %v", fe.filterId, err)
+			}
+		} else if msg.TypeMessage == dataStructures.ClearClient {
+			log.Infof("FilterStopovers %v | Client %v finished. Clearing its data...", fe.filterId, msg.ClientId)
+			fe.consumer.ClearData(msg.ClientId)
+			fe.params.Remove(msg.ClientId)
+			err := fe.eof.HandleClear(msg, fe.prodToCons, membership.SizeOf(msg, fe.config.InputQueueName, fe.config.TotalEofNodes))
+			if err != nil {
+				log.Errorf("FilterStopovers %v | Error handling Clear | %v", fe.filterId, err)
+			}
 		} else if msg.TypeMessage == dataStructures.FlightRows {
 			log.Debugf("FilterStopovers %v | Received flight rows. Now filtering...", fe.filterId)
 			fe.handleFlightRows(msg)
+			_ = fe.eof.ReportBatch(msg)
 		} else {
 			log.Warnf("FilterStopovers %v | Warn Message | Unknonw message type received. Skipping it...", fe.filterId)
+			fe.consumer.DeadLetter(msg.DynMaps, fmt.Errorf("unknown type of message %v", msg.TypeMessage))
 		}
-		err := fe.checkpointer.DoCheckpoint(fe.filterId)
+		err = fe.checkpointer.DoCheckpoint(fe.filterId)
 		if err != nil {
 			log.Errorf("FilterStopovers #%v | Error on checkpointing | %v", fe.filterId, err)
 		}
 	}
 }
 
+// handleFlightRows Sends the rows with at least the minimum stopovers of the client of the message
 func (fe *FilterStopovers) handleFlightRows(msg *dataStructures.Message) {
 	var filteredRows []*dataStructures.DynamicMap
+	predicate := stopoversPredicate(fe.filter, fe.params.Of(msg).MinStopovers)
 	for _, row := range msg.DynMaps {
-		passesFilter, err := fe.filter.GreaterOrEquals(row, MinStopovers, utils.TotalStopovers)
+		passesFilter, err := predicate(row)
 		if err != nil {
 			log.Errorf("FilterStopovers %v | action: filter_stopovers | result: fail | skipping row | error: %v", fe.filterId, err)
+			fe.consumer.DeadLetter([]*dataStructures.DynamicMap{row}, fmt.Errorf("filter_stopovers: %v", err))
+			continue
 		}
 		if passesFilter {
 			filteredRows = append(filteredRows, row)
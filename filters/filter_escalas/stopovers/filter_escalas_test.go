@@ -1,12 +1,12 @@
-package main
+package stopovers
 
 import (
-	"encoding/binary"
 	"filters_config"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	"github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/brunograssano/Distribuidos-TP1/common/filters"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
 	"testing"
 	"time"
 )
@@ -43,11 +43,12 @@ func (m *mockConsumer) Abort(i int, errors chan error) {
 	panic("implement me")
 }
 
-func (m *mockConsumer) SetStatusOfLastMessage(b bool) {
-	//TODO implement me
-	panic("implement me")
+func (m *mockConsumer) RejectLastMessage(_ error) bool {
+	return true
 }
 
+func (m *mockConsumer) DeadLetter(_ []*data_structures.DynamicMap, _ error) {}
+
 func (m *mockConsumer) ClearData(s string) {
 	return
 }
@@ -56,12 +57,15 @@ func (m *mockConsumer) GetReceivedMessages(s string) int {
 	return 0
 }
 
-func (m *mockConsumer) Pop() (*data_structures.Message, bool) {
+func (m *mockConsumer) Pop() (*data_structures.Message, error) {
 	if !m.ok {
-		return &data_structures.Message{}, m.ok
+		return nil, queueProtocol.ErrConsumerClosed
 	}
 	msg, ok := <-m.inputChannel
-	return msg, ok
+	if !ok {
+		return nil, queueProtocol.ErrConsumerClosed
+	}
+	return msg, nil
 }
 
 func (m *mockConsumer) BindTo(_ string, _ string, _ string) error {
@@ -104,14 +108,15 @@ func TestGettingARowWithTotalStopoversLessThanThreeShouldNotSendIt(t *testing.T)
 		config:       &filters_config.FilterConfig{},
 		consumer:     mockCons,
 		producers:    arrayProducers,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *data_structures.Message, 10)}, nil),
 		filter:       filters.NewFilter(),
+		params:       queryparams.NewRegistry(),
 		checkpointer: checkpointer.NewCheckpointerHandler(),
 	}
 	go filterEscalas.FilterStopovers()
 
-	dynMap := make(map[string][]byte)
-	dynMap["totalStopovers"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["totalStopovers"], uint32(2))
+	dynMap := make(map[string]data_structures.Column)
+	dynMap["totalStopovers"] = data_structures.NewInt32Column(2)
 	row := data_structures.NewDynamicMap(dynMap)
 	msgToSend := &data_structures.Message{TypeMessage: data_structures.FlightRows, DynMaps: []*data_structures.DynamicMap{row}}
 	input <- msgToSend
@@ -141,14 +146,15 @@ func TestGettingARowWithTotalStopoversEqualToThreeShouldSendIt(t *testing.T) {
 		config:       &filters_config.FilterConfig{},
 		consumer:     mockCons,
 		producers:    arrayProducers,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *data_structures.Message, 10)}, nil),
 		filter:       filters.NewFilter(),
+		params:       queryparams.NewRegistry(),
 		checkpointer: checkpointer.NewCheckpointerHandler(),
 	}
 	go filterEscalas.FilterStopovers()
 
-	dynMap := make(map[string][]byte)
-	dynMap["totalStopovers"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["totalStopovers"], uint32(3))
+	dynMap := make(map[string]data_structures.Column)
+	dynMap["totalStopovers"] = data_structures.NewInt32Column(3)
 	row := data_structures.NewDynamicMap(dynMap)
 	msgToSend := &data_structures.Message{TypeMessage: data_structures.FlightRows, DynMaps: []*data_structures.DynamicMap{row}}
 	input <- msgToSend
@@ -186,14 +192,15 @@ func TestGettingARowWithTotalStopoversGreaterThanThreeShouldSendIt(t *testing.T)
 		config:       &filters_config.FilterConfig{},
 		consumer:     mockCons,
 		producers:    arrayProducers,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *data_structures.Message, 10)}, nil),
 		filter:       filters.NewFilter(),
+		params:       queryparams.NewRegistry(),
 		checkpointer: checkpointer.NewCheckpointerHandler(),
 	}
 	go filterEscalas.FilterStopovers()
 
-	dynMap := make(map[string][]byte)
-	dynMap["totalStopovers"] = make([]byte, 4)
-	binary.BigEndian.PutUint32(dynMap["totalStopovers"], uint32(4))
+	dynMap := make(map[string]data_structures.Column)
+	dynMap["totalStopovers"] = data_structures.NewInt32Column(4)
 	row := data_structures.NewDynamicMap(dynMap)
 	msgToSend := &data_structures.Message{TypeMessage: data_structures.FlightRows, DynMaps: []*data_structures.DynamicMap{row}}
 	input <- msgToSend
@@ -230,15 +237,16 @@ func TestWithLessEqualAndGreaterCasesTogetherShouldSendTwoOutOfThree(t *testing.
 		config:       &filters_config.FilterConfig{},
 		consumer:     mockCons,
 		producers:    arrayProducers,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *data_structures.Message, 10)}, nil),
 		filter:       filters.NewFilter(),
+		params:       queryparams.NewRegistry(),
 		checkpointer: checkpointer.NewCheckpointerHandler(),
 	}
 	go filterEscalas.FilterStopovers()
 
 	for i := 0; i < 3; i++ {
-		dynMap := make(map[string][]byte)
-		dynMap["totalStopovers"] = make([]byte, 4)
-		binary.BigEndian.PutUint32(dynMap["totalStopovers"], uint32(2+i))
+		dynMap := make(map[string]data_structures.Column)
+		dynMap["totalStopovers"] = data_structures.NewInt32Column(int32(2 + i))
 		row := data_structures.NewDynamicMap(dynMap)
 		msgToSend := &data_structures.Message{TypeMessage: data_structures.FlightRows, DynMaps: []*data_structures.DynamicMap{row}}
 		input <- msgToSend
@@ -269,3 +277,42 @@ func TestWithLessEqualAndGreaterCasesTogetherShouldSendTwoOutOfThree(t *testing.
 		t.Errorf("Expected to receive only 2 rows, but %v were received", rowCountRecvd)
 	}
 }
+
+func TestGettingARowWithLessStopoversThanTheClientMinimumShallNotPass(t *testing.T) {
+	input := make(chan *data_structures.Message)
+	output := make(chan *data_structures.Message)
+
+	mockCons := &mockConsumer{
+		inputChannel: input,
+		ok:           true,
+	}
+	arrayProducers := make([]queueProtocol.ProducerProtocolInterface, 1)
+	arrayProducers[0] = &mockProducer{
+		outputChannel: output,
+	}
+	filterEscalas := &FilterStopovers{
+		filterId:     0,
+		config:       &filters_config.FilterConfig{},
+		consumer:     mockCons,
+		producers:    arrayProducers,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *data_structures.Message, 10)}, nil),
+		filter:       filters.NewFilter(),
+		params:       queryparams.NewRegistry(),
+		checkpointer: checkpointer.NewCheckpointerHandler(),
+	}
+	go filterEscalas.FilterStopovers()
+
+	params := queryparams.Default()
+	params.MinStopovers = 5
+	dynMap := make(map[string]data_structures.Column)
+	dynMap["totalStopovers"] = data_structures.NewInt32Column(4)
+	row := data_structures.NewDynamicMap(dynMap)
+	msgToSend := &data_structures.Message{TypeMessage: data_structures.FlightRows, ClientId: "client", DynMaps: []*data_structures.DynamicMap{row}, Params: params.ToDynMap()}
+	input <- msgToSend
+	close(input)
+	select {
+	case <-output:
+		t.Errorf("Should not have received this row.")
+	case <-time.After(1 * time.Second):
+	}
+}
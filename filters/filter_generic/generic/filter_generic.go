@@ -0,0 +1,115 @@
+package generic
+
+import (
+	"errors"
+	"filters_config"
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
+	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	log "github.com/sirupsen/logrus"
+)
+
+// GenericFilter Filters the rows with the expression of the config
+type GenericFilter struct {
+	filterId     int
+	config       *filters_config.FilterConfig
+	consumer     queueProtocol.ConsumerProtocolInterface
+	producers    []queueProtocol.ProducerProtocolInterface
+	prodToCons   queueProtocol.ProducerProtocolInterface
+	eof          *queueProtocol.EOFReporter
+	checkpointer *checkpointer.CheckpointerHandler
+}
+
+func NewGenericFilter(
+	filterId int,
+	consumer queueProtocol.ConsumerProtocolInterface,
+	producers []queueProtocol.ProducerProtocolInterface,
+	prodToCons queueProtocol.ProducerProtocolInterface,
+	toCoordinator queueProtocol.ProducerProtocolInterface,
+	conf *filters_config.FilterConfig,
+	chkHandler *checkpointer.CheckpointerHandler,
+) *GenericFilter {
+	chkHandler.AddCheckpointable(consumer, filterId)
+	eofReporter := queueProtocol.NewEOFReporter(fmt.Sprintf("%v-%v", conf.ID, filterId), consumer, toCoordinator, producers)
+	return &GenericFilter{
+		filterId:     filterId,
+		config:       conf,
+		consumer:     consumer,
+		producers:    eofReporter.Outputs(),
+		prodToCons:   prodToCons,
+		eof:          eofReporter,
+		checkpointer: chkHandler,
+	}
+}
+
+func (gf *GenericFilter) Filter() {
+	for {
+		msg, err := gf.consumer.Pop()
+		if errors.Is(err, queueProtocol.ErrConsumerClosed) {
+			log.Infof("GenericFilter %v | Closing GenericFilter Goroutine...", gf.filterId)
+			break
+		}
+		if err != nil {
+			log.Errorf("GenericFilter %v | Skipping message | %v", gf.filterId, err)
+			continue
+		}
+		if msg.TypeMessage == dataStructures.EOFFlightRows {
+			log.Infof("GenericFilter %v | Received EOF. Now handling...", gf.filterId)
+			err := gf.eof.HandleEOF(msg)
+			if err != nil {
+				log.Errorf("GenericFilter %v | Error handling EOF | %v", gf.filterId, err)
+			}
+		} else if msg.TypeMessage == dataStructures.Abort {
+			log.Infof("GenericFilter %v | Received Abort of client %v. Clearing its data...", gf.filterId, msg.ClientId)
+			gf.consumer.ClearData(msg.ClientId)
+			err := gf.eof.HandleAbort(msg, gf.prodToCons, membership.SizeOf(msg, gf.config.InputQueueName, gf.config.TotalEofNodes))
+			if err != nil {
+				log.Errorf("GenericFilter %v | Error handling Abort | %v", gf.filterId, err)
+			}
+		} else if msg.TypeMessage == dataStructures.ClearClient {
+			log.Infof("GenericFilter %v | Client %v finished. Clearing its data...", gf.filterId, msg.ClientId)
+			gf.consumer.ClearData(msg.ClientId)
+			err := gf.eof.HandleClear(msg, gf.prodToCons, membership.SizeOf(msg, gf.config.InputQueueName, gf.config.TotalEofNodes))
+			if err != nil {
+				log.Errorf("GenericFilter %v | Error handling Clear | %v", gf.filterId, err)
+			}
+		} else if msg.TypeMessage == dataStructures.FlightRows {
+			log.Debugf("GenericFilter %v | Received flight rows. Now filtering...", gf.filterId)
+			gf.handleFlightRows(msg)
+			_ = gf.eof.ReportBatch(msg)
+		} else {
+			log.Warnf("GenericFilter %v | Warn Message | Unknonw message type received. Skipping it...", gf.filterId)
+			gf.consumer.DeadLetter(msg.DynMaps, fmt.Errorf("unknown type of message %v", msg.TypeMessage))
+		}
+		err = gf.checkpointer.DoCheckpoint(gf.filterId)
+		if err != nil {
+			log.Errorf("GenericFilter #%v | Error on checkpointing | %v", gf.filterId, err)
+		}
+	}
+}
+
+func (gf *GenericFilter) handleFlightRows(msg *dataStructures.Message) {
+	var filteredRows []*dataStructures.DynamicMap
+	for _, row := range msg.DynMaps {
+		passesFilter, err := gf.config.Predicate.Evaluate(row)
+		if err != nil {
+			log.Errorf("GenericFilter %v | action: filter_expression | result: fail | skipping row | error: %v", gf.filterId, err)
+			gf.consumer.DeadLetter([]*dataStructures.DynamicMap{row}, fmt.Errorf("filter_expression: %v", err))
+			continue
+		}
+		if passesFilter {
+			filteredRows = append(filteredRows, row)
+		}
+	}
+	if len(filteredRows) > 0 {
+		log.Debugf("GenericFilter %v | Sending filtered rows to next nodes. Input length: %v, output length: %v", gf.filterId, len(msg.DynMaps), len(filteredRows))
+		for _, producer := range gf.producers {
+			err := producer.Send(dataStructures.NewMessageWithData(msg, filteredRows))
+			if err != nil {
+				log.Errorf("GenericFilter %v | Error trying to send message that passed filter...", gf.filterId)
+			}
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package generic
+
+import (
+	"filters_config"
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
+	"github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/query"
+	"testing"
+	"time"
+)
+
+type (
+	mockConsumer struct {
+		inputChannel chan *data_structures.Message
+		ok           bool
+	}
+)
+
+func (m *mockConsumer) DoCheckpoint(errors chan error, i int, i2 int) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m *mockConsumer) RestoreCheckpoint(i int, i2 int, errors chan error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m *mockConsumer) GetCheckpointVersions(i int) [2]int {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m *mockConsumer) Commit(i int, errors chan error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m *mockConsumer) Abort(i int, errors chan error) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (m *mockConsumer) RejectLastMessage(_ error) bool {
+	return true
+}
+
+func (m *mockConsumer) DeadLetter(_ []*data_structures.DynamicMap, _ error) {}
+
+func (m *mockConsumer) ClearData(s string) {
+	return
+}
+
+func (m *mockConsumer) GetReceivedMessages(s string) int {
+	return 0
+}
+
+func (m *mockConsumer) Pop() (*data_structures.Message, error) {
+	if !m.ok {
+		return nil, queueProtocol.ErrConsumerClosed
+	}
+	msg, ok := <-m.inputChannel
+	if !ok {
+		return nil, queueProtocol.ErrConsumerClosed
+	}
+	return msg, nil
+}
+
+func (m *mockConsumer) BindTo(_ string, _ string, _ string) error {
+	return nil
+}
+
+type (
+	mockProducer struct {
+		outputChannel chan *data_structures.Message
+	}
+)
+
+func (m *mockProducer) ClearData(s string) {
+	return
+}
+
+func (m *mockProducer) GetSentMessages(s string) int {
+	return 0
+}
+
+func (m *mockProducer) Send(data *data_structures.Message) error {
+	m.outputChannel <- data
+	return nil
+}
+
+func TestGettingRowsShouldOnlySendTheOnesThatMeetTheExpression(t *testing.T) {
+	input := make(chan *data_structures.Message)
+	output := make(chan *data_structures.Message)
+
+	mockCons := &mockConsumer{
+		inputChannel: input,
+		ok:           true,
+	}
+	arrayProducers := make([]queueProtocol.ProducerProtocolInterface, 1)
+	arrayProducers[0] = &mockProducer{
+		outputChannel: output,
+	}
+	schema := query.Schema{"totalStopovers": data_structures.Int32Type, "totalTravelDistance": data_structures.Float32Type}
+	predicate, err := query.Compile("totalStopovers >= 3 AND totalTravelDistance > 100", schema)
+	if err != nil {
+		t.Fatalf("Error compiling the expression | %v", err)
+	}
+	genericFilter := &GenericFilter{
+		filterId:     0,
+		config:       &filters_config.FilterConfig{Predicate: predicate},
+		consumer:     mockCons,
+		producers:    arrayProducers,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *data_structures.Message, 10)}, nil),
+		checkpointer: checkpointer.NewCheckpointerHandler(),
+	}
+	go genericFilter.Filter()
+
+	var rows []*data_structures.DynamicMap
+	for i := 0; i < 4; i++ {
+		dynMap := make(map[string]data_structures.Column)
+		dynMap["totalStopovers"] = data_structures.NewInt32Column(int32(2 + i%2))
+		dynMap["totalTravelDistance"] = data_structures.NewFloat32Column(float32(50 * i))
+		rows = append(rows, data_structures.NewDynamicMap(dynMap))
+	}
+	input <- &data_structures.Message{TypeMessage: data_structures.FlightRows, DynMaps: rows}
+	close(input)
+	select {
+	case result := <-output:
+		if len(result.DynMaps) != 1 {
+			t.Fatalf("Expected to receive only 1 row, but %v were received", len(result.DynMaps))
+		}
+		distance, err := result.DynMaps[0].GetAsFloat("totalTravelDistance")
+		if err != nil || distance != 150 {
+			t.Errorf("Received a row that was not expected, with distance %v", distance)
+		}
+	case <-time.After(1 * time.Second):
+		t.Errorf("Timeout! Should have finished by now...")
+	}
+}
+
+func TestGettingRowsThatDoNotMeetTheExpressionShouldNotSendThem(t *testing.T) {
+	input := make(chan *data_structures.Message)
+	output := make(chan *data_structures.Message)
+
+	mockCons := &mockConsumer{
+		inputChannel: input,
+		ok:           true,
+	}
+	arrayProducers := make([]queueProtocol.ProducerProtocolInterface, 1)
+	arrayProducers[0] = &mockProducer{
+		outputChannel: output,
+	}
+	predicate, err := query.Compile("startingAirport = 'ATL'", query.Schema{"startingAirport": data_structures.StringType})
+	if err != nil {
+		t.Fatalf("Error compiling the expression | %v", err)
+	}
+	genericFilter := &GenericFilter{
+		filterId:     0,
+		config:       &filters_config.FilterConfig{Predicate: predicate},
+		consumer:     mockCons,
+		producers:    arrayProducers,
+		eof:          queueProtocol.NewEOFReporter("0", mockCons, &mockProducer{outputChannel: make(chan *data_structures.Message, 10)}, nil),
+		checkpointer: checkpointer.NewCheckpointerHandler(),
+	}
+	go genericFilter.Filter()
+
+	dynMap := make(map[string]data_structures.Column)
+	dynMap["startingAirport"] = data_structures.NewStringColumn("EZE")
+	otherDynMap := make(map[string]data_structures.Column)
+	otherDynMap["startingAirport"] = data_structures.NewInt32Column(3)
+	rows := []*data_structures.DynamicMap{data_structures.NewDynamicMap(dynMap), data_structures.NewDynamicMap(otherDynMap)}
+	input <- &data_structures.Message{TypeMessage: data_structures.FlightRows, DynMaps: rows}
+	close(input)
+	select {
+	case <-output:
+		t.Errorf("RowCount expected was 0")
+	case <-time.After(1 * time.Second):
+	}
+}
@@ -0,0 +1,3 @@
+module filter_generic
+
+go 1.21
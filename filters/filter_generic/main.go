@@ -0,0 +1,59 @@
+package main
+
+import (
+	"filter_generic/generic"
+	"filters_config"
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
+	"github.com/brunograssano/Distribuidos-TP1/common/heartbeat"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
+	middleware "github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	sigs := utils.CreateSignalListener()
+	env, err := filters_config.InitEnv()
+	if err != nil {
+		log.Fatalf("Main - Filter Generic | Error initializing env | %s", err)
+	}
+	config, err := filters_config.GetConfigFilters(env)
+	if err != nil {
+		log.Fatalf("Main - Filter Generic | Error initializing config | %s", err)
+	}
+	if config.Predicate == nil {
+		log.Fatalf("Main - Filter Generic | Error initializing config | filter.expression is required")
+	}
+	log.Infof("Main - Filter Generic | Filtering rows with %v", config.Predicate)
+
+	qMiddleware := middleware.NewQueueMiddleware(config.RabbitAddress)
+	qFactory := queuefactory.NewSimpleQueueFactory(qMiddleware, config.Compressions)
+	var services []*generic.GenericFilter
+	for i := 0; i < config.GoroutinesCount; i++ {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		inputQueue := qFactory.CreateConsumer(config.InputQueueName)
+		prodToCons := qFactory.CreateProducer(config.InputQueueName)
+		outputQueues := filters_config.NewOutputProducers(qMiddleware, config)
+		toCoordinator := qFactory.CreateProducer(queueProtocol.CoordinatorQueueName(config.InputQueueName))
+		gf := generic.NewGenericFilter(i, inputQueue, outputQueues, prodToCons, toCoordinator, config, checkpointerHandler)
+		services = append(services, gf)
+		checkpointerHandler.RestoreCheckpoint()
+	}
+	for i := 0; i < config.GoroutinesCount; i++ {
+		log.Infof("Main - Filter Generic | Spawning GoRoutine - Filter #%v", i)
+		go services[i].Filter()
+	}
+	if config.EOFCoordinator {
+		checkpointerHandler := checkpointer.NewCheckpointerHandler()
+		coordinator := filters_config.NewEOFCoordinator(qMiddleware, config, checkpointerHandler)
+		checkpointerHandler.RestoreCheckpoint()
+		log.Infof("Main - Filter Generic | Spawning GoRoutine - EOF Coordinator")
+		go coordinator.CoordinateLoop()
+	}
+	endSigHB := heartbeat.StartMemberHeartbeat(config.AddressesHealthCheckers, config.ServiceName, membership.Member{Stage: config.InputQueueName, Nodes: uint(config.GoroutinesCount)})
+	<-sigs
+	heartbeat.StopMemberHeartbeat(endSigHB)
+	qMiddleware.Close()
+}
@@ -0,0 +1,30 @@
+package filters_config
+
+import (
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
+)
+
+// NewOutputProducers Creates a producer for each output queue of the filter, followed by one for each output exchange
+func NewOutputProducers(qMiddleware middleware.QueueMiddlewareI, conf *FilterConfig) []queueProtocol.ProducerProtocolInterface {
+	qFactory := queuefactory.NewSimpleQueueFactory(qMiddleware, conf.Compressions)
+	var outputQueues []queueProtocol.ProducerProtocolInterface
+	for _, outputQueueName := range conf.OutputQueueNames {
+		outputQueues = append(outputQueues, qFactory.CreateProducer(outputQueueName))
+	}
+	for _, outputExchangeName := range conf.OutputExchangeNames {
+		qTopicFactory := queuefactory.NewTopicFactory(qMiddleware, []string{""}, outputExchangeName, conf.Compressions)
+		outputQueues = append(outputQueues, qTopicFactory.CreateProducer(""))
+	}
+	return outputQueues
+}
+
+// NewEOFCoordinator Creates the coordinator of the EOF of the filters, that sends the EOF to the outputs of the filter
+func NewEOFCoordinator(qMiddleware middleware.QueueMiddlewareI, conf *FilterConfig, chkHandler *checkpointer.CheckpointerHandler) *queueProtocol.EOFCoordinator {
+	name := queueProtocol.CoordinatorQueueName(conf.InputQueueName)
+	qFactory := queuefactory.NewSimpleQueueFactory(qMiddleware, conf.Compressions)
+	toNodes := qFactory.CreateProducer(conf.InputQueueName)
+	return queueProtocol.NewEOFCoordinator(name, qFactory.CreateConsumer(name), toNodes, NewOutputProducers(qMiddleware, conf), chkHandler)
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/brunograssano/Distribuidos-TP1/common/communication"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/brunograssano/Distribuidos-TP1/common/leader"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	socketsProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/sockets"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
@@ -20,6 +21,8 @@ type HealthChecker struct {
 	config             *Config
 	endSignal          chan bool
 	election           leader.ElectionService
+	// members Replicas registered in each stage through their heartbeats
+	members *membership.Registry
 }
 
 func NewHealthChecker(healthCheckerConfig *Config, election leader.ElectionService) *HealthChecker {
@@ -38,6 +41,7 @@ func NewHealthChecker(healthCheckerConfig *Config, election leader.ElectionServi
 		endSignal:          make(chan bool, 1),
 		election:           election,
 		mutexTimesLastHB:   sync.Mutex{},
+		members:            membership.NewRegistry(),
 	}
 }
 
@@ -106,18 +110,69 @@ func (h *HealthChecker) handleAcceptedConnection(conn *communication.TCPSocket)
 		log.Errorf("Healthchecker | Error receiving from connection | Err: %v", err)
 		return
 	}
-	if msg.TypeMessage != dataStructures.HeartBeat {
+	if msg.TypeMessage == dataStructures.GetMembership {
+		h.sendView(sph)
+		return
+	}
+	if msg.TypeMessage != dataStructures.HeartBeat && msg.TypeMessage != dataStructures.LeaveMembership {
 		log.Warnf("Healthchecker | Received unknown message type, skipping... | MsgType: %v", msg.TypeMessage)
 		return
 	}
-	serviceName, err := msg.DynMaps[0].GetAsString("name")
+	serviceName, err := msg.DynMaps[0].GetAsString(utils.ServiceName)
 	if err != nil {
 		log.Errorf("Healthchecker | Missing name for service | Err: %v", err)
 		return
 	}
+	if msg.TypeMessage == dataStructures.LeaveMembership {
+		h.leave(serviceName)
+		return
+	}
 	h.mutexTimesLastHB.Lock()
 	h.timesLastHeartbeat[serviceName] = time.Now()
 	h.mutexTimesLastHB.Unlock()
+	h.join(serviceName, msg.DynMaps[0])
+}
+
+// join Registers the service in its stage if the heartbeat has one
+func (h *HealthChecker) join(serviceName string, heartbeat *dataStructures.DynamicMap) {
+	stage, err := heartbeat.GetAsString(utils.MemberStage)
+	if err != nil {
+		return
+	}
+	nodes, err := heartbeat.GetAsInt64(utils.MemberNodes)
+	if err != nil {
+		log.Errorf("Healthchecker | Missing nodes of %v in stage %v | Err: %v", serviceName, stage, err)
+		return
+	}
+	member := membership.Member{Name: serviceName, Stage: stage, Nodes: uint(nodes)}
+	if firstKey, err := heartbeat.GetAsInt64(utils.MemberFirstKey); err == nil {
+		member.Keyed = true
+		member.FirstKey = uint(firstKey)
+	}
+	if h.members.Join(member) {
+		log.Infof("Healthchecker | %v joined stage %v with %v nodes | Epoch: %v", serviceName, stage, nodes, h.members.View().Epoch)
+	}
+}
+
+// leave Removes the service from its stage and stops watching it, as it was stopped on purpose
+func (h *HealthChecker) leave(serviceName string) {
+	h.mutexTimesLastHB.Lock()
+	delete(h.timesLastHeartbeat, serviceName)
+	h.mutexTimesLastHB.Unlock()
+	if h.members.Leave(serviceName) {
+		log.Infof("Healthchecker | %v left its stage | Epoch: %v", serviceName, h.members.View().Epoch)
+	}
+}
+
+// sendView Answers with the replicas registered in each stage
+func (h *HealthChecker) sendView(sph *socketsProtocol.SocketProtocolHandler) {
+	err := sph.Write(&dataStructures.Message{
+		TypeMessage: dataStructures.Membership,
+		DynMaps:     []*dataStructures.DynamicMap{h.members.View().ToDynMap()},
+	})
+	if err != nil {
+		log.Errorf("Healthchecker | Error sending the view of the stages | Err: %v", err)
+	}
 }
 
 func (h *HealthChecker) Close() {
@@ -8,26 +8,41 @@ import (
 	"github.com/brunograssano/Distribuidos-TP1/common/getters"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
+	"github.com/brunograssano/Distribuidos-TP1/common/retention"
 	log "github.com/sirupsen/logrus"
 )
 
 type Ex3Handler struct {
 	c                        *SaverConfig
 	journeyDispatcher        []*dispatcher.JourneyDispatcher
+	coordinator              *queueProtocol.EOFCoordinator
 	savers                   []*SaverForEx3
 	getter                   *getters.Getter
-	finishedSignals          chan string
+	finishedSignals          chan finishSignal
 	outputFilenames          []string
 	quantityFinishedByClient map[string]uint
+	collector                *retention.Collector
+}
+
+// NewCollector Creates the collector of the results of the clients, the ones of each saver and the definitive ones
+func NewCollector(c *SaverConfig) *retention.Collector {
+	return retention.NewCollector(c.Retention, func(clientId string) []string {
+		var artifacts []string
+		for i := 0; i < int(c.InternalSaversCount); i++ {
+			file := fmt.Sprintf("%v_%v_%v.csv", c.OutputFilePrefix, i, clientId)
+			artifacts = append(artifacts, fmt.Sprintf("%v_tmp/%v", clientId, file), fmt.Sprintf("%v/%v", clientId, file))
+		}
+		return artifacts
+	})
 }
 
 // NewEx3Handler Creates a new exercise 3 handler
-func NewEx3Handler(c *SaverConfig, dispatchersQFactory queuefactory.QueueProtocolFactory, internalQFactory queuefactory.QueueProtocolFactory) *Ex3Handler {
+func NewEx3Handler(c *SaverConfig, dispatchersQFactory queuefactory.QueueProtocolFactory, internalQFactory queuefactory.QueueProtocolFactory, collector *retention.Collector) *Ex3Handler {
 
 	// Creation of the JourneySavers, they handle the prices per journey
 	var internalSaversConsumers []*SaverForEx3
 	var outputFileNames []string
-	finishSignal := make(chan string, c.InternalSaversCount)
+	finishSignals := make(chan finishSignal, c.InternalSaversCount)
 	var toInternalSavers []queueProtocol.ProducerProtocolInterface
 	log.Infof("Ex3Handler | Creating %v savers...", int(c.InternalSaversCount))
 	for i := 0; i < int(c.InternalSaversCount); i++ {
@@ -35,7 +50,7 @@ func NewEx3Handler(c *SaverConfig, dispatchersQFactory queuefactory.QueueProtoco
 		internalSaversConsumers = append(internalSaversConsumers, NewSaverForEx3(
 			internalQFactory.CreateConsumer(fmt.Sprintf("saver3-internal-%v-%v", c.ID, i)),
 			c,
-			finishSignal,
+			finishSignals,
 			i,
 			checkpointerHandler,
 		))
@@ -44,21 +59,37 @@ func NewEx3Handler(c *SaverConfig, dispatchersQFactory queuefactory.QueueProtoco
 		checkpointerHandler.RestoreCheckpoint()
 		log.Infof("Ex3Handler | Created Saver #%v correctly...", i)
 	}
+	if err := rebalanceSavers(c, internalSaversConsumers); err != nil {
+		log.Fatalf("Ex3Handler | Error handing off the journeys between the savers | %v", err)
+	}
 
 	// Creation of the dispatcher to the JourneySavers
 	log.Infof("Ex3Handler | Creating dispatchers...")
 	var jds []*dispatcher.JourneyDispatcher
+	// Every replica receives all the rows, so each one coordinates the EOF of its own dispatchers
+	coordinatorQueueName := queueProtocol.CoordinatorQueueName(fmt.Sprintf("%v-%v", c.InputQueueName, c.ID))
+	// The internal savers are not in the views of the clients, so every client uses all of them
+	toKeyedSavers := func() *queueProtocol.KeyedOutputs {
+		return queueProtocol.NewKeyedOutputs("", uint(len(toInternalSavers)), func(idx int) queueProtocol.ProducerProtocolInterface {
+			return toInternalSavers[idx]
+		})
+	}
 	for i := uint(0); i < c.DispatchersCount; i++ {
 		checkpointerHandler := checkpointer.NewCheckpointerHandler()
 		// We create the input queue to the EX3 service
 		inputQueue := dispatchersQFactory.CreateConsumer(fmt.Sprintf("%v-%v", c.InputQueueName, c.ID))
 		prodToInput := dispatchersQFactory.CreateProducer(c.ID)
-		tmpDispatcher := dispatcher.NewJourneyDispatcher(i, inputQueue, prodToInput, toInternalSavers, checkpointerHandler, c.TotalEofNodes, fmt.Sprintf("%v", i))
+		toCoordinator := internalQFactory.CreateProducer(coordinatorQueueName)
+		tmpDispatcher := dispatcher.NewJourneyDispatcher(i, inputQueue, prodToInput, toCoordinator, toKeyedSavers(), checkpointerHandler, c.TotalEofNodes, fmt.Sprintf("%v", i), "", c.Partitioning)
 		jds = append(jds, tmpDispatcher)
 		checkpointerHandler.RestoreCheckpoint()
 	}
+	checkpointerHandler := checkpointer.NewCheckpointerHandler()
+	toDispatchers := dispatchersQFactory.CreateProducer(c.ID)
+	coordinator := queueProtocol.NewEOFCoordinator(coordinatorQueueName, internalQFactory.CreateConsumer(coordinatorQueueName), toDispatchers, toInternalSavers, checkpointerHandler)
+	checkpointerHandler.RestoreCheckpoint()
 
-	getterConf := getters.NewGetterConfig(c.ID, outputFileNames, c.GetterAddress, c.GetterBatchLines)
+	getterConf := getters.NewGetterConfig(c.ID, outputFileNames, c.GetterAddress, c.GetterBatchLines, false, collector)
 	getter, err := getters.NewGetter(getterConf)
 	if err != nil {
 		log.Fatalf("Ex3Handler | Error initializing Getter | %s", err)
@@ -67,21 +98,28 @@ func NewEx3Handler(c *SaverConfig, dispatchersQFactory queuefactory.QueueProtoco
 	return &Ex3Handler{
 		c:                        c,
 		journeyDispatcher:        jds,
+		coordinator:              coordinator,
 		savers:                   internalSaversConsumers,
 		getter:                   getter,
-		finishedSignals:          finishSignal,
+		finishedSignals:          finishSignals,
 		outputFilenames:          outputFileNames,
 		quantityFinishedByClient: make(map[string]uint),
+		collector:                collector,
 	}
 }
 
 func (se3 *Ex3Handler) handleFinishSignals() {
 	for {
-		clientId, ok := <-se3.finishedSignals
+		signal, ok := <-se3.finishedSignals
 		// If channels are closed is because I received a Close
 		if !ok {
 			return
 		}
+		clientId := signal.clientId
+		if signal.aborted {
+			se3.clearAbortedClient(clientId)
+			continue
+		}
 		_, existsCID := se3.quantityFinishedByClient[clientId]
 		if !existsCID {
 			se3.quantityFinishedByClient[clientId] = 0
@@ -89,16 +127,31 @@ func (se3 *Ex3Handler) handleFinishSignals() {
 		se3.quantityFinishedByClient[clientId]++
 		if se3.quantityFinishedByClient[clientId] == se3.c.InternalSaversCount {
 			log.Infof("Ex3Handler | All savers finished | Notifying getter that it is able to send results...")
-			//Renames the folder from tmp to definitive folder
-			err := filemanager.RenameFile(fmt.Sprintf("%v_tmp", clientId), fmt.Sprintf("%v", clientId))
+			//Moves the results from the tmp folder to the definitive folder
+			var files []string
+			for _, filename := range se3.outputFilenames {
+				files = append(files, fmt.Sprintf("%v_%v.csv", filename, clientId))
+			}
+			se3.collector.Track(clientId)
+			err := filemanager.MoveFilesBetweenFolders(files, fmt.Sprintf("%v_tmp", clientId), fmt.Sprintf("%v", clientId))
 			if err != nil {
-				log.Errorf("Ex3Handler | Error trying to rename client_id %v folder | %v", clientId, err)
+				log.Errorf("Ex3Handler | Error trying to move results of client_id %v | %v", clientId, err)
 			}
 			delete(se3.quantityFinishedByClient, clientId)
 		}
 	}
 }
 
+// clearAbortedClient Deletes the results of the client, the ones of the savers that finished and the definitive ones
+func (se3 *Ex3Handler) clearAbortedClient(clientId string) {
+	log.Infof("Ex3Handler | Client %v aborted | Deleting its results...", clientId)
+	delete(se3.quantityFinishedByClient, clientId)
+	err := filemanager.DeleteIfExists(fmt.Sprintf("%v_tmp", clientId), clientId)
+	if err != nil {
+		log.Errorf("Ex3Handler | Error trying to delete results of client_id %v | %v", clientId, err)
+	}
+}
+
 // StartHandler Starts the exercise 4 services as goroutines
 func (se3 *Ex3Handler) StartHandler() {
 	log.Debugf("Ex3Handler | Number of savers: %v", len(se3.savers))
@@ -112,11 +165,15 @@ func (se3 *Ex3Handler) StartHandler() {
 		log.Infof("Ex3Handler | Spawning Dispatcher #%v", idx)
 		go jd.DispatchLoop()
 	}
+	log.Infof("Ex3Handler | Spawning EOF Coordinator...")
+	go se3.coordinator.CoordinateLoop()
 
 	log.Infof("Ex3Handler | Spawning Getter...")
 	go se3.getter.ReturnResults()
 	log.Infof("Ex3Handler | Spawning task to handle when all savers finish...")
 	go se3.handleFinishSignals()
+	log.Infof("Ex3Handler | Spawning collector of the results...")
+	go se3.collector.CollectLoop()
 }
 
 // Close Closes the handler of the exercise 4
@@ -125,5 +182,6 @@ func (se3 *Ex3Handler) Close() {
 	close(se3.finishedSignals)
 	log.Infof("Ex3Handler | Closing Getter")
 	se3.getter.Close()
+	se3.collector.Close()
 	log.Infof("Ex3Handler | Ended closing resources")
 }
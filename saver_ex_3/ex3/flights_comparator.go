@@ -29,40 +29,31 @@ func (d DurationSlice) Swap(i, j int) {
 	d[i], d[j] = d[j], d[i]
 }
 
+// DecideWhichRowsToKeep Keeps the k fastest rows between the kept ones and the new flight row
 func DecideWhichRowsToKeep(
-	rowsKept [2]*dataStructures.DynamicMap,
+	rowsKept []*dataStructures.DynamicMap,
 	flightRow *dataStructures.DynamicMap,
+	k int,
 	saverId int,
-) [2]*dataStructures.DynamicMap {
-	if rowsKept[1] == nil && rowsKept[0] != nil {
-		return [2]*dataStructures.DynamicMap{rowsKept[0], flightRow}
-	} else if rowsKept[1] == nil && rowsKept[0] == nil {
-		log.Warnf("Saver %v | Journey should not already exist as both parts are nil | Replacing index 0 anyways...", saverId)
-		return [2]*dataStructures.DynamicMap{flightRow, nil}
-	}
+) []*dataStructures.DynamicMap {
 	//Create an array with the rows to facilitate indexation at last step
-	arrayOfRows := []*dataStructures.DynamicMap{
-		rowsKept[0],
-		rowsKept[1],
-		flightRow,
-	}
-
-	travelDurIdx0, err := rowsKept[0].GetAsInt(utils.ConvertedTravelDuration)
-	if err != nil {
-		log.Errorf("Saver %v | Error trying to get travelDuration of index 0 in compare | Journey map was: %v | %v", saverId, rowsKept, err)
-		return [2]*dataStructures.DynamicMap{rowsKept[0], rowsKept[1]}
+	arrayOfRows := append(append([]*dataStructures.DynamicMap{}, rowsKept...), flightRow)
+	arrayOfDurations := make(DurationSlice, 0, len(arrayOfRows))
+	for idx, row := range arrayOfRows {
+		travelDur, err := row.GetAsInt(utils.ConvertedTravelDuration)
+		if err != nil {
+			log.Errorf("Saver %v | Error trying to get travelDuration of index %v in compare | Journey rows were: %v | %v", saverId, idx, arrayOfRows, err)
+			return rowsKept
+		}
+		arrayOfDurations = append(arrayOfDurations, []int{idx, travelDur})
 	}
-	travelDurIdx1, err := rowsKept[1].GetAsInt(utils.ConvertedTravelDuration)
-	if err != nil {
-		log.Errorf("Saver %v | Error trying to get travelDuration of index 1 in compare | Journey map was: %v | %v", saverId, rowsKept, err)
-		return [2]*dataStructures.DynamicMap{rowsKept[0], rowsKept[1]}
+	sort.Stable(arrayOfDurations)
+	if len(arrayOfDurations) > k {
+		arrayOfDurations = arrayOfDurations[:k]
 	}
-	compareTravelDur, err := flightRow.GetAsInt(utils.ConvertedTravelDuration)
-	arrayOfDurations := DurationSlice{
-		[]int{0, travelDurIdx0},
-		[]int{1, travelDurIdx1},
-		[]int{2, compareTravelDur},
+	newRows := make([]*dataStructures.DynamicMap, 0, len(arrayOfDurations))
+	for _, duration := range arrayOfDurations {
+		newRows = append(newRows, arrayOfRows[duration[indexColumn]])
 	}
-	sort.Sort(arrayOfDurations)
-	return [2]*dataStructures.DynamicMap{arrayOfRows[arrayOfDurations[0][indexColumn]], arrayOfRows[arrayOfDurations[1][indexColumn]]}
+	return newRows
 }
@@ -0,0 +1,279 @@
+package ex3
+
+import (
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/dispatcher"
+	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+)
+
+const layoutFile = "saver3_layout.csv"
+const tmpLayoutFile = "saver3_layout_tmp.csv"
+const handoffFile = "saver3_handoff.csv"
+const tmpHandoffFile = "saver3_handoff_tmp.csv"
+
+// journeysByClient Rows kept of each journey of each client, as the savers keep them
+type journeysByClient map[string]map[string][]*dataStructures.DynamicMap
+
+// saversLayout How the journeys are split between the savers
+type saversLayout struct {
+	savers       uint
+	partitioning *dispatcher.PartitioningConfig
+}
+
+// String Returns the layout as savers,kind,virtualNodes,column;column
+func (l saversLayout) String() string {
+	return fmt.Sprintf("%v,%v", l.savers, l.partitioning)
+}
+
+func parseLayout(line string) (saversLayout, error) {
+	fields := strings.Split(strings.TrimSpace(line), utils.CommaSeparator)
+	if len(fields) != 4 {
+		return saversLayout{}, fmt.Errorf("wrong format of layout %v", line)
+	}
+	savers, err := strconv.Atoi(fields[0])
+	if err != nil || savers <= 0 {
+		return saversLayout{}, fmt.Errorf("invalid savers of layout %v", line)
+	}
+	virtualNodes, err := strconv.Atoi(fields[2])
+	if err != nil || virtualNodes < 0 {
+		return saversLayout{}, fmt.Errorf("invalid virtual nodes of layout %v", line)
+	}
+	columns := strings.ReplaceAll(fields[3], utils.DotCommaSeparator, utils.CommaSeparator)
+	partitioning, err := dispatcher.NewPartitioningConfig(fields[1], uint(virtualNodes), columns)
+	if err != nil {
+		return saversLayout{}, err
+	}
+	return saversLayout{savers: uint(savers), partitioning: partitioning}, nil
+}
+
+// readLayout Returns the layout of the last execution. Without one, the savers were split by the hash of
+// the airports modulo the savers, and we suppose that their number did not change
+func readLayout(c *SaverConfig) (saversLayout, error) {
+	if !filemanager.DirectoryExists(layoutFile) {
+		return saversLayout{savers: c.InternalSaversCount, partitioning: dispatcher.LegacyPartitioning()}, nil
+	}
+	reader, err := filemanager.NewFileReader(layoutFile)
+	if err != nil {
+		return saversLayout{}, err
+	}
+	defer utils.CloseFileAndNotifyError(reader)
+	if !reader.CanRead() {
+		return saversLayout{}, fmt.Errorf("the layout file %v is empty", layoutFile)
+	}
+	return parseLayout(reader.ReadLine())
+}
+
+// writeFileAtomically Writes the content in a temporal file and then renames it, so the file is complete or does not change
+func writeFileAtomically(file string, tmpFile string, content string) error {
+	if err := filemanager.DeleteIfExists(tmpFile); err != nil {
+		return err
+	}
+	writer, err := filemanager.NewFileWriter(tmpFile)
+	if err != nil {
+		return err
+	}
+	err = writer.WriteLine(content)
+	utils.CloseFileAndNotifyError(writer.FileManager)
+	if err != nil {
+		return err
+	}
+	return filemanager.RenameFile(tmpFile, file)
+}
+
+// rebalanceSavers Hands off the journeys kept between the savers if their layout changed since the last execution,
+// so each journey is in the saver that receives its rows now. The savers must have restored their checkpoints.
+// The new state is first written in the handoff file, that is applied until it succeeds, even after a crash
+func rebalanceSavers(c *SaverConfig, savers []*SaverForEx3) error {
+	current := saversLayout{savers: c.InternalSaversCount, partitioning: c.Partitioning}
+	if !filemanager.DirectoryExists(handoffFile) {
+		previous, err := readLayout(c)
+		if err != nil {
+			return err
+		}
+		if previous.String() == current.String() {
+			if filemanager.DirectoryExists(layoutFile) {
+				return nil
+			}
+			return writeFileAtomically(layoutFile, tmpLayoutFile, current.String())
+		}
+		state := stateOf(c, savers, previous.savers)
+		if isEmpty(state) {
+			log.Infof("Ex3Handler | The layout of the savers changed from %v to %v | There are no journeys to hand off", previous, current)
+			return writeFileAtomically(layoutFile, tmpLayoutFile, current.String())
+		}
+		log.Infof("Ex3Handler | The layout of the savers changed from %v to %v | Handing off the journeys...", previous, current)
+		err = writeFileAtomically(handoffFile, tmpHandoffFile, handoffString(previous.savers, current, assignJourneys(state, current)))
+		if err != nil {
+			return err
+		}
+	}
+	return applyHandoff(current, savers)
+}
+
+// stateOf Returns the journeys of each saver of the previous layout. The savers that were removed are restored from their checkpoints
+func stateOf(c *SaverConfig, savers []*SaverForEx3, previousSavers uint) []journeysByClient {
+	var state []journeysByClient
+	for id := 0; id < int(previousSavers); id++ {
+		if id < len(savers) {
+			state = append(state, savers[id].regsToPersistByClient)
+			continue
+		}
+		removedSaver := &SaverForEx3{c: c, id: id, regsToPersistByClient: make(journeysByClient)}
+		chkHandler := checkpointer.NewCheckpointerHandler()
+		chkHandler.AddCheckpointable(removedSaver, id)
+		chkHandler.RestoreCheckpoint()
+		state = append(state, removedSaver.regsToPersistByClient)
+	}
+	return state
+}
+
+func isEmpty(state []journeysByClient) bool {
+	for _, clients := range state {
+		for _, journeys := range clients {
+			if len(journeys) > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// assignJourneys Returns the journeys that each saver of the layout has to keep. The saver of a row is the one
+// of its key, so with the columns of the journey all its rows go to the same saver. If a journey was in more than
+// one saver and goes to one, its rows are joined
+func assignJourneys(state []journeysByClient, layout saversLayout) []journeysByClient {
+	partitioner := layout.partitioning.NewPartitioner()
+	assigned := make([]journeysByClient, layout.savers)
+	for id := range assigned {
+		assigned[id] = make(journeysByClient)
+	}
+	for previousId, clients := range state {
+		for clientId, journeys := range clients {
+			for journey, rows := range journeys {
+				for _, row := range rows {
+					owner := previousId % int(layout.savers)
+					key, err := dispatcher.KeyOf(row, layout.partitioning.KeyColumns)
+					if err != nil {
+						log.Errorf("Ex3Handler | Error getting the key of a row of journey %v of client %v | It goes to saver %v | %v", journey, clientId, owner, err)
+					} else {
+						owner = partitioner.Partition(key, int(layout.savers))
+					}
+					if _, exists := assigned[owner][clientId]; !exists {
+						assigned[owner][clientId] = make(map[string][]*dataStructures.DynamicMap)
+					}
+					assigned[owner][clientId][journey] = append(assigned[owner][clientId][journey], row)
+				}
+			}
+		}
+	}
+	return assigned
+}
+
+// handoffString Returns the handoff as a header with the previous savers and the new layout,
+// followed by the rows of each saver as {saverId};{clientId};{journey}@{dynMap}
+func handoffString(previousSavers uint, layout saversLayout, assigned []journeysByClient) string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString(fmt.Sprintf("%v,%v\n", previousSavers, layout))
+	for id, clients := range assigned {
+		for clientId, journeys := range clients {
+			for journey, rows := range journeys {
+				for _, row := range rows {
+					// The dynmap serializer adds \n
+					strBuilder.WriteString(fmt.Sprintf("%v;%v;%v@%v", id, clientId, journey, serializer.SerializeToString(row)))
+				}
+			}
+		}
+	}
+	return strBuilder.String()
+}
+
+// readHandoff Returns the previous savers, the layout and the journeys of each saver of the handoff file
+func readHandoff() (int, saversLayout, []journeysByClient, error) {
+	reader, err := filemanager.NewFileReader(handoffFile)
+	if err != nil {
+		return 0, saversLayout{}, nil, err
+	}
+	defer utils.CloseFileAndNotifyError(reader)
+	if !reader.CanRead() {
+		return 0, saversLayout{}, nil, fmt.Errorf("the handoff file %v is empty", handoffFile)
+	}
+	previousSaversAndLayout := strings.SplitN(reader.ReadLine(), utils.CommaSeparator, 2)
+	if len(previousSaversAndLayout) != 2 {
+		return 0, saversLayout{}, nil, fmt.Errorf("wrong format of the header of the handoff file %v", handoffFile)
+	}
+	previousSavers, err := strconv.Atoi(previousSaversAndLayout[0])
+	if err != nil {
+		return 0, saversLayout{}, nil, fmt.Errorf("invalid previous savers of the handoff: %v", err)
+	}
+	layout, err := parseLayout(previousSaversAndLayout[1])
+	if err != nil {
+		return 0, saversLayout{}, nil, err
+	}
+	assigned := make([]journeysByClient, layout.savers)
+	for id := range assigned {
+		assigned[id] = make(journeysByClient)
+	}
+	for reader.CanRead() {
+		line := reader.ReadLine()
+		keysAndDynMap := strings.SplitN(line, utils.AtSeparator, 2)
+		keys := strings.Split(keysAndDynMap[0], utils.DotCommaSeparator)
+		if len(keysAndDynMap) != 2 || len(keys) != 3 {
+			return 0, saversLayout{}, nil, fmt.Errorf("wrong format of handoff line %v", line)
+		}
+		id, err := strconv.Atoi(keys[0])
+		if err != nil || id < 0 || id >= len(assigned) {
+			return 0, saversLayout{}, nil, fmt.Errorf("invalid saver of handoff line %v", line)
+		}
+		dynMap, err := serializer.DeserializeFromString(keysAndDynMap[1])
+		if err != nil {
+			return 0, saversLayout{}, nil, err
+		}
+		clientId, journey := keys[1], keys[2]
+		if _, exists := assigned[id][clientId]; !exists {
+			assigned[id][clientId] = make(map[string][]*dataStructures.DynamicMap)
+		}
+		assigned[id][clientId][journey] = append(assigned[id][clientId][journey], dynMap)
+	}
+	return previousSavers, layout, assigned, reader.Err()
+}
+
+// applyHandoff Replaces the journeys of the savers by the ones of the handoff and checkpoints them. Then deletes
+// the checkpoints of the removed savers, saves the new layout and deletes the handoff
+func applyHandoff(current saversLayout, savers []*SaverForEx3) error {
+	previousSavers, layout, assigned, err := readHandoff()
+	if err != nil {
+		return err
+	}
+	if layout.String() != current.String() {
+		return fmt.Errorf("the handoff is for the layout %v, but the savers use %v. Restart them with the layout of the handoff", layout, current)
+	}
+	for id, saver := range savers {
+		saver.regsToPersistByClient = assigned[id]
+		if err = saver.checkpointer.DoCheckpoint(saver.id); err != nil {
+			return err
+		}
+	}
+	for id := len(savers); id < previousSavers; id++ {
+		log.Infof("Ex3Handler | Deleting the checkpoints of the removed saver %v", id)
+		err = filemanager.DeleteIfExists(
+			fmt.Sprintf("%v_%v_%v", id, "", oldFileSaver),
+			fmt.Sprintf("%v_%v_%v", id, "", currFileSaver),
+			fmt.Sprintf("%v_%v_%v", id, "", tmpFileSaver),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	if err = writeFileAtomically(layoutFile, tmpLayoutFile, current.String()); err != nil {
+		return err
+	}
+	log.Infof("Ex3Handler | Handed off the journeys to the savers of the layout %v", current)
+	return filemanager.DeleteFile(handoffFile)
+}
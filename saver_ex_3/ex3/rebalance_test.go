@@ -0,0 +1,90 @@
+package ex3
+
+import (
+	"fmt"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/dispatcher"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+)
+
+func journeyRow(starting string, destination string) *dataStructures.DynamicMap {
+	return dataStructures.NewDynamicMap(map[string]dataStructures.Column{
+		utils.StartingAirport:    dataStructures.NewStringColumn(starting),
+		utils.DestinationAirport: dataStructures.NewStringColumn(destination),
+	})
+}
+
+func TestTheJourneysAreHandedOffToTheSaversOfTheNewLayout(t *testing.T) {
+	workingDir, _ := os.Getwd()
+	assert.Nil(t, os.Chdir(t.TempDir()))
+	defer func() { _ = os.Chdir(workingDir) }()
+
+	previous := []journeysByClient{make(journeysByClient), make(journeysByClient)}
+	for i := 0; i < 40; i++ {
+		starting, destination := fmt.Sprintf("A%v", i), fmt.Sprintf("B%v", i)
+		journeys := previous[i%2]
+		if _, exists := journeys["client"]; !exists {
+			journeys["client"] = make(map[string][]*dataStructures.DynamicMap)
+		}
+		journey := fmt.Sprintf("%v-%v", starting, destination)
+		journeys["client"][journey] = []*dataStructures.DynamicMap{journeyRow(starting, destination), journeyRow(starting, destination)}
+	}
+	partitioning, err := dispatcher.NewPartitioningConfig(dispatcher.RingPartitioner, 0, "")
+	assert.Nil(t, err)
+	layout := saversLayout{savers: 3, partitioning: partitioning}
+
+	assigned := assignJourneys(previous, layout)
+	assert.Len(t, assigned, 3)
+	ring := partitioning.NewPartitioner()
+	journeys := 0
+	for id, clients := range assigned {
+		for journey, rows := range clients["client"] {
+			key, err := dispatcher.KeyOf(rows[0], partitioning.KeyColumns)
+			assert.Nil(t, err)
+			assert.Equal(t, id, ring.Partition(key, 3), "The journey %v is not in the saver of its key", journey)
+			assert.Len(t, rows, 2)
+			journeys++
+		}
+	}
+	assert.Equal(t, 40, journeys)
+
+	assert.Nil(t, writeFileAtomically(handoffFile, tmpHandoffFile, handoffString(2, layout, assigned)))
+	previousSavers, readLayout, readAssigned, err := readHandoff()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, previousSavers)
+	assert.Equal(t, layout.String(), readLayout.String())
+	for id := range assigned {
+		assert.Equal(t, len(assigned[id]["client"]), len(readAssigned[id]["client"]))
+	}
+}
+
+func TestTheRowsOfAJourneyGoToTheSaverOfTheirKey(t *testing.T) {
+	journeys := make(journeysByClient)
+	journeys["client"] = make(map[string][]*dataStructures.DynamicMap)
+	destinations := []string{"JFK", "MIA", "LAX", "ORD"}
+	for i := 0; i < 20; i++ {
+		destination := destinations[i%len(destinations)]
+		journey := "EZE-" + destination
+		journeys["client"][journey] = append(journeys["client"][journey], journeyRow("EZE", destination))
+	}
+	partitioning, err := dispatcher.NewPartitioningConfig(dispatcher.RingPartitioner, 0, utils.StartingAirport)
+	assert.Nil(t, err)
+
+	assigned := assignJourneys([]journeysByClient{journeys}, saversLayout{savers: 3, partitioning: partitioning})
+	ring := partitioning.NewPartitioner()
+	rows := 0
+	for id, clients := range assigned {
+		for _, journeyRows := range clients["client"] {
+			for _, row := range journeyRows {
+				key, err := dispatcher.KeyOf(row, partitioning.KeyColumns)
+				assert.Nil(t, err)
+				assert.Equal(t, id, ring.Partition(key, 3), "Each row should be in the saver of its key")
+				rows++
+			}
+		}
+	}
+	assert.Equal(t, 20, rows)
+}
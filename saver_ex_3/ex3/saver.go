@@ -1,24 +1,33 @@
 package ex3
 
 import (
+	"errors"
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
 	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"strings"
 )
 
+// finishSignal Notifies the handler that a saver finished with the client, because it persisted its results or the client aborted
+type finishSignal struct {
+	clientId string
+	aborted  bool
+}
+
 // SaverForEx3 Structure that handles the final results
 type SaverForEx3 struct {
 	c                     *SaverConfig
 	consumer              queueProtocol.ConsumerProtocolInterface
-	finishSig             chan string
-	regsToPersistByClient map[string]map[string][2]*dataStructures.DynamicMap
+	finishSig             chan finishSignal
+	regsToPersistByClient map[string]map[string][]*dataStructures.DynamicMap
 	id                    int
+	params                *queryparams.Registry
 	checkpointer          *checkpointer.CheckpointerHandler
 }
 
@@ -26,7 +35,7 @@ type SaverForEx3 struct {
 func NewSaverForEx3(
 	consumer queueProtocol.ConsumerProtocolInterface,
 	c *SaverConfig,
-	finishSig chan string,
+	finishSig chan finishSignal,
 	id int,
 	chkHandler *checkpointer.CheckpointerHandler,
 ) *SaverForEx3 {
@@ -36,7 +45,8 @@ func NewSaverForEx3(
 		consumer:              consumer,
 		finishSig:             finishSig,
 		id:                    id,
-		regsToPersistByClient: make(map[string]map[string][2]*dataStructures.DynamicMap),
+		params:                queryparams.NewRegistry(),
+		regsToPersistByClient: make(map[string]map[string][]*dataStructures.DynamicMap),
 		checkpointer:          chkHandler,
 	}
 	chkHandler.AddCheckpointable(saver, id)
@@ -47,64 +57,84 @@ func NewSaverForEx3(
 func (s *SaverForEx3) SaveData() {
 	log.Infof("Saver %v | Started goroutine", s.id)
 	for {
-		msg, ok := s.consumer.Pop()
-		if !ok {
+		msg, err := s.consumer.Pop()
+		if errors.Is(err, queueProtocol.ErrConsumerClosed) {
 			log.Infof("Saver %v | Exiting saver", s.id)
 			return
 		}
+		if err != nil {
+			log.Errorf("Saver %v | Skipping message | %v", s.id, err)
+			continue
+		}
 
 		log.Debugf("Saver %v | Received message: %v", s.id, msg)
 		if msg.TypeMessage == dataStructures.EOFFlightRows {
 			s.handleEOF(msg.ClientId)
+		} else if msg.TypeMessage == dataStructures.Abort {
+			s.handleAbort(msg.ClientId)
 		} else if msg.TypeMessage == dataStructures.FlightRows {
-			s.handleFlightRow(msg.DynMaps[0], msg.ClientId)
+			s.handleFlightRow(msg.DynMaps[0], msg.ClientId, s.params.Of(msg).FastestFlights)
 		}
-		err := s.checkpointer.DoCheckpoint(s.id)
+		err = s.checkpointer.DoCheckpoint(s.id)
 		if err != nil {
 			log.Errorf("Saver %v | Error on checkpointing | %v", s.id, err)
 		}
 	}
 }
 
-func (s *SaverForEx3) handleFlightRow(flightRow *dataStructures.DynamicMap, clientId string) {
+// handleFlightRow Keeps the row if it is one of the k fastest of its journey. The rows without the columns needed go to the dead-letter queue
+func (s *SaverForEx3) handleFlightRow(flightRow *dataStructures.DynamicMap, clientId string, k int) {
 	travelDurationStr, err := flightRow.GetAsString(utils.TravelDuration)
 	if err != nil {
 		log.Errorf("Saver %v | Error trying to get travel duration string | %v", s.id, err)
+		s.consumer.DeadLetter([]*dataStructures.DynamicMap{flightRow}, err)
+		return
 	}
 	stAirport, err := flightRow.GetAsString(utils.StartingAirport)
 	if err != nil {
 		log.Errorf("Saver %v | Error trying to get starting airport string | %v", s.id, err)
+		s.consumer.DeadLetter([]*dataStructures.DynamicMap{flightRow}, err)
+		return
 	}
 	destAirport, err := flightRow.GetAsString(utils.DestinationAirport)
 	if err != nil {
 		log.Errorf("Saver %v | Error trying to get destination airport string | %v", s.id, err)
+		s.consumer.DeadLetter([]*dataStructures.DynamicMap{flightRow}, err)
+		return
 	}
 	convertedTravelDuration, err := utils.ConvertTravelDurationToMinutesAsInt(travelDurationStr)
 	if err != nil {
 		log.Errorf("Saver %v | Error trying to convert travel duration to minutes | %v", s.id, err)
+		s.consumer.DeadLetter([]*dataStructures.DynamicMap{flightRow}, err)
+		return
 	}
 	journeyStr := fmt.Sprintf("%v-%v", stAirport, destAirport)
 	_, existsClient := s.regsToPersistByClient[clientId]
 	if !existsClient {
-		s.regsToPersistByClient[clientId] = make(map[string][2]*dataStructures.DynamicMap)
-	}
-	journeyMap, existsJM := s.regsToPersistByClient[clientId][journeyStr]
-	flightRow.AddColumn(utils.ConvertedTravelDuration, serializer.SerializeUint(uint32(convertedTravelDuration)))
-	if existsJM {
-		newRows := DecideWhichRowsToKeep(journeyMap, flightRow, s.id)
-		s.regsToPersistByClient[clientId][journeyStr] = newRows
-	} else {
-		s.regsToPersistByClient[clientId][journeyStr] = [2]*dataStructures.DynamicMap{flightRow, nil}
+		s.regsToPersistByClient[clientId] = make(map[string][]*dataStructures.DynamicMap)
 	}
+	journeyRows := s.regsToPersistByClient[clientId][journeyStr]
+	flightRow.AddColumn(utils.ConvertedTravelDuration, dataStructures.NewInt32Column(int32(convertedTravelDuration)))
+	s.regsToPersistByClient[clientId][journeyStr] = DecideWhichRowsToKeep(journeyRows, flightRow, k, s.id)
 
 }
 
 func (s *SaverForEx3) handleEOF(clientId string) {
 	log.Infof("Saver %v | Received all results | Persisting to file...", s.id)
 	s.persistToFile(clientId)
-	s.regsToPersistByClient[clientId] = make(map[string][2]*dataStructures.DynamicMap)
+	s.regsToPersistByClient[clientId] = make(map[string][]*dataStructures.DynamicMap)
+	s.params.Remove(clientId)
 	log.Infof("Saver %v | Sending finish signal...", s.id)
-	s.finishSig <- clientId
+	s.finishSig <- finishSignal{clientId: clientId}
+}
+
+// handleAbort Forgets the rows kept of the client and notifies the handler, that deletes the results persisted
+func (s *SaverForEx3) handleAbort(clientId string) {
+	log.Infof("Saver %v | Received Abort of client %v | Clearing its data...", s.id, clientId)
+	s.consumer.ClearData(clientId)
+	delete(s.regsToPersistByClient, clientId)
+	s.params.Remove(clientId)
+	s.finishSig <- finishSignal{clientId: clientId, aborted: true}
 }
 
 func (s *SaverForEx3) persistToFile(clientId string) {
@@ -118,9 +148,7 @@ func (s *SaverForEx3) persistToFile(clientId string) {
 	for journey, rows := range s.regsToPersistByClient[clientId] {
 		line.WriteString(fmt.Sprintf("journey=%v\n", journey))
 		for _, row := range rows {
-			if row != nil {
-				line.WriteString(serializer.SerializeToString(row))
-			}
+			line.WriteString(serializer.SerializeToString(row))
 		}
 	}
 	err = writer.WriteLine(line.String())
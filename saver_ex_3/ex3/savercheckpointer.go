@@ -8,7 +8,6 @@ import (
 	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
-	"strconv"
 	"strings"
 )
 
@@ -57,11 +56,9 @@ func (s *SaverForEx3) GetCheckpointString() string {
 	for clientId, dynMaps := range s.regsToPersistByClient {
 		for journey, dynMapArray := range dynMaps {
 			for _, dynamicMap := range dynMapArray {
-				if dynamicMap != nil {
-					dynMapString := serializer.SerializeToString(dynamicMap)
-					// The dynmap serializer adds \n
-					strBuilder.WriteString(fmt.Sprintf("%v;%v@%v", clientId, journey, dynMapString))
-				}
+				dynMapString := serializer.SerializeToString(dynamicMap)
+				// The dynmap serializer adds \n
+				strBuilder.WriteString(fmt.Sprintf("%v;%v@%v", clientId, journey, dynMapString))
 			}
 		}
 	}
@@ -83,32 +80,11 @@ func (s *SaverForEx3) readCheckpointAsState(fileToRestore string) {
 	filemanager.SkipHeader(fileReader)
 	for fileReader.CanRead() {
 		line := fileReader.ReadLine()
-		dynMap := dataStructures.NewDynamicMap(make(map[string][]byte))
 		keysAndDynMap := strings.Split(line, utils.AtSeparator)
-		columns := strings.Split(keysAndDynMap[1], utils.CommaSeparator)
-		const columnName = 0
-		const columnData = 1
-		for _, keyVal := range columns {
-			data := strings.Split(keyVal, utils.EqualsSeparator)
-			if data[columnName] == utils.TotalStopovers || data[columnName] == utils.ConvertedTravelDuration {
-				intVal, err := strconv.Atoi(data[columnData])
-				if err != nil {
-					log.Errorf("Saver %v | Error converting dynmap column into int | %v", s.id, err)
-					continue
-				}
-				dynMap.AddColumn(data[0], serializer.SerializeUint(uint32(intVal)))
-				continue
-			}
-			if data[columnName] == utils.TotalFare || data[columnName] == utils.TotalTravelDistance {
-				floatVal, err := strconv.ParseFloat(data[columnData], 32)
-				if err != nil {
-					log.Errorf("Saver %v | Error converting dynmap column into float32 | %v", s.id, err)
-					continue
-				}
-				dynMap.AddColumn(data[columnName], serializer.SerializeFloat(float32(floatVal)))
-				continue
-			}
-			dynMap.AddColumn(data[columnName], serializer.SerializeString(data[columnData]))
+		dynMap, err := serializer.DeserializeFromString(keysAndDynMap[1])
+		if err != nil {
+			log.Errorf("Saver %v | Error parsing dynmap of checkpoint line | %v", s.id, err)
+			continue
 		}
 
 		clientIdAndJourney := strings.Split(keysAndDynMap[0], utils.DotCommaSeparator)
@@ -120,15 +96,9 @@ func (s *SaverForEx3) readCheckpointAsState(fileToRestore string) {
 		journey := clientIdAndJourney[1]
 		_, exists := s.regsToPersistByClient[clientId]
 		if !exists {
-			s.regsToPersistByClient[clientId] = make(map[string][2]*dataStructures.DynamicMap)
-			s.regsToPersistByClient[clientId][journey] = [2]*dataStructures.DynamicMap{}
-		}
-		firstDM := s.regsToPersistByClient[clientId][journey][0]
-		if firstDM == nil {
-			s.regsToPersistByClient[clientId][journey] = [2]*dataStructures.DynamicMap{dynMap}
-			continue
+			s.regsToPersistByClient[clientId] = make(map[string][]*dataStructures.DynamicMap)
 		}
-		s.regsToPersistByClient[clientId][journey] = [2]*dataStructures.DynamicMap{s.regsToPersistByClient[clientId][journey][0], dynMap}
+		s.regsToPersistByClient[clientId][journey] = append(s.regsToPersistByClient[clientId][journey], dynMap)
 	}
 
 	err = fileReader.Err()
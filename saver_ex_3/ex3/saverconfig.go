@@ -5,6 +5,9 @@ import (
 	"strings"
 
 	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	"github.com/brunograssano/Distribuidos-TP1/common/dispatcher"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/retention"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -25,6 +28,11 @@ type SaverConfig struct {
 	ServiceName             string
 	AddressesHealthCheckers []string
 	TotalEofNodes           uint
+	Retention               *retention.Config
+	// Partitioning How the dispatchers split the journeys between the savers
+	Partitioning *dispatcher.PartitioningConfig
+	// Compressions Compression of the messages sent to each queue or exchange
+	Compressions queues.Compressions
 }
 
 // InitEnv Initializes the configuration properties from a config file and environment
@@ -42,6 +50,7 @@ func InitEnv() (*viper.Viper, error) {
 	// Add env variables supported
 	_ = v.BindEnv("id")
 	_ = v.BindEnv("log", "level")
+	_ = v.BindEnv("compression")
 	_ = v.BindEnv("rabbitmq", "address")
 	_ = v.BindEnv("rabbitmq", "queue", "input")
 	_ = v.BindEnv("saver", "output")
@@ -49,9 +58,15 @@ func InitEnv() (*viper.Viper, error) {
 	_ = v.BindEnv("dispatchers", "count")
 	_ = v.BindEnv("getter", "address")
 	_ = v.BindEnv("getter", "batch", "lines")
+	_ = v.BindEnv("retention", "ttl")
+	_ = v.BindEnv("retention", "fetched")
+	_ = v.BindEnv("retention", "interval")
 	_ = v.BindEnv("name")
 	_ = v.BindEnv("healthchecker", "addresses")
 	_ = v.BindEnv("total", "nodes", "for", "eof")
+	_ = v.BindEnv("dispatcher", "partitioner")
+	_ = v.BindEnv("dispatcher", "virtualnodes")
+	_ = v.BindEnv("dispatcher", "key", "columns")
 
 	// Try to read configuration from config file. If config file
 	// does not exist then ReadInConfig will fail but configuration
@@ -71,6 +86,11 @@ func GetConfig(env *viper.Viper) (*SaverConfig, error) {
 		return nil, err
 	}
 
+	compressions, err := config.GetCompressions(env.GetStringMapString("compression"))
+	if err != nil {
+		return nil, err
+	}
+
 	id := env.GetString("id")
 	if id == "" {
 		return nil, errors.New("missing id")
@@ -130,7 +150,17 @@ func GetConfig(env *viper.Viper) (*SaverConfig, error) {
 		dispatchersCount = utils.DefaultGoroutines
 	}
 
-	log.Infof("Saver3Config | action: config | result: success | id: %s | log_level: %s | rabbitAddress: %v | inputQueueName: %v | outputFilename: %v | getterAddress: %v | getterBatchLines: %v | dispatchers: %v",
+	retentionConfig, err := retention.NewConfig(id, env.GetString("retention.ttl"), env.GetString("retention.fetched"), env.GetString("retention.interval"))
+	if err != nil {
+		return nil, err
+	}
+
+	partitioning, err := dispatcher.NewPartitioningConfig(env.GetString("dispatcher.partitioner"), env.GetUint("dispatcher.virtualnodes"), env.GetString("dispatcher.key.columns"))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Saver3Config | action: config | result: success | id: %s | log_level: %s | rabbitAddress: %v | inputQueueName: %v | outputFilename: %v | getterAddress: %v | getterBatchLines: %v | dispatchers: %v | partitioning: %v",
 		id,
 		env.GetString("log.level"),
 		rabbitAddress,
@@ -138,7 +168,8 @@ func GetConfig(env *viper.Viper) (*SaverConfig, error) {
 		outputFilenamesStr,
 		getterAddress,
 		getterBatchLines,
-		dispatchersCount)
+		dispatchersCount,
+		partitioning)
 
 	return &SaverConfig{
 		ID:                      id,
@@ -152,5 +183,8 @@ func GetConfig(env *viper.Viper) (*SaverConfig, error) {
 		AddressesHealthCheckers: healthCheckerAddresses,
 		ServiceName:             serviceName,
 		TotalEofNodes:           TotalEofNodes,
+		Retention:               retentionConfig,
+		Partitioning:            partitioning,
+		Compressions:            compressions,
 	}, nil
 }
@@ -4,8 +4,10 @@ import (
 	"github.com/brunograssano/Distribuidos-TP1/common/heartbeat"
 	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
+	"github.com/brunograssano/Distribuidos-TP1/common/retention"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	"log"
+	"os"
 	"saver_ex_3/ex3"
 )
 
@@ -20,9 +22,13 @@ func main() {
 	if err != nil {
 		log.Fatalf("Main - Saver Ex3 | Error initializing config | %s", err)
 	}
+	collector := ex3.NewCollector(config)
+	if retention.HandleCommand(os.Args[1:], collector) {
+		return
+	}
 	qMiddleware := middleware.NewQueueMiddleware(config.RabbitAddress)
-	qFactory := queuefactory.NewTopicFactory(qMiddleware, []string{"", config.ID}, config.InputQueueName)
-	saverEx3 := ex3.NewEx3Handler(config, qFactory, queuefactory.NewSimpleQueueFactory(qMiddleware))
+	qFactory := queuefactory.NewTopicFactory(qMiddleware, []string{"", config.ID}, config.InputQueueName, config.Compressions)
+	saverEx3 := ex3.NewEx3Handler(config, qFactory, queuefactory.NewSimpleQueueFactory(qMiddleware, config.Compressions), collector)
 	go saverEx3.StartHandler()
 	endSigHB := heartbeat.StartHeartbeat(config.AddressesHealthCheckers, config.ServiceName)
 	<-sigs
@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/brunograssano/Distribuidos-TP1/common/heartbeat"
+	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"server/server"
@@ -17,7 +18,8 @@ func main() {
 	if err != nil {
 		log.Fatalf("Main - Server | Error initializing config | %s", err)
 	}
-	s := server.NewServer(config)
+	qMiddleware := middleware.NewQueueMiddleware(config.RabbitAddress)
+	s := server.NewServer(config, qMiddleware)
 	go s.StartServerLoop()
 	log.Infof("Main - Server | Spawned Server...")
 	endSigHB := heartbeat.StartHeartbeat(config.AddressesHealthCheckers, config.ServiceName)
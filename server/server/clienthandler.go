@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/communication"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
@@ -8,15 +9,23 @@ import (
 	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	socketsProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/sockets"
-	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
+	"math"
 	"time"
 )
 
 const maxSleep = 32
 const initialExpBackoffSleep = 2
 
+// abortMessageId Id of the abort published for a client. It is above the ids of the batches of the uploads,
+// so the stages do not discard it as a duplicate or an old message
+const abortMessageId = math.MaxInt32
+
+// errBusy The client was not admitted. The connection is closed, so the batches sent after the rejected one are not published
+var errBusy = errors.New("too many clients uploading")
+
 type ClientHandler struct {
 	rowsSent           uint
 	conn               *socketsProtocol.SocketProtocolHandler
@@ -24,17 +33,23 @@ type ClientHandler struct {
 	outQueueFlightRows queues.ProducerProtocolInterface
 	GetterAddresses    map[uint8][]string
 	clientId           string
+	sessions           *Sessions
+	scheduler          *FairScheduler
+	retryAfter         time.Duration
 }
 
-func NewClientHandler(conn *communication.TCPSocket, outQueueAirports middleware.ProducerInterface, outQueueFlightRows middleware.ProducerInterface, GetterAddresses map[uint8][]string) *ClientHandler {
+func NewClientHandler(conn *communication.TCPSocket, outQueueAirports middleware.ProducerInterface, outQueueFlightRows middleware.ProducerInterface, GetterAddresses map[uint8][]string, sessions *Sessions, scheduler *FairScheduler, retryAfter time.Duration, compressions queues.Compressions) *ClientHandler {
 	sph := socketsProtocol.NewSocketProtocolHandler(conn)
 	return &ClientHandler{
 		conn:               sph,
 		rowsSent:           0,
-		outQueueAirports:   queues.NewProducerQueueProtocolHandler(outQueueAirports),
-		outQueueFlightRows: queues.NewProducerQueueProtocolHandler(outQueueFlightRows),
+		outQueueAirports:   queues.NewProducerQueueProtocolHandler(outQueueAirports, compressions.Of(outQueueAirports.GetName())),
+		outQueueFlightRows: queues.NewProducerQueueProtocolHandler(outQueueFlightRows, compressions.Of(outQueueFlightRows.GetName())),
 		GetterAddresses:    GetterAddresses,
 		clientId:           "",
+		sessions:           sessions,
+		scheduler:          scheduler,
+		retryAfter:         retryAfter,
 	}
 }
 
@@ -65,6 +80,7 @@ func (ch *ClientHandler) handleGetResults(cliSPH *socketsProtocol.SocketProtocol
 		}
 		currRow += len(msg.DynMaps)
 		if msg.TypeMessage == dataStructures.EOFGetter {
+			ch.sessions.FinishResults(ch.clientId, exercise)
 			break
 		}
 	}
@@ -131,8 +147,10 @@ func (ch *ClientHandler) handleAirportMessage(message *dataStructures.Message) e
 	return nil
 }
 
+// handleFlightRowMessage Publishes the flights through the scheduler, once the rate of the client allows them
 func (ch *ClientHandler) handleFlightRowMessage(message *dataStructures.Message) error {
-	err := ch.outQueueFlightRows.Send(message)
+	time.Sleep(ch.sessions.Throttle(message.ClientId, len(message.DynMaps)))
+	err := ch.scheduler.Publish(message)
 	ch.rowsSent += uint(len(message.DynMaps))
 	if err != nil {
 		return err
@@ -141,31 +159,184 @@ func (ch *ClientHandler) handleFlightRowMessage(message *dataStructures.Message)
 	return nil
 }
 
+// uploadStreamOf Returns the connection that sent the EOF and the amount of connections that upload the data of the client.
+// Without them the client uploads through a single connection
+func uploadStreamOf(message *dataStructures.Message) (int, int) {
+	if len(message.DynMaps) == 0 {
+		return 0, 1
+	}
+	stream, errStream := message.DynMaps[0].GetAsInt(utils.UploadStream)
+	streams, errStreams := message.DynMaps[0].GetAsInt(utils.UploadStreams)
+	if errStream != nil || errStreams != nil || streams <= 0 {
+		return 0, 1
+	}
+	return stream, streams
+}
+
+// skippedLinesSummary Takes from the EOF the summary of the lines that the client could not parse, so it is not sent to the next stages
+func skippedLinesSummary(message *dataStructures.Message) (int64, []string) {
+	if len(message.DynMaps) == 0 {
+		return 0, nil
+	}
+	summary := message.DynMaps[0]
+	message.DynMaps = []*dataStructures.DynamicMap{}
+	skipped, err := summary.GetAsInt64(utils.SkippedLines)
+	if err != nil {
+		log.Warnf("ClientHandler | EOF without the skipped lines | %v", err)
+		return 0, nil
+	}
+	lineErrors, err := summary.GetAsList(utils.SkippedLinesErrors)
+	if err != nil {
+		log.Warnf("ClientHandler | EOF without the errors of the skipped lines | %v", err)
+	}
+	if skipped > 0 {
+		log.Warnf("ClientHandler | Client %v skipped %v lines | %v", message.ClientId, skipped, lineErrors)
+	}
+	return skipped, lineErrors
+}
+
+// handleEOFFlightRows Publishes the EOF of the flights with the rows published of the client, so the coordinator
+// of the first stage waits for them before sending it to the next stage
 func (ch *ClientHandler) handleEOFFlightRows(message *dataStructures.Message) error {
-	dynMap := dataStructures.NewDynamicMap(make(map[string][]byte))
-	dynMap.AddColumn(utils.NodesVisited, serializer.SerializeString(""))
+	expectedRows := ch.sessions.FlightRowsOf(message.ClientId)
+	dynMap := dataStructures.NewDynamicMap(make(map[string]dataStructures.Column))
+	dynMap.AddColumn(utils.ExpectedRows, dataStructures.NewInt64Column(int64(expectedRows)))
 	message.DynMaps = append(message.DynMaps, dynMap)
-	log.Infof("ClientHandler | Sending EOF | Batches sent: %v", ch.rowsSent)
+	log.Infof("ClientHandler | Sending EOF | Rows sent by this connection: %v | Rows of the client: %v", ch.rowsSent, expectedRows)
 	return ch.outQueueFlightRows.Send(message)
 }
 
+// ackBatch Tells the client that the batch was published, so it does not have to send it again
+func (ch *ClientHandler) ackBatch(message *dataStructures.Message, cliSPH *socketsProtocol.SocketProtocolHandler) error {
+	log.Debugf("ClientHandler | Sending ACK for batch | ClientId: %v | MessageId: %v", message.ClientId, message.MessageId)
+	return cliSPH.Write(dataStructures.NewCompleteMessage(dataStructures.BatchAck, []*dataStructures.DynamicMap{}, message.ClientId, message.MessageId))
+}
+
+// handleQueryParams Registers the parameters of the queries of the client. Invalid parameters are ignored,
+// so the client keeps the previous ones or the default ones
+func (ch *ClientHandler) handleQueryParams(message *dataStructures.Message) {
+	if len(message.DynMaps) == 0 {
+		log.Warnf("ClientHandler | Query parameters without data | ClientId: %v | Ignoring them...", message.ClientId)
+		return
+	}
+	params, err := queryparams.FromDynMap(message.DynMaps[0])
+	if err != nil {
+		log.Errorf("ClientHandler | Invalid query parameters | ClientId: %v | %v | Ignoring them...", message.ClientId, err)
+		return
+	}
+	log.Infof("ClientHandler | Got query parameters | ClientId: %v | %+v", message.ClientId, params)
+	ch.sessions.SetParams(message.ClientId, params.ToDynMap())
+}
+
+// handleAbort Publishes the abort of the session of the client through the airports and the flights, so every stage
+// clears what it has of the client. Then it acks the client. If the session was already aborted it is only acked
+func (ch *ClientHandler) handleAbort(message *dataStructures.Message, cliSPH *socketsProtocol.SocketProtocolHandler) error {
+	if !ch.sessions.IsAborted(message.ClientId) {
+		log.Infof("ClientHandler | Aborting session | ClientId: %v", message.ClientId)
+		ch.scheduler.Drop(message.ClientId)
+		abort := dataStructures.NewCompleteMessage(dataStructures.Abort, []*dataStructures.DynamicMap{}, message.ClientId, abortMessageId)
+		// The stages forward the abort through the nodes of the view of the client
+		abort.Params = ch.sessions.ParamsOf(message.ClientId)
+		err := ch.outQueueAirports.Send(abort)
+		if err != nil {
+			log.Errorf("ClientHandler | Error sending abort to the airports exchange | %v", err)
+			return err
+		}
+		dynMap := dataStructures.NewDynamicMap(make(map[string]dataStructures.Column))
+		dynMap.AddColumn(utils.NodesVisited, dataStructures.NewStringColumn(""))
+		abort.DynMaps = []*dataStructures.DynamicMap{dynMap}
+		err = ch.outQueueFlightRows.Send(abort)
+		if err != nil {
+			log.Errorf("ClientHandler | Error sending abort to the flights queue | %v", err)
+			return err
+		}
+		ch.sessions.AbortSession(message.ClientId)
+	}
+	log.Infof("ClientHandler | Sending ACK for Abort | ClientId: %v", message.ClientId)
+	return cliSPH.Write(dataStructures.NewCompleteMessage(dataStructures.Abort, []*dataStructures.DynamicMap{}, message.ClientId, message.MessageId))
+}
+
+// rejectBusy Tells the client that it was not admitted and the milliseconds to wait before retrying
+func (ch *ClientHandler) rejectBusy(message *dataStructures.Message, cliSPH *socketsProtocol.SocketProtocolHandler) error {
+	log.Infof("ClientHandler | Too many clients uploading, rejecting client %v | Retry after %v", message.ClientId, ch.retryAfter)
+	dynMap := dataStructures.NewDynamicMap(make(map[string]dataStructures.Column))
+	dynMap.AddColumn(utils.RetryAfter, dataStructures.NewInt64Column(ch.retryAfter.Milliseconds()))
+	err := cliSPH.Write(dataStructures.NewCompleteMessage(dataStructures.Busy, []*dataStructures.DynamicMap{dynMap}, message.ClientId, message.MessageId))
+	if err != nil {
+		return err
+	}
+	return errBusy
+}
+
+// isDataMessage Returns true if the message has data of the upload of a client
+func isDataMessage(message *dataStructures.Message) bool {
+	return message.TypeMessage == dataStructures.Airports || message.TypeMessage == dataStructures.EOFAirports ||
+		message.TypeMessage == dataStructures.FlightRows || message.TypeMessage == dataStructures.EOFFlightRows
+}
+
+// requestedQuery Returns true if the client requested the query. If the parameters of the client are unknown,
+// the results are asked to the getters
+func (ch *ClientHandler) requestedQuery(message *dataStructures.Message, query int) bool {
+	if message.Params == nil {
+		return true
+	}
+	params, err := queryparams.FromDynMap(message.Params)
+	if err != nil {
+		return true
+	}
+	return params.Requests(query)
+}
+
 func (ch *ClientHandler) handleMessage(message *dataStructures.Message, cliSPH *socketsProtocol.SocketProtocolHandler) error {
 	log.Debugf("ClientHandler | Received Message | {type: %v, rowCount:%v}", message.TypeMessage, len(message.DynMaps))
 	ch.clientId = message.ClientId
+	if message.TypeMessage == dataStructures.Abort {
+		return ch.handleAbort(message, cliSPH)
+	}
+	if ch.sessions.IsAborted(message.ClientId) && (isDataMessage(message) || message.TypeMessage == dataStructures.QueryParams) {
+		return fmt.Errorf("session %v was aborted, rejecting message of type %v", message.ClientId, message.TypeMessage)
+	}
+	if message.TypeMessage == dataStructures.QueryParams {
+		ch.handleQueryParams(message)
+		return nil
+	}
+	if isDataMessage(message) && !ch.sessions.Admit(message.ClientId) {
+		return ch.rejectBusy(message, cliSPH)
+	}
+	// The data carries the parameters of the client, so every stage knows them
+	message.Params = ch.sessions.ParamsOf(message.ClientId)
 	if message.TypeMessage == dataStructures.Airports || message.TypeMessage == dataStructures.EOFAirports {
+		var skipped int64
+		var skippedErrors []string
 		if message.TypeMessage == dataStructures.EOFAirports {
 			log.Infof("ClientHandler | Got EOF Airports | ClientId: %v", message.ClientId)
+			skipped, skippedErrors = skippedLinesSummary(message)
 		}
 		err := ch.handleAirportMessage(message)
-		if message.TypeMessage == dataStructures.EOFAirports {
+		if err == nil && message.TypeMessage == dataStructures.Airports {
+			ch.sessions.AddAirportBatch(message.ClientId)
+			err = ch.ackBatch(message, cliSPH)
+		}
+		if err == nil && message.TypeMessage == dataStructures.EOFAirports {
+			ch.sessions.FinishAirports(message.ClientId, skipped, skippedErrors)
 			log.Infof("ClientHandler | Sending ACK for EOF Airports | ClientId: %v", message.ClientId)
 			err = cliSPH.Write(dataStructures.NewCompleteMessage(dataStructures.EofAck, []*dataStructures.DynamicMap{}, message.ClientId, message.MessageId))
 		}
 		return err
 	}
 	if message.TypeMessage == dataStructures.EOFFlightRows {
-		log.Infof("ClientHandler | Got EOF FlightRows | ClientId: %v", message.ClientId)
-		err := ch.handleEOFFlightRows(message)
+		stream, streams := uploadStreamOf(message)
+		log.Infof("ClientHandler | Got EOF FlightRows | ClientId: %v | Stream: %v of %v", message.ClientId, stream+1, streams)
+		skipped, skippedErrors := skippedLinesSummary(message)
+		var err error
+		if ch.sessions.FinishFlightStream(message.ClientId, stream, streams) {
+			err = ch.handleEOFFlightRows(message)
+			if err != nil {
+				ch.sessions.FailFlightsEOF(message.ClientId)
+				return err
+			}
+			ch.sessions.FinishFlights(message.ClientId, skipped, skippedErrors)
+		}
 		if err == nil {
 			log.Infof("ClientHandler | Sending ACK for EOF FlightRows | ClientId: %v", message.ClientId)
 			err = cliSPH.Write(dataStructures.NewCompleteMessage(dataStructures.EofAck, []*dataStructures.DynamicMap{}, message.ClientId, message.MessageId))
@@ -173,13 +344,31 @@ func (ch *ClientHandler) handleMessage(message *dataStructures.Message, cliSPH *
 		return err
 	}
 	if message.TypeMessage == dataStructures.FlightRows {
-		return ch.handleFlightRowMessage(message)
+		err := ch.handleFlightRowMessage(message)
+		if err != nil {
+			return err
+		}
+		ch.sessions.AddFlightBatch(message.ClientId, message.MessageId, len(message.DynMaps))
+		return ch.ackBatch(message, cliSPH)
+	}
+	if message.TypeMessage == dataStructures.GetStatus {
+		log.Infof("ClientHandler | Sending status of session | ClientId: %v", message.ClientId)
+		status := ch.sessions.Status(message.ClientId)
+		return cliSPH.Write(dataStructures.NewCompleteMessage(dataStructures.Status, []*dataStructures.DynamicMap{status}, message.ClientId, 0))
 	}
 	if message.TypeMessage == dataStructures.GetResults {
 		ex, err := message.DynMaps[0].GetAsInt(utils.Exercise)
 		if err != nil {
 			log.Errorf("ClientHandler | Error getting exercise as int | %v", err)
 		}
+		if ch.sessions.IsAborted(message.ClientId) {
+			log.Infof("ClientHandler | Session was aborted, there are no results | ClientId: %v", message.ClientId)
+			return cliSPH.Write(dataStructures.NewCompleteMessage(dataStructures.Abort, []*dataStructures.DynamicMap{}, message.ClientId, 0))
+		}
+		if !ch.requestedQuery(message, ex) {
+			log.Infof("ClientHandler | Query %v was not requested | ClientId: %v", ex, message.ClientId)
+			return cliSPH.Write(dataStructures.NewCompleteMessage(dataStructures.NotRequested, []*dataStructures.DynamicMap{}, message.ClientId, 0))
+		}
 		row, err := message.DynMaps[0].GetAsInt(utils.NumberOfRow)
 		if err != nil {
 			log.Errorf("ClientHandler | Error getting row as int | %v", err)
@@ -199,6 +388,10 @@ func (ch *ClientHandler) StartClientLoop() {
 		}
 		log.Debugf("ClientHandler | Handling message from client | %v", message)
 		err = ch.handleMessage(message, ch.conn)
+		if errors.Is(err, errBusy) {
+			log.Infof("ClientHandler | Closing connection of client not admitted | ClientId: %v", message.ClientId)
+			return
+		}
 		if err != nil {
 			log.Errorf("ClientHandler | Error handling message | %v", err)
 			return
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter Token bucket of rows per second. It starts full and holds up to a second of rows, so short bursts are allowed
+type rateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// newRateLimiter Creates a limiter of rows per second. Without a rate it returns nil, that does not limit
+func newRateLimiter(rowsPerSecond uint) *rateLimiter {
+	if rowsPerSecond == 0 {
+		return nil
+	}
+	return &rateLimiter{rate: float64(rowsPerSecond), tokens: float64(rowsPerSecond), last: time.Now(), now: time.Now}
+}
+
+// reserve Takes the rows from the bucket and returns how long the caller has to wait before sending them.
+// The bucket can go below zero, so the next callers wait for the rows taken before them
+func (rl *rateLimiter) reserve(rows int) time.Duration {
+	if rl == nil {
+		return 0
+	}
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	now := rl.now()
+	rl.tokens = min(rl.rate, rl.tokens+now.Sub(rl.last).Seconds()*rl.rate)
+	rl.last = now
+	rl.tokens -= float64(rows)
+	if rl.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-rl.tokens / rl.rate * float64(time.Second))
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"errors"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// schedulerQuantum Rows that each client can publish per round
+const schedulerQuantum = utils.DefaultBatchLines
+
+// queuedPollPeriod Time between the checks of the batches waiting in the shared queue while it is full
+const queuedPollPeriod = 50 * time.Millisecond
+
+var errBatchDropped = errors.New("the batch was dropped before being published")
+
+// scheduledBatch Batch of flights of a client waiting to be published
+type scheduledBatch struct {
+	message *dataStructures.Message
+	done    chan error
+}
+
+// FairScheduler Publishes the flights of every client into the queue shared by all of them. Each client has its own
+// queue of batches, taken by deficit round robin, so in each round every client publishes the same amount of rows no
+// matter how many batches it has waiting. The batches waiting in the shared queue are bounded, so the ones of a big
+// client wait in its own queue instead of ahead of the ones of the rest. With a total rate of rows, the clients share it in equal parts
+type FairScheduler struct {
+	mutex    sync.Mutex
+	waiting  *sync.Cond
+	batches  map[string][]*scheduledBatch
+	deficits map[string]int
+	// round Clients with batches waiting, in the order of their turns
+	round    []string
+	turn     int
+	producer queues.ProducerProtocolInterface
+	limiter  *rateLimiter
+	closed   bool
+	// queued Returns the batches waiting in the shared queue. Nil if they are not bounded
+	queued    func() (int, error)
+	maxQueued int
+	// estimatedQueued Batches in the shared queue at the last check plus the ones published since then
+	estimatedQueued int
+}
+
+// NewFairScheduler Creates a scheduler that publishes up to the total rows per second, while the shared queue has less
+// than the max batches queued. A zero rate or max does not limit them
+func NewFairScheduler(producer queues.ProducerProtocolInterface, totalRowsPerSecond uint, maxQueued uint, queued func() (int, error)) *FairScheduler {
+	fs := &FairScheduler{
+		batches:   make(map[string][]*scheduledBatch),
+		deficits:  make(map[string]int),
+		producer:  producer,
+		limiter:   newRateLimiter(totalRowsPerSecond),
+		maxQueued: int(maxQueued),
+	}
+	if maxQueued > 0 {
+		fs.queued = queued
+	}
+	fs.waiting = sync.NewCond(&fs.mutex)
+	return fs
+}
+
+// Publish Queues the batch of the client and waits until it is published
+func (fs *FairScheduler) Publish(message *dataStructures.Message) error {
+	batch := &scheduledBatch{message: message, done: make(chan error, 1)}
+	fs.mutex.Lock()
+	if fs.closed {
+		fs.mutex.Unlock()
+		return errBatchDropped
+	}
+	if _, exists := fs.batches[message.ClientId]; !exists {
+		fs.round = append(fs.round, message.ClientId)
+	}
+	fs.batches[message.ClientId] = append(fs.batches[message.ClientId], batch)
+	fs.waiting.Signal()
+	fs.mutex.Unlock()
+	return <-batch.done
+}
+
+// Drop Discards the batches of the client that were not published
+func (fs *FairScheduler) Drop(clientId string) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	pending, exists := fs.batches[clientId]
+	if !exists {
+		return
+	}
+	log.Infof("FairScheduler | Dropping %v batches of client %v", len(pending), clientId)
+	for _, batch := range pending {
+		batch.done <- errBatchDropped
+	}
+	for i, client := range fs.round {
+		if client == clientId {
+			fs.leaveRound(i)
+			break
+		}
+	}
+}
+
+// PublishLoop Publishes the batches of the clients until the scheduler is closed
+func (fs *FairScheduler) PublishLoop() {
+	for {
+		fs.waitForRoomInQueue()
+		batch, ok := fs.nextBatch()
+		if !ok {
+			log.Infof("FairScheduler | Closing goroutine...")
+			return
+		}
+		time.Sleep(fs.limiter.reserve(len(batch.message.DynMaps)))
+		err := fs.producer.Send(batch.message)
+		if err == nil {
+			fs.estimatedQueued++
+		}
+		batch.done <- err
+	}
+}
+
+// waitForRoomInQueue Waits until the shared queue has less batches than the max. The queue is only checked once the
+// batches published since the last check could fill it
+func (fs *FairScheduler) waitForRoomInQueue() {
+	if fs.queued == nil {
+		return
+	}
+	for fs.estimatedQueued >= fs.maxQueued && !fs.isClosed() {
+		queued, err := fs.queued()
+		if err != nil {
+			log.Errorf("FairScheduler | Error getting the batches waiting in the queue | %v | Publishing without waiting...", err)
+			fs.estimatedQueued = 0
+			return
+		}
+		fs.estimatedQueued = queued
+		if queued >= fs.maxQueued {
+			time.Sleep(queuedPollPeriod)
+		}
+	}
+}
+
+func (fs *FairScheduler) isClosed() bool {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	return fs.closed
+}
+
+// Close Stops the loop and discards the batches that were not published
+func (fs *FairScheduler) Close() {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	fs.closed = true
+	for _, pending := range fs.batches {
+		for _, batch := range pending {
+			batch.done <- errBatchDropped
+		}
+	}
+	fs.batches = make(map[string][]*scheduledBatch)
+	fs.round = nil
+	fs.waiting.Broadcast()
+}
+
+// nextBatch Waits for a batch and returns the next one to publish. The client of the turn publishes while its deficit
+// covers the rows of its next batch, otherwise it gets the quantum for its next turn and the turn passes to the next client
+func (fs *FairScheduler) nextBatch() (*scheduledBatch, bool) {
+	fs.mutex.Lock()
+	defer fs.mutex.Unlock()
+	for len(fs.round) == 0 && !fs.closed {
+		fs.waiting.Wait()
+	}
+	if fs.closed {
+		return nil, false
+	}
+	for {
+		if fs.turn >= len(fs.round) {
+			fs.turn = 0
+		}
+		clientId := fs.round[fs.turn]
+		pending := fs.batches[clientId]
+		rows := len(pending[0].message.DynMaps)
+		if fs.deficits[clientId] < rows {
+			fs.deficits[clientId] += schedulerQuantum
+			fs.turn++
+			continue
+		}
+		fs.deficits[clientId] -= rows
+		fs.batches[clientId] = pending[1:]
+		if len(pending) == 1 {
+			fs.leaveRound(fs.turn)
+		}
+		return pending[0], true
+	}
+}
+
+// leaveRound Removes the client from the round. It loses its deficit, so a client can not save turns while it has nothing to publish
+func (fs *FairScheduler) leaveRound(index int) {
+	clientId := fs.round[index]
+	delete(fs.batches, clientId)
+	delete(fs.deficits, clientId)
+	fs.round = append(fs.round[:index], fs.round[index+1:]...)
+	if index < fs.turn {
+		fs.turn--
+	}
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestBatch(clientId string, messageId uint, rows int) *dataStructures.Message {
+	dynMaps := make([]*dataStructures.DynamicMap, rows)
+	return dataStructures.NewCompleteMessage(dataStructures.FlightRows, dynMaps, clientId, messageId)
+}
+
+func waitForQueuedBatches(t *testing.T, fs *FairScheduler, clientId string, expected int) {
+	for i := 0; i < 100; i++ {
+		fs.mutex.Lock()
+		queued := len(fs.batches[clientId])
+		fs.mutex.Unlock()
+		if queued == expected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Timeout! The batches of client %v were not queued", clientId)
+}
+
+// testSharedQueue Queue of flights shared by the clients, that the first stage consumes at its own pace
+type testSharedQueue struct {
+	mutex    sync.Mutex
+	waiting  []*dataStructures.Message
+	consumed []string
+}
+
+func (q *testSharedQueue) Send(msg *dataStructures.Message) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.waiting = append(q.waiting, msg)
+	return nil
+}
+
+func (q *testSharedQueue) length() (int, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.waiting), nil
+}
+
+// consumeUntil Consumes a batch each period until the client has the batches consumed, and returns the clients of the batches in order
+func (q *testSharedQueue) consumeUntil(t *testing.T, period time.Duration, clientId string, batches int) []string {
+	for i := 0; i < 10000; i++ {
+		time.Sleep(period)
+		q.mutex.Lock()
+		if len(q.waiting) > 0 {
+			q.consumed = append(q.consumed, q.waiting[0].ClientId)
+			q.waiting = q.waiting[1:]
+		}
+		consumed := append([]string{}, q.consumed...)
+		q.mutex.Unlock()
+		count := 0
+		for _, consumedClient := range consumed {
+			if consumedClient == clientId {
+				count++
+			}
+		}
+		if count == batches {
+			return consumed
+		}
+	}
+	t.Fatalf("Timeout! The batches of client %v were not consumed", clientId)
+	return nil
+}
+
+func TestTheBatchOfASmallClientIsNotPublishedAfterTheOnesOfABigClient(t *testing.T) {
+	published := make(chan *dataStructures.Message, 10)
+	fs := NewFairScheduler(queueProtocol.NewProducerChannel(published), 0, 0, nil)
+	for i := uint(0); i < 6; i++ {
+		go func(messageId uint) { _ = fs.Publish(newTestBatch("big", messageId, schedulerQuantum)) }(i)
+	}
+	waitForQueuedBatches(t, fs, "big", 6)
+	go func() { _ = fs.Publish(newTestBatch("small", 0, schedulerQuantum)) }()
+	waitForQueuedBatches(t, fs, "small", 1)
+
+	go fs.PublishLoop()
+	defer fs.Close()
+	var clients []string
+	for i := 0; i < 7; i++ {
+		select {
+		case msg := <-published:
+			clients = append(clients, msg.ClientId)
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout! Should have published the batches by now...")
+		}
+	}
+	assert.Equal(t, []string{"big", "small", "big", "big", "big", "big", "big"}, clients)
+}
+
+func TestDroppedBatchesAreNotPublished(t *testing.T) {
+	published := make(chan *dataStructures.Message, 10)
+	fs := NewFairScheduler(queueProtocol.NewProducerChannel(published), 0, 0, nil)
+	result := make(chan error, 1)
+	go func() { result <- fs.Publish(newTestBatch("aborted", 0, 1)) }()
+	waitForQueuedBatches(t, fs, "aborted", 1)
+
+	fs.Drop("aborted")
+	assert.ErrorIs(t, <-result, errBatchDropped)
+	assert.Empty(t, fs.round, "The client should leave the round")
+}
+
+func TestOnlyTheMaxClientsAreAdmittedToUpload(t *testing.T) {
+	sessions := newTestSessions(t, 1, time.Minute, nil)
+	now := time.Unix(1000, 0)
+	sessions.now = func() time.Time { return now }
+
+	assert.True(t, sessions.Admit("first"))
+	assert.False(t, sessions.Admit("second"), "There is already a client uploading")
+	assert.True(t, sessions.Admit("first"), "The client admitted keeps uploading")
+
+	sessions.FinishFlights("first", 0, nil)
+	assert.True(t, sessions.Admit("second"), "The first client finished its upload")
+	assert.True(t, sessions.Admit("first"), "A client that finished is always admitted")
+
+	now = now.Add(time.Minute)
+	assert.True(t, sessions.Admit("third"), "The idle clients do not count")
+}
+
+func TestTheRateLimiterAllowsABurstAndThenWaitsForTheRows(t *testing.T) {
+	limiter := newRateLimiter(100)
+	now := time.Unix(1000, 0)
+	limiter.last = now
+	limiter.now = func() time.Time { return now }
+
+	assert.Equal(t, time.Duration(0), limiter.reserve(100))
+	assert.Equal(t, 500*time.Millisecond, limiter.reserve(50))
+	now = now.Add(time.Second)
+	assert.Equal(t, time.Duration(0), limiter.reserve(50))
+}
+
+func TestTheBatchesOfALightClientDoNotWaitForTheUploadOfAHeavyClientWithoutLimitsConfigured(t *testing.T) {
+	queue := &testSharedQueue{}
+	fs := NewFairScheduler(queue, 0, defaultMaxQueuedBatches, queue.length)
+	go fs.PublishLoop()
+	defer fs.Close()
+	// The heavy client uploads through several connections at once
+	heavyBatches := 400
+	for stream := 0; stream < 4; stream++ {
+		go func(stream int) {
+			for i := stream; i < heavyBatches; i += 4 {
+				_ = fs.Publish(newTestBatch("heavy", uint(i), schedulerQuantum))
+			}
+		}(stream)
+	}
+	queue.consumeUntil(t, time.Millisecond, "heavy", 10)
+
+	lightBatches := 3
+	go func() {
+		for i := 0; i < lightBatches; i++ {
+			_ = fs.Publish(newTestBatch("light", uint(i), schedulerQuantum))
+		}
+	}()
+	consumed := queue.consumeUntil(t, time.Millisecond, "light", lightBatches)
+	heavyAhead := len(consumed) - lightBatches
+	assert.Less(t, heavyAhead, 10+lightBatches*(defaultMaxQueuedBatches+2), "The light client should only wait for the batches in the shared queue")
+	assert.Less(t, heavyAhead, heavyBatches/2)
+}
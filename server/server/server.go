@@ -1,37 +1,57 @@
 package server
 
 import (
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	"github.com/brunograssano/Distribuidos-TP1/common/communication"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
 	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	log "github.com/sirupsen/logrus"
+	"time"
 )
 
+// membershipPeriod Time between the queries of the view of the stages to the health checkers
+const membershipPeriod = 5 * time.Second
+
 type Server struct {
 	pSocket            *communication.PassiveTCPSocket
 	c                  *ServerConfig
-	qMiddleware        *middleware.QueueMiddleware
+	qMiddleware        middleware.QueueMiddlewareI
 	outQueueAirports   middleware.ProducerInterface
 	outQueueFlightRows middleware.ProducerInterface
+	sessions           *Sessions
+	scheduler          *FairScheduler
+	membership         *membership.Watcher
 }
 
-func NewServer(c *ServerConfig) *Server {
+func NewServer(c *ServerConfig, qMiddleware middleware.QueueMiddlewareI) *Server {
 	socket, err := communication.NewPassiveTCPSocket(c.ServerAddress)
 	if err != nil {
 		log.Fatalf("Server | action: create_server | result: fail | server_id: %v | error: %v", c.ID, err)
 	}
-	qMiddleware := middleware.NewQueueMiddleware(c.RabbitAddress)
 	qA := qMiddleware.CreateExchangeProducer(c.ExchangeNameAirports, c.ExchangeRKAirports, c.ExchangeTypeAirports, true)
 	qFR := qMiddleware.CreateProducer(c.QueueNameFlightRows, true)
+	watcher := membership.NewWatcher(c.AddressesHealthCheckers, membershipPeriod)
+	chkHandler := checkpointer.NewCheckpointerHandler()
+	sessions := NewSessions(c.MaxUploadingSessions, c.SessionIdleTimeout, c.SessionForget, c.ClientRowsPerSecond, watcher.Current, chkHandler)
+	chkHandler.RestoreCheckpoint()
 	return &Server{
 		pSocket:            socket,
 		c:                  c,
 		qMiddleware:        qMiddleware,
 		outQueueAirports:   qA,
 		outQueueFlightRows: qFR,
+		sessions:           sessions,
+		scheduler: NewFairScheduler(queues.NewProducerQueueProtocolHandler(qFR, c.Compressions.Of(c.QueueNameFlightRows)), c.TotalRowsPerSecond, c.MaxQueuedBatches, func() (int, error) {
+			return qMiddleware.QueueLength(c.QueueNameFlightRows)
+		}),
+		membership: watcher,
 	}
 }
 
 func (svr *Server) StartServerLoop() {
+	go svr.scheduler.PublishLoop()
+	go svr.membership.WatchLoop()
 	for {
 		accepted, err := svr.pSocket.Accept()
 		if err != nil {
@@ -43,12 +63,18 @@ func (svr *Server) StartServerLoop() {
 			svr.outQueueAirports,
 			svr.outQueueFlightRows,
 			svr.c.GetterAddresses,
+			svr.sessions,
+			svr.scheduler,
+			svr.c.RetryAfter,
+			svr.c.Compressions,
 		)
 		go ch.StartClientLoop()
 	}
 }
 
 func (svr *Server) End() error {
+	svr.scheduler.Close()
+	svr.membership.Close()
 	svr.qMiddleware.Close()
 	return svr.pSocket.Close()
 }
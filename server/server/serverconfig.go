@@ -4,12 +4,19 @@ import (
 	"errors"
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"strings"
+	"time"
 )
 
+const defaultIdleTimeout = 5 * time.Minute
+const defaultRetryAfter = 5 * time.Second
+const defaultSessionForget = time.Hour
+const defaultMaxQueuedBatches = 20
+
 type ServerConfig struct {
 	ID                      string
 	ServerAddress           string
@@ -21,6 +28,22 @@ type ServerConfig struct {
 	RabbitAddress           string
 	ServiceName             string
 	AddressesHealthCheckers []string
+	// MaxUploadingSessions Clients that can upload at the same time, the rest are told to retry later. Zero does not limit them
+	MaxUploadingSessions uint
+	// SessionIdleTimeout Time without data after which an upload stops counting for the max
+	SessionIdleTimeout time.Duration
+	// SessionForget Time that the sessions are kept once the client fetched its results or aborted them
+	SessionForget time.Duration
+	// RetryAfter Time that the clients not admitted wait before retrying
+	RetryAfter time.Duration
+	// ClientRowsPerSecond Rows of flights that each client can publish per second. Zero does not limit them
+	ClientRowsPerSecond uint
+	// TotalRowsPerSecond Rows of flights that all the clients can publish per second, shared in equal parts. Zero does not limit them
+	TotalRowsPerSecond uint
+	// MaxQueuedBatches Batches of flights that can wait in the shared queue, the rest wait in the queue of their client. Zero does not limit them
+	MaxQueuedBatches uint
+	// Compressions Compression of the messages sent to each queue or exchange
+	Compressions queues.Compressions
 }
 
 func InitEnv() (*viper.Viper, error) {
@@ -33,6 +56,7 @@ func InitEnv() (*viper.Viper, error) {
 
 	_ = v.BindEnv("id")
 	_ = v.BindEnv("log", "level")
+	_ = v.BindEnv("compression")
 	_ = v.BindEnv("server", "address")
 	_ = v.BindEnv("getter", "addresses", "1")
 	_ = v.BindEnv("getter", "addresses", "2")
@@ -45,6 +69,13 @@ func InitEnv() (*viper.Viper, error) {
 	_ = v.BindEnv("queues", "flightrows")
 	_ = v.BindEnv("name")
 	_ = v.BindEnv("healthchecker", "addresses")
+	_ = v.BindEnv("server", "sessions", "max")
+	_ = v.BindEnv("server", "sessions", "idle")
+	_ = v.BindEnv("server", "sessions", "retryafter")
+	_ = v.BindEnv("server", "sessions", "forget")
+	_ = v.BindEnv("server", "ratelimit", "client")
+	_ = v.BindEnv("server", "ratelimit", "total")
+	_ = v.BindEnv("server", "ratelimit", "queued")
 
 	v.SetConfigFile("./config.yaml")
 	if err := v.ReadInConfig(); err != nil {
@@ -60,6 +91,11 @@ func GetConfig(env *viper.Viper) (*ServerConfig, error) {
 		return nil, err
 	}
 
+	compressions, err := config.GetCompressions(env.GetStringMapString("compression"))
+	if err != nil {
+		return nil, err
+	}
+
 	id := env.GetString("id")
 	if id == "" {
 		return nil, errors.New("missing id")
@@ -116,11 +152,38 @@ func GetConfig(env *viper.Viper) (*ServerConfig, error) {
 	}
 	healthCheckerAddresses := strings.Split(healthCheckerAddressesString, utils.CommaSeparator)
 
-	log.Infof("ServerConfig | action: config | result: success | id: %s | log_level: %s | getterAddresses: %v | serverAddress: %v ",
+	idleTimeout, err := getDuration(env, "server.sessions.idle", defaultIdleTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	retryAfter, err := getDuration(env, "server.sessions.retryafter", defaultRetryAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionForget, err := getDuration(env, "server.sessions.forget", defaultSessionForget)
+	if err != nil {
+		return nil, err
+	}
+
+	maxUploadingSessions := env.GetUint("server.sessions.max")
+	clientRowsPerSecond := env.GetUint("server.ratelimit.client")
+	totalRowsPerSecond := env.GetUint("server.ratelimit.total")
+	maxQueuedBatches := uint(defaultMaxQueuedBatches)
+	if env.IsSet("server.ratelimit.queued") {
+		maxQueuedBatches = env.GetUint("server.ratelimit.queued")
+	}
+
+	log.Infof("ServerConfig | action: config | result: success | id: %s | log_level: %s | getterAddresses: %v | serverAddress: %v | maxUploadingSessions: %v | clientRowsPerSecond: %v | totalRowsPerSecond: %v | maxQueuedBatches: %v",
 		id,
 		env.GetString("log.level"),
 		getterAddressesMap,
 		serverAddress,
+		maxUploadingSessions,
+		clientRowsPerSecond,
+		totalRowsPerSecond,
+		maxQueuedBatches,
 	)
 
 	return &ServerConfig{
@@ -134,5 +197,26 @@ func GetConfig(env *viper.Viper) (*ServerConfig, error) {
 		RabbitAddress:           rabbitAddress,
 		AddressesHealthCheckers: healthCheckerAddresses,
 		ServiceName:             serviceName,
+		MaxUploadingSessions:    maxUploadingSessions,
+		SessionIdleTimeout:      idleTimeout,
+		SessionForget:           sessionForget,
+		RetryAfter:              retryAfter,
+		ClientRowsPerSecond:     clientRowsPerSecond,
+		TotalRowsPerSecond:      totalRowsPerSecond,
+		MaxQueuedBatches:        maxQueuedBatches,
+		Compressions:            compressions,
 	}, nil
 }
+
+// getDuration Returns the duration of the key, or the default one if it is not set
+func getDuration(env *viper.Viper, key string, defaultValue time.Duration) (time.Duration, error) {
+	value := env.GetString(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid %v: %v", key, value)
+	}
+	return duration, nil
+}
@@ -0,0 +1,413 @@
+package server
+
+import (
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	log "github.com/sirupsen/logrus"
+	"sync"
+	"time"
+)
+
+// Stages of the session of a client returned by the status
+const (
+	UnknownStage           = "unknown"
+	WaitingStage           = "waiting"
+	UploadingAirportsStage = "uploading_airports"
+	UploadingFlightsStage  = "uploading_flights"
+	ProcessingStage        = "processing"
+	FetchingResultsStage   = "fetching_results"
+	FinishedStage          = "finished"
+	AbortedStage           = "aborted"
+)
+
+type session struct {
+	airportBatches uint
+	flightBatches  uint
+	flightRows     uint
+	// flightBatchIds Batches of flights published. A batch that the client sends again is not counted twice
+	flightBatchIds      map[uint]bool
+	airportsDone        bool
+	flightsDone         bool
+	skippedAirportLines int64
+	skippedFlightLines  int64
+	airportLinesErrors  []string
+	flightLinesErrors   []string
+	// flightStreams Connections that upload the flights and the ones that sent their EOF
+	flightStreams         int
+	finishedFlightStreams map[int]bool
+	publishingFlightsEOF  bool
+	// params Parameters of the queries of the client, with the view of the stages pinned to it.
+	// Without them the stages use the default ones
+	params *dataStructures.DynamicMap
+	// view Nodes of each stage when the client was admitted. The stages use them for the whole session
+	view    *membership.View
+	aborted bool
+	// admitted The client can upload its data. It holds one of the sessions allowed while it uploads
+	admitted bool
+	// fetchedQueries Queries whose results the client fetched until their end
+	fetchedQueries map[int]bool
+	// finishedAt When the client fetched the results of every query it requested or aborted the session, zero before
+	finishedAt   time.Time
+	lastActivity time.Time
+	limiter      *rateLimiter
+}
+
+// pinView Keeps the view for the rest of the session and adds it to the parameters, so every message of the client carries it
+func (s *session) pinView(view *membership.View) {
+	if view == nil || s.view != nil {
+		return
+	}
+	s.view = view
+	// The messages already sent keep the previous parameters, so they are copied instead of changed
+	params := dataStructures.NewDynamicMap(make(map[string]dataStructures.Column))
+	if s.params != nil {
+		for name, column := range s.params.GetCurrentMap() {
+			params.AddColumn(name, column)
+		}
+	}
+	view.AddToDynMap(params)
+	s.params = params
+}
+
+func (s *session) stage() string {
+	if s.aborted {
+		return AbortedStage
+	}
+	if !s.finishedAt.IsZero() {
+		return FinishedStage
+	}
+	if len(s.fetchedQueries) > 0 {
+		return FetchingResultsStage
+	}
+	if s.flightsDone {
+		return ProcessingStage
+	}
+	if s.airportsDone {
+		return UploadingFlightsStage
+	}
+	if !s.admitted {
+		return WaitingStage
+	}
+	return UploadingAirportsStage
+}
+
+// fetchedAll Returns true if the client fetched the results of every query it requested
+func (s *session) fetchedAll() bool {
+	queries := queryparams.AllQueries()
+	if s.params != nil {
+		if params, err := queryparams.FromDynMap(s.params); err == nil {
+			queries = params.Queries
+		}
+	}
+	for _, query := range queries {
+		if !s.fetchedQueries[query] {
+			return false
+		}
+	}
+	return true
+}
+
+// finish Marks the session as finished and drops what is only needed while the client uploads
+func (s *session) finish(now time.Time) {
+	s.finishedAt = now
+	s.flightBatchIds = nil
+	s.finishedFlightStreams = nil
+	s.limiter = nil
+}
+
+// uploading Returns true if the client holds one of the sessions allowed, because it is uploading and was not idle
+func (s *session) uploading(now time.Time, idleTimeout time.Duration) bool {
+	if !s.admitted || s.flightsDone || s.aborted {
+		return false
+	}
+	return idleTimeout == 0 || now.Sub(s.lastActivity) < idleTimeout
+}
+
+// Sessions Progress of the uploads of the clients, shared by the connections of the server.
+// Each change is checkpointed, so the sessions survive a restart of the server
+type Sessions struct {
+	mutex    sync.Mutex
+	sessions map[string]*session
+	// maxUploading Clients that can upload at the same time. Zero does not limit them
+	maxUploading uint
+	idleTimeout  time.Duration
+	// forgetAfter Time that the finished and aborted sessions are kept, so their status can still be asked
+	forgetAfter         time.Duration
+	clientRowsPerSecond uint
+	now                 func() time.Time
+	// currentView Returns the current view of the stages, or nil if it is unknown
+	currentView  func() *membership.View
+	checkpointer *checkpointer.CheckpointerHandler
+}
+
+// NewSessions Creates the sessions of the server. Up to maxUploading clients upload at the same time, not counting
+// the ones without data for longer than the idle timeout, and each one up to the rows per second. Zero values do not limit them.
+// The sessions are forgotten once they finished or were aborted for longer than forgetAfter.
+// Each client is pinned to the view of currentView when it is admitted. If it is nil, the stages use their configured nodes
+func NewSessions(
+	maxUploading uint,
+	idleTimeout time.Duration,
+	forgetAfter time.Duration,
+	clientRowsPerSecond uint,
+	currentView func() *membership.View,
+	chkHandler *checkpointer.CheckpointerHandler,
+) *Sessions {
+	if currentView == nil {
+		currentView = func() *membership.View { return nil }
+	}
+	s := &Sessions{
+		sessions:            make(map[string]*session),
+		maxUploading:        maxUploading,
+		idleTimeout:         idleTimeout,
+		forgetAfter:         forgetAfter,
+		clientRowsPerSecond: clientRowsPerSecond,
+		now:                 time.Now,
+		currentView:         currentView,
+		checkpointer:        chkHandler,
+	}
+	chkHandler.AddCheckpointable(s, sessionsCheckpointId)
+	return s
+}
+
+// update Applies a change to the session of the client, creating it if it does not exist
+func (s *Sessions) update(clientId string, change func(*session)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	clientSession, exists := s.sessions[clientId]
+	if !exists {
+		clientSession = &session{}
+		s.sessions[clientId] = clientSession
+	}
+	change(clientSession)
+}
+
+// persist Applies a change to the session of the client that has to survive a restart, and checkpoints the sessions
+func (s *Sessions) persist(clientId string, change func(*session)) {
+	s.update(clientId, func(clientSession *session) {
+		change(clientSession)
+		s.checkpoint()
+	})
+}
+
+// checkpoint Saves the sessions. It is called with the mutex held, so the checkpoint reads them without taking it
+func (s *Sessions) checkpoint() {
+	err := s.checkpointer.DoCheckpoint(sessionsCheckpointId)
+	if err != nil {
+		log.Errorf("Sessions | Error on checkpointing | %v", err)
+	}
+}
+
+// forgetFinished Removes the sessions that finished or were aborted longer than forgetAfter ago
+func (s *Sessions) forgetFinished(now time.Time) {
+	for clientId, clientSession := range s.sessions {
+		if !clientSession.finishedAt.IsZero() && now.Sub(clientSession.finishedAt) >= s.forgetAfter {
+			log.Infof("Sessions | Forgetting session of client %v, finished at %v", clientId, clientSession.finishedAt)
+			delete(s.sessions, clientId)
+		}
+	}
+}
+
+// Admit Returns true if the client can upload its data. A client is admitted while less than the max clients are
+// uploading, and then it can upload until it finishes. Clients that finished their upload are always admitted
+func (s *Sessions) Admit(clientId string) bool {
+	admitted := false
+	s.update(clientId, func(clientSession *session) {
+		now := s.now()
+		if !clientSession.admitted && !clientSession.flightsDone && s.maxUploading > 0 && s.uploadingCount(now) >= s.maxUploading {
+			return
+		}
+		clientSession.lastActivity = now
+		admitted = true
+		if !clientSession.admitted {
+			clientSession.pinView(s.currentView())
+			clientSession.admitted = true
+			s.checkpoint()
+		}
+	})
+	return admitted
+}
+
+// uploadingCount Returns the amount of clients that hold one of the sessions allowed
+func (s *Sessions) uploadingCount(now time.Time) uint {
+	count := uint(0)
+	for _, clientSession := range s.sessions {
+		if clientSession.uploading(now, s.idleTimeout) {
+			count++
+		}
+	}
+	return count
+}
+
+// Throttle Takes the rows from the rate of the client and returns how long it has to wait before publishing them
+func (s *Sessions) Throttle(clientId string, rows int) time.Duration {
+	var limiter *rateLimiter
+	s.update(clientId, func(clientSession *session) {
+		if clientSession.limiter == nil {
+			clientSession.limiter = newRateLimiter(s.clientRowsPerSecond)
+		}
+		limiter = clientSession.limiter
+	})
+	return limiter.reserve(rows)
+}
+
+// AddAirportBatch Registers a batch of airports published
+func (s *Sessions) AddAirportBatch(clientId string) {
+	s.persist(clientId, func(clientSession *session) { clientSession.airportBatches++ })
+}
+
+// AddFlightBatch Registers a batch of flights published. The batches that were already published are ignored
+func (s *Sessions) AddFlightBatch(clientId string, messageId uint, rows int) {
+	s.persist(clientId, func(clientSession *session) {
+		if clientSession.flightBatchIds == nil {
+			clientSession.flightBatchIds = make(map[uint]bool)
+		}
+		if clientSession.flightBatchIds[messageId] {
+			return
+		}
+		clientSession.flightBatchIds[messageId] = true
+		clientSession.flightBatches++
+		clientSession.flightRows += uint(rows)
+	})
+}
+
+// FlightRowsOf Returns the rows of the flights of the client published, the ones that the first stage has to receive
+func (s *Sessions) FlightRowsOf(clientId string) uint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	clientSession, exists := s.sessions[clientId]
+	if !exists {
+		return 0
+	}
+	return clientSession.flightRows
+}
+
+// FinishAirports Registers that the EOF of the airports was published, with the lines that the client skipped
+func (s *Sessions) FinishAirports(clientId string, skipped int64, lineErrors []string) {
+	s.persist(clientId, func(clientSession *session) {
+		clientSession.airportsDone = true
+		clientSession.skippedAirportLines = skipped
+		clientSession.airportLinesErrors = lineErrors
+	})
+}
+
+// FinishFlightStream Registers the EOF of one of the connections that upload the flights of the client.
+// Returns true when every connection finished and the EOF of the flights was not published yet. Only one of the
+// connections gets true, and it has to call FinishFlights or FailFlightsEOF after publishing it
+func (s *Sessions) FinishFlightStream(clientId string, stream int, streams int) bool {
+	allFinished := false
+	s.persist(clientId, func(clientSession *session) {
+		if clientSession.flightStreams != streams || clientSession.finishedFlightStreams == nil {
+			clientSession.flightStreams = streams
+			clientSession.finishedFlightStreams = make(map[int]bool)
+		}
+		clientSession.finishedFlightStreams[stream] = true
+		allFinished = len(clientSession.finishedFlightStreams) >= streams && !clientSession.flightsDone && !clientSession.publishingFlightsEOF
+		clientSession.publishingFlightsEOF = clientSession.publishingFlightsEOF || allFinished
+	})
+	return allFinished
+}
+
+// FailFlightsEOF Registers that the EOF of the flights could not be published, so it is published when the client sends it again
+func (s *Sessions) FailFlightsEOF(clientId string) {
+	s.update(clientId, func(clientSession *session) { clientSession.publishingFlightsEOF = false })
+}
+
+// FinishFlights Registers that the EOF of the flights was published, with the lines that the client skipped
+func (s *Sessions) FinishFlights(clientId string, skipped int64, lineErrors []string) {
+	s.persist(clientId, func(clientSession *session) {
+		clientSession.flightsDone = true
+		clientSession.publishingFlightsEOF = false
+		clientSession.skippedFlightLines = skipped
+		clientSession.flightLinesErrors = lineErrors
+	})
+}
+
+// SetParams Registers the parameters of the queries of the client
+func (s *Sessions) SetParams(clientId string, params *dataStructures.DynamicMap) {
+	s.persist(clientId, func(clientSession *session) {
+		clientSession.params = params
+		if clientSession.view != nil {
+			clientSession.view.AddToDynMap(params)
+		}
+	})
+}
+
+// ParamsOf Returns the parameters of the queries of the client, or nil if it did not send them
+func (s *Sessions) ParamsOf(clientId string) *dataStructures.DynamicMap {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	clientSession, exists := s.sessions[clientId]
+	if !exists {
+		return nil
+	}
+	return clientSession.params
+}
+
+// AbortSession Registers that the abort of the session of the client was published. What it uploaded is forgotten,
+// and it stops holding one of the sessions allowed. The session is forgotten after a while
+func (s *Sessions) AbortSession(clientId string) {
+	s.persist(clientId, func(clientSession *session) {
+		now := s.now()
+		*clientSession = session{aborted: true, finishedAt: now}
+		s.forgetFinished(now)
+	})
+}
+
+// FinishResults Registers that the client fetched the results of the query until their end. Once it fetched the ones
+// of every query it requested the session is finished, and it is forgotten after a while
+func (s *Sessions) FinishResults(clientId string, query int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	clientSession, exists := s.sessions[clientId]
+	if !exists || !clientSession.finishedAt.IsZero() {
+		return
+	}
+	if clientSession.fetchedQueries == nil {
+		clientSession.fetchedQueries = make(map[int]bool)
+	}
+	clientSession.fetchedQueries[query] = true
+	if clientSession.fetchedAll() {
+		log.Infof("Sessions | Client %v fetched the results of every query", clientId)
+		now := s.now()
+		clientSession.finish(now)
+		s.forgetFinished(now)
+	}
+	s.checkpoint()
+}
+
+// IsAborted Returns true if the client cancelled the session
+func (s *Sessions) IsAborted(clientId string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	clientSession, exists := s.sessions[clientId]
+	return exists && clientSession.aborted
+}
+
+// Status Returns the stage of the session and the amount of data received
+func (s *Sessions) Status(clientId string) *dataStructures.DynamicMap {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	status := make(map[string]dataStructures.Column)
+	clientSession, exists := s.sessions[clientId]
+	if !exists {
+		status[utils.SessionStage] = dataStructures.NewStringColumn(UnknownStage)
+		return dataStructures.NewDynamicMap(status)
+	}
+	status[utils.SessionStage] = dataStructures.NewStringColumn(clientSession.stage())
+	status[utils.AirportBatches] = dataStructures.NewInt64Column(int64(clientSession.airportBatches))
+	status[utils.FlightBatches] = dataStructures.NewInt64Column(int64(clientSession.flightBatches))
+	status[utils.FlightRowsReceived] = dataStructures.NewInt64Column(int64(clientSession.flightRows))
+	status[utils.SkippedAirportLines] = dataStructures.NewInt64Column(clientSession.skippedAirportLines)
+	status[utils.SkippedFlightLines] = dataStructures.NewInt64Column(clientSession.skippedFlightLines)
+	lineErrors := append(append([]string{}, clientSession.airportLinesErrors...), clientSession.flightLinesErrors...)
+	status[utils.SkippedLinesErrors] = dataStructures.NewListColumn(lineErrors)
+	if clientSession.params != nil {
+		for key, value := range clientSession.params.GetCurrentMap() {
+			status[key] = value
+		}
+	}
+	return dataStructures.NewDynamicMap(status)
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
+	"github.com/brunograssano/Distribuidos-TP1/common/queryparams"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+const testForgetAfter = time.Hour
+
+// newTestSessions Creates the sessions in a temporary directory, where they keep their checkpoints
+func newTestSessions(t *testing.T, maxUploading uint, idleTimeout time.Duration, currentView func() *membership.View) *Sessions {
+	wd, err := os.Getwd()
+	assert.Nil(t, err)
+	assert.Nil(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	return NewSessions(maxUploading, idleTimeout, testForgetAfter, 0, currentView, checkpointer.NewCheckpointerHandler())
+}
+
+// restartSessions Creates the sessions again from the checkpoint of the previous ones, as the server does when it restarts
+func restartSessions(previous *Sessions) *Sessions {
+	chkHandler := checkpointer.NewCheckpointerHandler()
+	sessions := NewSessions(previous.maxUploading, previous.idleTimeout, previous.forgetAfter, 0, previous.currentView, chkHandler)
+	sessions.now = previous.now
+	chkHandler.RestoreCheckpoint()
+	return sessions
+}
+
+func stageOf(t *testing.T, sessions *Sessions, clientId string) string {
+	stage, err := sessions.Status(clientId).GetAsString(utils.SessionStage)
+	assert.Nil(t, err)
+	return stage
+}
+
+func TestTheRowsOfABatchSentAgainAreCountedOnce(t *testing.T) {
+	sessions := newTestSessions(t, 0, 0, nil)
+	sessions.AddFlightBatch("cliente", 1, 10)
+	sessions.AddFlightBatch("cliente", 2, 5)
+	sessions.AddFlightBatch("cliente", 1, 10)
+
+	assert.Equal(t, uint(15), sessions.FlightRowsOf("cliente"), "The rows of the batch sent again should not be expected twice")
+	assert.Equal(t, uint(0), sessions.FlightRowsOf("otro"))
+}
+
+func TestTheRowsOfAnAbortedSessionAreForgotten(t *testing.T) {
+	sessions := newTestSessions(t, 0, 0, nil)
+	sessions.AddFlightBatch("cliente", 1, 10)
+	sessions.AbortSession("cliente")
+
+	assert.Equal(t, uint(0), sessions.FlightRowsOf("cliente"))
+}
+
+func TestTheClientKeepsTheViewOfWhenItWasAdmitted(t *testing.T) {
+	view := membership.NewView()
+	view.Epoch = 1
+	view.Sizes["filters"] = 4
+	sessions := newTestSessions(t, 0, 0, func() *membership.View { return view })
+	sessions.SetParams("cliente", queryparams.Default().ToDynMap())
+	sessions.Admit("cliente")
+
+	view = membership.NewView()
+	view.Epoch = 2
+	view.Sizes["filters"] = 6
+	sessions.Admit("cliente")
+	sessions.SetParams("cliente", queryparams.Default().ToDynMap())
+
+	msg := &dataStructures.Message{ClientId: "cliente", Params: sessions.ParamsOf("cliente")}
+	assert.Equal(t, uint(4), membership.SizeOf(msg, "filters", 1), "The client should keep the nodes of the epoch it started in")
+	_, err := queryparams.FromDynMap(msg.Params)
+	assert.Nil(t, err, "The parameters of the queries should be kept")
+}
+
+func TestTheSessionsAreRestoredAfterARestart(t *testing.T) {
+	sessions := newTestSessions(t, 0, 0, nil)
+	params := queryparams.Default()
+	params.Queries = []int{2}
+	sessions.SetParams("cliente", params.ToDynMap())
+	sessions.Admit("cliente")
+	sessions.AddAirportBatch("cliente")
+	sessions.FinishAirports("cliente", 2, []string{"line 3: missing column, \"Latitude\""})
+	sessions.AddFlightBatch("cliente", 1, 10)
+	sessions.AddFlightBatch("otro", 1, 5)
+	sessions.AbortSession("otro")
+
+	restarted := restartSessions(sessions)
+	assert.Equal(t, UploadingFlightsStage, stageOf(t, restarted, "cliente"))
+	assert.Equal(t, uint(10), restarted.FlightRowsOf("cliente"))
+	assert.True(t, restarted.IsAborted("otro"))
+	restoredParams, err := queryparams.FromDynMap(restarted.ParamsOf("cliente"))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2}, restoredParams.Queries)
+	lineErrors, err := restarted.Status("cliente").GetAsList(utils.SkippedLinesErrors)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"line 3: missing column, \"Latitude\""}, lineErrors)
+}
+
+func TestTheSessionFinishesOnceTheResultsOfEveryRequestedQueryAreFetched(t *testing.T) {
+	sessions := newTestSessions(t, 0, 0, nil)
+	params := queryparams.Default()
+	params.Queries = []int{1, 3}
+	sessions.SetParams("cliente", params.ToDynMap())
+	sessions.Admit("cliente")
+	sessions.FinishFlights("cliente", 0, nil)
+	assert.Equal(t, ProcessingStage, stageOf(t, sessions, "cliente"))
+
+	sessions.FinishResults("cliente", 1)
+	assert.Equal(t, FetchingResultsStage, stageOf(t, sessions, "cliente"))
+	assert.Equal(t, FetchingResultsStage, stageOf(t, restartSessions(sessions), "cliente"))
+
+	sessions.FinishResults("cliente", 3)
+	assert.Equal(t, FinishedStage, stageOf(t, sessions, "cliente"))
+	assert.Equal(t, FinishedStage, stageOf(t, restartSessions(sessions), "cliente"))
+}
+
+func TestTheFinishedAndAbortedSessionsAreForgottenAfterAWhile(t *testing.T) {
+	sessions := newTestSessions(t, 0, 0, nil)
+	now := time.Unix(1000, 0)
+	sessions.now = func() time.Time { return now }
+	sessions.AddFlightBatch("finished", 1, 10)
+	for _, query := range queryparams.AllQueries() {
+		sessions.FinishResults("finished", query)
+	}
+	assert.Nil(t, sessions.sessions["finished"].flightBatchIds, "The batches of a finished session are not needed anymore")
+
+	now = now.Add(testForgetAfter)
+	sessions.AbortSession("aborted")
+	assert.Equal(t, UnknownStage, stageOf(t, sessions, "finished"))
+	assert.True(t, sessions.IsAborted("aborted"))
+
+	now = now.Add(testForgetAfter)
+	assert.Equal(t, UnknownStage, stageOf(t, restartSessions(sessions), "aborted"), "The restart should forget the old sessions")
+}
+
+func TestTheFinishedStreamsAreKeptAfterARestart(t *testing.T) {
+	sessions := newTestSessions(t, 0, 0, nil)
+	assert.False(t, sessions.FinishFlightStream("cliente", 0, 2))
+
+	restarted := restartSessions(sessions)
+	assert.True(t, restarted.FinishFlightStream("cliente", 1, 2), "The EOF of the first stream was acked before the restart")
+	assert.False(t, restarted.FinishFlightStream("cliente", 0, 2), "The EOF of the flights is published once")
+}
+
+func TestTheRowsExpectedAreKeptAfterARestartWithoutCountingABatchTwice(t *testing.T) {
+	sessions := newTestSessions(t, 0, 0, nil)
+	sessions.AddFlightBatch("cliente", 1, 10)
+
+	restarted := restartSessions(sessions)
+	restarted.AddFlightBatch("cliente", 1, 10)
+	restarted.AddFlightBatch("cliente", 2, 5)
+	assert.Equal(t, uint(15), restarted.FlightRowsOf("cliente"), "The batch published before the restart should not be expected twice")
+}
+
+func TestTheClientKeepsItsViewAfterARestart(t *testing.T) {
+	view := membership.NewView()
+	view.Epoch = 1
+	view.Sizes["filters"] = 4
+	sessions := newTestSessions(t, 0, 0, func() *membership.View { return view })
+	sessions.Admit("cliente")
+
+	view = membership.NewView()
+	view.Epoch = 2
+	view.Sizes["filters"] = 6
+	restarted := restartSessions(sessions)
+	restarted.Admit("cliente")
+	restarted.SetParams("cliente", queryparams.Default().ToDynMap())
+
+	msg := &dataStructures.Message{ClientId: "cliente", Params: restarted.ParamsOf("cliente")}
+	assert.Equal(t, uint(4), membership.SizeOf(msg, "filters", 1), "The client should keep the nodes of the epoch it started in")
+}
@@ -0,0 +1,271 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
+	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
+	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
+	"github.com/brunograssano/Distribuidos-TP1/common/membership"
+	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
+	"github.com/brunograssano/Distribuidos-TP1/common/utils"
+	log "github.com/sirupsen/logrus"
+	"strconv"
+	"strings"
+)
+
+// sessionsCheckpointId Id of the sessions in the checkpointer of the server
+const sessionsCheckpointId = 0
+const sessionsCheckpointName = "server"
+
+const oldFileSessions = "sessions_chk_old.csv"
+const currFileSessions = "sessions_chk_curr.csv"
+const tmpFileSessions = "sessions_chk_tmp.csv"
+
+// Columns of the checkpoint of a session that are not in its status
+const (
+	admittedColumn           = "admitted"
+	airportsDoneColumn       = "airportsDone"
+	flightsDoneColumn        = "flightsDone"
+	abortedColumn            = "aborted"
+	airportLinesErrorsColumn = "airportLinesErrors"
+	flightLinesErrorsColumn  = "flightLinesErrors"
+	fetchedQueriesColumn     = "fetchedQueries"
+	flightStreamsColumn      = "flightStreams"
+	finishedStreamsColumn    = "finishedFlightStreams"
+	flightBatchIdsColumn     = "flightBatchIds"
+	finishedAtColumn         = "finishedAt"
+)
+
+func (s *Sessions) DoCheckpoint(errors chan error, id int, chkId int) {
+	checkpointer.DoCheckpointWithParser(errors, id, s, sessionsCheckpointName, tmpFileSessions, chkId)
+}
+
+func (s *Sessions) Commit(id int, response chan error) {
+	log.Debugf("Sessions | Commiting checkpoint for id: %v", id)
+	checkpointer.HandleOldFile(id, sessionsCheckpointName, oldFileSessions)
+	checkpointer.HandleCurrFile(id, sessionsCheckpointName, currFileSessions, oldFileSessions)
+	checkpointer.HandleTmpFile(id, sessionsCheckpointName, tmpFileSessions, currFileSessions)
+	response <- nil
+}
+
+func (s *Sessions) Abort(id int, response chan error) {
+	checkpointer.DeleteTmpFile(id, sessionsCheckpointName, tmpFileSessions)
+	response <- nil
+}
+
+func (s *Sessions) GetCheckpointVersions(id int) [2]int {
+	return checkpointer.GetCurrentValidCheckpoints(id, sessionsCheckpointName, currFileSessions, oldFileSessions)
+}
+
+func (s *Sessions) RestoreCheckpoint(checkpointToRestore int, id int, result chan error) {
+	checkpointIds := checkpointer.GetCurrentValidCheckpoints(id, sessionsCheckpointName, currFileSessions, oldFileSessions)
+	filesArray := []string{
+		fmt.Sprintf("%v_%v_%v", id, sessionsCheckpointName, oldFileSessions),
+		fmt.Sprintf("%v_%v_%v", id, sessionsCheckpointName, currFileSessions),
+	}
+	for idx, chkId := range checkpointIds {
+		if chkId == checkpointToRestore {
+			s.readCheckpointAsState(filesArray[idx])
+			break
+		}
+	}
+	result <- nil
+}
+
+// GetCheckpointString Returns a line for each session. It is called while the change that checkpoints holds the mutex
+func (s *Sessions) GetCheckpointString() string {
+	linesToWrite := strings.Builder{}
+	for clientId, clientSession := range s.sessions {
+		//{clientId},{session as base64},{params as base64}
+		params := ""
+		if clientSession.params != nil {
+			params = base64.StdEncoding.EncodeToString(serializer.SerializeDynMap(clientSession.params))
+		}
+		sessionBytes := serializer.SerializeDynMap(clientSession.toDynMap())
+		linesToWrite.WriteString(fmt.Sprintf("%v,%v,%v\n", clientId, base64.StdEncoding.EncodeToString(sessionBytes), params))
+	}
+	return linesToWrite.String()
+}
+
+func (s *Sessions) readCheckpointAsState(fileToRestore string) {
+	if !filemanager.DirectoryExists(fileToRestore) {
+		log.Infof("Sessions | Does not have a checkpoint: %v", fileToRestore)
+		return
+	}
+	log.Infof("Sessions | Restoring checkpoint: %v", fileToRestore)
+	fileReader, err := filemanager.NewFileReader(fileToRestore)
+	if err != nil {
+		log.Fatalf("Sessions | Error trying to read checkpoint file: %v | %v", fileToRestore, err)
+	}
+	defer utils.CloseFileAndNotifyError(fileReader)
+
+	filemanager.SkipHeader(fileReader)
+	now := s.now()
+	for fileReader.CanRead() {
+		fields := strings.Split(fileReader.ReadLine(), utils.CommaSeparator)
+		if len(fields) != 3 {
+			log.Errorf("Sessions | Error deserializing checkpoint | %v", fields)
+			continue
+		}
+		clientSession, err := parseSession(fields[1], fields[2])
+		if err != nil {
+			log.Errorf("Sessions | Error deserializing session of client %v | %v", fields[0], err)
+			continue
+		}
+		// The clients that were uploading have the idle timeout to continue, as if they had just sent data
+		clientSession.lastActivity = now
+		s.sessions[fields[0]] = clientSession
+	}
+	err = fileReader.Err()
+	if err != nil {
+		log.Errorf("Sessions | Error reading from checkpoint: %v | %v", fileToRestore, err)
+	}
+	s.forgetFinished(now)
+	log.Infof("Sessions | Restored checkpoint successfully: %v | Sessions: %v", fileToRestore, len(s.sessions))
+}
+
+// toDynMap Returns what the session has to keep after a restart
+func (s *session) toDynMap() *dataStructures.DynamicMap {
+	var flightBatchIds []string
+	for messageId := range s.flightBatchIds {
+		flightBatchIds = append(flightBatchIds, strconv.FormatUint(uint64(messageId), 10))
+	}
+	columns := map[string]dataStructures.Column{
+		utils.AirportBatches:      dataStructures.NewInt64Column(int64(s.airportBatches)),
+		utils.FlightBatches:       dataStructures.NewInt64Column(int64(s.flightBatches)),
+		utils.FlightRowsReceived:  dataStructures.NewInt64Column(int64(s.flightRows)),
+		utils.SkippedAirportLines: dataStructures.NewInt64Column(s.skippedAirportLines),
+		utils.SkippedFlightLines:  dataStructures.NewInt64Column(s.skippedFlightLines),
+		admittedColumn:            dataStructures.NewBoolColumn(s.admitted),
+		airportsDoneColumn:        dataStructures.NewBoolColumn(s.airportsDone),
+		flightsDoneColumn:         dataStructures.NewBoolColumn(s.flightsDone),
+		abortedColumn:             dataStructures.NewBoolColumn(s.aborted),
+		airportLinesErrorsColumn:  dataStructures.NewListColumn(s.airportLinesErrors),
+		flightLinesErrorsColumn:   dataStructures.NewListColumn(s.flightLinesErrors),
+		fetchedQueriesColumn:      dataStructures.NewListColumn(intSetToList(s.fetchedQueries)),
+		flightStreamsColumn:       dataStructures.NewInt64Column(int64(s.flightStreams)),
+		finishedStreamsColumn:     dataStructures.NewListColumn(intSetToList(s.finishedFlightStreams)),
+		flightBatchIdsColumn:      dataStructures.NewListColumn(flightBatchIds),
+	}
+	if !s.finishedAt.IsZero() {
+		columns[finishedAtColumn] = dataStructures.NewTimestampColumn(s.finishedAt)
+	}
+	return dataStructures.NewDynamicMap(columns)
+}
+
+func intSetToList(set map[int]bool) []string {
+	var list []string
+	for number := range set {
+		list = append(list, strconv.Itoa(number))
+	}
+	return list
+}
+
+// sessionReader Reads the columns of a session from its checkpoint, keeping the first error
+type sessionReader struct {
+	dynMap *dataStructures.DynamicMap
+	err    error
+}
+
+func (r *sessionReader) keep(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *sessionReader) uint(column string) uint {
+	value, err := r.dynMap.GetAsInt64(column)
+	r.keep(err)
+	return uint(value)
+}
+
+func (r *sessionReader) int64(column string) int64 {
+	value, err := r.dynMap.GetAsInt64(column)
+	r.keep(err)
+	return value
+}
+
+func (r *sessionReader) bool(column string) bool {
+	value, err := r.dynMap.GetAsBool(column)
+	r.keep(err)
+	return value
+}
+
+func (r *sessionReader) list(column string) []string {
+	value, err := r.dynMap.GetAsList(column)
+	r.keep(err)
+	return value
+}
+
+// intSet Reads a list of numbers as a set
+func (r *sessionReader) intSet(column string) map[int]bool {
+	set := make(map[int]bool)
+	for _, element := range r.list(column) {
+		number, err := strconv.Atoi(element)
+		r.keep(err)
+		set[number] = true
+	}
+	return set
+}
+
+// uintSet Reads a list of ids as a set
+func (r *sessionReader) uintSet(column string) map[uint]bool {
+	set := make(map[uint]bool)
+	for _, element := range r.list(column) {
+		id, err := strconv.ParseUint(element, 10, 64)
+		r.keep(err)
+		set[uint(id)] = true
+	}
+	return set
+}
+
+func decodeDynMap(encoded string) (*dataStructures.DynamicMap, error) {
+	dynMapBytes, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	dynMap, _, err := serializer.DeserializeDynMap(dynMapBytes)
+	return dynMap, err
+}
+
+// parseSession Returns the session of its checkpoint and the one of its parameters, that can be empty
+func parseSession(encodedSession string, encodedParams string) (*session, error) {
+	dynMap, err := decodeDynMap(encodedSession)
+	if err != nil {
+		return nil, err
+	}
+	r := &sessionReader{dynMap: dynMap}
+	clientSession := &session{
+		airportBatches:        r.uint(utils.AirportBatches),
+		flightBatches:         r.uint(utils.FlightBatches),
+		flightRows:            r.uint(utils.FlightRowsReceived),
+		skippedAirportLines:   r.int64(utils.SkippedAirportLines),
+		skippedFlightLines:    r.int64(utils.SkippedFlightLines),
+		admitted:              r.bool(admittedColumn),
+		airportsDone:          r.bool(airportsDoneColumn),
+		flightsDone:           r.bool(flightsDoneColumn),
+		aborted:               r.bool(abortedColumn),
+		airportLinesErrors:    r.list(airportLinesErrorsColumn),
+		flightLinesErrors:     r.list(flightLinesErrorsColumn),
+		fetchedQueries:        r.intSet(fetchedQueriesColumn),
+		flightStreams:         int(r.int64(flightStreamsColumn)),
+		finishedFlightStreams: r.intSet(finishedStreamsColumn),
+		flightBatchIds:        r.uintSet(flightBatchIdsColumn),
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if finishedAt, err := dynMap.GetAsTimestamp(finishedAtColumn); err == nil {
+		clientSession.finishedAt = finishedAt
+	}
+	if encodedParams != "" {
+		clientSession.params, err = decodeDynMap(encodedParams)
+		if err != nil {
+			return nil, err
+		}
+		// The view pinned to the client travels in its parameters, so it keeps it after the restart
+		clientSession.view = membership.FromDynMap(clientSession.params)
+	}
+	return clientSession, nil
+}
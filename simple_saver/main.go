@@ -6,8 +6,10 @@ import (
 	"github.com/brunograssano/Distribuidos-TP1/common/heartbeat"
 	"github.com/brunograssano/Distribuidos-TP1/common/middleware"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
+	"github.com/brunograssano/Distribuidos-TP1/common/retention"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	"log"
+	"os"
 	"simple_saver/saver"
 )
 
@@ -24,15 +26,21 @@ func main() {
 		log.Fatalf("Main - Simple Saver | Error initializing config | %s", err)
 	}
 
+	collector := saver.NewCollector(config)
+	if retention.HandleCommand(os.Args[1:], collector) {
+		return
+	}
+
 	qMiddleware := middleware.NewQueueMiddleware(config.RabbitAddress)
-	qFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.InputQueueName, "")
+	qFactory := queuefactory.NewFanoutExchangeQueueFactory(qMiddleware, config.InputQueueName, "", config.Compressions)
 	checkpointerHandler := checkpointer.NewCheckpointerHandler()
-	simpleSaver := saver.NewSimpleSaver(qFactory, config, checkpointerHandler)
+	simpleSaver := saver.NewSimpleSaver(qFactory, config, checkpointerHandler, collector)
 	checkpointerHandler.RestoreCheckpoint()
 	
 	go simpleSaver.SaveData()
+	go collector.CollectLoop()
 
-	getterConf := getters.NewGetterConfig(config.ID, []string{config.OutputFileName}, config.GetterAddress, config.GetterBatchLines)
+	getterConf := getters.NewGetterConfig(config.ID, []string{config.OutputFileName}, config.GetterAddress, config.GetterBatchLines, config.GetterStreaming, collector)
 	getter, err := getters.NewGetter(getterConf)
 	if err != nil {
 		log.Fatalf("Main - Simple Saver | Error initializing Getter | %s", err)
@@ -43,4 +51,5 @@ func main() {
 	endSigHB <- true
 	qMiddleware.Close()
 	getter.Close()
+	collector.Close()
 }
@@ -1,12 +1,14 @@
 package saver
 
 import (
+	"errors"
 	"fmt"
 	"github.com/brunograssano/Distribuidos-TP1/common/checkpointer"
 	dataStructures "github.com/brunograssano/Distribuidos-TP1/common/data_structures"
 	"github.com/brunograssano/Distribuidos-TP1/common/filemanager"
 	queueProtocol "github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
 	"github.com/brunograssano/Distribuidos-TP1/common/queuefactory"
+	"github.com/brunograssano/Distribuidos-TP1/common/retention"
 	"github.com/brunograssano/Distribuidos-TP1/common/serializer"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
@@ -19,6 +21,7 @@ type SimpleSaver struct {
 	c            *Config
 	consumer     queueProtocol.ConsumerProtocolInterface
 	checkpointer *checkpointer.CheckpointerHandler
+	collector    *retention.Collector
 }
 
 // NewSimpleSaver Creates a new saver for the results
@@ -26,27 +29,40 @@ func NewSimpleSaver(
 	qFactory queuefactory.QueueProtocolFactory,
 	c *Config,
 	chkHandler *checkpointer.CheckpointerHandler,
+	collector *retention.Collector,
 ) *SimpleSaver {
 	consumer := qFactory.CreateConsumer(fmt.Sprintf("%v-%v", c.InputQueueName, c.ID))
 	chkHandler.AddCheckpointable(consumer, saverId)
-	return &SimpleSaver{c: c, consumer: consumer, checkpointer: chkHandler}
+	return &SimpleSaver{c: c, consumer: consumer, checkpointer: chkHandler, collector: collector}
+}
+
+// NewCollector Creates the collector of the results of the clients, the finished ones and the ones in progress
+func NewCollector(c *Config) *retention.Collector {
+	return retention.NewCollector(c.Retention, func(clientId string) []string {
+		file := resultsFileName(c, clientId)
+		return []string{file, fmt.Sprintf("%v/%v", clientId, file)}
+	})
 }
 
 // SaveData Saves the results from the queue in a file
 func (s *SimpleSaver) SaveData() {
 	log.Infof("SimpleSaver | Goroutine started")
 	for {
-		msg, ok := s.consumer.Pop()
-		if !ok {
+		msg, err := s.consumer.Pop()
+		if errors.Is(err, queueProtocol.ErrConsumerClosed) {
 			log.Infof("SimpleSaver | Exiting saver")
 			return
 		}
+		if err != nil {
+			log.Errorf("SimpleSaver | Skipping message | %v", err)
+			continue
+		}
 		if msg.TypeMessage == dataStructures.EOFFlightRows {
 			log.Infof("SimpleSaver | Received all results from client %v. Renaming file saved...", msg.ClientId)
-			err := filemanager.MoveFiles([]string{fmt.Sprintf("%v_%v.csv", s.c.OutputFileName, msg.ClientId)}, msg.ClientId)
-			if err != nil {
-				log.Errorf("SimpleSaver | Error moving to file to folder | %v", err)
-			}
+			s.handleEOF(msg)
+		} else if msg.TypeMessage == dataStructures.Abort {
+			log.Infof("SimpleSaver | Received Abort of client %v. Deleting its results...", msg.ClientId)
+			s.handleAbort(msg)
 		} else if msg.TypeMessage == dataStructures.FlightRows {
 			err := s.handleFlightRows(msg)
 			if err != nil {
@@ -54,19 +70,55 @@ func (s *SimpleSaver) SaveData() {
 				return
 			}
 		}
-		err := s.checkpointer.DoCheckpoint(saverId)
+		err = s.checkpointer.DoCheckpoint(saverId)
 		if err != nil {
 			log.Errorf("SimpleSaver | Error on checkpointing | %v", err)
 		}
 	}
 }
 
+// handleEOF Moves the results of the client to its folder. If there were no results an empty file is moved,
+// so the getter knows that the results are ready
+func (s *SimpleSaver) handleEOF(msg *dataStructures.Message) {
+	file := s.resultsFileName(msg.ClientId)
+	if !filemanager.DirectoryExists(file) {
+		writer, err := filemanager.NewFileWriter(file)
+		if err != nil {
+			log.Errorf("SimpleSaver | Error creating empty results file | %v", err)
+			return
+		}
+		utils.CloseFileAndNotifyError(writer.FileManager)
+	}
+	s.collector.Track(msg.ClientId)
+	err := filemanager.MoveFiles([]string{file}, msg.ClientId)
+	if err != nil {
+		log.Errorf("SimpleSaver | Error moving to file to folder | %v", err)
+	}
+}
+
+// handleAbort Deletes the partial results of the client and the folder of its results
+func (s *SimpleSaver) handleAbort(msg *dataStructures.Message) {
+	s.consumer.ClearData(msg.ClientId)
+	err := filemanager.DeleteIfExists(s.resultsFileName(msg.ClientId), msg.ClientId)
+	if err != nil {
+		log.Errorf("SimpleSaver | Error deleting results of client %v | %v", msg.ClientId, err)
+	}
+}
+
+func (s *SimpleSaver) resultsFileName(clientId string) string {
+	return resultsFileName(s.c, clientId)
+}
+
+func resultsFileName(c *Config, clientId string) string {
+	return fmt.Sprintf("%v_%v.csv", c.OutputFileName, clientId)
+}
+
 func (s *SimpleSaver) handleFlightRows(msg *dataStructures.Message) error {
-	if filemanager.DirectoryExists(msg.ClientId) {
+	file := s.resultsFileName(msg.ClientId)
+	if filemanager.DirectoryExists(fmt.Sprintf("%v/%v", msg.ClientId, file)) {
 		log.Warnf("SimpleSaver | Already processed client %v, discarding message...", msg.ClientId)
 		return nil
 	}
-	file := fmt.Sprintf("%v_%v.csv", s.c.OutputFileName, msg.ClientId)
 	writer, err := filemanager.NewFileWriter(file)
 	if err != nil {
 		log.Errorf("SimpleSaver | Error opening file writer of output")
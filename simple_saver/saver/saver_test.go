@@ -28,9 +28,9 @@ func TestTheSaverShouldWriteAllLines(t *testing.T) {
 	writer := &FileWriterMock{lines: 0, err: nil}
 	saver := &SimpleSaver{}
 
-	dynMap := make(map[string][]byte)
-	dynMap["col1"] = []byte("Some data")
-	dynMap["col2"] = []byte("More data")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["col1"] = dataStructures.NewStringColumn("Some data")
+	dynMap["col2"] = dataStructures.NewStringColumn("More data")
 
 	row := dataStructures.NewDynamicMap(dynMap)
 	err := saver.writeRowsToFile([]*dataStructures.DynamicMap{row, row, row}, writer)
@@ -42,9 +42,9 @@ func TestTheSaverShouldReturnAnError(t *testing.T) {
 	writer := &FileWriterMock{lines: 0, err: errors.New("IO Error")}
 	saver := &SimpleSaver{}
 
-	dynMap := make(map[string][]byte)
-	dynMap["col1"] = []byte("Some data")
-	dynMap["col2"] = []byte("More data")
+	dynMap := make(map[string]dataStructures.Column)
+	dynMap["col1"] = dataStructures.NewStringColumn("Some data")
+	dynMap["col2"] = dataStructures.NewStringColumn("More data")
 
 	row := dataStructures.NewDynamicMap(dynMap)
 	err := saver.writeRowsToFile([]*dataStructures.DynamicMap{row, row, row}, writer)
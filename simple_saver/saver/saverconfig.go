@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/brunograssano/Distribuidos-TP1/common/config"
+	"github.com/brunograssano/Distribuidos-TP1/common/protocol/queues"
+	"github.com/brunograssano/Distribuidos-TP1/common/retention"
 	"github.com/brunograssano/Distribuidos-TP1/common/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -18,8 +20,12 @@ type Config struct {
 	RabbitAddress           string
 	GetterAddress           string
 	GetterBatchLines        uint
+	GetterStreaming         bool
 	AddressesHealthCheckers []string
 	ServiceName             string
+	Retention               *retention.Config
+	// Compressions Compression of the messages sent to each queue or exchange
+	Compressions queues.Compressions
 }
 
 // InitEnv Initializes the configuration properties from a config file and environment
@@ -37,11 +43,16 @@ func InitEnv() (*viper.Viper, error) {
 	// Add env variables supported
 	_ = v.BindEnv("id")
 	_ = v.BindEnv("log", "level")
+	_ = v.BindEnv("compression")
 	_ = v.BindEnv("rabbitmq", "address")
 	_ = v.BindEnv("rabbitmq", "queue", "input")
 	_ = v.BindEnv("saver", "output")
 	_ = v.BindEnv("getter", "address")
 	_ = v.BindEnv("getter", "batch", "lines")
+	_ = v.BindEnv("getter", "streaming")
+	_ = v.BindEnv("retention", "ttl")
+	_ = v.BindEnv("retention", "fetched")
+	_ = v.BindEnv("retention", "interval")
 	_ = v.BindEnv("name")
 	_ = v.BindEnv("healthchecker", "addresses")
 	// Try to read configuration from config file. If config file
@@ -62,6 +73,11 @@ func GetConfig(env *viper.Viper) (*Config, error) {
 		return nil, err
 	}
 
+	compressions, err := config.GetCompressions(env.GetStringMapString("compression"))
+	if err != nil {
+		return nil, err
+	}
+
 	id := env.GetString("id")
 	if id == "" {
 		return nil, errors.New("missing id")
@@ -104,14 +120,22 @@ func GetConfig(env *viper.Viper) (*Config, error) {
 		getterBatchLines = utils.DefaultBatchLines
 	}
 
-	log.Infof("SaverConfig | action: config | result: success | id: %s | log_level: %s | rabbitAddress: %v | inputQueueName: %v | outputFilename: %v | getterAddress: %v | getterBatchLines: %v",
+	getterStreaming := env.GetBool("getter.streaming")
+
+	retentionConfig, err := retention.NewConfig(id, env.GetString("retention.ttl"), env.GetString("retention.fetched"), env.GetString("retention.interval"))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("SaverConfig | action: config | result: success | id: %s | log_level: %s | rabbitAddress: %v | inputQueueName: %v | outputFilename: %v | getterAddress: %v | getterBatchLines: %v | getterStreaming: %v",
 		id,
 		env.GetString("log.level"),
 		rabbitAddress,
 		inputQueueName,
 		outputFilename,
 		getterAddress,
-		getterBatchLines)
+		getterBatchLines,
+		getterStreaming)
 
 	return &Config{
 		ID:                      id,
@@ -120,7 +144,10 @@ func GetConfig(env *viper.Viper) (*Config, error) {
 		RabbitAddress:           rabbitAddress,
 		GetterAddress:           getterAddress,
 		GetterBatchLines:        getterBatchLines,
+		GetterStreaming:         getterStreaming,
 		AddressesHealthCheckers: healthCheckerAddresses,
 		ServiceName:             serviceName,
+		Retention:               retentionConfig,
+		Compressions:            compressions,
 	}, nil
 }